@@ -22,6 +22,9 @@ var (
 		"role.oneof":                errors.New("should be one of librarian or patrons"),
 		"password.required":         errors.New("is required"),
 		"password.validatepassword": errors.New("password should contain uppercase and lowercase letter and no special character"),
+		"email.required_without":    errors.New("email or cardNumber is required"),
+		"userID.required_without":   errors.New("userID or cardNumber is required"),
+		"cardNumber.librarycard":    errors.New("should be a library card number"),
 		// book related errors
 		"ISBN.required":          errors.New("is required"),
 		"title.required":         errors.New("is required"),
@@ -0,0 +1,142 @@
+// Package bookimport turns catalogue files from spreadsheets and other library systems into books
+package bookimport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/marc"
+	"integrated-library-service/model"
+)
+
+var (
+	// ErrUnsupportedFormat is an error when a file is in none of the import formats
+	ErrUnsupportedFormat = errors.New("unsupported import format")
+	// ErrNoRows is an error when a file contains no rows to import
+	ErrNoRows = errors.New("import file contains no rows")
+)
+
+// Row is a parsed row of an import file, Err is set when the row can not be imported
+type Row struct {
+	Number int64
+	Book   *model.CreateBookRequest
+	Err    error
+}
+
+// ISBN returns the ISBN of the row as it was given
+func (r Row) ISBN() string {
+	if r.Book == nil {
+		return ""
+	}
+	return r.Book.ISBN
+}
+
+// Title returns the title of the row as it was given
+func (r Row) Title() string {
+	if r.Book == nil {
+		return ""
+	}
+	return r.Book.Title
+}
+
+// DetectFormat returns the format of a file by its extension
+func DetectFormat(filename string) (model.BookImportFormat, error) {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return model.BookImportFormatCSV, nil
+	case strings.HasSuffix(lower, ".xml"):
+		return model.BookImportFormatMARCXML, nil
+	case strings.HasSuffix(lower, ".mrc"), strings.HasSuffix(lower, ".marc"):
+		return model.BookImportFormatMARC, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Read parses every row of an import file, mapping is only used for CSV files
+func Read(r io.Reader, format model.BookImportFormat, mapping map[string]string) ([]Row, error) {
+	var (
+		rows []Row
+		err  error
+	)
+	switch format {
+	case model.BookImportFormatCSV:
+		rows, err = ReadCSV(r, mapping)
+	case model.BookImportFormatMARC:
+		rows, err = readMARC(marc.NewReader(r))
+	case model.BookImportFormatMARCXML:
+		rows, err = readMARC(marc.NewXMLReader(r))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+// validate checks the fields a book needs to be stored, normalises its ISBN and takes the primary
+// author and genre from the lists when they are not given
+func validate(book *model.CreateBookRequest) error {
+	if len(book.Author) == 0 && len(book.Authors) > 0 {
+		book.Author = book.Authors[0]
+	}
+	if len(book.Genre) == 0 {
+		book.Genre = "other"
+		if len(book.Genres) > 0 {
+			book.Genre = book.Genres[0]
+		}
+	}
+
+	missing := []string{}
+	if len(strings.TrimSpace(book.ISBN)) == 0 {
+		missing = append(missing, "ISBN")
+	}
+	if len(strings.TrimSpace(book.Title)) == 0 {
+		missing = append(missing, "title")
+	}
+	if len(strings.TrimSpace(book.Author)) == 0 {
+		missing = append(missing, "author")
+	}
+	if book.PublishedDate.IsZero() {
+		missing = append(missing, "publishedDate")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+
+	ISBN13, _, err := isbn.Parse(book.ISBN)
+	if err != nil {
+		return fmt.Errorf("%w %q", err, book.ISBN)
+	}
+	book.ISBN = ISBN13
+
+	if book.PageCount < 0 {
+		return errors.New("pageCount must not be negative")
+	}
+	if book.BooksLeft != nil && *book.BooksLeft < 0 {
+		return errors.New("booksLeft must not be negative")
+	}
+	if book.MaturityRating != "" && book.MaturityRating != "NOT_MATURE" && book.MaturityRating != "MATURE" {
+		return fmt.Errorf("maturityRating %q must be NOT_MATURE or MATURE", book.MaturityRating)
+	}
+	return nil
+}
+
+// parseDate parses the publication dates found in catalogue files, down to just the year
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02", "2006-01", "2006", "02/01/2006", "2/1/2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
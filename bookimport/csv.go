@@ -0,0 +1,222 @@
+package bookimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+)
+
+var (
+	// ErrUnknownField is an error when a column mapping names a field books do not have
+	ErrUnknownField = errors.New("unknown book field in column mapping")
+	// ErrMissingColumn is an error when a mapped or required column is not in the header row
+	ErrMissingColumn = errors.New("column missing in header row")
+)
+
+// listSeparators split the authors and genres columns
+const listSeparators = ";|"
+
+// csvFields are the book fields a CSV column can be mapped to, by default a column whose header
+// equals the field name case insensitively is used
+var csvFields = map[string]func(book *model.CreateBookRequest, value string) error{
+	"ISBN":     func(book *model.CreateBookRequest, value string) error { book.ISBN = value; return nil },
+	"title":    func(book *model.CreateBookRequest, value string) error { book.Title = value; return nil },
+	"subtitle": func(book *model.CreateBookRequest, value string) error { book.Subtitle = value; return nil },
+	"author":   func(book *model.CreateBookRequest, value string) error { book.Author = value; return nil },
+	"authors": func(book *model.CreateBookRequest, value string) error {
+		book.Authors = splitList(value)
+		return nil
+	},
+	"genre": func(book *model.CreateBookRequest, value string) error { book.Genre = value; return nil },
+	"genres": func(book *model.CreateBookRequest, value string) error {
+		book.Genres = splitList(value)
+		return nil
+	},
+	"publishedDate": func(book *model.CreateBookRequest, value string) error {
+		t, err := parseDate(value)
+		book.PublishedDate = t
+		return err
+	},
+	"desc":        func(book *model.CreateBookRequest, value string) error { book.Description = value; return nil },
+	"previewLink": func(book *model.CreateBookRequest, value string) error { book.PreviewLink = value; return nil },
+	"coverImage":  func(book *model.CreateBookRequest, value string) error { book.CoverImage = value; return nil },
+	"publisher":   func(book *model.CreateBookRequest, value string) error { book.Publisher = value; return nil },
+	"language":    func(book *model.CreateBookRequest, value string) error { book.Language = value; return nil },
+	"maturityRating": func(book *model.CreateBookRequest, value string) error {
+		book.MaturityRating = strings.ToUpper(value)
+		return nil
+	},
+	"pageCount": func(book *model.CreateBookRequest, value string) error {
+		pageCount, err := parseInt("pageCount", value)
+		if pageCount != nil {
+			book.PageCount = *pageCount
+		}
+		return err
+	},
+	"shelfNumber": func(book *model.CreateBookRequest, value string) (err error) {
+		book.ShelfNumber, err = parseInt("shelfNumber", value)
+		return err
+	},
+	"price": func(book *model.CreateBookRequest, value string) (err error) {
+		book.Price, err = parseFloat("price", value)
+		return err
+	},
+	"booksLeft": func(book *model.CreateBookRequest, value string) (err error) {
+		book.BooksLeft, err = parseInt("booksLeft", value)
+		return err
+	},
+	"inLibrary": func(book *model.CreateBookRequest, value string) error {
+		inLibrary, err := strconv.ParseBool(strings.ToLower(value))
+		if err != nil {
+			return fmt.Errorf("inLibrary %q is not a boolean", value)
+		}
+		book.InLibrary = &inLibrary
+		return nil
+	},
+}
+
+// ReadCSV parses a CSV file whose first row is the header. mapping maps book fields to column
+// headers, fields which are not mapped use the column named like them if there is one. Rows are
+// numbered by their line in the file.
+func ReadCSV(r io.Reader, mapping map[string]string) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := mapColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []Row{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, Row{Number: int64(parseErr.StartLine), Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isBlank(record) {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{Number: int64(line), Book: &model.CreateBookRequest{}}
+		for field, column := range columns {
+			if column >= len(record) {
+				continue
+			}
+			// cells escaped against spreadsheet formulas by the catalogue export are read as they were
+			value := export.UnescapeCSVCell(strings.TrimSpace(record[column]))
+			if len(value) == 0 {
+				continue
+			}
+			if err := csvFields[field](row.Book, value); err != nil && row.Err == nil {
+				row.Err = err
+			}
+		}
+		if row.Err == nil {
+			row.Err = validate(row.Book)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// mapColumns returns the column index of every book field found in the header
+func mapColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+
+	columns := map[string]int{}
+	for field, column := range mapping {
+		if _, ok := csvFields[field]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownField, field)
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrMissingColumn, column)
+		}
+		columns[field] = i
+	}
+	for field := range csvFields {
+		if _, mapped := columns[field]; mapped {
+			continue
+		}
+		if i, ok := index[strings.ToLower(field)]; ok {
+			columns[field] = i
+		}
+	}
+
+	for _, required := range []string{"ISBN", "title"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, required)
+		}
+	}
+	return columns, nil
+}
+
+// splitList splits a list column and drops empty entries
+func splitList(value string) []string {
+	names := []string{}
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(listSeparators, r) }) {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseInt parses a whole number column
+func parseInt(field, value string) (*int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q is not a whole number", field, value)
+	}
+	return &n, nil
+}
+
+// parseFloat parses a decimal number column
+func parseFloat(field, value string) (*float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q is not a number", field, value)
+	}
+	return &n, nil
+}
+
+// isBlank reports whether every cell of a record is empty
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if len(strings.TrimSpace(cell)) > 0 {
+			return false
+		}
+	}
+	return true
+}
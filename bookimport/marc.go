@@ -0,0 +1,149 @@
+package bookimport
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/marc"
+	"integrated-library-service/model"
+)
+
+// recordReader is implemented by the ISO 2709 and the MARCXML readers
+type recordReader interface {
+	Read() (*marc.Record, error)
+}
+
+var (
+	yearPattern      = regexp.MustCompile(`\d{4}`)
+	pageCountPattern = regexp.MustCompile(`(\d+)\s*(p\b|p\.|pages)`)
+)
+
+// readMARC parses every record, rows are numbered by the position of their record in the file
+func readMARC(reader recordReader) ([]Row, error) {
+	rows := []Row{}
+	for number := int64(1); ; number++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if errors.Is(err, marc.ErrInvalidRecord) {
+			rows = append(rows, Row{Number: number, Err: err})
+			continue
+		}
+		// a broken length hides where the following records start, the ones before it are still imported
+		if errors.Is(err, marc.ErrInvalidRecordLength) && len(rows) > 0 {
+			return append(rows, Row{Number: number, Err: err}), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := Row{Number: number, Book: fromMARC(record)}
+		row.Err = validate(row.Book)
+		rows = append(rows, row)
+	}
+}
+
+// fromMARC maps the bibliographic fields of a MARC 21 record to a book
+func fromMARC(record *marc.Record) *model.CreateBookRequest {
+	book := &model.CreateBookRequest{
+		Title:       trimPunctuation(record.Value("245", "a")),
+		Subtitle:    trimPunctuation(record.Value("245", "b")),
+		Description: record.Value("520", "a"),
+		PreviewLink: record.Value("856", "u"),
+		Authors:     []string{},
+		Genres:      []string{},
+	}
+
+	// the first ISBN with a valid check digit, qualifiers like "(pbk.)" follow the number
+	for _, value := range record.Values("020", "a") {
+		candidate := strings.Fields(value)
+		if len(candidate) == 0 {
+			continue
+		}
+		if _, _, err := isbn.Parse(candidate[0]); err == nil {
+			book.ISBN = candidate[0]
+			break
+		}
+		if len(book.ISBN) == 0 {
+			book.ISBN = candidate[0]
+		}
+	}
+
+	for _, tag := range []string{"100", "110", "700", "710"} {
+		for _, name := range record.Values(tag, "a") {
+			book.Authors = append(book.Authors, trimPunctuation(name))
+		}
+	}
+	for _, tag := range []string{"650", "655"} {
+		for _, subject := range record.Values(tag, "a") {
+			book.Genres = append(book.Genres, strings.TrimSuffix(trimPunctuation(subject), "."))
+		}
+	}
+	book.Authors = uniqueStrings(book.Authors)
+	book.Genres = uniqueStrings(book.Genres)
+
+	// RDA records publish in 264, older records in 260
+	for _, tag := range []string{"264", "260"} {
+		if publisher := record.Value(tag, "b"); len(publisher) > 0 && len(book.Publisher) == 0 {
+			book.Publisher = trimPunctuation(publisher)
+		}
+		if date := record.Value(tag, "c"); book.PublishedDate.IsZero() {
+			book.PublishedDate = yearOf(date)
+		}
+	}
+
+	fixed := record.Control("008")
+	if book.PublishedDate.IsZero() && len(fixed) >= 11 {
+		book.PublishedDate = yearOf(fixed[7:11])
+	}
+	if len(fixed) >= 38 {
+		book.Language = strings.TrimSpace(fixed[35:38])
+	}
+	if language := record.Value("041", "a"); len(book.Language) == 0 && len(language) >= 3 {
+		book.Language = language[:3]
+	}
+
+	if match := pageCountPattern.FindStringSubmatch(record.Value("300", "a")); match != nil {
+		book.PageCount, _ = strconv.ParseInt(match[1], 10, 64)
+	}
+
+	return book
+}
+
+// yearOf returns the first of January of the first four digit year in value
+func yearOf(value string) time.Time {
+	year := yearPattern.FindString(value)
+	if len(year) == 0 {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006", year)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// trimPunctuation drops the ISBD punctuation MARC puts at the end of subfields
+func trimPunctuation(value string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(value), " /:;,="))
+}
+
+// uniqueStrings drops empty and repeated values, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if len(value) == 0 || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
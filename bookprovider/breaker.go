@@ -0,0 +1,75 @@
+package bookprovider
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker stops calling a provider after consecutive failures and lets a single
+// trial call through once the open duration has passed
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	openDuration     time.Duration
+	failures         int
+	openedAt         time.Time
+	trialInFlight    bool
+}
+
+// NewCircuitBreaker returns a closed circuit breaker
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+	}
+}
+
+// Allow reports whether a call may go to the provider
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.failures < cb.failureThreshold {
+		return true
+	}
+	// open, wait for the open duration to pass
+	if time.Since(cb.openedAt) < cb.openDuration {
+		return false
+	}
+	// half open, only one trial call at a time
+	if cb.trialInFlight {
+		return false
+	}
+	cb.trialInFlight = true
+	return true
+}
+
+// Success closes the circuit
+func (cb *CircuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.trialInFlight = false
+}
+
+// Failure records a failed call and opens the circuit once the threshold is reached
+func (cb *CircuitBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.trialInFlight = false
+	if cb.failures >= cb.failureThreshold {
+		cb.openedAt = time.Now()
+	}
+}
+
+// Abandoned ends a call the caller cancelled or timed out without a result, it says nothing about
+// the provider so it neither opens nor closes the circuit
+func (cb *CircuitBreaker) Abandoned() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trialInFlight = false
+}
@@ -0,0 +1,87 @@
+package bookprovider
+
+import (
+	"testing"
+	"time"
+)
+
+// expire moves the opening of the circuit back past its open duration
+func expire(cb *CircuitBreaker) {
+	cb.openedAt = time.Now().Add(-2 * cb.openDuration)
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		cb.Failure()
+		if !cb.Allow() {
+			t.Fatalf("Allow() after %d failures = false, want true", i+1)
+		}
+	}
+	cb.Failure()
+	if cb.Allow() {
+		t.Fatal("Allow() after 3 failures = true, want false")
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Minute)
+
+	cb.Failure()
+	cb.Success()
+	cb.Failure()
+	if !cb.Allow() {
+		t.Fatal("Allow() after failure, success, failure = false, want true")
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		trialEnd func(cb *CircuitBreaker)
+		wantOpen bool
+	}{
+		{"successful trial closes", (*CircuitBreaker).Success, false},
+		{"failed trial opens again", (*CircuitBreaker).Failure, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(1, time.Minute)
+			cb.Failure()
+			expire(cb)
+
+			if !cb.Allow() {
+				t.Fatal("Allow() after the open duration = false, want a trial call")
+			}
+			if cb.Allow() {
+				t.Fatal("Allow() while the trial is in flight = true, want false")
+			}
+
+			tt.trialEnd(cb)
+			if got := !cb.Allow(); got != tt.wantOpen {
+				t.Errorf("open after the trial = %v, want %v", got, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerAbandonedTrial(t *testing.T) {
+	cb := NewCircuitBreaker(1, time.Minute)
+	cb.Failure()
+	expire(cb)
+
+	if !cb.Allow() {
+		t.Fatal("Allow() after the open duration = false, want a trial call")
+	}
+	cb.Abandoned()
+
+	// the abandoned trial neither closed the circuit nor started a new open duration
+	if cb.failures != 1 {
+		t.Errorf("failures after an abandoned trial = %d, want 1", cb.failures)
+	}
+	if !cb.Allow() {
+		t.Error("Allow() after an abandoned trial = false, want another trial call")
+	}
+}
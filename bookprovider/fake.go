@@ -0,0 +1,136 @@
+package bookprovider
+
+import (
+	"context"
+	_ "embed" // fixture embedding
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"integrated-library-service/model"
+)
+
+// FakeProviderName is the name the fixture backed provider is registered with in the provider priority list
+const FakeProviderName = "fake"
+
+var (
+	//go:embed fixtures/books.json
+	defaultFixture []byte
+)
+
+// FakeProvider is a fixture backed Provider for tests and offline development.
+type FakeProvider struct {
+	books []*model.CreateBookRequest
+	// Err, when set, is returned from every call to simulate an upstream failure
+	Err error
+}
+
+// NewFakeProvider returns a FakeProvider serving the given books.
+func NewFakeProvider(books []*model.CreateBookRequest) *FakeProvider {
+	return &FakeProvider{
+		books: books,
+	}
+}
+
+// NewFakeProviderFromFixture loads a FakeProvider from a json fixture file, the bundled fixture is used when path is empty.
+func NewFakeProviderFromFixture(path string) (*FakeProvider, error) {
+	fixture := defaultFixture
+	if len(path) != 0 {
+		data, err := os.ReadFile(path) // #nosec G304 -- fixture path comes from operator configuration
+		if err != nil {
+			return nil, err
+		}
+		fixture = data
+	}
+
+	books := []*model.CreateBookRequest{}
+	if err := json.Unmarshal(fixture, &books); err != nil {
+		return nil, err
+	}
+
+	return NewFakeProvider(books), nil
+}
+
+// Name returns the provider name of the fake provider
+func (f *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+// Search matches the search text against the fixture books
+func (f *FakeProvider) Search(_ context.Context, request *model.SearchRequest) ([]*model.CreateBookRequest, int, error) {
+	if f.Err != nil {
+		return nil, 0, f.Err
+	}
+
+	searchText := strings.ToLower(request.SearchText)
+	matches := []*model.CreateBookRequest{}
+	for _, book := range f.books {
+		var field string
+		switch request.SearchBy {
+		case "title":
+			field = book.Title
+		case "author":
+			field = book.Author
+		case "isbn":
+			field = book.ISBN
+		case "genre", "subject":
+			field = book.Genre
+		default:
+			field = strings.Join([]string{book.Title, book.Author, book.Genre, book.ISBN}, " ")
+		}
+		if strings.Contains(strings.ToLower(field), searchText) {
+			matches = append(matches, book)
+		}
+	}
+
+	return paginate(matches, request.Page, request.Limit), len(matches), nil
+}
+
+// GetByISBN returns the fixture book with the given ISBN
+func (f *FakeProvider) GetByISBN(_ context.Context, ISBN string) (*model.CreateBookRequest, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	for _, book := range f.books {
+		if book.ISBN == ISBN {
+			return book, nil
+		}
+	}
+
+	return nil, ErrBookNotFound
+}
+
+// ListNewReleases returns the fixture books ordered by published date, newest first
+func (f *FakeProvider) ListNewReleases(_ context.Context, request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error) {
+	if f.Err != nil {
+		return nil, 0, f.Err
+	}
+
+	books := make([]*model.CreateBookRequest, len(f.books))
+	copy(books, f.books)
+	sort.SliceStable(books, func(i, j int) bool {
+		return books[i].PublishedDate.After(books[j].PublishedDate)
+	})
+
+	return paginate(books, request.Page, request.Limit), len(books), nil
+}
+
+// paginate returns the requested page of books
+func paginate(books []*model.CreateBookRequest, page, limit uint32) []*model.CreateBookRequest {
+	if page == 0 || limit == 0 {
+		return books
+	}
+
+	start := int((page - 1) * limit)
+	if start >= len(books) {
+		return []*model.CreateBookRequest{}
+	}
+	end := start + int(limit)
+	if end > len(books) {
+		end = len(books)
+	}
+
+	return books[start:end]
+}
@@ -0,0 +1,139 @@
+package bookprovider
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrNoProviders is an error when the chain has no provider configured
+	ErrNoProviders = errors.New("no book provider configured")
+	// ErrAllProvidersFailed is an error when every provider in the chain failed
+	ErrAllProvidersFailed = errors.New("all book providers failed")
+	// ErrBookNotFound is an error when no provider knows the requested book, providers return it
+	// wrapped when they do not know a book
+	ErrBookNotFound = errors.New("book not found")
+)
+
+// Provider is an interface for external book metadata sources.
+type Provider interface {
+	Name() string
+	Search(ctx context.Context, request *model.SearchRequest) ([]*model.CreateBookRequest, int, error)
+	GetByISBN(ctx context.Context, ISBN string) (*model.CreateBookRequest, error)
+	ListNewReleases(ctx context.Context, request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error)
+}
+
+// Chain is a Provider which falls back through its providers in priority order
+// when one of them errors or returns nothing.
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain returns a new Chain trying the given providers in order.
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{
+		providers: providers,
+	}
+}
+
+// ParsePriority splits a comma separated priority list like "google,openlibrary"
+func ParsePriority(priority string) []string {
+	names := []string{}
+	for _, name := range strings.Split(priority, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Name returns the names of the chained providers
+func (ch *Chain) Name() string {
+	names := make([]string, 0, len(ch.providers))
+	for _, provider := range ch.providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Search returns the first non empty search result in priority order
+func (ch *Chain) Search(ctx context.Context, request *model.SearchRequest) ([]*model.CreateBookRequest, int, error) {
+	return ch.list(ctx, "Search", func(provider Provider) ([]*model.CreateBookRequest, int, error) {
+		return provider.Search(ctx, request)
+	})
+}
+
+// ListNewReleases returns the first non empty new releases list in priority order
+func (ch *Chain) ListNewReleases(ctx context.Context, request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error) {
+	return ch.list(ctx, "ListNewReleases", func(provider Provider) ([]*model.CreateBookRequest, int, error) {
+		return provider.ListNewReleases(ctx, request)
+	})
+}
+
+// GetByISBN returns the book from the first provider which knows the ISBN, a provider not knowing
+// the book is not a failure
+func (ch *Chain) GetByISBN(ctx context.Context, ISBN string) (*model.CreateBookRequest, error) {
+	if len(ch.providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	failed := 0
+	for _, provider := range ch.providers {
+		// the caller gave up, do not keep hitting the remaining providers
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		book, err := provider.GetByISBN(ctx, ISBN)
+		if errors.Is(err, ErrBookNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Error().Msgf("[Error] GetByISBN(), provider %s err: %v", provider.Name(), err)
+			failed++
+			continue
+		}
+		if book != nil {
+			return book, nil
+		}
+	}
+
+	if failed == len(ch.providers) {
+		return nil, ErrAllProvidersFailed
+	}
+	return nil, ErrBookNotFound
+}
+
+// list runs fn against every provider until one returns books
+func (ch *Chain) list(ctx context.Context, operation string, fn func(provider Provider) ([]*model.CreateBookRequest, int, error)) ([]*model.CreateBookRequest, int, error) {
+	if len(ch.providers) == 0 {
+		return nil, 0, ErrNoProviders
+	}
+
+	failed := 0
+	for _, provider := range ch.providers {
+		// the caller gave up, do not keep hitting the remaining providers
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		books, total, err := fn(provider)
+		if err != nil {
+			log.Error().Msgf("[Error] %s(), provider %s err: %v", operation, provider.Name(), err)
+			failed++
+			continue
+		}
+		if len(books) > 0 {
+			return books, total, nil
+		}
+	}
+
+	if failed == len(ch.providers) {
+		return nil, 0, ErrAllProvidersFailed
+	}
+	return []*model.CreateBookRequest{}, 0, nil
+}
@@ -0,0 +1,230 @@
+package bookprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"integrated-library-service/model"
+)
+
+// namedProvider renames a FakeProvider so a chain of fakes can tell them apart
+type namedProvider struct {
+	*FakeProvider
+	name string
+}
+
+func (n namedProvider) Name() string {
+	return n.name
+}
+
+func fakeBook(ISBN, title string) *model.CreateBookRequest {
+	return &model.CreateBookRequest{
+		ISBN:          ISBN,
+		Title:         title,
+		Author:        "Author of " + title,
+		Genre:         "Fiction",
+		PublishedDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func fake(name string, err error, books ...*model.CreateBookRequest) Provider {
+	provider := NewFakeProvider(books)
+	provider.Err = err
+	return namedProvider{FakeProvider: provider, name: name}
+}
+
+func titles(books []*model.CreateBookRequest) []string {
+	titles := []string{}
+	for _, book := range books {
+		titles = append(titles, book.Title)
+	}
+	return titles
+}
+
+func TestChainSearch(t *testing.T) {
+	errUpstream := errors.New("upstream down")
+	hobbit := fakeBook("9780547928227", "The Hobbit")
+	dune := fakeBook("9780441172719", "Dune")
+
+	tests := []struct {
+		name       string
+		providers  []Provider
+		wantTitles []string
+		wantErr    error
+	}{
+		{
+			name:       "first provider wins when both have results",
+			providers:  []Provider{fake("google", nil, hobbit), fake("openlibrary", nil, dune, hobbit)},
+			wantTitles: []string{"The Hobbit"},
+		},
+		{
+			name:       "priority order decides the winner",
+			providers:  []Provider{fake("openlibrary", nil, dune, hobbit), fake("google", nil, hobbit)},
+			wantTitles: []string{"Dune", "The Hobbit"},
+		},
+		{
+			name:       "falls back when the first provider fails",
+			providers:  []Provider{fake("google", errUpstream, hobbit), fake("openlibrary", nil, dune)},
+			wantTitles: []string{"Dune"},
+		},
+		{
+			name:       "falls back when the first provider has nothing",
+			providers:  []Provider{fake("google", nil), fake("openlibrary", nil, dune)},
+			wantTitles: []string{"Dune"},
+		},
+		{
+			name:       "empty result when nobody has books",
+			providers:  []Provider{fake("google", nil), fake("openlibrary", errUpstream, dune)},
+			wantTitles: []string{},
+		},
+		{
+			name:      "every provider failing is an error",
+			providers: []Provider{fake("google", errUpstream), fake("openlibrary", errUpstream)},
+			wantErr:   ErrAllProvidersFailed,
+		},
+		{
+			name:    "no providers",
+			wantErr: ErrNoProviders,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			books, _, err := NewChain(tt.providers...).Search(context.Background(), &model.SearchRequest{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Search() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got := titles(books); !reflect.DeepEqual(got, tt.wantTitles) {
+				t.Errorf("Search() titles = %v, want %v", got, tt.wantTitles)
+			}
+		})
+	}
+}
+
+func TestChainGetByISBN(t *testing.T) {
+	errUpstream := errors.New("upstream down")
+	hobbit := fakeBook("9780547928227", "The Hobbit")
+	otherHobbit := fakeBook("9780547928227", "The Hobbit, Second Edition")
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		wantTitle string
+		wantErr   error
+	}{
+		{
+			name:      "first provider knowing the book wins",
+			providers: []Provider{fake("google", nil, hobbit), fake("openlibrary", nil, otherHobbit)},
+			wantTitle: "The Hobbit",
+		},
+		{
+			name:      "falls back when the first provider does not know the book",
+			providers: []Provider{fake("google", nil), fake("openlibrary", nil, otherHobbit)},
+			wantTitle: "The Hobbit, Second Edition",
+		},
+		{
+			name:      "falls back when the first provider fails",
+			providers: []Provider{fake("google", errUpstream, hobbit), fake("openlibrary", nil, otherHobbit)},
+			wantTitle: "The Hobbit, Second Edition",
+		},
+		{
+			name:      "not found when one provider failed and the other does not know it",
+			providers: []Provider{fake("google", errUpstream), fake("openlibrary", nil)},
+			wantErr:   ErrBookNotFound,
+		},
+		{
+			name:      "every provider failing is an error",
+			providers: []Provider{fake("google", errUpstream), fake("openlibrary", errUpstream)},
+			wantErr:   ErrAllProvidersFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book, err := NewChain(tt.providers...).GetByISBN(context.Background(), "9780547928227")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetByISBN() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if book.Title != tt.wantTitle {
+				t.Errorf("GetByISBN() title = %q, want %q", book.Title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestChainStopsWhenCallerGaveUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	chain := NewChain(fake("google", nil, fakeBook("9780547928227", "The Hobbit")))
+	if _, _, err := chain.ListNewReleases(ctx, &model.GetAllBooksRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListNewReleases() err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestChainName(t *testing.T) {
+	chain := NewChain(fake("google", nil), fake("openlibrary", nil))
+	if got := chain.Name(); got != "google,openlibrary" {
+		t.Errorf("Name() = %q, want %q", got, "google,openlibrary")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		priority string
+		want     []string
+	}{
+		{"google,openlibrary", []string{"google", "openlibrary"}},
+		{" OpenLibrary , fake ,", []string{"openlibrary", "fake"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := ParsePriority(tt.priority); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePriority(%q) = %v, want %v", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestFakeProviderFromFixture(t *testing.T) {
+	provider, err := NewFakeProviderFromFixture("")
+	if err != nil {
+		t.Fatalf("NewFakeProviderFromFixture() err = %v", err)
+	}
+
+	book, err := provider.GetByISBN(context.Background(), "9780547928227")
+	if err != nil {
+		t.Fatalf("GetByISBN() err = %v", err)
+	}
+	if book.Title != "The Hobbit" {
+		t.Errorf("GetByISBN() title = %q, want %q", book.Title, "The Hobbit")
+	}
+
+	books, total, err := provider.ListNewReleases(context.Background(), &model.GetAllBooksRequest{Page: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListNewReleases() err = %v", err)
+	}
+	if total != 4 {
+		t.Errorf("ListNewReleases() total = %d, want 4", total)
+	}
+	if got, want := titles(books), []string{"The Go Programming Language", "The Hobbit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListNewReleases() titles = %v, want %v", got, want)
+	}
+
+	books, _, err = provider.Search(context.Background(), &model.SearchRequest{SearchBy: "author", SearchText: "tolkien"})
+	if err != nil {
+		t.Fatalf("Search() err = %v", err)
+	}
+	if got, want := titles(books), []string{"The Hobbit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() titles = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,5 @@
+-- merged duplicates are not restored
+ALTER TABLE "books" DROP CONSTRAINT IF EXISTS "books_ISBN_format";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "isbnLegacy";
+ALTER TABLE "books" DROP CONSTRAINT IF EXISTS "books_ISBN10_key";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "ISBN10";
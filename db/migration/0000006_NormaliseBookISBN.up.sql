@@ -0,0 +1,166 @@
+BEGIN;
+
+-- isbn13_is_valid checks the format and check digit of an ISBN-13
+CREATE OR REPLACE FUNCTION isbn13_is_valid(value TEXT) RETURNS BOOLEAN AS $$
+DECLARE
+    total INT := 0;
+BEGIN
+    IF value IS NULL OR value !~ '^97[89][0-9]{10}$' THEN
+        RETURN false;
+    END IF;
+    FOR i IN 1..13 LOOP
+        total := total + (CASE WHEN i % 2 = 0 THEN 3 ELSE 1 END) * substr(value, i, 1)::INT;
+    END LOOP;
+    RETURN total % 10 = 0;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- isbn10_to_13 converts a valid ISBN-10 into its ISBN-13 form, NULL for anything else
+CREATE OR REPLACE FUNCTION isbn10_to_13(value TEXT) RETURNS TEXT AS $$
+DECLARE
+    total INT := 0;
+    body TEXT;
+BEGIN
+    IF value IS NULL OR value !~ '^[0-9]{9}[0-9X]$' THEN
+        RETURN NULL;
+    END IF;
+    FOR i IN 1..10 LOOP
+        total := total + (11 - i) * (CASE WHEN substr(value, i, 1) = 'X' THEN 10 ELSE substr(value, i, 1)::INT END);
+    END LOOP;
+    IF total % 11 <> 0 THEN
+        RETURN NULL;
+    END IF;
+    body := '978' || substr(value, 1, 9);
+    total := 0;
+    FOR i IN 1..12 LOOP
+        total := total + (CASE WHEN i % 2 = 0 THEN 3 ELSE 1 END) * substr(body, i, 1)::INT;
+    END LOOP;
+    RETURN body || ((10 - total % 10) % 10)::TEXT;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- isbn13_to_10 converts a 978 prefixed ISBN-13 into its ISBN-10 alias, NULL for anything else
+CREATE OR REPLACE FUNCTION isbn13_to_10(value TEXT) RETURNS TEXT AS $$
+DECLARE
+    total INT := 0;
+    body TEXT;
+    check_digit INT;
+BEGIN
+    IF NOT isbn13_is_valid(value) OR value !~ '^978' THEN
+        RETURN NULL;
+    END IF;
+    body := substr(value, 4, 9);
+    FOR i IN 1..9 LOOP
+        total := total + (11 - i) * substr(body, i, 1)::INT;
+    END LOOP;
+    check_digit := (11 - total % 11) % 11;
+    RETURN body || (CASE WHEN check_digit = 10 THEN 'X' ELSE check_digit::TEXT END);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- isbn_canonical returns the ISBN-13 of any valid ISBN notation, NULL for other identifiers
+CREATE OR REPLACE FUNCTION isbn_canonical(value TEXT) RETURNS TEXT AS $$
+DECLARE
+    cleaned TEXT := upper(regexp_replace(coalesce(value, ''), '[- ]', '', 'g'));
+BEGIN
+    IF isbn13_is_valid(cleaned) THEN
+        RETURN cleaned;
+    END IF;
+    RETURN isbn10_to_13(cleaned);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "ISBN10" VARCHAR(10);
+
+-- provider imports without a usable ISBN and without any history are dropped, they are re-imported under their ISBN
+DELETE FROM "books" b
+WHERE
+    isbn_canonical(b."ISBN") IS NULL AND
+    b."inLibrary" = false AND
+    NOT EXISTS (SELECT 1 FROM "checkout_tickets" ct WHERE ct."bookID" = b."ID") AND
+    NOT EXISTS (SELECT 1 FROM "reviews" r WHERE r."bookID" = b."ID");
+
+-- every duplicate is merged into one surviving row per canonical ISBN
+CREATE TEMP TABLE "book_merge" ON COMMIT DROP AS
+SELECT
+    merged."ID" AS "duplicate",
+    merged."survivor"
+FROM (
+    SELECT
+        b."ID",
+        first_value(b."ID") OVER (
+            PARTITION BY isbn_canonical(b."ISBN")
+            ORDER BY b."inLibrary" DESC, b."booksLeft" DESC, b."createdAt", b."ID"
+        ) AS "survivor"
+    FROM "books" b
+    WHERE isbn_canonical(b."ISBN") IS NOT NULL
+) AS merged
+WHERE merged."ID" <> merged."survivor";
+
+UPDATE "checkout_tickets" ct SET "bookID" = bm."survivor"
+FROM "book_merge" bm
+WHERE ct."bookID" = bm."duplicate";
+
+UPDATE "reviews" r SET "bookID" = bm."survivor"
+FROM "book_merge" bm
+WHERE r."bookID" = bm."duplicate";
+
+UPDATE "books" s SET
+    "inLibrary" = s."inLibrary" OR agg."inLibrary",
+    "booksLeft" = s."booksLeft" + agg."booksLeft",
+    "views" = s."views" + agg."views",
+    "reviewCount" = s."reviewCount" + agg."reviewCount",
+    "approximateDemand" = GREATEST(s."approximateDemand", agg."approximateDemand"),
+    "wishList" = ARRAY(SELECT DISTINCT unnest(s."wishList" || agg."wishList")),
+    "viewsList" = ARRAY(SELECT DISTINCT unnest(s."viewsList" || agg."viewsList")),
+    "reviewsList" = ARRAY(SELECT DISTINCT unnest(s."reviewsList" || agg."reviewsList")),
+    "updatedAt" = NOW()
+FROM (
+    SELECT
+        bm."survivor",
+        bool_or(d."inLibrary") AS "inLibrary",
+        SUM(d."booksLeft") AS "booksLeft",
+        SUM(d."views") AS "views",
+        SUM(d."reviewCount") AS "reviewCount",
+        MAX(d."approximateDemand") AS "approximateDemand",
+        ARRAY(SELECT unnest(d2."wishList") FROM "books" d2 JOIN "book_merge" bm2 ON d2."ID" = bm2."duplicate" WHERE bm2."survivor" = bm."survivor") AS "wishList",
+        ARRAY(SELECT unnest(d2."viewsList") FROM "books" d2 JOIN "book_merge" bm2 ON d2."ID" = bm2."duplicate" WHERE bm2."survivor" = bm."survivor") AS "viewsList",
+        ARRAY(SELECT unnest(d2."reviewsList") FROM "books" d2 JOIN "book_merge" bm2 ON d2."ID" = bm2."duplicate" WHERE bm2."survivor" = bm."survivor") AS "reviewsList"
+    FROM "book_merge" bm
+    JOIN "books" d ON d."ID" = bm."duplicate"
+    GROUP BY bm."survivor"
+) AS agg
+WHERE s."ID" = agg."survivor";
+
+UPDATE "books" SET "wishlistCount" = cardinality("wishList")
+WHERE "ID" IN (SELECT DISTINCT "survivor" FROM "book_merge");
+
+DELETE FROM "books" WHERE "ID" IN (SELECT "duplicate" FROM "book_merge");
+
+UPDATE "books" SET
+    "ISBN" = isbn_canonical("ISBN"),
+    "ISBN10" = isbn13_to_10(isbn_canonical("ISBN"))
+WHERE isbn_canonical("ISBN") IS NOT NULL;
+
+-- user book lists reference books by ISBN, rewrite them to the canonical form
+UPDATE "book_details" SET
+    "reservedBookList" = ARRAY(SELECT DISTINCT coalesce(isbn_canonical(e), e) FROM unnest("reservedBookList") AS e),
+    "pendingBooksList" = ARRAY(SELECT DISTINCT coalesce(isbn_canonical(e), e) FROM unnest("pendingBooksList") AS e),
+    "checkedOutBookList" = ARRAY(SELECT DISTINCT coalesce(isbn_canonical(e), e) FROM unnest("checkedOutBookList") AS e),
+    "completedBooksList" = ARRAY(SELECT DISTINCT coalesce(isbn_canonical(e), e) FROM unnest("completedBooksList") AS e),
+    "wishlistBooks" = ARRAY(SELECT DISTINCT coalesce(isbn_canonical(e), e) FROM unnest("wishlistBooks") AS e);
+
+ALTER TABLE "books" ADD CONSTRAINT "books_ISBN10_key" UNIQUE ("ISBN10");
+
+-- library owned rows with a non ISBN identifier are kept as they are and flagged, so the format
+-- check only applies to new rows and later updates of the legacy rows keep passing it
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "isbnLegacy" BOOLEAN NOT NULL DEFAULT false;
+UPDATE "books" SET "isbnLegacy" = true WHERE "ISBN" !~ '^97[89][0-9]{10}$';
+ALTER TABLE "books" ADD CONSTRAINT "books_ISBN_format" CHECK ("isbnLegacy" OR "ISBN" ~ '^97[89][0-9]{10}$');
+
+DROP FUNCTION isbn_canonical(TEXT);
+DROP FUNCTION isbn13_to_10(TEXT);
+DROP FUNCTION isbn10_to_13(TEXT);
+DROP FUNCTION isbn13_is_valid(TEXT);
+
+COMMIT;
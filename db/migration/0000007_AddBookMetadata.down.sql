@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "book_genres";
+DROP TABLE IF EXISTS "genres";
+DROP TABLE IF EXISTS "book_authors";
+DROP TABLE IF EXISTS "authors";
+DROP INDEX IF EXISTS "books_language_idx";
+ALTER TABLE "books"
+    DROP COLUMN IF EXISTS "subtitle",
+    DROP COLUMN IF EXISTS "publisher",
+    DROP COLUMN IF EXISTS "pageCount",
+    DROP COLUMN IF EXISTS "language",
+    DROP COLUMN IF EXISTS "maturityRating";
@@ -0,0 +1,65 @@
+BEGIN;
+
+ALTER TABLE "books"
+    ADD COLUMN IF NOT EXISTS "subtitle" VARCHAR,
+    ADD COLUMN IF NOT EXISTS "publisher" VARCHAR,
+    ADD COLUMN IF NOT EXISTS "pageCount" NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "language" VARCHAR,
+    ADD COLUMN IF NOT EXISTS "maturityRating" VARCHAR;
+
+CREATE TABLE IF NOT EXISTS "authors" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "name" VARCHAR NOT NULL UNIQUE,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS "book_authors" (
+    "bookID" UUID NOT NULL,
+    "authorID" UUID NOT NULL,
+    "position" NUMERIC NOT NULL DEFAULT 1,
+    PRIMARY KEY ("bookID", "authorID"),
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE CASCADE,
+    FOREIGN KEY ("authorID") REFERENCES "authors"("ID") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "genres" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "name" VARCHAR NOT NULL UNIQUE,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS "book_genres" (
+    "bookID" UUID NOT NULL,
+    "genreID" UUID NOT NULL,
+    "position" NUMERIC NOT NULL DEFAULT 1,
+    PRIMARY KEY ("bookID", "genreID"),
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE CASCADE,
+    FOREIGN KEY ("genreID") REFERENCES "genres"("ID") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "book_authors_authorID_idx" ON "book_authors"("authorID");
+CREATE INDEX IF NOT EXISTS "book_genres_genreID_idx" ON "book_genres"("genreID");
+CREATE INDEX IF NOT EXISTS "books_language_idx" ON "books"("language");
+
+-- the single author and genre of existing books become their first author and genre
+INSERT INTO "authors"("name")
+SELECT DISTINCT TRIM("author") FROM "books" WHERE TRIM("author") <> ''
+ON CONFLICT ("name") DO NOTHING;
+
+INSERT INTO "book_authors"("bookID", "authorID", "position")
+SELECT b."ID", a."ID", 1
+FROM "books" b
+JOIN "authors" a ON a."name" = TRIM(b."author")
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "genres"("name")
+SELECT DISTINCT TRIM("genre") FROM "books" WHERE TRIM("genre") <> ''
+ON CONFLICT ("name") DO NOTHING;
+
+INSERT INTO "book_genres"("bookID", "genreID", "position")
+SELECT b."ID", g."ID", 1
+FROM "books" b
+JOIN "genres" g ON g."name" = TRIM(b."genre")
+ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "catalogue_sync_runs";
+DROP TYPE IF EXISTS SYNC_RUN_STATUS;
+ALTER TABLE "books" DROP COLUMN IF EXISTS "metadataSyncedAt";
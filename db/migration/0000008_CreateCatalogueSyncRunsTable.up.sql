@@ -0,0 +1,22 @@
+BEGIN;
+
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "metadataSyncedAt" TIMESTAMP(3);
+
+CREATE TYPE SYNC_RUN_STATUS AS ENUM('running','succeeded','failed');
+
+CREATE TABLE IF NOT EXISTS "catalogue_sync_runs" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "trigger" VARCHAR NOT NULL DEFAULT 'scheduled',
+    "status" SYNC_RUN_STATUS NOT NULL DEFAULT 'running',
+    "added" NUMERIC NOT NULL DEFAULT 0,
+    "updated" NUMERIC NOT NULL DEFAULT 0,
+    "skipped" NUMERIC NOT NULL DEFAULT 0,
+    "failed" NUMERIC NOT NULL DEFAULT 0,
+    "error" TEXT,
+    "startedAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "finishedAt" TIMESTAMP(3)
+);
+
+CREATE INDEX IF NOT EXISTS "catalogue_sync_runs_startedAt_idx" ON "catalogue_sync_runs"("startedAt" DESC);
+
+COMMIT;
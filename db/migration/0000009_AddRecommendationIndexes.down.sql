@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "checkout_tickets_userID_idx";
+DROP INDEX IF EXISTS "checkout_tickets_bookID_idx";
+DROP INDEX IF EXISTS "reviews_userID_idx";
+DROP INDEX IF EXISTS "book_authors_bookID_idx";
+DROP INDEX IF EXISTS "book_genres_bookID_idx";
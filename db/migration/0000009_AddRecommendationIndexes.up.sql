@@ -0,0 +1,9 @@
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS "checkout_tickets_userID_idx" ON "checkout_tickets"("userID");
+CREATE INDEX IF NOT EXISTS "checkout_tickets_bookID_idx" ON "checkout_tickets"("bookID");
+CREATE INDEX IF NOT EXISTS "reviews_userID_idx" ON "reviews"("userID");
+CREATE INDEX IF NOT EXISTS "book_authors_bookID_idx" ON "book_authors"("bookID");
+CREATE INDEX IF NOT EXISTS "book_genres_bookID_idx" ON "book_genres"("bookID");
+
+COMMIT;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "books_desc_trgm_idx";
+DROP TABLE IF EXISTS "book_similarities";
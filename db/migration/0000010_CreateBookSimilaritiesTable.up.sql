@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "book_similarities" (
+    "bookID" UUID NOT NULL,
+    "similarBookID" UUID NOT NULL,
+    "score" REAL NOT NULL DEFAULT 0,
+    "authorScore" REAL NOT NULL DEFAULT 0,
+    "genreScore" REAL NOT NULL DEFAULT 0,
+    "descriptionScore" REAL NOT NULL DEFAULT 0,
+    "coBorrowScore" REAL NOT NULL DEFAULT 0,
+    "computedAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("bookID", "similarBookID"),
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE CASCADE,
+    FOREIGN KEY ("similarBookID") REFERENCES "books"("ID") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "book_similarities_bookID_score_idx" ON "book_similarities"("bookID", "score" DESC);
+
+-- lets the description similarity join use the % operator instead of comparing every pair
+CREATE INDEX IF NOT EXISTS "books_desc_trgm_idx" ON "books" USING GIN ("desc" gin_trgm_ops);
+
+COMMIT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "books_approximateDemand_idx";
+DROP INDEX IF EXISTS "checkout_tickets_checkedOutOn_idx";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "demandComputedAt";
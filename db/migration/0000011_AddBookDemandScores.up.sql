@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "demandComputedAt" TIMESTAMP(3);
+
+-- the demand job decays checkouts and holds by their age
+CREATE INDEX IF NOT EXISTS "checkout_tickets_checkedOutOn_idx" ON "checkout_tickets"("checkedOutOn");
+CREATE INDEX IF NOT EXISTS "books_approximateDemand_idx" ON "books"("approximateDemand" DESC);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "search_misses" (
+    "term" TEXT PRIMARY KEY,
+    "misses" INTEGER NOT NULL DEFAULT 1,
+    "firstMissedAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "lastMissedAt" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "search_misses_lastMissedAt_idx" ON "search_misses"("lastMissedAt");
+
+COMMIT;
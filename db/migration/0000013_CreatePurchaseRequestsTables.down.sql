@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "purchase_request_events";
+DROP TABLE IF EXISTS "purchase_requests";
+DROP TYPE IF EXISTS PURCHASE_REQUEST_STATUS;
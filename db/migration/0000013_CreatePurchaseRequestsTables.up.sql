@@ -0,0 +1,52 @@
+BEGIN;
+
+CREATE TYPE PURCHASE_REQUEST_STATUS AS ENUM('submitted','under_review','approved','ordered','received','rejected');
+
+CREATE TABLE IF NOT EXISTS "purchase_requests" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "userID" UUID NOT NULL,
+    "bookID" UUID,
+    "ISBN" VARCHAR(13) NOT NULL,
+    "title" VARCHAR(100),
+    "note" TEXT,
+    "status" PURCHASE_REQUEST_STATUS NOT NULL DEFAULT 'submitted',
+    "holdID" UUID,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP(3),
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE CASCADE,
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE SET NULL,
+    FOREIGN KEY ("holdID") REFERENCES "checkout_tickets"("ID") ON DELETE SET NULL
+);
+
+-- a patron has at most one open request per ISBN
+CREATE UNIQUE INDEX IF NOT EXISTS "purchase_requests_open_userID_ISBN_idx"
+    ON "purchase_requests"("userID", "ISBN") WHERE "status" NOT IN ('received', 'rejected');
+CREATE INDEX IF NOT EXISTS "purchase_requests_status_createdAt_idx" ON "purchase_requests"("status", "createdAt");
+
+CREATE TABLE IF NOT EXISTS "purchase_request_events" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "requestID" UUID NOT NULL,
+    "status" PURCHASE_REQUEST_STATUS NOT NULL,
+    "comment" TEXT,
+    "changedBy" UUID,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("requestID") REFERENCES "purchase_requests"("ID") ON DELETE CASCADE,
+    FOREIGN KEY ("changedBy") REFERENCES "users"("userID") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS "purchase_request_events_requestID_idx" ON "purchase_request_events"("requestID", "createdAt");
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "userID" UUID NOT NULL,
+    "type" VARCHAR(50) NOT NULL,
+    "message" TEXT NOT NULL,
+    "referenceID" UUID,
+    "readAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "notifications_userID_createdAt_idx" ON "notifications"("userID", "createdAt" DESC);
+
+COMMIT;
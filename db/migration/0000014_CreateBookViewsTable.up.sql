@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS "book_views" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "bookID" UUID NOT NULL,
+    "userID" UUID,
+    "viewedAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE CASCADE,
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS "book_views_bookID_viewedAt_idx" ON "book_views"("bookID", "viewedAt");
+CREATE INDEX IF NOT EXISTS "book_views_userID_bookID_viewedAt_idx" ON "book_views"("userID", "bookID", "viewedAt" DESC);
+CREATE INDEX IF NOT EXISTS "book_views_viewedAt_idx" ON "book_views"("viewedAt");
+
+-- "viewsList" only keeps the 50 most recent viewers from now on
+UPDATE "books" SET
+    "viewsList" = "viewsList"[array_length("viewsList", 1) - 49:]
+WHERE
+    array_length("viewsList", 1) > 50;
+
+COMMIT;
@@ -0,0 +1,3 @@
+ALTER TABLE "checkout_tickets" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "version";
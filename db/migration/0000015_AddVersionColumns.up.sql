@@ -0,0 +1,8 @@
+BEGIN;
+
+-- bumped by every write to the fields clients edit, used for If-Match on PATCH
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "version" BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "version" BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE "checkout_tickets" ADD COLUMN IF NOT EXISTS "version" BIGINT NOT NULL DEFAULT 1;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- a user has at most one pending email change, a new request replaces it
+CREATE TABLE IF NOT EXISTS "email_change_requests" (
+    "userID" UUID NOT NULL PRIMARY KEY,
+    "newEmail" VARCHAR(50) NOT NULL,
+    "tokenHash" VARCHAR(64) NOT NULL UNIQUE,
+    "expiresAt" TIMESTAMP(3) NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE CASCADE
+);
+
+COMMIT;
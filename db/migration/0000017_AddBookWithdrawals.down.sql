@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "book_withdrawals";
+DROP TYPE IF EXISTS BOOK_WITHDRAWAL_ACTION;
+DROP INDEX IF EXISTS "books_withdrawnAt_idx";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "withdrawalReason";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "withdrawnAt";
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- withdrawn books stay in the table for the loan and review history but are hidden from the catalogue
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "withdrawnAt" TIMESTAMP(3);
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "withdrawalReason" TEXT;
+
+CREATE INDEX IF NOT EXISTS "books_withdrawnAt_idx" ON "books"("withdrawnAt") WHERE "withdrawnAt" IS NOT NULL;
+
+CREATE TYPE BOOK_WITHDRAWAL_ACTION AS ENUM('withdraw', 'restore', 'purge');
+
+-- the ISBN and title are kept so the history outlives a purged book
+CREATE TABLE IF NOT EXISTS "book_withdrawals" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "bookID" UUID,
+    "ISBN" VARCHAR NOT NULL,
+    "title" VARCHAR(100) NOT NULL,
+    "action" BOOK_WITHDRAWAL_ACTION NOT NULL,
+    "copies" INT,
+    "reason" TEXT,
+    "librarianID" UUID,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE SET NULL,
+    FOREIGN KEY ("librarianID") REFERENCES "users"("userID") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS "book_withdrawals_ISBN_createdAt_idx" ON "book_withdrawals"("ISBN", "createdAt");
+
+COMMIT;
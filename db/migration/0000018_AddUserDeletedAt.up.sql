@@ -0,0 +1,8 @@
+BEGIN;
+
+-- closed accounts are anonymised in place so their loans and reviews keep counting
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "deletedAt" TIMESTAMP(3);
+
+CREATE INDEX IF NOT EXISTS "users_deletedAt_idx" ON "users"("deletedAt") WHERE "deletedAt" IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "book_import_rows";
+DROP TABLE IF EXISTS "book_imports";
+DROP TYPE IF EXISTS BOOK_IMPORT_OUTCOME;
+DROP TYPE IF EXISTS BOOK_IMPORT_STATUS;
+DROP TYPE IF EXISTS BOOK_IMPORT_FORMAT;
@@ -0,0 +1,42 @@
+BEGIN;
+
+CREATE TYPE BOOK_IMPORT_FORMAT AS ENUM('csv', 'marc', 'marcxml');
+
+CREATE TYPE BOOK_IMPORT_STATUS AS ENUM('queued', 'running', 'succeeded', 'failed');
+
+CREATE TYPE BOOK_IMPORT_OUTCOME AS ENUM('created', 'updated', 'error');
+
+CREATE TABLE IF NOT EXISTS "book_imports" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "format" BOOK_IMPORT_FORMAT NOT NULL,
+    "filename" VARCHAR NOT NULL,
+    "dryRun" BOOLEAN NOT NULL DEFAULT false,
+    "status" BOOK_IMPORT_STATUS NOT NULL DEFAULT 'queued',
+    "totalRows" INT NOT NULL DEFAULT 0,
+    "processed" INT NOT NULL DEFAULT 0,
+    "created" INT NOT NULL DEFAULT 0,
+    "updated" INT NOT NULL DEFAULT 0,
+    "failed" INT NOT NULL DEFAULT 0,
+    "error" TEXT,
+    "librarianID" UUID,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "startedAt" TIMESTAMP(3),
+    "finishedAt" TIMESTAMP(3),
+    FOREIGN KEY ("librarianID") REFERENCES "users"("userID") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS "book_imports_createdAt_idx" ON "book_imports"("createdAt" DESC);
+
+-- the per row report, for a dry run the outcome is what the import would have done
+CREATE TABLE IF NOT EXISTS "book_import_rows" (
+    "importID" UUID NOT NULL,
+    "rowNumber" INT NOT NULL,
+    "ISBN" VARCHAR,
+    "title" VARCHAR,
+    "outcome" BOOK_IMPORT_OUTCOME NOT NULL,
+    "message" TEXT,
+    PRIMARY KEY ("importID", "rowNumber"),
+    FOREIGN KEY ("importID") REFERENCES "book_imports"("ID") ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "user_invites";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "cardNumber";
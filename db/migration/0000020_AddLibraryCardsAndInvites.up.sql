@@ -0,0 +1,15 @@
+BEGIN;
+
+-- the card number is an alternate login and checkout identifier, users without a card have NULL
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "cardNumber" VARCHAR(14) UNIQUE;
+
+-- imported users have no password until they accept their invite, a new invite replaces the old one
+CREATE TABLE IF NOT EXISTS "user_invites" (
+    "userID" UUID NOT NULL PRIMARY KEY,
+    "tokenHash" VARCHAR(64) NOT NULL UNIQUE,
+    "expiresAt" TIMESTAMP(3) NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE CASCADE
+);
+
+COMMIT;
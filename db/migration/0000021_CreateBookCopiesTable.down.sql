@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "book_copies";
+
+DROP SEQUENCE IF EXISTS "book_copy_barcode_seq";
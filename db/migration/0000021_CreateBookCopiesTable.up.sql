@@ -0,0 +1,16 @@
+BEGIN;
+
+-- the barcodes of book copies are numbered from a sequence and get a check digit in the service
+CREATE SEQUENCE IF NOT EXISTS "book_copy_barcode_seq";
+
+-- copies are registered when their codes or labels are first printed, loans still count per book
+CREATE TABLE IF NOT EXISTS "book_copies" (
+    "barcode" VARCHAR(14) NOT NULL PRIMARY KEY,
+    "bookID" UUID NOT NULL,
+    "copyNumber" INTEGER NOT NULL,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    UNIQUE ("bookID", "copyNumber"),
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "audit_logs";
+DROP INDEX IF EXISTS "checkout_tickets_copyBarcode_idx";
+ALTER TABLE "checkout_tickets" DROP COLUMN IF EXISTS "copyBarcode";
+DROP TABLE IF EXISTS "kiosk_sessions";
+DROP TABLE IF EXISTS "kiosk_devices";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pinFailedAttempts";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pinHash";
@@ -0,0 +1,57 @@
+BEGIN;
+
+-- patrons sign in at self-service kiosks with their library card and a PIN, repeated wrong PINs lock it
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "pinHash" VARCHAR(255);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "pinFailedAttempts" INTEGER NOT NULL DEFAULT 0;
+
+-- kiosks authenticate with a device token instead of a user JWT, only its hash is stored
+CREATE TABLE IF NOT EXISTS "kiosk_devices" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "name" VARCHAR(100) NOT NULL,
+    "tokenHash" VARCHAR(64) NOT NULL UNIQUE,
+    "createdBy" UUID,
+    "lastSeenAt" TIMESTAMP(3),
+    "revokedAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("createdBy") REFERENCES "users"("userID") ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS "kiosk_sessions" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "deviceID" UUID NOT NULL,
+    "userID" UUID NOT NULL,
+    "tokenHash" VARCHAR(64) NOT NULL UNIQUE,
+    "expiresAt" TIMESTAMP(3) NOT NULL,
+    "endedAt" TIMESTAMP(3),
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("deviceID") REFERENCES "kiosk_devices"("ID") ON DELETE CASCADE,
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE CASCADE
+);
+
+-- loans made at a kiosk remember the copy so it can be returned by its barcode
+ALTER TABLE "checkout_tickets" ADD COLUMN IF NOT EXISTS "copyBarcode" VARCHAR(14);
+CREATE INDEX IF NOT EXISTS "checkout_tickets_copyBarcode_idx" ON "checkout_tickets"("copyBarcode") WHERE "isReturned" = false;
+
+-- who did what, actions at a kiosk are attributed to the device and the patron session
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "ID" UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "action" VARCHAR(50) NOT NULL,
+    "actorID" UUID,
+    "deviceID" UUID,
+    "sessionID" UUID,
+    "userID" UUID,
+    "entityType" VARCHAR(50),
+    "entityID" VARCHAR(64),
+    "details" JSONB,
+    "createdAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("actorID") REFERENCES "users"("userID") ON DELETE SET NULL,
+    FOREIGN KEY ("deviceID") REFERENCES "kiosk_devices"("ID") ON DELETE SET NULL,
+    FOREIGN KEY ("sessionID") REFERENCES "kiosk_sessions"("ID") ON DELETE SET NULL,
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS "audit_logs_createdAt_idx" ON "audit_logs"("createdAt" DESC);
+CREATE INDEX IF NOT EXISTS "audit_logs_deviceID_createdAt_idx" ON "audit_logs"("deviceID", "createdAt" DESC);
+CREATE INDEX IF NOT EXISTS "audit_logs_sessionID_idx" ON "audit_logs"("sessionID");
+
+COMMIT;
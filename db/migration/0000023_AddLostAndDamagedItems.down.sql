@@ -0,0 +1,6 @@
+ALTER TABLE "book_copies" DROP COLUMN IF EXISTS "status";
+ALTER TABLE "checkout_tickets" DROP COLUMN IF EXISTS "incidentFee";
+ALTER TABLE "checkout_tickets" DROP COLUMN IF EXISTS "foundAt";
+ALTER TABLE "checkout_tickets" DROP COLUMN IF EXISTS "damagedAt";
+ALTER TABLE "checkout_tickets" DROP COLUMN IF EXISTS "lostAt";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "price";
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- the replacement cost of a copy, fees for lost and damaged copies are charged from it
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "price" NUMERIC;
+
+-- a loan ends lost or damaged instead of returned, a lost copy can still be found later
+ALTER TABLE "checkout_tickets" ADD COLUMN IF NOT EXISTS "lostAt" TIMESTAMP(3);
+ALTER TABLE "checkout_tickets" ADD COLUMN IF NOT EXISTS "damagedAt" TIMESTAMP(3);
+ALTER TABLE "checkout_tickets" ADD COLUMN IF NOT EXISTS "foundAt" TIMESTAMP(3);
+-- the replacement or repair fee included in "fineAmount", refunded when a lost copy is found
+ALTER TABLE "checkout_tickets" ADD COLUMN IF NOT EXISTS "incidentFee" NUMERIC NOT NULL DEFAULT 0;
+
+ALTER TABLE "book_copies" ADD COLUMN IF NOT EXISTS "status" VARCHAR(20) NOT NULL DEFAULT 'available';
+
+COMMIT;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "checkout_tickets_updatedAt_idx";
+DROP TABLE IF EXISTS "daily_book_views";
+DROP TABLE IF EXISTS "daily_active_users";
+DROP TABLE IF EXISTS "daily_rollups";
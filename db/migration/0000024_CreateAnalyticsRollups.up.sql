@@ -0,0 +1,35 @@
+BEGIN;
+
+-- the dashboard reads the activity of every day from here, the rollup job recomputes recent days
+CREATE TABLE IF NOT EXISTS "daily_rollups" (
+    "day" DATE PRIMARY KEY,
+    "checkoutTickets" BIGINT NOT NULL DEFAULT 0,
+    "checkouts" BIGINT NOT NULL DEFAULT 0,
+    "returns" BIGINT NOT NULL DEFAULT 0,
+    "newUsers" BIGINT NOT NULL DEFAULT 0,
+    "newBooks" BIGINT NOT NULL DEFAULT 0,
+    "fineAmount" NUMERIC NOT NULL DEFAULT 0,
+    "views" BIGINT NOT NULL DEFAULT 0,
+    "refreshedAt" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+-- active users of a period can not be summed from the days, so every active user of a day is kept
+CREATE TABLE IF NOT EXISTS "daily_active_users" (
+    "day" DATE NOT NULL,
+    "userID" UUID NOT NULL,
+    PRIMARY KEY ("day", "userID")
+);
+
+CREATE TABLE IF NOT EXISTS "daily_book_views" (
+    "day" DATE NOT NULL,
+    "bookID" UUID NOT NULL,
+    "views" BIGINT NOT NULL DEFAULT 0,
+    "uniqueViewers" BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY ("day", "bookID"),
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE CASCADE
+);
+
+-- fines of old tickets change, the job finds their days by the last update of the ticket
+CREATE INDEX IF NOT EXISTS "checkout_tickets_updatedAt_idx" ON "checkout_tickets"("updatedAt");
+
+COMMIT;
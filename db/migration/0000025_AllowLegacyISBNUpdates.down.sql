@@ -0,0 +1 @@
+-- the flag is kept, 0000006 owns it
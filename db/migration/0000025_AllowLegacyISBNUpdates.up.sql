@@ -0,0 +1,10 @@
+BEGIN;
+
+-- databases which ran the first version of 0000006 check the ISBN format on every update of a
+-- legacy row, flag those rows and let the format check skip them
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "isbnLegacy" BOOLEAN NOT NULL DEFAULT false;
+UPDATE "books" SET "isbnLegacy" = true WHERE "ISBN" !~ '^97[89][0-9]{10}$' AND "isbnLegacy" = false;
+ALTER TABLE "books" DROP CONSTRAINT IF EXISTS "books_ISBN_format";
+ALTER TABLE "books" ADD CONSTRAINT "books_ISBN_format" CHECK ("isbnLegacy" OR "ISBN" ~ '^97[89][0-9]{10}$');
+
+COMMIT;
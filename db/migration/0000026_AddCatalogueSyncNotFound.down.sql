@@ -0,0 +1 @@
+ALTER TABLE "catalogue_sync_runs" DROP COLUMN IF EXISTS "notFound";
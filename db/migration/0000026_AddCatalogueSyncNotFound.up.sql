@@ -0,0 +1,6 @@
+BEGIN;
+
+-- books no provider knows are marked as checked and counted apart from failures
+ALTER TABLE "catalogue_sync_runs" ADD COLUMN IF NOT EXISTS "notFound" NUMERIC NOT NULL DEFAULT 0;
+
+COMMIT;
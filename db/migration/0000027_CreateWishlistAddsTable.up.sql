@@ -0,0 +1,24 @@
+BEGIN;
+
+-- wishlist entries with the time they were added, so demand and recommendations can let them age
+CREATE TABLE IF NOT EXISTS "wishlist_adds" (
+    "userID" UUID NOT NULL,
+    "bookID" UUID NOT NULL,
+    "addedAt" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("userID", "bookID"),
+    FOREIGN KEY ("userID") REFERENCES "users"("userID") ON DELETE CASCADE,
+    FOREIGN KEY ("bookID") REFERENCES "books"("ID") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "wishlist_adds_bookID_idx" ON "wishlist_adds"("bookID");
+
+-- the existing entries carry no date, they start ageing now
+INSERT INTO "wishlist_adds"("userID", "bookID")
+SELECT DISTINCT
+    bd."userID", b."ID"
+FROM
+    "book_details" bd
+JOIN "books" b ON b."ISBN" = ANY(bd."wishlistBooks") OR b."ISBN10" = ANY(bd."wishlistBooks")
+ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,2 @@
+ALTER TABLE "book_withdrawals" DROP COLUMN IF EXISTS "barcodes";
+ALTER TABLE "books" DROP COLUMN IF EXISTS "purgedAt";
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- purged books keep their row, stripped of their catalogue details, so their loans and reviews stay
+ALTER TABLE "books" ADD COLUMN IF NOT EXISTS "purgedAt" TIMESTAMP(3);
+
+-- copies are withdrawn and restored by barcode, the history names them
+ALTER TABLE "book_withdrawals" ADD COLUMN IF NOT EXISTS "barcodes" VARCHAR(14)[];
+
+COMMIT;
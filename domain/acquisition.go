@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrGetAcquisitionSuggestionsFailed is an error when building the acquisition suggestions failed
+	ErrGetAcquisitionSuggestionsFailed = errors.New("get acquisition suggestions failed")
+)
+
+// GetAcquisitionSuggestions ranks the books to add copies of, the catalogue books to acquire and the recent
+// search terms without local hits. Copies needed is the number of copies serving the waiting holds and the
+// share of wishlists expected to borrow, less the copies left on the shelf. A zero limit returns every
+// suggestion on a single page.
+func (l *LibraryService) GetAcquisitionSuggestions(request *model.GetAcquisitionSuggestionsRequest) ([]model.AcquisitionSuggestion, uint, error) {
+	sqlStatement := `
+		WITH "holds" AS (
+			SELECT "bookID", COUNT(*) AS "holds"
+			FROM "checkout_tickets"
+			WHERE "isCheckedOut" = false AND "isReturned" = false
+			GROUP BY "bookID"
+		),
+		"bookSignals" AS (
+			SELECT
+				b."ISBN",
+				b."title",
+				b."author",
+				b."inLibrary",
+				b."booksLeft"::bigint AS "booksLeft",
+				COALESCE(h."holds", 0)::bigint AS "holds",
+				b."wishlistCount"::bigint AS "wishlistCount",
+				b."approximateDemand"::bigint AS "approximateDemand",
+				CEIL((COALESCE(h."holds", 0) + b."wishlistCount" * $2::float) / $1::float)::bigint AS "copiesWanted"
+			FROM
+				"books" b
+			LEFT JOIN "holds" h ON h."bookID" = b."ID"
+			WHERE
+				b."withdrawnAt" IS NULL
+		),
+		"suggestions" AS (
+			SELECT
+				CASE WHEN "inLibrary" THEN $7 ELSE $8 END AS "kind",
+				"ISBN",
+				"title",
+				"author",
+				"inLibrary",
+				"booksLeft",
+				"holds",
+				"wishlistCount",
+				"approximateDemand",
+				0::bigint AS "searchMisses",
+				CASE
+					WHEN "inLibrary" THEN GREATEST("copiesWanted" - "booksLeft", 0)
+					ELSE GREATEST("copiesWanted", 1)
+				END AS "copiesNeeded",
+				"holds" * $3::float + "wishlistCount" * $4::float + "approximateDemand" * $5::float AS "score"
+			FROM
+				"bookSignals"
+			WHERE
+				("inLibrary" AND "copiesWanted" > "booksLeft") OR
+				(NOT "inLibrary" AND ("holds" > 0 OR "wishlistCount" > 0))
+			UNION ALL
+			SELECT
+				$9,
+				CASE WHEN m."term" ~ '^97[89][0-9]{10}$' THEN m."term" ELSE '' END,
+				m."term",
+				'',
+				false,
+				0,
+				0,
+				0,
+				0,
+				m."misses"::bigint,
+				1,
+				m."misses" * $6::float
+			FROM
+				"search_misses" m
+			WHERE
+				m."lastMissedAt" > NOW() - make_interval(days => $10) AND
+				-- books added since the search are no longer suggested
+				NOT EXISTS (
+					SELECT 1 FROM "books" b
+					WHERE
+						POSITION(m."term" IN LOWER(b."title")) > 0 OR POSITION(m."term" IN LOWER(b."author")) > 0 OR
+						b."ISBN" = m."term" OR b."ISBN10" = m."term"
+				)
+		)
+		SELECT
+			"kind",
+			"ISBN",
+			"title",
+			"author",
+			"inLibrary",
+			"booksLeft",
+			"holds",
+			"wishlistCount",
+			"approximateDemand",
+			"searchMisses",
+			"copiesNeeded",
+			"score",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"suggestions"
+		ORDER BY
+			"score" DESC, "copiesNeeded" DESC, "title" ASC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ""
+	if request.Limit > 0 {
+		limitOffset = fmt.Sprintf(` LIMIT %d OFFSET %d`, request.Limit, (request.Page-1)*(request.Limit))
+	}
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(
+		sqlStatement,
+		model.AcquisitionPatronsPerCopy,
+		model.AcquisitionWishlistConversion,
+		model.AcquisitionHoldWeight,
+		model.AcquisitionWishlistWeight,
+		model.AcquisitionDemandWeight,
+		model.AcquisitionSearchMissWeight,
+		model.AcquisitionKindAddCopies,
+		model.AcquisitionKindAcquire,
+		model.AcquisitionKindSearchTerm,
+		model.AcquisitionSearchMissDays,
+	)
+	if err != nil {
+		log.Error().Msgf("[Error] GetAcquisitionSuggestions(), db.Query err: %v", err)
+		return nil, 0, ErrGetAcquisitionSuggestionsFailed
+	}
+	defer rows.Close()
+
+	var (
+		suggestions = []model.AcquisitionSuggestion{}
+		totalRows   uint32
+	)
+	for rows.Next() {
+		var suggestion model.AcquisitionSuggestion
+		err := rows.Scan(
+			&suggestion.Kind,
+			&suggestion.ISBN,
+			&suggestion.Title,
+			&suggestion.Author,
+			&suggestion.InLibrary,
+			&suggestion.BooksLeft,
+			&suggestion.Holds,
+			&suggestion.WishlistCount,
+			&suggestion.ApproximateDemand,
+			&suggestion.SearchMisses,
+			&suggestion.CopiesNeeded,
+			&suggestion.Score,
+			&totalRows,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetAcquisitionSuggestions(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetAcquisitionSuggestionsFailed
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if request.Limit == 0 {
+		return suggestions, 1, nil
+	}
+	// Calculate total pages
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return suggestions, uint(totalPages), nil
+}
+
+// recordSearchMiss counts a search term which matched no local book, failures are only logged as the
+// search itself succeeded. An ISBN is counted as its ISBN-13 so the miss clears once the book is added.
+func (l *LibraryService) recordSearchMiss(searchText string) {
+	term := strings.ToLower(strings.Join(strings.Fields(searchText), " "))
+	if len(term) == 0 || len(term) > 200 {
+		return
+	}
+	if ISBN13, _, err := isbn.Parse(term); err == nil {
+		term = ISBN13
+	}
+
+	sqlStatement := `
+		INSERT INTO "search_misses"("term")
+		VALUES ($1)
+		ON CONFLICT("term")
+		DO UPDATE SET
+			"misses" = "search_misses"."misses" + 1,
+			"lastMissedAt" = NOW();
+	`
+
+	if _, err := l.db.Exec(sqlStatement, term); err != nil {
+		log.Error().Msgf("[Error] recordSearchMiss(), db.Exec err: %v", err)
+	}
+}
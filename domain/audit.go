@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedGetAuditLogs is an error when get audit logs failed
+	ErrFailedGetAuditLogs = errors.New("get audit logs failed")
+)
+
+// auditDetails encodes the details of an audit log entry, entries without details store NULL
+func auditDetails(details map[string]interface{}) (interface{}, error) {
+	if len(details) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// writeAuditLog appends an entry to the audit trail, in the transaction of the action it records
+func writeAuditLog(ex execer, entry *model.AuditLog, details map[string]interface{}) error {
+	encoded, err := auditDetails(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = ex.Exec(`
+		INSERT INTO "audit_logs" (
+			"action", "actorID", "deviceID", "sessionID", "userID", "entityType", "entityID", "details"
+		) VALUES (
+			$1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8
+		);
+	`,
+		entry.Action,
+		entry.ActorID,
+		entry.DeviceID,
+		entry.SessionID,
+		entry.UserID,
+		entry.EntityType,
+		entry.EntityID,
+		encoded,
+	)
+	return err
+}
+
+// GetAuditLogs returns a page of the audit trail, newest first
+func (l *LibraryService) GetAuditLogs(request *model.GetAuditLogsRequest) ([]model.AuditLog, uint, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	filter := func(column, value string) {
+		if len(value) == 0 {
+			return
+		}
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(`%s = $%d`, column, len(args)))
+	}
+	filter(`"deviceID"`, request.DeviceID)
+	filter(`"userID"`, request.UserID)
+	filter(`"action"`, request.Action)
+	where := strings.Join(conditions, " AND ")
+
+	sqlStatement := `
+		SELECT
+			"ID",
+			"action",
+			"actorID",
+			"deviceID",
+			"sessionID",
+			"userID",
+			COALESCE("entityType", ''),
+			COALESCE("entityID", ''),
+			"details",
+			"createdAt"
+		FROM
+			"audit_logs"
+		WHERE
+			%s
+		ORDER BY
+			"createdAt" DESC, "ID" DESC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, where, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement, args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetAuditLogs(), db.Query err: %v", err)
+		return nil, 0, ErrFailedGetAuditLogs
+	}
+	defer rows.Close()
+
+	entries := []model.AuditLog{}
+	for rows.Next() {
+		var (
+			entry   model.AuditLog
+			details []byte
+		)
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Action,
+			&entry.ActorID,
+			&entry.DeviceID,
+			&entry.SessionID,
+			&entry.UserID,
+			&entry.EntityType,
+			&entry.EntityID,
+			&details,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetAuditLogs(), rows.Scan err: %v", err)
+			return nil, 0, ErrFailedGetAuditLogs
+		}
+		if len(details) > 0 {
+			entry.Details = details
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetAuditLogs(), rows.Err err: %v", err)
+		return nil, 0, ErrFailedGetAuditLogs
+	}
+
+	var totalRows uint
+	err = l.db.QueryRow(`SELECT COUNT(*) FROM "audit_logs" WHERE `+where+`;`, args...).Scan(&totalRows)
+	if err != nil {
+		log.Error().Msgf("[Error] GetAuditLogs(), count query err: %v", err)
+		return nil, 0, ErrFailedGetAuditLogs
+	}
+	// Calculate total pages
+	totalPages := (uint32(totalRows) + request.Limit - 1) / request.Limit
+
+	return entries, uint(totalPages), nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"integrated-library-service/isbn"
 	"integrated-library-service/model"
 	"strings"
 	"time"
@@ -27,20 +28,20 @@ var (
 	ErrUpdateBookNotFound = errors.New("update book not found")
 	// ErrFailedDeleteBook is an error when delete book failed
 	ErrFailedDeleteBook = errors.New("delete book failed")
+	// ErrInvalidISBN is an error when a book ISBN is neither a valid ISBN-10 nor ISBN-13
+	ErrInvalidISBN = errors.New("invalid ISBN")
 )
 
 // CreateBook creates a new book or updates an existing one based on ISBN
 func (l *LibraryService) CreateBook(book *model.CreateBookRequest) error {
-	title := book.Title
-	author := book.Author
-
-	if len(title) > 100 {
-		title = title[:100]
+	ISBN13, ISBN10, err := isbn.Parse(book.ISBN)
+	if err != nil {
+		log.Error().Msgf("[Error] CreateBook(), isbn.Parse err: %v", err)
+		return ErrInvalidISBN
 	}
 
-	if len(author) > 50 {
-		author = author[:50]
-	}
+	title := truncateRunes(book.Title, 100)
+	author := truncateRunes(book.Author, 50)
 
 	sqlStatement := `
 		INSERT INTO "books"(
@@ -62,17 +63,31 @@ func (l *LibraryService) CreateBook(book *model.CreateBookRequest) error {
 			"approximateDemand",
 			"reviewsList",
 			"viewsList",
-			"wishList"
+			"wishList",
+			"ISBN10",
+			"subtitle",
+			"publisher",
+			"pageCount",
+			"language",
+			"maturityRating",
+			"price"
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NULLIF($20, ''),
+			NULLIF($21, ''), NULLIF($22, ''), $23, NULLIF($24, ''), NULLIF($25, ''), $26
 		) 
 		ON CONFLICT("ISBN") 
 		DO NOTHING;
 	`
 
-	_, err := l.db.Exec(
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] CreateBook(), db.Begin err: %v", err)
+		return ErrFailedCreateBook
+	}
+
+	res, err := tx.Exec(
 		sqlStatement,
-		book.ISBN,
+		ISBN13,
 		title,
 		author,
 		book.Genre,
@@ -91,26 +106,55 @@ func (l *LibraryService) CreateBook(book *model.CreateBookRequest) error {
 		pq.Array(book.ReviewsList),
 		pq.Array(book.ViewsList),
 		pq.Array(book.WishList),
+		ISBN10,
+		book.Subtitle,
+		book.Publisher,
+		book.PageCount,
+		book.Language,
+		book.MaturityRating,
+		book.Price,
 	)
 
 	if err != nil {
 		log.Error().Msgf("[Error] CreateBook(), db.QueryRow err: %v", err)
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] CreateBook(), tx.Rollback err: %v", err)
+		}
+		return ErrFailedCreateBook
+	}
+
+	// an existing book is left untouched, so only a new one gets its authors and genres
+	if rowsEffected, err := res.RowsAffected(); err == nil && rowsEffected > 0 {
+		authors, genres := bookContributors(book.Author, book.Authors, book.Genre, book.Genres)
+		if err := setBookAuthorsAndGenres(tx, ISBN13, authors, genres); err != nil {
+			log.Error().Msgf("[Error] CreateBook(), setBookAuthorsAndGenres err: %v", err)
+			if err := tx.Rollback(); err != nil {
+				log.Error().Msgf("[Error] CreateBook(), tx.Rollback err: %v", err)
+			}
+			return ErrFailedCreateBook
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] CreateBook(), tx.Commit err: %v", err)
 		return ErrFailedCreateBook
 	}
 
 	return nil
 }
 
-// CreateBooksBatch creates multiple books at once
-func (l *LibraryService) CreateBooksBatch(books []*model.CreateBookRequest) error {
+// CreateBooksBatch creates multiple books at once, books with an invalid ISBN are skipped and reported
+// instead of failing the batch
+func (l *LibraryService) CreateBooksBatch(books []*model.CreateBookRequest) (*model.CreateBooksBatchResponse, error) {
+	result := &model.CreateBooksBatchResponse{Skipped: []model.SkippedBatchBook{}}
 	if len(books) == 0 {
-		return nil // No books to insert
+		return result, nil // No books to insert
 	}
 
 	tx, err := l.db.Begin()
 	if err != nil {
 		log.Error().Msgf("[Error] CreateBooksBatch(), db.Begin err: %v", err)
-		return ErrFailedCreateBook
+		return nil, ErrFailedCreateBook
 	}
 	defer func() {
 		if r := recover(); r != nil {
@@ -141,9 +185,17 @@ func (l *LibraryService) CreateBooksBatch(books []*model.CreateBookRequest) erro
 			"approximateDemand",
 			"reviewsList",
 			"viewsList",
-			"wishList"
+			"wishList",
+			"ISBN10",
+			"subtitle",
+			"publisher",
+			"pageCount",
+			"language",
+			"maturityRating",
+			"price"
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NULLIF($20, ''),
+			NULLIF($21, ''), NULLIF($22, ''), $23, NULLIF($24, ''), NULLIF($25, ''), $26
 		) 
 		ON CONFLICT("ISBN") 
 		DO NOTHING;
@@ -154,26 +206,28 @@ func (l *LibraryService) CreateBooksBatch(books []*model.CreateBookRequest) erro
 		log.Error().Msgf("[Error] CreateBooksBatch(), tx.Prepare err: %v", err)
 		if err := tx.Rollback(); err != nil {
 			log.Error().Msgf("[Error] CreateBooksBatch(), db.Begin err: %v", err)
-			return ErrFailedCreateBook
+			return nil, ErrFailedCreateBook
 		}
-		return ErrFailedCreateBook
+		return nil, ErrFailedCreateBook
 	}
 	defer stmt.Close()
 
-	for _, book := range books {
-		title := book.Title
-		author := book.Author
-
-		if len(title) > 100 {
-			title = title[:100]
+	for index, book := range books {
+		ISBN13, ISBN10, err := isbn.Parse(book.ISBN)
+		if err != nil {
+			result.Skipped = append(result.Skipped, model.SkippedBatchBook{
+				Index:   index,
+				ISBN:    book.ISBN,
+				Message: ErrInvalidISBN.Error(),
+			})
+			continue
 		}
 
-		if len(author) > 50 {
-			author = author[:50]
-		}
+		title := truncateRunes(book.Title, 100)
+		author := truncateRunes(book.Author, 50)
 
-		_, err := stmt.Exec(
-			book.ISBN,
+		res, err := stmt.Exec(
+			ISBN13,
 			title,
 			author,
 			book.Genre,
@@ -192,15 +246,37 @@ func (l *LibraryService) CreateBooksBatch(books []*model.CreateBookRequest) erro
 			pq.Array(book.ReviewsList),
 			pq.Array(book.ViewsList),
 			pq.Array(book.WishList),
+			ISBN10,
+			book.Subtitle,
+			book.Publisher,
+			book.PageCount,
+			book.Language,
+			book.MaturityRating,
+			book.Price,
 		)
 
 		if err != nil {
 			log.Error().Msgf("[Error] CreateBooksBatch(), stmt.QueryRow err: %v", err)
 			if err := tx.Rollback(); err != nil {
 				log.Error().Msgf("[Error] CreateBooksBatch(), db.Begin err: %v", err)
-				return ErrFailedCreateBook
+				return nil, ErrFailedCreateBook
+			}
+			return nil, ErrFailedCreateBook
+		}
+
+		rowsEffected, err := res.RowsAffected()
+		if err != nil || rowsEffected == 0 {
+			result.Existing++
+		} else {
+			result.Created++
+			authors, genres := bookContributors(book.Author, book.Authors, book.Genre, book.Genres)
+			if err := setBookAuthorsAndGenres(tx, ISBN13, authors, genres); err != nil {
+				log.Error().Msgf("[Error] CreateBooksBatch(), setBookAuthorsAndGenres err: %v", err)
+				if err := tx.Rollback(); err != nil {
+					log.Error().Msgf("[Error] CreateBooksBatch(), db.Begin err: %v", err)
+				}
+				return nil, ErrFailedCreateBook
 			}
-			return ErrFailedCreateBook
 		}
 	}
 
@@ -209,77 +285,26 @@ func (l *LibraryService) CreateBooksBatch(books []*model.CreateBookRequest) erro
 		log.Error().Msgf("[Error] CreateBooksBatch(), tx.Commit err: %v", err)
 		if err := tx.Rollback(); err != nil {
 			log.Error().Msgf("[Error] CreateBooksBatch(), db.Begin err: %v", err)
-			return ErrFailedCreateBook
+			return nil, ErrFailedCreateBook
 		}
-		return ErrFailedCreateBook
+		return nil, ErrFailedCreateBook
 	}
 
-	return nil
+	return result, nil
 }
 
-// GetBookByID retrieves a book by its ID
+// GetBookByISBN retrieves a book by either its ISBN-13 or its ISBN-10
 func (l *LibraryService) GetBookByISBN(ISBN string) (*model.Book, error) {
 	sqlStatement := `
 		SELECT
-			"ID",
-			"ISBN",
-			"title",
-			"author",
-			"genre",
-			"publishedDate",
-			"desc",
-			"previewLink",
-			"coverImage",
-			"shelfNumber",
-			"inLibrary",
-			"views",
-			"booksLeft",
-			"wishlistCount",
-			"rating",
-			"reviewCount",
-			"approximateDemand",
-			"createdAt",
-			"updatedAt",
-			"reviewsList",
-			"viewsList",
-			"wishList"
+			` + bookColumns + `
 		FROM
 			"books"
 		WHERE
-			"ISBN" = $1;
+			"ISBN" = ANY($1) OR "ISBN10" = ANY($1);
 	`
 
-	var (
-		book       model.Book
-		updatedAt  sql.NullTime
-		reviewList pq.StringArray
-		viewList   pq.StringArray
-		wishList   pq.StringArray
-	)
-	err := l.db.QueryRow(sqlStatement, ISBN).Scan(
-		&book.ID,
-		&book.ISBN,
-		&book.Title,
-		&book.Author,
-		&book.Genre,
-		&book.PublishedDate,
-		&book.Description,
-		&book.PreviewLink,
-		&book.CoverImage,
-		&book.ShelfNumber,
-		&book.InLibrary,
-		&book.Views,
-		&book.BooksLeft,
-		&book.WishlistCount,
-		&book.Rating,
-		&book.ReviewCount,
-		&book.ApproximateDemand,
-		&book.CreatedAt,
-		&updatedAt,
-		&reviewList,
-		&viewList,
-		&wishList,
-	)
+	book, err := scanBook(l.db.QueryRow(sqlStatement, pq.Array(isbnLookupKeys([]string{ISBN}))))
 
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
@@ -290,11 +315,6 @@ func (l *LibraryService) GetBookByISBN(ISBN string) (*model.Book, error) {
 		return nil, ErrFailedGetBookByID
 	}
 
-	book.UpdatedAt = &updatedAt.Time
-	book.ReviewsList = reviewList
-	book.ViewsList = viewList
-	book.WishList = wishList
-
 	// get ratings from helper
 	ratings, err := l.getAverageRating(book.ID)
 	if err != nil && errors.Is(err, ErrRatingNotFound) {
@@ -303,72 +323,21 @@ func (l *LibraryService) GetBookByISBN(ISBN string) (*model.Book, error) {
 	}
 	book.Rating = *ratings.Rating
 
-	return &book, nil
+	return book, nil
 }
 
 // GetBookWithBookID retrieves a book by its ID
 func (l *LibraryService) GetBookWithBookID(bookID string) (*model.Book, error) {
 	sqlStatement := `
 		SELECT
-			"ID",
-			"ISBN",
-			"title",
-			"author",
-			"genre",
-			"publishedDate",
-			"desc",
-			"previewLink",
-			"coverImage",
-			"shelfNumber",
-			"inLibrary",
-			"views",
-			"booksLeft",
-			"wishlistCount",
-			"rating",
-			"reviewCount",
-			"approximateDemand",
-			"createdAt",
-			"updatedAt",
-			"reviewsList",
-			"viewsList",
-			"wishList"
+			` + bookColumns + `
 		FROM
 			"books"
 		WHERE
 			"ID" = $1;
 	`
 
-	var (
-		book       model.Book
-		updatedAt  sql.NullTime
-		reviewList pq.StringArray
-		viewList   pq.StringArray
-		wishList   pq.StringArray
-	)
-	err := l.db.QueryRow(sqlStatement, bookID).Scan(
-		&book.ID,
-		&book.ISBN,
-		&book.Title,
-		&book.Author,
-		&book.Genre,
-		&book.PublishedDate,
-		&book.Description,
-		&book.PreviewLink,
-		&book.CoverImage,
-		&book.ShelfNumber,
-		&book.InLibrary,
-		&book.Views,
-		&book.BooksLeft,
-		&book.WishlistCount,
-		&book.Rating,
-		&book.ReviewCount,
-		&book.ApproximateDemand,
-		&book.CreatedAt,
-		&updatedAt,
-		&reviewList,
-		&viewList,
-		&wishList,
-	)
+	book, err := scanBook(l.db.QueryRow(sqlStatement, bookID))
 
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
@@ -379,11 +348,6 @@ func (l *LibraryService) GetBookWithBookID(bookID string) (*model.Book, error) {
 		return nil, ErrFailedGetBookByID
 	}
 
-	book.UpdatedAt = &updatedAt.Time
-	book.ReviewsList = reviewList
-	book.ViewsList = viewList
-	book.WishList = wishList
-
 	// get ratings from helper
 	ratings, err := l.getAverageRating(book.ID)
 	if err != nil && errors.Is(err, ErrRatingNotFound) {
@@ -392,37 +356,18 @@ func (l *LibraryService) GetBookWithBookID(bookID string) (*model.Book, error) {
 	}
 	book.Rating = *ratings.Rating
 
-	return &book, nil
+	return book, nil
 }
 
 // getAllBooks retrieves all books from the database
 func (l *LibraryService) GetAllBooks(request *model.GetAllBooksRequest) ([]model.Book, uint, error) {
 	sqlStatement := `
 		SELECT 
-			"ID",
-			"ISBN",
-			"title",
-			"author",
-			"genre",
-			"publishedDate",
-			"desc",
-			"previewLink",
-			"coverImage",
-			"shelfNumber",
-			"inLibrary",
-			"views",
-			"booksLeft",
-			"wishlistCount",
-			"rating",
-			"reviewCount",
-			"approximateDemand",
-			"createdAt",
-			"updatedAt",
-			"reviewsList",
-			"viewsList",
-			"wishList"
+			` + bookColumns + `
 		FROM 
 			"books"
+		WHERE
+			"withdrawnAt" IS NULL
 		ORDER BY 
 			%s -- orderby
 		%s; -- criteria for limit and offset 
@@ -475,46 +420,11 @@ func (l *LibraryService) GetAllBooks(request *model.GetAllBooksRequest) ([]model
 
 	var books []model.Book
 	for rows.Next() {
-		var (
-			book       model.Book
-			updatedAt  sql.NullTime
-			reviewList pq.StringArray
-			viewList   pq.StringArray
-			wishList   pq.StringArray
-		)
-		err := rows.Scan(
-			&book.ID,
-			&book.ISBN,
-			&book.Title,
-			&book.Author,
-			&book.Genre,
-			&book.PublishedDate,
-			&book.Description,
-			&book.PreviewLink,
-			&book.CoverImage,
-			&book.ShelfNumber,
-			&book.InLibrary,
-			&book.Views,
-			&book.BooksLeft,
-			&book.WishlistCount,
-			&book.Rating,
-			&book.ReviewCount,
-			&book.ApproximateDemand,
-			&book.CreatedAt,
-			&updatedAt,
-			&reviewList,
-			&viewList,
-			&wishList,
-		)
+		book, err := scanBook(rows)
 		if err != nil {
 			log.Error().Msgf("[Error] GetAllBooks(), rows.Scan err: %v", err)
 			return nil, 0, err
 		}
-		book.UpdatedAt = &updatedAt.Time
-		book.ReviewsList = reviewList
-		book.ViewsList = viewList
-		book.WishList = wishList
-
 		// get ratings from helper
 		ratings, err := l.getAverageRating(book.ID)
 		if err != nil && errors.Is(err, ErrRatingNotFound) {
@@ -523,7 +433,7 @@ func (l *LibraryService) GetAllBooks(request *model.GetAllBooksRequest) ([]model
 		}
 		book.Rating = *ratings.Rating
 
-		books = append(books, book)
+		books = append(books, *book)
 	}
 
 	sqlStatementCount := `
@@ -531,11 +441,12 @@ func (l *LibraryService) GetAllBooks(request *model.GetAllBooksRequest) ([]model
 			COUNT(*)
 		FROM 
 			"books"
-		%s; --LIMIT AND OFFSET 
+		WHERE
+			"withdrawnAt" IS NULL;
 	`
 
 	var totalRows uint
-	err = l.db.QueryRow(fmt.Sprintf(sqlStatementCount, limitOffset)).Scan(&totalRows)
+	err = l.db.QueryRow(sqlStatementCount).Scan(&totalRows)
 	if err != nil {
 		log.Error().Msgf("[Error] GetAllBooksForSearch(), count query err: %v", err)
 		return nil, 0, err
@@ -550,28 +461,7 @@ func (l *LibraryService) GetAllBooks(request *model.GetAllBooksRequest) ([]model
 func (l *LibraryService) GetAllBooksForSearch(request *model.SearchRequest) ([]model.Book, uint, error) {
 	sqlStatement := `
 		SELECT 
-			"ID",
-			"ISBN",
-			"title",
-			"author",
-			"genre",
-			"publishedDate",
-			"desc",
-			"previewLink",
-			"coverImage",
-			"shelfNumber",
-			"inLibrary",
-			"views",
-			"booksLeft",
-			"wishlistCount",
-			"rating",
-			"reviewCount",
-			"approximateDemand",
-			"createdAt",
-			"updatedAt",
-			"reviewsList",
-			"viewsList",
-			"wishList"
+			` + bookColumns + `
 		FROM 
 			"books"
 		WHERE 
@@ -581,6 +471,88 @@ func (l *LibraryService) GetAllBooksForSearch(request *model.SearchRequest) ([]m
 		%s; -- criteria for limit and offset 
 	`
 
+	searchBy, orderBy, args := bookSearch(request)
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, searchBy, orderBy, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement, args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetAllBooks(), db.Query err: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var books []model.Book
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			log.Error().Msgf("[Error] GetAllBooksForSearch(), rows.Scan err: %v", err)
+			return nil, 0, err
+		}
+		// get ratings from helper
+		ratings, err := l.getAverageRating(book.ID)
+		if err != nil && errors.Is(err, ErrRatingNotFound) {
+			log.Error().Msgf("[Error] GetAllBooksForSearch(), getAverageRating err: %v", err)
+			return nil, 0, err
+		}
+		book.Rating = *ratings.Rating
+
+		books = append(books, *book)
+	}
+
+	sqlStatementCount := `
+		SELECT 
+			COUNT(*)
+		FROM 
+			"books"
+		WHERE 
+			%s
+	`
+
+	var totalRows uint
+	err = l.db.QueryRow(fmt.Sprintf(sqlStatementCount, searchBy), args...).Scan(&totalRows)
+	// no rows
+	if errors.Is(err, sql.ErrNoRows) {
+		return []model.Book{}, 0, nil
+	}
+	if err != nil {
+		log.Error().Msgf("[Error] GetAllBooksForSearch(), count query err: %v", err)
+		return nil, 0, err
+	}
+	// terms patrons found nothing for feed the acquisition suggestions, unless the filters and not
+	// the term left nothing
+	if totalRows == 0 && request.Page == 1 && request.SearchBy != "recommendation" && l.searchTermMisses(request) {
+		l.recordSearchMiss(request.SearchText)
+	}
+	// Calculate total pages
+	totalPages := (uint32(totalRows) + request.Limit - 1) / request.Limit
+
+	return books, uint(totalPages), nil
+}
+
+// searchTermMisses reports whether the search text alone matches no book, a search without filters
+// which found nothing is known to miss
+func (l *LibraryService) searchTermMisses(request *model.SearchRequest) bool {
+	filtered := len(request.Language) > 0 || len(request.Publisher) > 0 || len(request.MaturityRating) > 0 ||
+		request.MinPageCount > 0 || request.MaxPageCount > 0
+	if !filtered {
+		return true
+	}
+
+	searchBy, _, args := bookSearch(&model.SearchRequest{SearchBy: request.SearchBy, SearchText: request.SearchText})
+	var matches bool
+	if err := l.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM "books" WHERE `+searchBy+`);`, args...).Scan(&matches); err != nil {
+		log.Error().Msgf("[Error] searchTermMisses(), db.QueryRow err: %v", err)
+		return false
+	}
+	return !matches
+}
+
+// bookSearch returns the WHERE conditions, the ORDER BY and the arguments of a book search, withdrawn
+// books never match
+func bookSearch(request *model.SearchRequest) (string, string, []interface{}) {
 	orderBy := `%s ASC`
 
 	if request.OrderBy == "descending" {
@@ -610,164 +582,112 @@ func (l *LibraryService) GetAllBooksForSearch(request *model.SearchRequest) ([]m
 		orderBy = fmt.Sprintf(orderBy, `"wishlistCount"`)
 	case "views":
 		orderBy = fmt.Sprintf(orderBy, `"views"`)
+	case "pageCount":
+		orderBy = fmt.Sprintf(orderBy, `"pageCount"`)
+	case "publisher":
+		orderBy = fmt.Sprintf(orderBy, `"publisher"`)
 	default:
 		orderBy = fmt.Sprintf(orderBy, `"title"`)
 	}
 
+	var searchBy string
+	args := []interface{}{}
 	searchText := "%" + request.SearchText + "%"
-	searchBy := `%s`
 	switch request.SearchBy {
 	case "title":
-		searchBy = fmt.Sprintf(searchBy, `(LOWER("title") LIKE LOWER($1))`)
+		args = append(args, searchText)
+		searchBy = `(LOWER("title") LIKE LOWER($1))`
 	case "author":
-		searchBy = fmt.Sprintf(searchBy, `(LOWER("author") LIKE LOWER($1))`)
+		args = append(args, searchText)
+		searchBy = `(LOWER("author") LIKE LOWER($1) OR ` + authorMatches + `)`
 	case "isbn":
-		searchBy = fmt.Sprintf(searchBy, `(LOWER("ISBN") LIKE LOWER($1))`)
+		args = append(args, "%"+isbn.Normalize(request.SearchText)+"%")
+		searchBy = `("ISBN" LIKE $1 OR "ISBN10" LIKE $1)`
 	case "genre", "subject":
-		searchBy = fmt.Sprintf(searchBy, `(LOWER("genre") LIKE LOWER($1))`)
+		args = append(args, searchText)
+		searchBy = `(LOWER("genre") LIKE LOWER($1) OR ` + genreMatches + `)`
+	case "publisher":
+		args = append(args, searchText)
+		searchBy = `(LOWER("publisher") LIKE LOWER($1))`
 	case "recommendation": // even if any one word from the search text matches we can return that book
-		words := strings.Fields(request.SearchText)
 		var conditions []string
-		for _, word := range words {
-			conditions = append(conditions, fmt.Sprintf(`(LOWER("title") LIKE LOWER('%%%s%%') OR LOWER("author") LIKE LOWER('%%%s%%') OR LOWER("genre") LIKE LOWER('%%%s%%') OR LOWER("ISBN") LIKE LOWER('%%%s%%'))`, word, word, word, word))
+		for _, word := range strings.Fields(request.SearchText) {
+			args = append(args, "%"+word+"%")
+			conditions = append(conditions, fmt.Sprintf(`(LOWER("title") LIKE LOWER($%[1]d) OR LOWER("author") LIKE LOWER($%[1]d) OR LOWER("genre") LIKE LOWER($%[1]d) OR LOWER("ISBN") LIKE LOWER($%[1]d))`, len(args)))
 		}
 		searchBy = strings.Join(conditions, " OR ")
+		if len(conditions) == 0 {
+			searchBy = "TRUE"
+		}
 	default:
-		searchBy = fmt.Sprintf(searchBy, `(LOWER("title") LIKE LOWER($1) OR LOWER("author") LIKE LOWER($1) OR LOWER("genre") LIKE LOWER($1) OR LOWER("ISBN") LIKE LOWER($1))`)
+		args = append(args, searchText)
+		searchBy = `(LOWER("title") LIKE LOWER($1) OR LOWER("author") LIKE LOWER($1) OR LOWER("genre") LIKE LOWER($1) OR LOWER("ISBN") LIKE LOWER($1) OR ` + authorMatches + `)`
 	}
 
-	limitOffset := ` LIMIT %d OFFSET %d`
-	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
-	sqlStatement = fmt.Sprintf(sqlStatement, searchBy, orderBy, limitOffset)
-
-	var rows *sql.Rows
-	var err error
-	if request.SearchBy == "recommendation" {
-		rows, err = l.db.Query(sqlStatement)
-	} else {
-		rows, err = l.db.Query(sqlStatement, searchText)
-	}
-	if err != nil {
-		log.Error().Msgf("[Error] GetAllBooks(), db.Query err: %v", err)
-		return nil, 0, err
-	}
-	defer rows.Close()
+	// withdrawn books are kept for their history only
+	searchBy = "(" + searchBy + `) AND "withdrawnAt" IS NULL` + bookSearchFilters(request, &args)
 
-	var books []model.Book
-	for rows.Next() {
-		var (
-			book       model.Book
-			updatedAt  sql.NullTime
-			reviewList pq.StringArray
-			viewList   pq.StringArray
-			wishList   pq.StringArray
-		)
-		err := rows.Scan(
-			&book.ID,
-			&book.ISBN,
-			&book.Title,
-			&book.Author,
-			&book.Genre,
-			&book.PublishedDate,
-			&book.Description,
-			&book.PreviewLink,
-			&book.CoverImage,
-			&book.ShelfNumber,
-			&book.InLibrary,
-			&book.Views,
-			&book.BooksLeft,
-			&book.WishlistCount,
-			&book.Rating,
-			&book.ReviewCount,
-			&book.ApproximateDemand,
-			&book.CreatedAt,
-			&updatedAt,
-			&reviewList,
-			&viewList,
-			&wishList,
-		)
-		if err != nil {
-			log.Error().Msgf("[Error] GetAllBooksForSearch(), rows.Scan err: %v", err)
-			return nil, 0, err
-		}
-		book.UpdatedAt = &updatedAt.Time
-		book.ReviewsList = reviewList
-		book.ViewsList = viewList
-		book.WishList = wishList
+	return searchBy, orderBy, args
+}
 
-		// get ratings from helper
-		ratings, err := l.getAverageRating(book.ID)
-		if err != nil && errors.Is(err, ErrRatingNotFound) {
-			log.Error().Msgf("[Error] GetAllBooksForSearch(), getAverageRating err: %v", err)
-			return nil, 0, err
-		}
-		book.Rating = *ratings.Rating
+const (
+	// authorMatches matches books with any linked author like $1
+	authorMatches = `EXISTS (
+		SELECT 1 FROM "book_authors"
+		JOIN "authors" ON "authors"."ID" = "book_authors"."authorID"
+		WHERE "book_authors"."bookID" = "books"."ID" AND LOWER("authors"."name") LIKE LOWER($1)
+	)`
+	// genreMatches matches books with any linked genre like $1
+	genreMatches = `EXISTS (
+		SELECT 1 FROM "book_genres"
+		JOIN "genres" ON "genres"."ID" = "book_genres"."genreID"
+		WHERE "book_genres"."bookID" = "books"."ID" AND LOWER("genres"."name") LIKE LOWER($1)
+	)`
+)
 
-		books = append(books, book)
+// bookSearchFilters returns the AND conditions for the optional book filters of a search request,
+// appending their values to args
+func bookSearchFilters(request *model.SearchRequest, args *[]interface{}) string {
+	var conditions []string
+	addCondition := func(condition string, value interface{}) {
+		*args = append(*args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(*args)))
 	}
 
-	sqlStatementCount := `
-		SELECT 
-			COUNT(*)
-		FROM 
-			"books"
-		WHERE 
-			%s
-	`
-
-	var totalRows uint
-	if request.SearchBy == "recommendation" {
-		err = l.db.QueryRow(fmt.Sprintf(sqlStatementCount, searchBy)).Scan(&totalRows)
-	} else {
-		err = l.db.QueryRow(fmt.Sprintf(sqlStatementCount, searchBy), searchText).Scan(&totalRows)
+	if len(request.Language) > 0 {
+		addCondition(`LOWER("language") = LOWER($%d)`, request.Language)
 	}
-	// no rows
-	if errors.Is(err, sql.ErrNoRows) {
-		return []model.Book{}, 0, nil
+	if len(request.Publisher) > 0 {
+		addCondition(`LOWER("publisher") LIKE LOWER($%d)`, "%"+request.Publisher+"%")
 	}
-	if err != nil {
-		log.Error().Msgf("[Error] GetAllBooksForSearch(), count query err: %v", err)
-		return nil, 0, err
+	if len(request.MaturityRating) > 0 {
+		addCondition(`"maturityRating" = $%d`, request.MaturityRating)
+	}
+	if request.MinPageCount > 0 {
+		addCondition(`"pageCount" >= $%d`, request.MinPageCount)
+	}
+	if request.MaxPageCount > 0 {
+		addCondition(`"pageCount" <= $%d`, request.MaxPageCount)
 	}
-	// Calculate total pages
-	totalPages := (uint32(totalRows) + request.Limit - 1) / request.Limit
 
-	return books, uint(totalPages), nil
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(conditions, " AND ")
 }
 
 // GetAllBooksFromSpecific retrieves all books from the database for given string arr
 func (l *LibraryService) GetAllBooksFromSpecific(request []string) ([]model.Book, error) {
 	sqlStatement := `
 		SELECT 
-			"ID",
-			"ISBN",
-			"title",
-			"author",
-			"genre",
-			"publishedDate",
-			"desc",
-			"previewLink",
-			"coverImage",
-			"shelfNumber",
-			"inLibrary",
-			"views",
-			"booksLeft",
-			"wishlistCount",
-			"rating",
-			"reviewCount",
-			"approximateDemand",
-			"createdAt",
-			"updatedAt",
-			"reviewsList",
-			"viewsList",
-			"wishList"
+			` + bookColumns + `
 		FROM 
 			"books"
 		WHERE 
-			"ISBN" = ANY($1);
+			"ISBN" = ANY($1) OR "ISBN10" = ANY($1);
 	`
 
-	rows, err := l.db.Query(sqlStatement, pq.Array(request))
+	rows, err := l.db.Query(sqlStatement, pq.Array(isbnLookupKeys(request)))
 	if err != nil {
 		log.Error().Msgf("[Error] GetAllBooksFromSpecific(), db.Query err: %v", err)
 		return nil, err
@@ -776,46 +696,11 @@ func (l *LibraryService) GetAllBooksFromSpecific(request []string) ([]model.Book
 
 	var books []model.Book
 	for rows.Next() {
-		var (
-			book       model.Book
-			updatedAt  sql.NullTime
-			reviewList pq.StringArray
-			viewList   pq.StringArray
-			wishList   pq.StringArray
-		)
-		err := rows.Scan(
-			&book.ID,
-			&book.ISBN,
-			&book.Title,
-			&book.Author,
-			&book.Genre,
-			&book.PublishedDate,
-			&book.Description,
-			&book.PreviewLink,
-			&book.CoverImage,
-			&book.ShelfNumber,
-			&book.InLibrary,
-			&book.Views,
-			&book.BooksLeft,
-			&book.WishlistCount,
-			&book.Rating,
-			&book.ReviewCount,
-			&book.ApproximateDemand,
-			&book.CreatedAt,
-			&updatedAt,
-			&reviewList,
-			&viewList,
-			&wishList,
-		)
+		book, err := scanBook(rows)
 		if err != nil {
 			log.Error().Msgf("[Error] GetAllBooksFromSpecific(), rows.Scan err: %v", err)
 			return nil, err
 		}
-		book.UpdatedAt = &updatedAt.Time
-		book.ReviewsList = reviewList
-		book.ViewsList = viewList
-		book.WishList = wishList
-
 		// get ratings from helper
 		ratings, err := l.getAverageRating(book.ID)
 		if err != nil && errors.Is(err, ErrRatingNotFound) {
@@ -824,7 +709,7 @@ func (l *LibraryService) GetAllBooksFromSpecific(request []string) ([]model.Book
 		}
 		book.Rating = *ratings.Rating
 
-		books = append(books, book)
+		books = append(books, *book)
 	}
 
 	return books, nil
@@ -874,35 +759,14 @@ func (l *LibraryService) GetAllBooksByBookDetailsFrom(request *model.GetAllBooks
 
 	bookSqlStatement := `
 		SELECT 
-			"ID",
-			"ISBN",
-			"title",
-			"author",
-			"genre",
-			"publishedDate",
-			"desc",
-			"previewLink",
-			"coverImage",
-			"shelfNumber",
-			"inLibrary",
-			"views",
-			"booksLeft",
-			"wishlistCount",
-			"rating",
-			"reviewCount",
-			"approximateDemand",
-			"createdAt",
-			"updatedAt",
-			"reviewsList",
-			"viewsList",
-			"wishList"
+			` + bookColumns + `
 		FROM 
 			"books"
 		WHERE 
-			"ISBN" = ANY($1);
+			"ISBN" = ANY($1) OR "ISBN10" = ANY($1);
 	`
 
-	rows, err := l.db.Query(bookSqlStatement, pq.Array(bookList))
+	rows, err := l.db.Query(bookSqlStatement, pq.Array(isbnLookupKeys(bookList)))
 	if err != nil {
 		log.Error().Msgf("[Error] GetAllBooks(), db.Query err: %v", err)
 		return nil, err
@@ -911,46 +775,11 @@ func (l *LibraryService) GetAllBooksByBookDetailsFrom(request *model.GetAllBooks
 
 	var books []model.Book
 	for rows.Next() {
-		var (
-			book       model.Book
-			updatedAt  sql.NullTime
-			reviewList pq.StringArray
-			viewList   pq.StringArray
-			wishList   pq.StringArray
-		)
-		err := rows.Scan(
-			&book.ID,
-			&book.ISBN,
-			&book.Title,
-			&book.Author,
-			&book.Genre,
-			&book.PublishedDate,
-			&book.Description,
-			&book.PreviewLink,
-			&book.CoverImage,
-			&book.ShelfNumber,
-			&book.InLibrary,
-			&book.Views,
-			&book.BooksLeft,
-			&book.WishlistCount,
-			&book.Rating,
-			&book.ReviewCount,
-			&book.ApproximateDemand,
-			&book.CreatedAt,
-			&updatedAt,
-			&reviewList,
-			&viewList,
-			&wishList,
-		)
+		book, err := scanBook(rows)
 		if err != nil {
 			log.Error().Msgf("[Error] GetAllBooks(), rows.Scan err: %v", err)
 			return nil, err
 		}
-		book.UpdatedAt = &updatedAt.Time
-		book.ReviewsList = reviewList
-		book.ViewsList = viewList
-		book.WishList = wishList
-
 		// get ratings from helper
 		ratings, err := l.getAverageRating(book.ID)
 		if err != nil && errors.Is(err, ErrRatingNotFound) {
@@ -959,13 +788,14 @@ func (l *LibraryService) GetAllBooksByBookDetailsFrom(request *model.GetAllBooks
 		}
 		book.Rating = *ratings.Rating
 
-		books = append(books, book)
+		books = append(books, *book)
 	}
 
 	return books, nil
 }
 
-// UpdateBook updates an existing book in the "books" table
+// UpdateBook updates an existing book in the "books" table, the wishlist and its count are kept as they
+// are maintained by AddToWishlist and RemoveFromWishlist
 func (l *LibraryService) UpdateBook(book *model.UpdateBookRequest) error {
 	sqlStatement := `
 		UPDATE "books" SET
@@ -978,69 +808,149 @@ func (l *LibraryService) UpdateBook(book *model.UpdateBookRequest) error {
 			"coverImage" = $8,
 			"shelfNumber" = $9,
 			"inLibrary" = $10,
-			"views" = $11,
-			"booksLeft" = $12,
-			"wishlistCount" = $13,
-			"rating" = $14,
-			"reviewCount" = $15,
-			"approximateDemand" = $16,
-			"updatedAt" = $17,
-			"reviewsList" = $18,
-			"viewsList" = $19,
-			"wishList" = $20
+			"booksLeft" = $11,
+			"rating" = $12,
+			"reviewCount" = $13,
+			"updatedAt" = $14,
+			"reviewsList" = $15,
+			"subtitle" = NULLIF($16, ''),
+			"publisher" = NULLIF($17, ''),
+			"pageCount" = $18,
+			"language" = NULLIF($19, ''),
+			"maturityRating" = NULLIF($20, ''),
+			"price" = COALESCE($21, "price"),
+			"version" = "version" + 1
 		WHERE
 			"ISBN" = $1;
 	`
 
+	ISBN := book.ISBN
+	if ISBN13, _, err := isbn.Parse(book.ISBN); err == nil {
+		ISBN = ISBN13
+	}
+
+	// the first of the authors and genres is kept as the primary author and genre
+	author, genre := book.Author, book.Genre
+	if authors := uniqueNames(book.Authors); len(authors) > 0 {
+		author = authors[0]
+	}
+	if genres := uniqueNames(book.Genres); len(genres) > 0 {
+		genre = genres[0]
+	}
+	author = truncateRunes(author, 50)
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] UpdateBook(), db.Begin err: %v", err)
+		return ErrFailedUpdateBook
+	}
+
+	// wishlisting patrons are notified when the book becomes available
+	var (
+		bookID                        string
+		wasAvailable                  bool
+		previousAuthor, previousGenre string
+	)
+	err = tx.QueryRow(`
+		SELECT "ID", "inLibrary" AND "booksLeft" > 0, "author", "genre"
+		FROM "books" WHERE "ISBN" = $1 FOR UPDATE;
+	`, ISBN).Scan(&bookID, &wasAvailable, &previousAuthor, &previousGenre)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] UpdateBook(), tx.Rollback err: %v", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUpdateBookNotFound
+		}
+		log.Error().Msgf("[Error] UpdateBook(), tx.QueryRow err: %v", err)
+		return ErrFailedUpdateBook
+	}
+
 	updatedAt := time.Now().UTC().Format(time.RFC3339)
-	res, err := l.db.Exec(
+	res, err := tx.Exec(
 		sqlStatement,
-		book.ISBN,
+		ISBN,
 		book.Title,
-		book.Author,
-		book.Genre,
+		author,
+		genre,
 		book.PublishedDate,
 		book.Description,
 		book.PreviewLink,
 		book.CoverImage,
 		book.ShelfNumber,
 		book.InLibrary,
-		book.Views,
 		book.BooksLeft,
-		book.WishlistCount,
 		book.Rating,
 		book.ReviewCount,
-		book.ApproximateDemand,
 		updatedAt,
 		pq.Array(book.ReviewsList),
-		pq.Array(book.ViewsList),
-		pq.Array(book.WishList),
+		book.Subtitle,
+		book.Publisher,
+		book.PageCount,
+		book.Language,
+		book.MaturityRating,
+		book.Price,
 	)
 
 	if err != nil {
 		log.Error().Msgf("[Error] UpdateBook(), db.QueryRow err: %v", err)
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] UpdateBook(), tx.Rollback err: %v", err)
+		}
 		return ErrFailedUpdateBook
 	}
 
 	if rowsEffected, err := res.RowsAffected(); err != nil || rowsEffected == 0 {
 		log.Error().Msgf("[error] UpdateBook(), [No rows affected]  : %v", err)
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] UpdateBook(), tx.Rollback err: %v", err)
+		}
 		return ErrUpdateBookNotFound
 	}
 
-	return nil
-}
+	// links are replaced for the lists the request carries, without a list a changed author or genre
+	// is linked on its own like CreateBook links it
+	authors, genres := uniqueNames(book.Authors), uniqueNames(book.Genres)
+	if len(authors) == 0 && author != previousAuthor {
+		authors = uniqueNames([]string{book.Author})
+	}
+	if len(genres) == 0 && genre != previousGenre {
+		genres = uniqueNames([]string{book.Genre})
+	}
+	if err := setBookAuthorsAndGenres(tx, ISBN, authors, genres); err != nil {
+		log.Error().Msgf("[Error] UpdateBook(), setBookAuthorsAndGenres err: %v", err)
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] UpdateBook(), tx.Rollback err: %v", err)
+		}
+		return ErrFailedUpdateBook
+	}
 
-// DeleteBook deletes a book from the "books" table based on bookID
-func (l *LibraryService) DeleteBook(bookID string) error {
-	sqlStatement := `
-		DELETE FROM "books" WHERE "ID" = $1;
-	`
+	if !wasAvailable {
+		if err := notifyWishlistBackInStock(tx, bookID); err != nil {
+			if err := tx.Rollback(); err != nil {
+				log.Error().Msgf("[Error] UpdateBook(), tx.Rollback err: %v", err)
+			}
+			return ErrFailedUpdateBook
+		}
+	}
 
-	_, err := l.db.Exec(sqlStatement, bookID)
-	if err != nil {
-		log.Error().Msgf("[Error] DeleteBook(), db.Exec err: %v", err)
-		return ErrFailedDeleteBook
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] UpdateBook(), tx.Commit err: %v", err)
+		return ErrFailedUpdateBook
 	}
 
 	return nil
 }
+
+// isbnLookupKeys returns the canonical ISBN-13 of every valid ISBN next to the value as given,
+// so both current and legacy identifiers can be matched against "ISBN" and "ISBN10"
+func isbnLookupKeys(values []string) []string {
+	keys := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		keys = append(keys, value)
+		if ISBN13, _, err := isbn.Parse(value); err == nil && ISBN13 != value {
+			keys = append(keys, ISBN13)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,412 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/itemcode"
+	"integrated-library-service/librarycard"
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrBookCopyBookNotFound is an error when the book of a copy is not found
+	ErrBookCopyBookNotFound = errors.New("book not found")
+	// ErrBookCopyNotFound is an error when a book has no copy with the number or barcode
+	ErrBookCopyNotFound = errors.New("book copy not found")
+	// ErrFailedGetBookCopies is an error when get book copies failed
+	ErrFailedGetBookCopies = errors.New("get book copies failed")
+	// ErrFailedRegisterBookCopies is an error when register book copies failed
+	ErrFailedRegisterBookCopies = errors.New("register book copies failed")
+	// ErrScanCodeUnknown is an error when a scanned code is no library card, book copy, checkout ticket or book
+	ErrScanCodeUnknown = errors.New("scanned code is not a known library card, book copy, checkout ticket or book")
+	// ErrFailedScanCode is an error when resolving a scanned code failed
+	ErrFailedScanCode = errors.New("scan code lookup failed")
+)
+
+// uuidPattern matches the IDs of books and checkout tickets
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// copyBook is the book copies are registered for
+type copyBook struct {
+	ID          string
+	ISBN        string
+	Title       string
+	Author      string
+	ShelfNumber int64
+	Withdrawn   bool
+	// TotalCopies counts the copies on the shelf and on loan
+	TotalCopies int64
+}
+
+// copies returns the number of copies of the book, a book without copies on the shelf or on loan
+// still has the copy which is on order
+func (b *copyBook) copies() int64 {
+	return max(b.TotalCopies, 1)
+}
+
+// registeredCopy is the barcode and status of a registered copy
+type registeredCopy struct {
+	Barcode string
+	Status  model.CopyStatus
+}
+
+// bookCopy returns the copy of the book with the barcode, number and status
+func (b *copyBook) bookCopy(registered registeredCopy, copyNumber int64) model.BookCopy {
+	return model.BookCopy{
+		Barcode:     registered.Barcode,
+		BookID:      b.ID,
+		CopyNumber:  copyNumber,
+		ISBN:        b.ISBN,
+		Title:       b.Title,
+		Author:      b.Author,
+		ShelfNumber: b.ShelfNumber,
+		Status:      registered.Status,
+	}
+}
+
+// copyBookSQL selects a book by either ISBN with the number of its copies on the shelf and on loan
+const copyBookSQL = `
+	SELECT
+		"ID", "ISBN", "title", "author", "shelfNumber", "withdrawnAt" IS NOT NULL,
+		"booksLeft" + (
+			SELECT COUNT(*) FROM "checkout_tickets" ct
+			WHERE ct."bookID" = "books"."ID" AND ct."isCheckedOut" = true AND ct."isReturned" = false
+		)
+	FROM "books"
+	WHERE "ISBN" = ANY($1) OR "ISBN10" = ANY($1)
+`
+
+// scanCopyBook scans a book selected by copyBookSQL
+func scanCopyBook(row *sql.Row) (*copyBook, error) {
+	book := copyBook{}
+	err := row.Scan(&book.ID, &book.ISBN, &book.Title, &book.Author, &book.ShelfNumber, &book.Withdrawn, &book.TotalCopies)
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// lockCopyBook locks a book by either ISBN while its copies are registered
+func lockCopyBook(tx *sql.Tx, ISBN string) (*copyBook, error) {
+	return scanCopyBook(tx.QueryRow(copyBookSQL+` FOR UPDATE;`, pq.Array(isbnLookupKeys([]string{ISBN}))))
+}
+
+// getCopyBook returns a book by either ISBN to list its registered copies
+func (l *LibraryService) getCopyBook(ISBN string) (*copyBook, error) {
+	return scanCopyBook(l.db.QueryRow(copyBookSQL+`;`, pq.Array(isbnLookupKeys([]string{ISBN}))))
+}
+
+// bookCopyBarcodes returns the barcodes and statuses of the registered copies of a book by copy number
+func bookCopyBarcodes(tx *sql.Tx, bookID string) (map[int64]registeredCopy, error) {
+	rows, err := tx.Query(`SELECT "copyNumber", "barcode", "status" FROM "book_copies" WHERE "bookID" = $1;`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	barcodes := map[int64]registeredCopy{}
+	for rows.Next() {
+		var (
+			copyNumber int64
+			registered registeredCopy
+		)
+		if err := rows.Scan(&copyNumber, &registered.Barcode, &registered.Status); err != nil {
+			return nil, err
+		}
+		barcodes[copyNumber] = registered
+	}
+	return barcodes, rows.Err()
+}
+
+// registerBookCopy gives a copy of a book the next barcode, new copies are available
+func registerBookCopy(tx *sql.Tx, bookID string, copyNumber int64) (registeredCopy, error) {
+	var sequence int64
+	if err := tx.QueryRow(`SELECT nextval('book_copy_barcode_seq');`).Scan(&sequence); err != nil {
+		return registeredCopy{}, err
+	}
+
+	barcode := itemcode.Format(sequence)
+	_, err := tx.Exec(`
+		INSERT INTO "book_copies" ("barcode", "bookID", "copyNumber") VALUES ($1, $2, $3);
+	`, barcode, bookID, copyNumber)
+	if err != nil {
+		return registeredCopy{}, err
+	}
+	return registeredCopy{Barcode: barcode, Status: model.CopyStatusAvailable}, nil
+}
+
+// RegisterBookCopies gives the first copies of a book which have no barcode yet their barcodes, up to
+// the number of copies of the book when copies is zero. It returns the first copies, registered before
+// or now, and registered copies keep their barcode afterwards.
+func (l *LibraryService) RegisterBookCopies(ISBN string, copies int64) ([]model.BookCopy, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] RegisterBookCopies(), db.Begin err: %v", err)
+		return nil, ErrFailedRegisterBookCopies
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] RegisterBookCopies(), tx.Rollback err: %v", err)
+		}
+	}
+
+	book, err := lockCopyBook(tx, ISBN)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyBookNotFound
+		}
+		log.Error().Msgf("[Error] RegisterBookCopies(), lockCopyBook err: %v", err)
+		return nil, ErrFailedRegisterBookCopies
+	}
+	if book.Withdrawn {
+		rollback()
+		return nil, ErrBookWithdrawn
+	}
+
+	barcodes, err := bookCopyBarcodes(tx, book.ID)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RegisterBookCopies(), bookCopyBarcodes err: %v", err)
+		return nil, ErrFailedRegisterBookCopies
+	}
+
+	if copies == 0 {
+		copies = book.copies()
+	}
+
+	result := make([]model.BookCopy, 0, copies)
+	for copyNumber := int64(1); copyNumber <= copies; copyNumber++ {
+		registered, ok := barcodes[copyNumber]
+		if !ok {
+			registered, err = registerBookCopy(tx, book.ID, copyNumber)
+			if err != nil {
+				rollback()
+				log.Error().Msgf("[Error] RegisterBookCopies(), registerBookCopy err: %v", err)
+				return nil, ErrFailedRegisterBookCopies
+			}
+		}
+		result = append(result, book.bookCopy(registered, copyNumber))
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] RegisterBookCopies(), tx.Commit err: %v", err)
+		return nil, ErrFailedRegisterBookCopies
+	}
+
+	return result, nil
+}
+
+// GetBookCopies returns the first registered copies of a book with their barcodes by copy number, all
+// of them when copies is zero
+func (l *LibraryService) GetBookCopies(ISBN string, copies int64) ([]model.BookCopy, error) {
+	book, err := l.getCopyBook(ISBN)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyBookNotFound
+		}
+		log.Error().Msgf("[Error] GetBookCopies(), getCopyBook err: %v", err)
+		return nil, ErrFailedGetBookCopies
+	}
+	if book.Withdrawn {
+		return nil, ErrBookWithdrawn
+	}
+
+	rows, err := l.db.Query(`
+		SELECT "copyNumber", "barcode", "status" FROM "book_copies"
+		WHERE "bookID" = $1
+		ORDER BY "copyNumber" ASC
+		LIMIT NULLIF($2, 0);
+	`, book.ID, copies)
+	if err != nil {
+		log.Error().Msgf("[Error] GetBookCopies(), db.Query err: %v", err)
+		return nil, ErrFailedGetBookCopies
+	}
+	defer rows.Close()
+
+	result := []model.BookCopy{}
+	for rows.Next() {
+		var (
+			copyNumber int64
+			registered registeredCopy
+		)
+		if err := rows.Scan(&copyNumber, &registered.Barcode, &registered.Status); err != nil {
+			log.Error().Msgf("[Error] GetBookCopies(), rows.Scan err: %v", err)
+			return nil, ErrFailedGetBookCopies
+		}
+		result = append(result, book.bookCopy(registered, copyNumber))
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetBookCopies(), rows.Err err: %v", err)
+		return nil, ErrFailedGetBookCopies
+	}
+
+	return result, nil
+}
+
+// GetBookCopy returns a registered copy of a book with its barcode
+func (l *LibraryService) GetBookCopy(ISBN string, copyNumber int64) (*model.BookCopy, error) {
+	book, err := l.getCopyBook(ISBN)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyBookNotFound
+		}
+		log.Error().Msgf("[Error] GetBookCopy(), getCopyBook err: %v", err)
+		return nil, ErrFailedGetBookCopies
+	}
+	if book.Withdrawn {
+		return nil, ErrBookWithdrawn
+	}
+
+	var registered registeredCopy
+	err = l.db.QueryRow(`
+		SELECT "barcode", "status" FROM "book_copies" WHERE "bookID" = $1 AND "copyNumber" = $2;
+	`, book.ID, copyNumber).Scan(&registered.Barcode, &registered.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyNotFound
+		}
+		log.Error().Msgf("[Error] GetBookCopy(), db.QueryRow err: %v", err)
+		return nil, ErrFailedGetBookCopies
+	}
+
+	bookCopy := book.bookCopy(registered, copyNumber)
+	return &bookCopy, nil
+}
+
+// bookCopyByBarcodeSQL selects the copy with a barcode and its book
+const bookCopyByBarcodeSQL = `
+	SELECT
+		bc."barcode", bc."bookID", bc."copyNumber", b."ISBN", b."title", b."author", b."shelfNumber", bc."status"
+	FROM
+		"book_copies" bc INNER JOIN "books" b ON b."ID" = bc."bookID"
+	WHERE
+		bc."barcode" = $1;
+`
+
+// scanBookCopy scans a copy selected by bookCopyByBarcodeSQL
+func scanBookCopy(row *sql.Row) (*model.BookCopy, error) {
+	bookCopy := model.BookCopy{}
+	err := row.Scan(
+		&bookCopy.Barcode,
+		&bookCopy.BookID,
+		&bookCopy.CopyNumber,
+		&bookCopy.ISBN,
+		&bookCopy.Title,
+		&bookCopy.Author,
+		&bookCopy.ShelfNumber,
+		&bookCopy.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// GetBookCopyByBarcode returns the copy with the barcode
+func (l *LibraryService) GetBookCopyByBarcode(barcode string) (*model.BookCopy, error) {
+	bookCopy, err := scanBookCopy(l.db.QueryRow(bookCopyByBarcodeSQL, barcode))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyNotFound
+		}
+		log.Error().Msgf("[Error] GetBookCopyByBarcode(), db.QueryRow err: %v", err)
+		return nil, ErrFailedGetBookCopies
+	}
+
+	return bookCopy, nil
+}
+
+// ScanCode resolves a scanned code to what it stands for. Library card numbers and copy barcodes
+// are told apart by their prefix, IDs are looked up as checkout tickets and then as books, and
+// anything else is tried as an ISBN.
+func (l *LibraryService) ScanCode(code string) (*model.ScanResult, error) {
+	code = strings.TrimSpace(code)
+
+	if cardNumber, err := librarycard.Parse(code); err == nil {
+		userID, err := l.GetUserIDByCardNumber(cardNumber)
+		if err != nil {
+			return nil, scanCodeError(err, ErrLibraryCardNotFound)
+		}
+		user, err := l.GetUserWithBookDetails(userID)
+		if err != nil {
+			return nil, scanCodeError(err, ErrGetUserWithBookDetailsNotFound)
+		}
+		return &model.ScanResult{Type: model.ScanTypeUser, Code: cardNumber, User: user}, nil
+	}
+
+	if barcode, err := itemcode.Parse(code); err == nil {
+		bookCopy, err := l.GetBookCopyByBarcode(barcode)
+		if err != nil {
+			return nil, scanCodeError(err, ErrBookCopyNotFound)
+		}
+		return &model.ScanResult{Type: model.ScanTypeBookCopy, Code: barcode, BookCopy: bookCopy}, nil
+	}
+
+	if uuidPattern.MatchString(code) {
+		ID := strings.ToLower(code)
+		ticket, err := l.GetCheckoutTicketByID(ID)
+		if err == nil {
+			return &model.ScanResult{Type: model.ScanTypeCheckoutTicket, Code: ID, CheckoutTicket: ticket}, nil
+		}
+		if !errors.Is(err, ErrGetCheckoutTicketByIDNotFound) {
+			return nil, ErrFailedScanCode
+		}
+
+		book, err := l.GetBookWithBookID(ID)
+		if err != nil {
+			return nil, scanCodeError(err, ErrGetBookByIDNotFound)
+		}
+		return &model.ScanResult{Type: model.ScanTypeBook, Code: ID, Book: book}, nil
+	}
+
+	if ISBN13, _, err := isbn.Parse(code); err == nil {
+		book, err := l.GetBookByISBN(ISBN13)
+		if err != nil {
+			return nil, scanCodeError(err, ErrGetBookByIDNotFound)
+		}
+		return &model.ScanResult{Type: model.ScanTypeBook, Code: ISBN13, Book: book}, nil
+	}
+
+	return nil, ErrScanCodeUnknown
+}
+
+// scanCodeError reports a lookup ending in notFound as an unknown code
+func scanCodeError(err, notFound error) error {
+	if errors.Is(err, notFound) {
+		return ErrScanCodeUnknown
+	}
+	return ErrFailedScanCode
+}
+
+// moveShelfCopies puts copies of a book back on the shelf or takes them off it, the shelf count
+// never drops below zero. Users wishlisting the book are notified when it comes back in stock.
+func moveShelfCopies(tx *sql.Tx, bookID string, delta int64) error {
+	var wasAvailable bool
+	err := tx.QueryRow(`
+		UPDATE "books" SET
+			"booksLeft" = GREATEST("books"."booksLeft" + $2, 0),
+			"updatedAt" = NOW(),
+			"version" = "books"."version" + 1
+		FROM (
+			SELECT "inLibrary" AND "booksLeft" > 0 AS "wasAvailable" FROM "books" WHERE "ID" = $1 FOR UPDATE
+		) AS "previous"
+		WHERE
+			"books"."ID" = $1
+		RETURNING "previous"."wasAvailable";
+	`, bookID, delta).Scan(&wasAvailable)
+	if err != nil {
+		return err
+	}
+	if !wasAvailable && delta > 0 {
+		return notifyWishlistBackInStock(tx, bookID)
+	}
+	return nil
+}
@@ -0,0 +1,489 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedCreateBookImport is an error when recording a new book import failed
+	ErrFailedCreateBookImport = errors.New("create book import failed")
+	// ErrFailedUpdateBookImport is an error when recording the progress of a book import failed
+	ErrFailedUpdateBookImport = errors.New("update book import failed")
+	// ErrFailedImportBook is an error when storing an imported book failed
+	ErrFailedImportBook = errors.New("import book failed")
+	// ErrGetBookImportsFailed is an error when listing book imports failed
+	ErrGetBookImportsFailed = errors.New("get book imports failed")
+	// ErrGetBookImportNotFound is an error when the book import is not found
+	ErrGetBookImportNotFound = errors.New("book import not found")
+)
+
+// bookImportColumns are the columns scanned by scanBookImport
+const bookImportColumns = `
+	"ID",
+	"format",
+	"filename",
+	"dryRun",
+	"status",
+	"totalRows",
+	"processed",
+	"created",
+	"updated",
+	"failed",
+	COALESCE("error", ''),
+	"librarianID",
+	"createdAt",
+	"startedAt",
+	"finishedAt"`
+
+// CreateBookImport records a queued book import and returns its ID
+func (l *LibraryService) CreateBookImport(bookImport *model.BookImport) (string, error) {
+	sqlStatement := `
+		INSERT INTO "book_imports"("format", "filename", "dryRun", "totalRows", "librarianID")
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING "ID";
+	`
+
+	var importID string
+	err := l.db.QueryRow(
+		sqlStatement,
+		bookImport.Format,
+		bookImport.Filename,
+		bookImport.DryRun,
+		bookImport.TotalRows,
+		bookImport.LibrarianID,
+	).Scan(&importID)
+	if err != nil {
+		log.Error().Msgf("[Error] CreateBookImport(), db.QueryRow err: %v", err)
+		return "", ErrFailedCreateBookImport
+	}
+
+	return importID, nil
+}
+
+// StartBookImport marks a queued book import as running
+func (l *LibraryService) StartBookImport(importID string) error {
+	sqlStatement := `
+		UPDATE "book_imports" SET "status" = $2, "startedAt" = NOW() WHERE "ID" = $1;
+	`
+
+	if _, err := l.db.Exec(sqlStatement, importID, model.BookImportStatusRunning); err != nil {
+		log.Error().Msgf("[Error] StartBookImport(), db.Exec err: %v", err)
+		return ErrFailedUpdateBookImport
+	}
+
+	return nil
+}
+
+// ImportBook creates a book or updates the book with its ISBN. Given fields replace the stored ones,
+// the shelf and stock are only changed when the row has them. A dry run rolls the change back, so
+// the outcome is what the import would do.
+func (l *LibraryService) ImportBook(book *model.CreateBookRequest, dryRun bool) (model.BookImportOutcome, error) {
+	ISBN13, ISBN10, err := isbn.Parse(book.ISBN)
+	if err != nil {
+		return "", ErrInvalidISBN
+	}
+
+	title := truncateRunes(book.Title, 100)
+	author := truncateRunes(book.Author, 50)
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] ImportBook(), db.Begin err: %v", err)
+		return "", ErrFailedImportBook
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] ImportBook(), tx.Rollback err: %v", err)
+		}
+	}
+
+	var withdrawn, wasAvailable bool
+	err = tx.QueryRow(`
+		SELECT "withdrawnAt" IS NOT NULL, "inLibrary" AND "booksLeft" > 0 FROM "books" WHERE "ISBN" = $1 FOR UPDATE;
+	`, ISBN13).Scan(&withdrawn, &wasAvailable)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Msgf("[Error] ImportBook(), tx.QueryRow err: %v", err)
+		rollback()
+		return "", ErrFailedImportBook
+	}
+	if withdrawn {
+		rollback()
+		return "", ErrBookWithdrawn
+	}
+
+	sqlStatement := `
+		INSERT INTO "books"(
+			"ISBN",
+			"title",
+			"author",
+			"genre",
+			"publishedDate",
+			"desc",
+			"previewLink",
+			"coverImage",
+			"shelfNumber",
+			"inLibrary",
+			"booksLeft",
+			"ISBN10",
+			"subtitle",
+			"publisher",
+			"pageCount",
+			"language",
+			"maturityRating",
+			"price"
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9, 0), COALESCE($10, false), COALESCE($11, 0), NULLIF($12, ''),
+			NULLIF($13, ''), NULLIF($14, ''), $15, NULLIF($16, ''), NULLIF($17, ''), $18
+		)
+		ON CONFLICT("ISBN")
+		DO UPDATE SET
+			"title" = EXCLUDED."title",
+			"author" = EXCLUDED."author",
+			"genre" = EXCLUDED."genre",
+			"publishedDate" = EXCLUDED."publishedDate",
+			"desc" = COALESCE(NULLIF(EXCLUDED."desc", ''), "books"."desc"),
+			"previewLink" = COALESCE(NULLIF(EXCLUDED."previewLink", ''), "books"."previewLink"),
+			"coverImage" = COALESCE(NULLIF(EXCLUDED."coverImage", ''), "books"."coverImage"),
+			"shelfNumber" = COALESCE($9, "books"."shelfNumber"),
+			"inLibrary" = COALESCE($10, "books"."inLibrary"),
+			"booksLeft" = COALESCE($11, "books"."booksLeft"),
+			"ISBN10" = COALESCE(EXCLUDED."ISBN10", "books"."ISBN10"),
+			"subtitle" = COALESCE(EXCLUDED."subtitle", "books"."subtitle"),
+			"publisher" = COALESCE(EXCLUDED."publisher", "books"."publisher"),
+			"pageCount" = CASE WHEN EXCLUDED."pageCount" > 0 THEN EXCLUDED."pageCount" ELSE "books"."pageCount" END,
+			"language" = COALESCE(EXCLUDED."language", "books"."language"),
+			"maturityRating" = COALESCE(EXCLUDED."maturityRating", "books"."maturityRating"),
+			"price" = COALESCE($18, "books"."price"),
+			"updatedAt" = NOW(),
+			"version" = "books"."version" + 1
+		RETURNING "ID", (xmax = 0) AS "inserted", "inLibrary" AND "booksLeft" > 0 AS "available";
+	`
+
+	var (
+		bookID              string
+		inserted, available bool
+	)
+	err = tx.QueryRow(
+		sqlStatement,
+		ISBN13,
+		title,
+		author,
+		book.Genre,
+		book.PublishedDate,
+		book.Description,
+		book.PreviewLink,
+		book.CoverImage,
+		book.ShelfNumber,
+		book.InLibrary,
+		book.BooksLeft,
+		ISBN10,
+		book.Subtitle,
+		book.Publisher,
+		book.PageCount,
+		book.Language,
+		book.MaturityRating,
+		book.Price,
+	).Scan(&bookID, &inserted, &available)
+	if err != nil {
+		log.Error().Msgf("[Error] ImportBook(), tx.QueryRow upsert err: %v", err)
+		rollback()
+		return "", ErrFailedImportBook
+	}
+
+	authors, genres := bookContributors(book.Author, book.Authors, book.Genre, book.Genres)
+	if err := setBookAuthorsAndGenres(tx, ISBN13, authors, genres); err != nil {
+		log.Error().Msgf("[Error] ImportBook(), setBookAuthorsAndGenres err: %v", err)
+		rollback()
+		return "", ErrFailedImportBook
+	}
+
+	if !inserted && !wasAvailable && available {
+		if err := notifyWishlistBackInStock(tx, bookID); err != nil {
+			rollback()
+			return "", ErrFailedImportBook
+		}
+	}
+
+	outcome := model.BookImportOutcomeUpdated
+	if inserted {
+		outcome = model.BookImportOutcomeCreated
+	}
+
+	if dryRun {
+		rollback()
+		return outcome, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] ImportBook(), tx.Commit err: %v", err)
+		return "", ErrFailedImportBook
+	}
+
+	return outcome, nil
+}
+
+// RecordBookImportRows stores the report of a chunk of rows and adds them to the counts of the import
+func (l *LibraryService) RecordBookImportRows(importID string, rows []model.BookImportRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var (
+		numbers                  = make([]int64, 0, len(rows))
+		ISBNs                    = make([]string, 0, len(rows))
+		titles                   = make([]string, 0, len(rows))
+		outcomes                 = make([]string, 0, len(rows))
+		messages                 = make([]string, 0, len(rows))
+		created, updated, failed int64
+	)
+	for _, row := range rows {
+		numbers = append(numbers, row.RowNumber)
+		ISBNs = append(ISBNs, row.ISBN)
+		titles = append(titles, row.Title)
+		outcomes = append(outcomes, string(row.Outcome))
+		messages = append(messages, row.Message)
+
+		switch row.Outcome {
+		case model.BookImportOutcomeCreated:
+			created++
+		case model.BookImportOutcomeUpdated:
+			updated++
+		default:
+			failed++
+		}
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] RecordBookImportRows(), db.Begin err: %v", err)
+		return ErrFailedUpdateBookImport
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] RecordBookImportRows(), tx.Rollback err: %v", err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO "book_import_rows"("importID", "rowNumber", "ISBN", "title", "outcome", "message")
+		SELECT
+			$1, r."rowNumber", NULLIF(r."ISBN", ''), NULLIF(r."title", ''), r."outcome"::BOOK_IMPORT_OUTCOME, NULLIF(r."message", '')
+		FROM
+			UNNEST($2::int[], $3::varchar[], $4::varchar[], $5::text[], $6::text[])
+				AS r("rowNumber", "ISBN", "title", "outcome", "message")
+		ON CONFLICT ("importID", "rowNumber")
+		DO NOTHING;
+	`, importID, pq.Array(numbers), pq.Array(ISBNs), pq.Array(titles), pq.Array(outcomes), pq.Array(messages))
+	if err != nil {
+		log.Error().Msgf("[Error] RecordBookImportRows(), tx.Exec rows err: %v", err)
+		rollback()
+		return ErrFailedUpdateBookImport
+	}
+
+	_, err = tx.Exec(`
+		UPDATE "book_imports" SET
+			"processed" = "processed" + $2,
+			"created" = "created" + $3,
+			"updated" = "updated" + $4,
+			"failed" = "failed" + $5
+		WHERE
+			"ID" = $1;
+	`, importID, len(rows), created, updated, failed)
+	if err != nil {
+		log.Error().Msgf("[Error] RecordBookImportRows(), tx.Exec counts err: %v", err)
+		rollback()
+		return ErrFailedUpdateBookImport
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] RecordBookImportRows(), tx.Commit err: %v", err)
+		return ErrFailedUpdateBookImport
+	}
+
+	return nil
+}
+
+// FinishBookImport records the status and error of a finished book import
+func (l *LibraryService) FinishBookImport(importID string, status model.BookImportStatus, errMessage string) error {
+	sqlStatement := `
+		UPDATE "book_imports" SET
+			"status" = $2,
+			"error" = NULLIF($3, ''),
+			"finishedAt" = NOW()
+		WHERE
+			"ID" = $1;
+	`
+
+	if _, err := l.db.Exec(sqlStatement, importID, status, errMessage); err != nil {
+		log.Error().Msgf("[Error] FinishBookImport(), db.Exec err: %v", err)
+		return ErrFailedUpdateBookImport
+	}
+
+	return nil
+}
+
+// GetBookImports returns the book imports, the most recent first
+func (l *LibraryService) GetBookImports(request *model.GetBookImportsRequest) ([]model.BookImport, uint, error) {
+	sqlStatement := `
+		SELECT
+			` + bookImportColumns + `,
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"book_imports"
+		ORDER BY
+			"createdAt" DESC, "ID" ASC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement)
+	if err != nil {
+		log.Error().Msgf("[Error] GetBookImports(), db.Query err: %v", err)
+		return nil, 0, ErrGetBookImportsFailed
+	}
+	defer rows.Close()
+
+	var (
+		imports   = []model.BookImport{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		bookImport, err := scanBookImport(scanWithExtra(rows, &totalRows))
+		if err != nil {
+			log.Error().Msgf("[Error] GetBookImports(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetBookImportsFailed
+		}
+		imports = append(imports, *bookImport)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetBookImports(), rows.Err err: %v", err)
+		return nil, 0, ErrGetBookImportsFailed
+	}
+
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return imports, uint(totalPages), nil
+}
+
+// GetBookImport returns a book import with its progress and counts
+func (l *LibraryService) GetBookImport(importID string) (*model.BookImport, error) {
+	sqlStatement := `SELECT ` + bookImportColumns + ` FROM "book_imports" WHERE "ID" = $1;`
+
+	bookImport, err := scanBookImport(l.db.QueryRow(sqlStatement, importID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGetBookImportNotFound
+		}
+		log.Error().Msgf("[Error] GetBookImport(), db.QueryRow err: %v", err)
+		return nil, ErrGetBookImportsFailed
+	}
+
+	return bookImport, nil
+}
+
+// GetBookImportRows returns the per row report of a book import in file order
+func (l *LibraryService) GetBookImportRows(importID string, request *model.GetBookImportRowsRequest) ([]model.BookImportRow, uint, error) {
+	if _, err := l.GetBookImport(importID); err != nil {
+		return nil, 0, err
+	}
+
+	sqlStatement := `
+		SELECT
+			"rowNumber",
+			COALESCE("ISBN", ''),
+			COALESCE("title", ''),
+			"outcome",
+			COALESCE("message", ''),
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"book_import_rows"
+		WHERE
+			"importID" = $1 AND ($2 = '' OR "outcome"::text = $2)
+		ORDER BY
+			"rowNumber" ASC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement, importID, string(request.Outcome))
+	if err != nil {
+		log.Error().Msgf("[Error] GetBookImportRows(), db.Query err: %v", err)
+		return nil, 0, ErrGetBookImportsFailed
+	}
+	defer rows.Close()
+
+	var (
+		reportRows = []model.BookImportRow{}
+		totalRows  uint32
+	)
+	for rows.Next() {
+		row := model.BookImportRow{}
+		if err := rows.Scan(&row.RowNumber, &row.ISBN, &row.Title, &row.Outcome, &row.Message, &totalRows); err != nil {
+			log.Error().Msgf("[Error] GetBookImportRows(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetBookImportsFailed
+		}
+		reportRows = append(reportRows, row)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetBookImportRows(), rows.Err err: %v", err)
+		return nil, 0, ErrGetBookImportsFailed
+	}
+
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return reportRows, uint(totalPages), nil
+}
+
+// scanBookImport scans the bookImportColumns of a row
+func scanBookImport(row rowScanner) (*model.BookImport, error) {
+	var (
+		bookImport model.BookImport
+		librarian  sql.NullString
+		started    sql.NullTime
+		finished   sql.NullTime
+	)
+	err := row.Scan(
+		&bookImport.ID,
+		&bookImport.Format,
+		&bookImport.Filename,
+		&bookImport.DryRun,
+		&bookImport.Status,
+		&bookImport.TotalRows,
+		&bookImport.Processed,
+		&bookImport.Created,
+		&bookImport.Updated,
+		&bookImport.Failed,
+		&bookImport.Error,
+		&librarian,
+		&bookImport.CreatedAt,
+		&started,
+		&finished,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if librarian.Valid {
+		bookImport.LibrarianID = &librarian.String
+	}
+	if started.Valid {
+		bookImport.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		bookImport.FinishedAt = &finished.Time
+	}
+	return &bookImport, nil
+}
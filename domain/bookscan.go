@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"database/sql"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+
+	"integrated-library-service/model"
+)
+
+// bookColumns is the column list of the "books" table every book select scans with scanBook
+const bookColumns = `"ID",
+			"ISBN",
+			"ISBN10",
+			"title",
+			"author",
+			"genre",
+			"publishedDate",
+			"desc",
+			"previewLink",
+			"coverImage",
+			"shelfNumber",
+			"inLibrary",
+			"views",
+			"booksLeft",
+			"wishlistCount",
+			"rating",
+			"reviewCount",
+			"approximateDemand",
+			"createdAt",
+			"updatedAt",
+			"reviewsList",
+			"viewsList",
+			"wishList",
+			"subtitle",
+			"publisher",
+			"pageCount",
+			"language",
+			"maturityRating",
+			"version",
+			"withdrawnAt",
+			"withdrawalReason",
+			"price",
+			ARRAY(
+				SELECT "authors"."name" FROM "book_authors"
+				JOIN "authors" ON "authors"."ID" = "book_authors"."authorID"
+				WHERE "book_authors"."bookID" = "books"."ID"
+				ORDER BY "book_authors"."position"
+			) AS "authors",
+			ARRAY(
+				SELECT "genres"."name" FROM "book_genres"
+				JOIN "genres" ON "genres"."ID" = "book_genres"."genreID"
+				WHERE "book_genres"."bookID" = "books"."ID"
+				ORDER BY "book_genres"."position"
+			) AS "genres"`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraScanner scans the columns selected after bookColumns into its extra destinations
+type extraScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+// Scan scans the book columns followed by the extra columns
+func (e extraScanner) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.extra...)...)
+}
+
+// scanWithExtra lets scanBook scan rows which select more columns after bookColumns
+func scanWithExtra(row rowScanner, extra ...interface{}) rowScanner {
+	return extraScanner{row: row, extra: extra}
+}
+
+// scanBook scans a row selected with bookColumns into a book
+func scanBook(row rowScanner) (*model.Book, error) {
+	var (
+		book        model.Book
+		ISBN10      sql.NullString
+		updatedAt   sql.NullTime
+		reviewList  pq.StringArray
+		viewList    pq.StringArray
+		wishList    pq.StringArray
+		subtitle    sql.NullString
+		publisher   sql.NullString
+		language    sql.NullString
+		maturity    sql.NullString
+		withdrawnAt sql.NullTime
+		withdrawal  sql.NullString
+		price       sql.NullFloat64
+		authors     pq.StringArray
+		genres      pq.StringArray
+	)
+	err := row.Scan(
+		&book.ID,
+		&book.ISBN,
+		&ISBN10,
+		&book.Title,
+		&book.Author,
+		&book.Genre,
+		&book.PublishedDate,
+		&book.Description,
+		&book.PreviewLink,
+		&book.CoverImage,
+		&book.ShelfNumber,
+		&book.InLibrary,
+		&book.Views,
+		&book.BooksLeft,
+		&book.WishlistCount,
+		&book.Rating,
+		&book.ReviewCount,
+		&book.ApproximateDemand,
+		&book.CreatedAt,
+		&updatedAt,
+		&reviewList,
+		&viewList,
+		&wishList,
+		&subtitle,
+		&publisher,
+		&book.PageCount,
+		&language,
+		&maturity,
+		&book.Version,
+		&withdrawnAt,
+		&withdrawal,
+		&price,
+		&authors,
+		&genres,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	book.ISBN10 = ISBN10.String
+	book.UpdatedAt = &updatedAt.Time
+	book.ReviewsList = reviewList
+	book.ViewsList = viewList
+	book.WishList = wishList
+	book.Subtitle = subtitle.String
+	book.Publisher = publisher.String
+	book.Language = language.String
+	book.MaturityRating = maturity.String
+	if withdrawnAt.Valid {
+		book.WithdrawnAt = &withdrawnAt.Time
+	}
+	book.WithdrawalReason = withdrawal.String
+	if price.Valid {
+		book.Price = &price.Float64
+	}
+	book.Authors = authors
+	book.Genres = genres
+
+	return &book, nil
+}
+
+// bookContributors returns the trimmed, de-duplicated authors and genres of a book,
+// falling back to the single author and genre when no lists are given
+func bookContributors(author string, authors []string, genre string, genres []string) ([]string, []string) {
+	if len(authors) == 0 {
+		authors = []string{author}
+	}
+	if len(genres) == 0 {
+		genres = []string{genre}
+	}
+	return uniqueNames(authors), uniqueNames(genres)
+}
+
+// uniqueNames trims the names and drops empty and repeated ones, keeping the first occurrence
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
+
+// truncateRunes cuts s to at most limit characters, VARCHAR columns count characters and cutting
+// bytes could split a multi-byte character in half
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
+
+// setBookAuthorsAndGenres replaces the authors and genres linked to the book with the given ISBN,
+// the order of the lists is kept in the "position" column and an empty list leaves its links untouched
+func setBookAuthorsAndGenres(exec execer, ISBN string, authors, genres []string) error {
+	links := []struct {
+		table     string
+		linkTable string
+		linkKey   string
+		names     []string
+	}{
+		{`"authors"`, `"book_authors"`, `"authorID"`, authors},
+		{`"genres"`, `"book_genres"`, `"genreID"`, genres},
+	}
+
+	for _, link := range links {
+		if len(link.names) == 0 {
+			continue
+		}
+
+		names := pq.Array(link.names)
+		insertNames := `INSERT INTO ` + link.table + `("name") SELECT unnest($1::varchar[]) ON CONFLICT ("name") DO NOTHING;`
+		if _, err := exec.Exec(insertNames, names); err != nil {
+			return err
+		}
+
+		deleteLinks := `DELETE FROM ` + link.linkTable + ` WHERE "bookID" = (SELECT "ID" FROM "books" WHERE "ISBN" = $1);`
+		if _, err := exec.Exec(deleteLinks, ISBN); err != nil {
+			return err
+		}
+
+		insertLinks := `
+			INSERT INTO ` + link.linkTable + `("bookID", ` + link.linkKey + `, "position")
+			SELECT "books"."ID", ` + link.table + `."ID", "names"."position"
+			FROM "books"
+			CROSS JOIN unnest($2::varchar[]) WITH ORDINALITY AS "names"("name", "position")
+			JOIN ` + link.table + ` ON ` + link.table + `."name" = "names"."name"
+			WHERE "books"."ISBN" = $1;
+		`
+		if _, err := exec.Exec(insertLinks, ISBN, names); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedRecordBookView is an error when recording a book view failed
+	ErrFailedRecordBookView = errors.New("record book view failed")
+	// ErrRecordBookViewNotFound is an error when the viewed book is not found
+	ErrRecordBookViewNotFound = errors.New("viewed book not found")
+	// ErrFailedGetBookViews is an error when the book view analytics failed
+	ErrFailedGetBookViews = errors.New("get book views failed")
+)
+
+// RecordBookView records that the user viewed the book. Views of the same book by the same user within
+// model.BookViewDedupeWindow are not counted. A counted view is stored in "book_views", increments "views"
+// and moves the user to the end of "viewsList", which keeps the model.BookViewsListSize most recent viewers.
+func (l *LibraryService) RecordBookView(bookID, userID string) (*model.RecordBookViewResponse, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] RecordBookView(), db.Begin err: %v", err)
+		return nil, ErrFailedRecordBookView
+	}
+
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] RecordBookView(), tx.Rollback err: %v", err)
+		}
+	}
+
+	// concurrent views of the same book by the same user wait for each other so only one is counted
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2));`, bookID, userID); err != nil {
+		log.Error().Msgf("[Error] RecordBookView(), tx.Exec lock err: %v", err)
+		rollback()
+		return nil, ErrFailedRecordBookView
+	}
+
+	response := &model.RecordBookViewResponse{}
+	err = tx.QueryRow(`
+		SELECT
+			"views"::bigint,
+			NOT EXISTS (
+				SELECT 1 FROM "book_views"
+				WHERE "bookID" = $1 AND "userID" = $2 AND "viewedAt" > NOW() - make_interval(secs => $3::float)
+			)
+		FROM
+			"books"
+		WHERE
+			"ID" = $1;
+	`, bookID, userID, model.BookViewDedupeWindow.Seconds()).Scan(&response.Views, &response.Recorded)
+	if errors.Is(err, sql.ErrNoRows) {
+		rollback()
+		return nil, ErrRecordBookViewNotFound
+	}
+	if err != nil {
+		log.Error().Msgf("[Error] RecordBookView(), tx.QueryRow err: %v", err)
+		rollback()
+		return nil, ErrFailedRecordBookView
+	}
+
+	if !response.Recorded {
+		rollback()
+		return response, nil
+	}
+
+	if _, err := tx.Exec(`INSERT INTO "book_views"("bookID", "userID") VALUES ($1, $2);`, bookID, userID); err != nil {
+		log.Error().Msgf("[Error] RecordBookView(), tx.Exec insert err: %v", err)
+		rollback()
+		return nil, ErrFailedRecordBookView
+	}
+
+	err = tx.QueryRow(`
+		UPDATE "books" SET
+			"views" = "books"."views" + 1,
+			"viewsList" = "viewers"."list"[GREATEST(array_length("viewers"."list", 1) - $3 + 1, 1):]
+		FROM (
+			SELECT array_append(array_remove("viewsList", $2::varchar), $2::varchar) AS "list" FROM "books" WHERE "ID" = $1
+		) AS "viewers"
+		WHERE
+			"books"."ID" = $1
+		RETURNING "books"."views"::bigint;
+	`, bookID, userID, model.BookViewsListSize).Scan(&response.Views)
+	if err != nil {
+		log.Error().Msgf("[Error] RecordBookView(), tx.QueryRow update err: %v", err)
+		rollback()
+		return nil, ErrFailedRecordBookView
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] RecordBookView(), tx.Commit err: %v", err)
+		return nil, ErrFailedRecordBookView
+	}
+
+	return response, nil
+}
+
+// GetBookViewsOverTime returns the views per day, week or month between from and to, periods without
+// views included, optionally of a single book
+func (l *LibraryService) GetBookViewsOverTime(request *model.GetBookViewsOverTimeRequest) ([]model.BookViewsPeriod, error) {
+	granularity := request.Granularity
+	if len(granularity) == 0 {
+		granularity = "day"
+	}
+
+	sqlStatement := `
+		SELECT
+			"series"."period",
+			COUNT(v."ID") AS "views",
+			COUNT(DISTINCT v."userID") AS "uniqueViewers"
+		FROM
+			generate_series(
+				DATE_TRUNC($1, $2::timestamp),
+				DATE_TRUNC($1, $3::timestamp),
+				('1 ' || $1)::interval
+			) AS "series"("period")
+		LEFT JOIN "book_views" v ON
+			DATE_TRUNC($1, v."viewedAt") = "series"."period" AND
+			v."viewedAt" >= $2::timestamp AND v."viewedAt" < $3::timestamp + INTERVAL '1 day' AND
+			($4 = '' OR v."bookID" = NULLIF($4, '')::uuid)
+		GROUP BY
+			"series"."period"
+		ORDER BY
+			"series"."period" ASC;
+	`
+
+	rows, err := l.db.Query(sqlStatement, granularity, request.From, request.To, request.BookID)
+	if err != nil {
+		log.Error().Msgf("[Error] GetBookViewsOverTime(), db.Query err: %v", err)
+		return nil, ErrFailedGetBookViews
+	}
+	defer rows.Close()
+
+	periods := []model.BookViewsPeriod{}
+	for rows.Next() {
+		var period model.BookViewsPeriod
+		if err := rows.Scan(&period.Period, &period.Views, &period.UniqueViewers); err != nil {
+			log.Error().Msgf("[Error] GetBookViewsOverTime(), rows.Scan err: %v", err)
+			return nil, ErrFailedGetBookViews
+		}
+		periods = append(periods, period)
+	}
+
+	return periods, nil
+}
+
+// GetTopViewedBooks returns the books viewed the most over the last days, a week and 10 books by default
+func (l *LibraryService) GetTopViewedBooks(request *model.GetTopViewedBooksRequest) ([]model.TopViewedBook, error) {
+	days, limit := request.Days, request.Limit
+	if days == 0 {
+		days = 7
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	sqlStatement := `
+		SELECT
+			` + bookColumns + `,
+			"recent"."recentViews",
+			"recent"."uniqueViewers"
+		FROM (
+			SELECT "bookID", COUNT(*) AS "recentViews", COUNT(DISTINCT "userID") AS "uniqueViewers"
+			FROM "book_views"
+			WHERE "viewedAt" > NOW() - make_interval(days => $1)
+			GROUP BY "bookID"
+		) AS "recent"
+		JOIN "books" ON "books"."ID" = "recent"."bookID"
+		WHERE
+			"books"."withdrawnAt" IS NULL
+		ORDER BY
+			"recent"."recentViews" DESC, "books"."ID" ASC
+		LIMIT $2;
+	`
+
+	rows, err := l.db.Query(sqlStatement, days, limit)
+	if err != nil {
+		log.Error().Msgf("[Error] GetTopViewedBooks(), db.Query err: %v", err)
+		return nil, ErrFailedGetBookViews
+	}
+	defer rows.Close()
+
+	books := []model.TopViewedBook{}
+	for rows.Next() {
+		var topViewed model.TopViewedBook
+		book, err := scanBook(scanWithExtra(rows, &topViewed.RecentViews, &topViewed.UniqueViewers))
+		if err != nil {
+			log.Error().Msgf("[Error] GetTopViewedBooks(), rows.Scan err: %v", err)
+			return nil, ErrFailedGetBookViews
+		}
+		topViewed.Book = *book
+		books = append(books, topViewed)
+	}
+
+	return books, nil
+}
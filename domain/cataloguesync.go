@@ -0,0 +1,333 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedSyncBook is an error when upserting a synced book failed
+	ErrFailedSyncBook = errors.New("sync book failed")
+	// ErrFailedGetStaleBooks is an error when listing books with stale metadata failed
+	ErrFailedGetStaleBooks = errors.New("get stale books failed")
+	// ErrFailedCreateCatalogueSyncRun is an error when recording a new catalogue sync run failed
+	ErrFailedCreateCatalogueSyncRun = errors.New("create catalogue sync run failed")
+	// ErrFailedFinishCatalogueSyncRun is an error when recording the result of a catalogue sync run failed
+	ErrFailedFinishCatalogueSyncRun = errors.New("finish catalogue sync run failed")
+	// ErrFailedGetCatalogueSyncRuns is an error when listing catalogue sync runs failed
+	ErrFailedGetCatalogueSyncRuns = errors.New("get catalogue sync runs failed")
+)
+
+// SyncBook inserts a book coming from a metadata provider, or refreshes the bibliographic metadata of
+// an existing book last synced before staleBefore. Curated fields like title, authors, genres, shelf
+// and stock are never overwritten for existing books.
+func (l *LibraryService) SyncBook(book *model.CreateBookRequest, staleBefore time.Time) (model.SyncOutcome, error) {
+	ISBN13, ISBN10, err := isbn.Parse(book.ISBN)
+	if err != nil {
+		log.Error().Msgf("[Error] SyncBook(), isbn.Parse %q err: %v", book.ISBN, err)
+		return "", ErrInvalidISBN
+	}
+
+	title := truncateRunes(book.Title, 100)
+	author := truncateRunes(book.Author, 50)
+
+	sqlStatement := `
+		INSERT INTO "books"(
+			"ISBN",
+			"title",
+			"author",
+			"genre",
+			"publishedDate",
+			"desc",
+			"previewLink",
+			"coverImage",
+			"shelfNumber",
+			"inLibrary",
+			"views",
+			"booksLeft",
+			"wishlistCount",
+			"rating",
+			"reviewCount",
+			"approximateDemand",
+			"reviewsList",
+			"viewsList",
+			"wishList",
+			"ISBN10",
+			"subtitle",
+			"publisher",
+			"pageCount",
+			"language",
+			"maturityRating",
+			"metadataSyncedAt"
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NULLIF($20, ''),
+			NULLIF($21, ''), NULLIF($22, ''), $23, NULLIF($24, ''), NULLIF($25, ''), NOW()
+		)
+		ON CONFLICT("ISBN")
+		DO UPDATE SET
+			"ISBN10" = COALESCE(EXCLUDED."ISBN10", "books"."ISBN10"),
+			"subtitle" = COALESCE(EXCLUDED."subtitle", "books"."subtitle"),
+			"desc" = COALESCE(NULLIF(EXCLUDED."desc", ''), "books"."desc"),
+			"previewLink" = COALESCE(NULLIF(EXCLUDED."previewLink", ''), "books"."previewLink"),
+			"coverImage" = COALESCE(NULLIF(EXCLUDED."coverImage", ''), "books"."coverImage"),
+			"publishedDate" = CASE
+				WHEN EXTRACT(YEAR FROM EXCLUDED."publishedDate") > 1 THEN EXCLUDED."publishedDate"
+				ELSE "books"."publishedDate"
+			END,
+			"publisher" = COALESCE(EXCLUDED."publisher", "books"."publisher"),
+			"pageCount" = CASE WHEN EXCLUDED."pageCount" > 0 THEN EXCLUDED."pageCount" ELSE "books"."pageCount" END,
+			"language" = COALESCE(EXCLUDED."language", "books"."language"),
+			"maturityRating" = COALESCE(EXCLUDED."maturityRating", "books"."maturityRating"),
+			"metadataSyncedAt" = NOW(),
+			"updatedAt" = NOW(),
+			"version" = "books"."version" + 1
+		WHERE
+			("books"."metadataSyncedAt" IS NULL OR "books"."metadataSyncedAt" < $26) AND "books"."purgedAt" IS NULL
+		RETURNING (xmax = 0) AS "inserted";
+	`
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] SyncBook(), db.Begin err: %v", err)
+		return "", ErrFailedSyncBook
+	}
+
+	var inserted bool
+	err = tx.QueryRow(
+		sqlStatement,
+		ISBN13,
+		title,
+		author,
+		book.Genre,
+		book.PublishedDate,
+		book.Description,
+		book.PreviewLink,
+		book.CoverImage,
+		book.ShelfNumber,
+		book.InLibrary,
+		book.Views,
+		book.BooksLeft,
+		book.WishlistCount,
+		book.Rating,
+		book.ReviewCount,
+		book.ApproximateDemand,
+		pq.Array(book.ReviewsList),
+		pq.Array(book.ViewsList),
+		pq.Array(book.WishList),
+		ISBN10,
+		book.Subtitle,
+		book.Publisher,
+		book.PageCount,
+		book.Language,
+		book.MaturityRating,
+		staleBefore,
+	).Scan(&inserted)
+
+	// the conflicting row was fresh so nothing was written
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] SyncBook(), tx.Rollback err: %v", err)
+		}
+		return model.SyncOutcomeSkipped, nil
+	}
+	if err != nil {
+		log.Error().Msgf("[Error] SyncBook(), tx.QueryRow err: %v", err)
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] SyncBook(), tx.Rollback err: %v", err)
+		}
+		return "", ErrFailedSyncBook
+	}
+
+	outcome := model.SyncOutcomeUpdated
+	if inserted {
+		outcome = model.SyncOutcomeAdded
+		authors, genres := bookContributors(book.Author, book.Authors, book.Genre, book.Genres)
+		if err := setBookAuthorsAndGenres(tx, ISBN13, authors, genres); err != nil {
+			log.Error().Msgf("[Error] SyncBook(), setBookAuthorsAndGenres err: %v", err)
+			if err := tx.Rollback(); err != nil {
+				log.Error().Msgf("[Error] SyncBook(), tx.Rollback err: %v", err)
+			}
+			return "", ErrFailedSyncBook
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] SyncBook(), tx.Commit err: %v", err)
+		return "", ErrFailedSyncBook
+	}
+
+	return outcome, nil
+}
+
+// GetStaleBookISBNs returns up to limit ISBNs of books whose metadata was last synced before staleBefore,
+// the least recently synced first. Purged books are never synced again.
+func (l *LibraryService) GetStaleBookISBNs(staleBefore time.Time, limit int) ([]string, error) {
+	sqlStatement := `
+		SELECT
+			"ISBN"
+		FROM
+			"books"
+		WHERE
+			("metadataSyncedAt" IS NULL OR "metadataSyncedAt" < $1) AND "purgedAt" IS NULL
+		ORDER BY
+			"metadataSyncedAt" ASC NULLS FIRST, "createdAt" ASC
+		LIMIT $2;
+	`
+
+	rows, err := l.db.Query(sqlStatement, staleBefore, limit)
+	if err != nil {
+		log.Error().Msgf("[Error] GetStaleBookISBNs(), db.Query err: %v", err)
+		return nil, ErrFailedGetStaleBooks
+	}
+	defer rows.Close()
+
+	ISBNs := []string{}
+	for rows.Next() {
+		var ISBN string
+		if err := rows.Scan(&ISBN); err != nil {
+			log.Error().Msgf("[Error] GetStaleBookISBNs(), rows.Scan err: %v", err)
+			return nil, ErrFailedGetStaleBooks
+		}
+		ISBNs = append(ISBNs, ISBN)
+	}
+
+	return ISBNs, nil
+}
+
+// MarkBookMetadataSynced marks the metadata of a book as synced without changing it, so books no
+// provider knows are not retried on every run
+func (l *LibraryService) MarkBookMetadataSynced(ISBN string) error {
+	sqlStatement := `
+		UPDATE "books" SET "metadataSyncedAt" = NOW() WHERE "ISBN" = $1;
+	`
+
+	if _, err := l.db.Exec(sqlStatement, ISBN); err != nil {
+		log.Error().Msgf("[Error] MarkBookMetadataSynced(), db.Exec err: %v", err)
+		return ErrFailedSyncBook
+	}
+
+	return nil
+}
+
+// CreateCatalogueSyncRun records the start of a catalogue sync run and returns its ID
+func (l *LibraryService) CreateCatalogueSyncRun(trigger string) (string, error) {
+	sqlStatement := `
+		INSERT INTO "catalogue_sync_runs"("trigger", "status")
+		VALUES ($1, $2)
+		RETURNING "ID";
+	`
+
+	var runID string
+	if err := l.db.QueryRow(sqlStatement, trigger, model.SyncRunStatusRunning).Scan(&runID); err != nil {
+		log.Error().Msgf("[Error] CreateCatalogueSyncRun(), db.QueryRow err: %v", err)
+		return "", ErrFailedCreateCatalogueSyncRun
+	}
+
+	return runID, nil
+}
+
+// FinishCatalogueSyncRun records the counts, status and error of a finished catalogue sync run
+func (l *LibraryService) FinishCatalogueSyncRun(run *model.CatalogueSyncRun) error {
+	sqlStatement := `
+		UPDATE "catalogue_sync_runs" SET
+			"status" = $2,
+			"added" = $3,
+			"updated" = $4,
+			"skipped" = $5,
+			"failed" = $6,
+			"error" = NULLIF($7, ''),
+			"notFound" = $8,
+			"finishedAt" = NOW()
+		WHERE
+			"ID" = $1;
+	`
+
+	_, err := l.db.Exec(sqlStatement, run.ID, run.Status, run.Added, run.Updated, run.Skipped, run.Failed, run.Error, run.NotFound)
+	if err != nil {
+		log.Error().Msgf("[Error] FinishCatalogueSyncRun(), db.Exec err: %v", err)
+		return ErrFailedFinishCatalogueSyncRun
+	}
+
+	return nil
+}
+
+// GetCatalogueSyncRuns returns the catalogue sync runs, the most recent first
+func (l *LibraryService) GetCatalogueSyncRuns(request *model.GetCatalogueSyncRunsRequest) ([]model.CatalogueSyncRun, uint, error) {
+	sqlStatement := `
+		SELECT
+			"ID",
+			"trigger",
+			"status",
+			"added",
+			"updated",
+			"skipped",
+			"failed",
+			"notFound",
+			COALESCE("error", ''),
+			"startedAt",
+			"finishedAt"
+		FROM
+			"catalogue_sync_runs"
+		ORDER BY
+			"startedAt" DESC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement)
+	if err != nil {
+		log.Error().Msgf("[Error] GetCatalogueSyncRuns(), db.Query err: %v", err)
+		return nil, 0, ErrFailedGetCatalogueSyncRuns
+	}
+	defer rows.Close()
+
+	runs := []model.CatalogueSyncRun{}
+	for rows.Next() {
+		var (
+			run        model.CatalogueSyncRun
+			finishedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&run.ID,
+			&run.Trigger,
+			&run.Status,
+			&run.Added,
+			&run.Updated,
+			&run.Skipped,
+			&run.Failed,
+			&run.NotFound,
+			&run.Error,
+			&run.StartedAt,
+			&finishedAt,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetCatalogueSyncRuns(), rows.Scan err: %v", err)
+			return nil, 0, ErrFailedGetCatalogueSyncRuns
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+
+	var totalRows uint
+	if err := l.db.QueryRow(`SELECT COUNT(*) FROM "catalogue_sync_runs";`).Scan(&totalRows); err != nil {
+		log.Error().Msgf("[Error] GetCatalogueSyncRuns(), count query err: %v", err)
+		return nil, 0, ErrFailedGetCatalogueSyncRuns
+	}
+	// Calculate total pages
+	totalPages := (uint32(totalRows) + request.Limit - 1) / request.Limit
+
+	return runs, uint(totalPages), nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"integrated-library-service/librarycard"
 	"integrated-library-service/model"
 
 	"github.com/rs/zerolog/log"
@@ -38,6 +39,17 @@ var (
 
 // CreateCheckoutTicket creates a new checkout ticket
 func (l *LibraryService) CreateCheckoutTicket(ticket *model.CreateCheckoutRequest) error {
+	// a scanned library card stands for its user
+	if len(ticket.UserID) == 0 {
+		cardNumber, err := librarycard.Parse(ticket.CardNumber)
+		if err != nil {
+			return ErrLibraryCardNotFound
+		}
+		if ticket.UserID, err = l.GetUserIDByCardNumber(cardNumber); err != nil {
+			return err
+		}
+	}
+
 	user, err := l.GetUserWithBookDetails(ticket.UserID)
 	if err != nil {
 		log.Error().Msgf("[Error] CreateCheckoutTicket(), GetUserWithBookDetails err: %v", err)
@@ -54,6 +66,10 @@ func (l *LibraryService) CreateCheckoutTicket(ticket *model.CreateCheckoutReques
 		return ErrFailedCreateCheckoutTicket
 	}
 
+	if book.WithdrawnAt != nil {
+		return ErrBookWithdrawn
+	}
+
 	if book.BooksLeft == 0 {
 		return ErrOutOfStock
 	}
@@ -114,6 +130,11 @@ func (l *LibraryService) GetCheckoutTicketByID(ticketID string) (*model.Checkout
 			"reservedOn",
 			"checkedOutOn",
 			"returnedDate",
+			"lostAt",
+			"damagedAt",
+			"foundAt",
+			"incidentFee",
+			"version",
 			"createdAt",
 			"updatedAt"
 		FROM 
@@ -128,6 +149,9 @@ func (l *LibraryService) GetCheckoutTicketByID(ticketID string) (*model.Checkout
 		reservedOn   sql.NullTime
 		checkedOutOn sql.NullTime
 		returnedDate sql.NullTime
+		lostAt       sql.NullTime
+		damagedAt    sql.NullTime
+		foundAt      sql.NullTime
 	)
 	err := l.db.QueryRow(sqlStatement, ticketID).Scan(
 		&ticket.ID,
@@ -140,6 +164,11 @@ func (l *LibraryService) GetCheckoutTicketByID(ticketID string) (*model.Checkout
 		&reservedOn,
 		&checkedOutOn,
 		&returnedDate,
+		&lostAt,
+		&damagedAt,
+		&foundAt,
+		&ticket.IncidentFee,
+		&ticket.Version,
 		&ticket.CreatedAt,
 		&updatedAt,
 	)
@@ -157,6 +186,15 @@ func (l *LibraryService) GetCheckoutTicketByID(ticketID string) (*model.Checkout
 	ticket.CheckedOutOn = checkedOutOn.Time
 	ticket.ReturnedDate = returnedDate.Time
 	ticket.ReservedOn = reservedOn.Time
+	if lostAt.Valid {
+		ticket.LostAt = &lostAt.Time
+	}
+	if damagedAt.Valid {
+		ticket.DamagedAt = &damagedAt.Time
+	}
+	if foundAt.Valid {
+		ticket.FoundAt = &foundAt.Time
+	}
 
 	return &ticket, nil
 }
@@ -175,6 +213,10 @@ func (l *LibraryService) GetCheckoutsByUserID(bookID, userID string) ([]model.Ch
 			"reservedOn",
 			"checkedOutOn",
 			"returnedDate",
+			"lostAt",
+			"damagedAt",
+			"foundAt",
+			"incidentFee",
 			"createdAt",
 			"updatedAt"
 		FROM 
@@ -196,6 +238,9 @@ func (l *LibraryService) GetCheckoutsByUserID(bookID, userID string) ([]model.Ch
 			reservedOn   sql.NullTime
 			checkedOutOn sql.NullTime
 			returnedDate sql.NullTime
+			lostAt       sql.NullTime
+			damagedAt    sql.NullTime
+			foundAt      sql.NullTime
 		)
 		err := rows.Scan(
 			&ticket.ID,
@@ -208,6 +253,10 @@ func (l *LibraryService) GetCheckoutsByUserID(bookID, userID string) ([]model.Ch
 			&reservedOn,
 			&checkedOutOn,
 			&returnedDate,
+			&lostAt,
+			&damagedAt,
+			&foundAt,
+			&ticket.IncidentFee,
 			&ticket.CreatedAt,
 			&updatedAt,
 		)
@@ -220,6 +269,15 @@ func (l *LibraryService) GetCheckoutsByUserID(bookID, userID string) ([]model.Ch
 		ticket.CheckedOutOn = checkedOutOn.Time
 		ticket.ReturnedDate = returnedDate.Time
 		ticket.ReservedOn = reservedOn.Time
+		if lostAt.Valid {
+			ticket.LostAt = &lostAt.Time
+		}
+		if damagedAt.Valid {
+			ticket.DamagedAt = &damagedAt.Time
+		}
+		if foundAt.Valid {
+			ticket.FoundAt = &foundAt.Time
+		}
 		tickets = append(tickets, ticket)
 	}
 
@@ -284,24 +342,7 @@ func (l *LibraryService) GetAllCheckoutTicketsWithDetails(request *model.GetAllC
 		;
 	`
 
-	orderBy := `%s ASC`
-
-	if request.OrderBy == "descending" {
-		orderBy = `%s DESC`
-	}
-
-	switch request.SortBy {
-	case "reservedOn":
-		orderBy = fmt.Sprintf(orderBy, `ct."reservedOn"`)
-	case "checkedoutOn":
-		orderBy = fmt.Sprintf(orderBy, `ct."checkedOutOn"`)
-	case "returnedOn":
-		orderBy = fmt.Sprintf(orderBy, `ct."returnedDate"`)
-	case "fineAmount":
-		orderBy = fmt.Sprintf(orderBy, `ct."fineAmount"`)
-	default:
-		orderBy = fmt.Sprintf(orderBy, `ct."reservedOn"`)
-	}
+	orderBy := checkoutOrderBy(request.SortBy, request.OrderBy)
 
 	limitOffset := ` LIMIT %d OFFSET %d`
 	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
@@ -425,7 +466,8 @@ func (l *LibraryService) UpdateCheckoutTicket(ticket *model.UpdateCheckoutTicket
 			"reservedOn" = $8,
 			"checkedOutOn" = $9,
 			"returnedDate" = $10,
-			"updatedAt" = $11
+			"updatedAt" = $11,
+			"version" = "version" + 1
 		WHERE
 			"ID" = $1;
 	`
@@ -468,3 +510,27 @@ func (l *LibraryService) DeleteCheckoutTicket(ticketID string) error {
 
 	return nil
 }
+
+// checkoutOrderBy returns the ORDER BY of a checkout ticket listing
+func checkoutOrderBy(sortBy, orderDirection string) string {
+	orderBy := `%s ASC`
+
+	if orderDirection == "descending" {
+		orderBy = `%s DESC`
+	}
+
+	switch sortBy {
+	case "reservedOn":
+		orderBy = fmt.Sprintf(orderBy, `ct."reservedOn"`)
+	case "checkedoutOn":
+		orderBy = fmt.Sprintf(orderBy, `ct."checkedOutOn"`)
+	case "returnedOn":
+		orderBy = fmt.Sprintf(orderBy, `ct."returnedDate"`)
+	case "fineAmount":
+		orderBy = fmt.Sprintf(orderBy, `ct."fineAmount"`)
+	default:
+		orderBy = fmt.Sprintf(orderBy, `ct."reservedOn"`)
+	}
+
+	return orderBy
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrGetUserPasswordFailed is an error when get user password failed
+	ErrGetUserPasswordFailed = errors.New("get user password failed")
+	// ErrGetUserPasswordNotFound is an error when the user of get user password is not found
+	ErrGetUserPasswordNotFound = errors.New("get user password not found")
+	// ErrFailedChangePassword is an error when change password failed
+	ErrFailedChangePassword = errors.New("change password failed")
+	// ErrFailedCreateEmailChange is an error when create email change failed
+	ErrFailedCreateEmailChange = errors.New("create email change failed")
+	// ErrEmailInUse is an error when the new email already belongs to a user
+	ErrEmailInUse = errors.New("email is already in use")
+	// ErrFailedConfirmEmailChange is an error when confirm email change failed
+	ErrFailedConfirmEmailChange = errors.New("confirm email change failed")
+	// ErrEmailChangeTokenInvalid is an error when an email change token is unknown or expired
+	ErrEmailChangeTokenInvalid = errors.New("email change token is invalid or expired")
+)
+
+// GetUserPasswordHash returns the stored password hash of a user
+func (l *LibraryService) GetUserPasswordHash(userID string) (string, error) {
+	var password string
+	err := l.db.QueryRow(`SELECT "password" FROM "users" WHERE "userID" = $1;`, userID).Scan(&password)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrGetUserPasswordNotFound
+		}
+		log.Error().Msgf("[Error] GetUserPasswordHash(), db.QueryRow err: %v", err)
+		return "", ErrGetUserPasswordFailed
+	}
+
+	return password, nil
+}
+
+// ChangePassword replaces the password hash of a user
+func (l *LibraryService) ChangePassword(userID, passwordHash string) error {
+	sqlStatement := `
+		UPDATE "users" SET
+			"password" = $1,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE
+			"userID" = $2;
+	`
+
+	res, err := l.db.Exec(sqlStatement, passwordHash, userID)
+	if err != nil {
+		log.Error().Msgf("[Error] ChangePassword(), db.Exec err: %v", err)
+		return ErrFailedChangePassword
+	}
+
+	if rowsEffected, err := res.RowsAffected(); err != nil || rowsEffected == 0 {
+		log.Error().Msgf("[error] ChangePassword(), [No rows affected]  : %v", err)
+		return ErrGetUserPasswordNotFound
+	}
+
+	return nil
+}
+
+// CreateEmailChangeRequest stores a pending email change replacing an earlier one of the user and
+// returns the verification token, only its hash is stored
+func (l *LibraryService) CreateEmailChangeRequest(userID, email string) (string, error) {
+	var inUse bool
+	err := l.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM "users" WHERE "email" = $1);`, email).Scan(&inUse)
+	if err != nil {
+		log.Error().Msgf("[Error] CreateEmailChangeRequest(), db.QueryRow err: %v", err)
+		return "", ErrFailedCreateEmailChange
+	}
+	if inUse {
+		return "", ErrEmailInUse
+	}
+
+	token, err := newToken()
+	if err != nil {
+		log.Error().Msgf("[Error] CreateEmailChangeRequest(), newToken err: %v", err)
+		return "", ErrFailedCreateEmailChange
+	}
+
+	sqlStatement := `
+		INSERT INTO "email_change_requests"("userID", "newEmail", "tokenHash", "expiresAt")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("userID")
+		DO UPDATE SET
+			"newEmail" = EXCLUDED."newEmail",
+			"tokenHash" = EXCLUDED."tokenHash",
+			"expiresAt" = EXCLUDED."expiresAt",
+			"createdAt" = NOW();
+	`
+
+	expiresAt := time.Now().UTC().Add(model.EmailChangeTTL)
+	if _, err := l.db.Exec(sqlStatement, userID, email, hashToken(token), expiresAt); err != nil {
+		log.Error().Msgf("[Error] CreateEmailChangeRequest(), db.Exec err: %v", err)
+		return "", ErrFailedCreateEmailChange
+	}
+
+	return token, nil
+}
+
+// ConfirmEmailChange moves a user to the email of a pending change with a valid token
+func (l *LibraryService) ConfirmEmailChange(token string) (*model.EmailChange, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] ConfirmEmailChange(), db.Begin err: %v", err)
+		return nil, ErrFailedConfirmEmailChange
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] ConfirmEmailChange(), tx.Rollback err: %v", err)
+		}
+	}
+
+	// the request is consumed whether it is still valid or not
+	change := model.EmailChange{}
+	var expiresAt time.Time
+	err = tx.QueryRow(`
+		DELETE FROM "email_change_requests" WHERE "tokenHash" = $1
+		RETURNING "userID", "newEmail", "expiresAt";
+	`, hashToken(token)).Scan(&change.UserID, &change.NewEmail, &expiresAt)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEmailChangeTokenInvalid
+		}
+		log.Error().Msgf("[Error] ConfirmEmailChange(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedConfirmEmailChange
+	}
+	if time.Now().UTC().After(expiresAt) {
+		if err := tx.Commit(); err != nil {
+			log.Error().Msgf("[Error] ConfirmEmailChange(), tx.Commit err: %v", err)
+		}
+		return nil, ErrEmailChangeTokenInvalid
+	}
+
+	err = tx.QueryRow(`
+		UPDATE "users" SET
+			"email" = $1,
+			"updatedAt" = NOW(),
+			"version" = "users"."version" + 1
+		FROM (
+			SELECT "email" FROM "users" WHERE "userID" = $2 FOR UPDATE
+		) AS "previous"
+		WHERE
+			"users"."userID" = $2
+		RETURNING "previous"."email";
+	`, change.NewEmail, change.UserID).Scan(&change.OldEmail)
+	if err != nil {
+		rollback()
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, ErrEmailInUse
+		}
+		log.Error().Msgf("[Error] ConfirmEmailChange(), tx.QueryRow update err: %v", err)
+		return nil, ErrFailedConfirmEmailChange
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] ConfirmEmailChange(), tx.Commit err: %v", err)
+		return nil, ErrFailedConfirmEmailChange
+	}
+
+	return &change, nil
+}
+
+// newToken returns a random verification token
+func newToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+// hashToken hashes a verification token for storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,10 +3,12 @@ package domain
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"integrated-library-service/model"
+	"math"
+	"strings"
 	"time"
 
-	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
@@ -19,117 +21,358 @@ var (
 
 	// ErrGetHighDemandBooksFailed is when get high demand book fails
 	ErrGetHighDemandBooksFailed = errors.New("get high demand book failed")
+
+	// ErrDashboardRangeInvalid is when the last day of the dashboard is before its first day
+	ErrDashboardRangeInvalid = errors.New("to must not be before from")
+
+	// ErrDashboardTooManyPeriods is when the line graph would have more than DashboardMaxPeriods periods
+	ErrDashboardTooManyPeriods = fmt.Errorf("the line graph can have at most %d periods, choose a coarser granularity", model.DashboardMaxPeriods)
 )
 
-// GetDashboardLineGraphData retrieves Dashboard's Graph related data
-func (l *LibraryService) GetDashboardLineGraphData() ([]model.DashboardLineGraphData, error) {
-	// dashboardData is a collection of past 7 Months, ordered on date in ascending order
-	dashboardData := make([]model.DashboardLineGraphData, 0)
+// circulationActivity returns every reservation, checkout and return of a user between the days of
+// the SQL expressions from and to, a user is active in a period with any of them
+func circulationActivity(from, to string) string {
+	return strings.NewReplacer("$from", from, "$to", to).Replace(`
+	"activity" AS (
+		SELECT "userID", "reservedOn" AS "at" FROM "checkout_tickets"
+		WHERE "reservedOn" >= $from::date AND "reservedOn" < $to::date + 1
+		UNION ALL
+		SELECT "userID", "checkedOutOn" FROM "checkout_tickets"
+		WHERE "isCheckedOut" = true AND "checkedOutOn" >= $from::date AND "checkedOutOn" < $to::date + 1
+		UNION ALL
+		SELECT "userID", "returnedDate" FROM "checkout_tickets"
+		WHERE "isReturned" = true AND "returnedDate" >= $from::date AND "returnedDate" < $to::date + 1
+	)`)
+}
 
-	// Query to generate a series of the past 7 months
-	seriesSQL := `
-		SELECT 
-			DATE_TRUNC('month', NOW() - INTERVAL '6 months' + INTERVAL '1 month' * generate_series(0, 6)) AS month
+// dashboardToday is the current day in UTC
+func dashboardToday() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// dashboardPeriodStart returns the start of the day, week, month or year of t like DATE_TRUNC,
+// weeks start on Monday
+func dashboardPeriodStart(t time.Time, granularity string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch granularity {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// dashboardAddPeriods moves the start of a period n periods on, or back for a negative n
+func dashboardAddPeriods(start time.Time, granularity string, n int) time.Time {
+	switch granularity {
+	case "week":
+		return start.AddDate(0, 0, 7*n)
+	case "month":
+		return start.AddDate(0, n, 0)
+	case "year":
+		return start.AddDate(n, 0, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// dashboardChange returns the change from previous to current in percent, none when previous is zero
+func dashboardChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := math.Round((current-previous)/previous*1000) / 10
+	return &change
+}
+
+// dashboardComparison compares the counts of a period with the ones of the period before it
+func dashboardComparison(current, previous *model.DashboardPeriodCounts) model.DashboardComparison {
+	return model.DashboardComparison{
+		Current:  *current,
+		Previous: *previous,
+		Change: map[string]*float64{
+			"activeUsers":     dashboardChange(float64(current.ActiveUsers), float64(previous.ActiveUsers)),
+			"newUsers":        dashboardChange(float64(current.NewUsers), float64(previous.NewUsers)),
+			"newBooks":        dashboardChange(float64(current.NewBooks), float64(previous.NewBooks)),
+			"checkoutTickets": dashboardChange(float64(current.CheckoutTickets), float64(previous.CheckoutTickets)),
+			"checkouts":       dashboardChange(float64(current.Checkouts), float64(previous.Checkouts)),
+			"returns":         dashboardChange(float64(current.Returns), float64(previous.Returns)),
+			"fineAmount":      dashboardChange(current.FineAmount, previous.FineAmount),
+			"views":           dashboardChange(float64(current.Views), float64(previous.Views)),
+		},
+	}
+}
+
+// getDashboardPeriodCounts counts the activity of the library in the days from and to from the
+// daily rollups
+func (l *LibraryService) getDashboardPeriodCounts(from, to time.Time) (*model.DashboardPeriodCounts, error) {
+	sqlStatement := `
+		SELECT
+			(
+				SELECT COUNT(DISTINCT "userID") FROM "daily_active_users"
+				WHERE "day" >= $1::date AND "day" <= $2::date
+			) AS "activeUsers",
+			COALESCE(SUM("newUsers"), 0)::bigint AS "newUsers",
+			COALESCE(SUM("newBooks"), 0)::bigint AS "newBooks",
+			COALESCE(SUM("checkoutTickets"), 0)::bigint AS "checkoutTickets",
+			COALESCE(SUM("checkouts"), 0)::bigint AS "checkouts",
+			COALESCE(SUM("returns"), 0)::bigint AS "returns",
+			COALESCE(SUM("fineAmount"), 0)::float AS "fineAmount",
+			COALESCE(SUM("views"), 0)::bigint AS "views"
+		FROM
+			"daily_rollups"
+		WHERE
+			"day" >= $1::date AND "day" <= $2::date;
 	`
 
-	// Query to retrieve dashboardData for the past 7 months
+	counts := model.DashboardPeriodCounts{From: from, To: to}
+	err := l.db.QueryRow(sqlStatement, from.Format("2006-01-02"), to.Format("2006-01-02")).Scan(
+		&counts.ActiveUsers,
+		&counts.NewUsers,
+		&counts.NewBooks,
+		&counts.CheckoutTickets,
+		&counts.Checkouts,
+		&counts.Returns,
+		&counts.FineAmount,
+		&counts.Views,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+// getDashboardGraphPeriods returns the activity of every period of the granularity from the period
+// of from to the period of to from the daily rollups, only the days from and to are counted
+func (l *LibraryService) getDashboardGraphPeriods(granularity string, from, to time.Time) ([]model.DashboardLineGraphData, error) {
 	sqlStatement := `
-		SELECT 
-			series.month,
-			COALESCE(COUNT(DISTINCT u."userID"), 0) AS noOfActiveUsers,
-			COALESCE(COUNT(c."ID"), 0) AS noOfCheckouts
-		FROM 
-			(SELECT 
-				DATE_TRUNC('month', NOW() - INTERVAL '6 months' + INTERVAL '1 month' * generate_series(0, 6)) AS month
-			) AS series
-		LEFT JOIN 
-			"users" AS u ON DATE_TRUNC('month', u."createdAt") = series.month
-		LEFT JOIN 
-			"checkout_tickets" AS c ON DATE_TRUNC('month', c."createdAt") = series.month AND u."userID" = c."userID"
-		GROUP BY 
-			1
-		ORDER BY 
-			1;
+		WITH "series" AS (
+			SELECT
+				"period",
+				GREATEST("period", $2::date)::date AS "start",
+				LEAST("period" + ('1 ' || $1)::interval, $3::date + 1)::date AS "end"
+			FROM
+				generate_series(
+					DATE_TRUNC($1, $2::timestamp),
+					DATE_TRUNC($1, $3::timestamp),
+					('1 ' || $1)::interval
+				) AS "series"("period")
+		)
+		SELECT
+			s."period",
+			(
+				SELECT COUNT(DISTINCT a."userID") FROM "daily_active_users" a
+				WHERE a."day" >= s."start" AND a."day" < s."end"
+			),
+			COALESCE(SUM(r."checkouts"), 0)::bigint,
+			COALESCE(SUM(r."returns"), 0)::bigint,
+			COALESCE(SUM(r."newUsers"), 0)::bigint,
+			COALESCE(SUM(r."views"), 0)::bigint
+		FROM
+			"series" s
+		LEFT JOIN "daily_rollups" r ON r."day" >= s."start" AND r."day" < s."end"
+		GROUP BY
+			s."period", s."start", s."end"
+		ORDER BY
+			s."period" ASC;
 	`
 
-	// Execute the series query to get all months
-	rows, err := l.db.Query(seriesSQL)
+	rows, err := l.db.Query(sqlStatement, granularity, from.Format("2006-01-02"), to.Format("2006-01-02"))
 	if err != nil {
-		log.Error().Msgf("[Error] GetDashboardLineGraphData(), series query err: %v", err)
-		return nil, ErrGetDashboardLineGraphDataFailed
+		return nil, err
 	}
 	defer rows.Close()
 
-	// Iterate over the series of months
+	periods := []model.DashboardLineGraphData{}
 	for rows.Next() {
-		var month time.Time
-		err := rows.Scan(&month)
+		var period model.DashboardLineGraphData
+		err := rows.Scan(
+			&period.Period,
+			&period.NoOfActiveusers,
+			&period.NoOfCheckouts,
+			&period.NoOfReturns,
+			&period.NoOfNewUsers,
+			&period.NoOfViews,
+		)
 		if err != nil {
-			log.Error().Msgf("[Error] GetDashboardLineGraphData(), series rows.Scan err: %v", err)
-			return nil, ErrGetDashboardLineGraphDataFailed
+			return nil, err
 		}
+		period.Month = period.Period
+		periods = append(periods, period)
+	}
+	return periods, rows.Err()
+}
 
-		// Append the month with no data to the dashboardData
-		dashboardData = append(dashboardData, model.DashboardLineGraphData{
-			Month:           month,
-			NoOfActiveusers: 0,
-			NoOfCheckouts:   0,
-		})
+// getDashboardFreshness returns the oldest refresh of the daily rollups of the days from and to, and
+// the number of those days up to today which were never rolled up
+func (l *LibraryService) getDashboardFreshness(from, to time.Time) (*model.DashboardFreshness, error) {
+	sqlStatement := `
+		SELECT
+			MIN(r."refreshedAt"),
+			COUNT(*) FILTER (WHERE r."day" IS NULL)
+		FROM
+			generate_series($1::date, LEAST($2::date, CURRENT_DATE), '1 day') AS "series"("day")
+		LEFT JOIN "daily_rollups" r ON r."day" = "series"."day"::date;
+	`
+
+	var freshness model.DashboardFreshness
+	var refreshedAt sql.NullTime
+	err := l.db.QueryRow(sqlStatement, from.Format("2006-01-02"), to.Format("2006-01-02")).Scan(
+		&refreshedAt,
+		&freshness.MissingDays,
+	)
+	if err != nil {
+		return nil, err
 	}
+	if refreshedAt.Valid {
+		freshness.RefreshedAt = &refreshedAt.Time
+	}
+	return &freshness, nil
+}
+
+// getDashboardMostViewedBooks returns the books with the most views in the days from and to from the
+// daily rollups, a patron viewing a book on several days is a unique viewer of every day
+func (l *LibraryService) getDashboardMostViewedBooks(from, to time.Time) ([]model.DashboardBookViews, error) {
+	sqlStatement := `
+		SELECT
+			b."ID",
+			b."ISBN",
+			b."title",
+			SUM(v."views")::bigint AS "views",
+			SUM(v."uniqueViewers")::bigint AS "uniqueViewers"
+		FROM
+			"daily_book_views" v
+		JOIN "books" b ON b."ID" = v."bookID"
+		WHERE
+			v."day" >= $1::date AND v."day" <= $2::date
+		GROUP BY
+			b."ID"
+		ORDER BY
+			"views" DESC, "uniqueViewers" DESC, b."ID"
+		LIMIT $3;
+	`
 
-	// Execute the main query to retrieve data for each month
-	rows, err = l.db.Query(sqlStatement)
+	rows, err := l.db.Query(sqlStatement, from.Format("2006-01-02"), to.Format("2006-01-02"), model.DashboardMostViewedBooks)
 	if err != nil {
-		log.Error().Msgf("[Error] GetDashboardLineGraphData(), db.Query err: %v", err)
-		return nil, ErrGetDashboardLineGraphDataFailed
+		return nil, err
 	}
 	defer rows.Close()
 
+	books := []model.DashboardBookViews{}
 	for rows.Next() {
-		var dashboardLineGraphData model.DashboardLineGraphData
-		err := rows.Scan(
-			&dashboardLineGraphData.Month,
-			&dashboardLineGraphData.NoOfActiveusers,
-			&dashboardLineGraphData.NoOfCheckouts,
-		)
-		if err != nil {
-			log.Error().Msgf("[Error] GetDashboardLineGraphData(), rows.Scan err: %v", err)
-			return nil, ErrGetDashboardLineGraphDataFailed
+		var book model.DashboardBookViews
+		if err := rows.Scan(&book.BookID, &book.ISBN, &book.Title, &book.Views, &book.UniqueViewers); err != nil {
+			return nil, err
 		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
 
-		// Update the corresponding month's data
-		for i := range dashboardData {
-			if dashboardData[i].Month.Equal(dashboardLineGraphData.Month) {
-				dashboardData[i].NoOfActiveusers = dashboardLineGraphData.NoOfActiveusers
-				dashboardData[i].NoOfCheckouts = dashboardLineGraphData.NoOfCheckouts
-				break
-			}
+// GetDashboardLineGraphData retrieves Dashboard's Graph related data per day, week, month or year
+// of the requested days, the last seven months without dates. The previous graph has as many
+// periods right before the first one, the comparison is with as many days right before the
+// requested ones. The counts are read from the daily rollups.
+func (l *LibraryService) GetDashboardLineGraphData(request *model.DashboardRequest) (*model.DashboardLineGraph, error) {
+	granularity := request.Granularity
+	if len(granularity) == 0 {
+		granularity = "month"
+	}
+
+	to := dashboardToday()
+	if request.To != nil {
+		to = dashboardPeriodStart(*request.To, "day")
+	}
+	from := dashboardAddPeriods(dashboardPeriodStart(to, granularity), granularity, 1-model.DashboardGraphPeriods)
+	if request.From != nil {
+		from = dashboardPeriodStart(*request.From, "day")
+	}
+	if to.Before(from) {
+		return nil, ErrDashboardRangeInvalid
+	}
+
+	first, last := dashboardPeriodStart(from, granularity), dashboardPeriodStart(to, granularity)
+	periods := 0
+	for start := first; !start.After(last); start = dashboardAddPeriods(start, granularity, 1) {
+		if periods++; periods > model.DashboardMaxPeriods {
+			return nil, ErrDashboardTooManyPeriods
 		}
 	}
+	previousFirst := dashboardAddPeriods(first, granularity, -periods)
+	previousLast := first.AddDate(0, 0, -1)
+
+	// the comparison is of as many days, partial first and last periods would skew whole ones
+	days := int(to.Sub(from).Hours()/24) + 1
+	previousFrom, previousTo := from.AddDate(0, 0, -days), from.AddDate(0, 0, -1)
 
-	return dashboardData, nil
+	graph := model.DashboardLineGraph{Granularity: granularity}
+	var err error
+	graph.GraphData, err = l.getDashboardGraphPeriods(granularity, from, to)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardLineGraphData(), getDashboardGraphPeriods err: %v", err)
+		return nil, ErrGetDashboardLineGraphDataFailed
+	}
+	graph.PreviousGraphData, err = l.getDashboardGraphPeriods(granularity, previousFirst, previousLast)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardLineGraphData(), getDashboardGraphPeriods previous err: %v", err)
+		return nil, ErrGetDashboardLineGraphDataFailed
+	}
+
+	current, err := l.getDashboardPeriodCounts(from, to)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardLineGraphData(), getDashboardPeriodCounts err: %v", err)
+		return nil, ErrGetDashboardLineGraphDataFailed
+	}
+	previous, err := l.getDashboardPeriodCounts(previousFrom, previousTo)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardLineGraphData(), getDashboardPeriodCounts previous err: %v", err)
+		return nil, ErrGetDashboardLineGraphDataFailed
+	}
+	graph.Comparison = dashboardComparison(current, previous)
+
+	if previousFrom.Before(previousFirst) {
+		previousFirst = previousFrom
+	}
+	freshness, err := l.getDashboardFreshness(previousFirst, to)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardLineGraphData(), getDashboardFreshness err: %v", err)
+		return nil, ErrGetDashboardLineGraphDataFailed
+	}
+	graph.Freshness = *freshness
+
+	return &graph, nil
 }
 
-// GetDashboardDataBoard retrieves for Dashboard's data board
-func (l *LibraryService) GetDashboardDataBoard() (*model.DashboardDataBoard, error) {
+// GetDashboardDataBoard retrieves for Dashboard's data board, the period counts are of the
+// requested days and compared with as many days before them, the current month without dates. The
+// period counts are read from the daily rollups.
+func (l *LibraryService) GetDashboardDataBoard(request *model.DashboardRequest) (*model.DashboardDataBoard, error) {
 	var dashboardData model.DashboardDataBoard
 
-	// Query to get total counts from the entire database
+	to := dashboardToday()
+	if request.To != nil {
+		to = dashboardPeriodStart(*request.To, "day")
+	}
+	from := dashboardPeriodStart(to, "month")
+	if request.From != nil {
+		from = dashboardPeriodStart(*request.From, "day")
+	}
+	if to.Before(from) {
+		return nil, ErrDashboardRangeInvalid
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	previousFrom, previousTo := from.AddDate(0, 0, -days), from.AddDate(0, 0, -1)
+
+	// Query to get total counts from the entire database, deleted rows would make sums of the
+	// daily rollups drift so the totals are counted live
 	totalCountsSQL := `
 		SELECT
 			(SELECT COUNT(*) FROM users) AS usersCount,
 			(SELECT COUNT(*) FROM books) AS booksCount,
 			(SELECT COUNT(*) FROM checkout_tickets) AS checkoutsCount,
-			(SELECT SUM("fineAmount") FROM checkout_tickets) AS revenueAmount
-	`
-
-	// Query to get monthly counts for the current month
-	monthlyCountsSQL := `
-		SELECT
-			(SELECT COUNT(*) FROM books WHERE DATE_TRUNC('month', "createdAt") = DATE_TRUNC('month', CURRENT_DATE)) AS monthlyNewBooksAddedCount,
-			(SELECT COUNT(*) FROM users WHERE DATE_TRUNC('month', "createdAt") = DATE_TRUNC('month', CURRENT_DATE)) AS monthlyNewRegisteredUserCount,
-			(SELECT COUNT(*) FROM checkout_tickets WHERE DATE_TRUNC('month', "createdAt") = DATE_TRUNC('month', CURRENT_DATE)) AS monthlyNewCheckoutTicketsCount,
-			(SELECT SUM("fineAmount") FROM checkout_tickets WHERE DATE_TRUNC('month', "createdAt") = DATE_TRUNC('month', CURRENT_DATE)) AS monthlyFineAmountTotal
+			(SELECT COALESCE(ROUND(SUM("fineAmount")), 0)::bigint FROM checkout_tickets) AS revenueAmount
 	`
 
 	// Retrieve total counts
@@ -144,18 +387,36 @@ func (l *LibraryService) GetDashboardDataBoard() (*model.DashboardDataBoard, err
 		return &model.DashboardDataBoard{}, ErrGetDashboardDataBoardFailed
 	}
 
-	// Retrieve monthly counts for the current month
-	err = l.db.QueryRow(monthlyCountsSQL).Scan(
-		&dashboardData.MonthlyNewBooksAddedCount,
-		&dashboardData.MonthlyNewRegisteredUserCount,
-		&dashboardData.MonthlyNewCheckoutTicketsCount,
-		&dashboardData.MonthlyFineAmountTotal,
-	)
+	// Retrieve the counts of the period and of the one before it
+	current, err := l.getDashboardPeriodCounts(from, to)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardDataBoard(), retrieving period counts: %v", err)
+		return &model.DashboardDataBoard{}, ErrGetDashboardDataBoardFailed
+	}
+	previous, err := l.getDashboardPeriodCounts(previousFrom, previousTo)
 	if err != nil {
-		log.Error().Msgf("[Error] GetDashboardDataBoard(), retrieving monthly counts: %v", err)
+		log.Error().Msgf("[Error] GetDashboardDataBoard(), retrieving previous period counts: %v", err)
 		return &model.DashboardDataBoard{}, ErrGetDashboardDataBoardFailed
 	}
 
+	dashboardData.MonthlyNewBooksAddedCount = int(current.NewBooks)
+	dashboardData.MonthlyNewRegisteredUserCount = int(current.NewUsers)
+	dashboardData.MonthlyNewCheckoutTicketsCount = int(current.CheckoutTickets)
+	dashboardData.MonthlyFineAmountTotal = int(math.Round(current.FineAmount))
+	dashboardData.Comparison = dashboardComparison(current, previous)
+
+	dashboardData.MostViewedBooks, err = l.getDashboardMostViewedBooks(from, to)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardDataBoard(), retrieving most viewed books: %v", err)
+		return &model.DashboardDataBoard{}, ErrGetDashboardDataBoardFailed
+	}
+	freshness, err := l.getDashboardFreshness(previousFrom, to)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDashboardDataBoard(), retrieving rollup freshness: %v", err)
+		return &model.DashboardDataBoard{}, ErrGetDashboardDataBoardFailed
+	}
+	dashboardData.Freshness = *freshness
+
 	return &dashboardData, nil
 }
 
@@ -166,32 +427,13 @@ func (l *LibraryService) GetHighDemandBooks() (*model.HighDemandBooks, error) {
 	// Query to retrieve high demand books
 	sqlStatement := `
         SELECT 
-            "ID",
-            "ISBN",
-            "title",
-            "author",
-            "genre",
-            "publishedDate",
-            "desc",
-            "previewLink",
-            "coverImage",
-            "shelfNumber",
-            "inLibrary",
-            "views",
-            "booksLeft",
-            "wishlistCount",
-            "rating",
-            "reviewCount",
-            "approximateDemand",
-            "createdAt",
-            "updatedAt",
-            "reviewsList",
-            "viewsList",
-            "wishList"
+            ` + bookColumns + `
         FROM 
             "books"
+        WHERE
+            "withdrawnAt" IS NULL
         ORDER BY 
-            "wishlistCount" DESC
+            "approximateDemand" DESC, "wishlistCount" DESC
         LIMIT 3;
     `
 
@@ -203,44 +445,12 @@ func (l *LibraryService) GetHighDemandBooks() (*model.HighDemandBooks, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var book model.Book
-		var updatedAt sql.NullTime
-		var reviewList, viewList, wishList pq.StringArray
-
-		err := rows.Scan(
-			&book.ID,
-			&book.ISBN,
-			&book.Title,
-			&book.Author,
-			&book.Genre,
-			&book.PublishedDate,
-			&book.Description,
-			&book.PreviewLink,
-			&book.CoverImage,
-			&book.ShelfNumber,
-			&book.InLibrary,
-			&book.Views,
-			&book.BooksLeft,
-			&book.WishlistCount,
-			&book.Rating,
-			&book.ReviewCount,
-			&book.ApproximateDemand,
-			&book.CreatedAt,
-			&updatedAt,
-			&reviewList,
-			&viewList,
-			&wishList,
-		)
+		book, err := scanBook(rows)
 		if err != nil {
 			log.Error().Msgf("[Error] GetHighDemandBooks(), rows.Scan err: %v", err)
 			return nil, ErrGetHighDemandBooksFailed
 		}
-		book.UpdatedAt = &updatedAt.Time
-		book.ReviewsList = reviewList
-		book.ViewsList = viewList
-		book.WishList = wishList
-
-		highDemandBooks = append(highDemandBooks, book)
+		highDemandBooks = append(highDemandBooks, *book)
 	}
 
 	return &highDemandBooks, nil
@@ -1,12 +1,10 @@
 package domain
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"integrated-library-service/model"
 
-	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
@@ -15,39 +13,18 @@ var (
 	ErrGetBooksByApproximateDemandFailed = errors.New("get approximate demand books failed")
 )
 
-// GetBooksByApproximateDemand
+// GetBooksByApproximateDemand returns the books in the library ordered by the approximate demand last
+// stored by the demand job, the most demanded first
 func (l *LibraryService) GetBooksByApproximateDemand(request *model.GetBooksByApproximateDemandRequest) ([]model.Book, uint, error) {
 	sqlStatement := `
         SELECT 
-			"ID",
-			"ISBN",
-			"title",
-			"author",
-			"genre",
-			"publishedDate",
-			"desc",
-			"previewLink",
-			"coverImage",
-			"shelfNumber",
-			"inLibrary",
-			"views",
-			"booksLeft",
-			"wishlistCount",
-			"rating",
-			"reviewCount",
-			"approximateDemand",
-			"createdAt",
-			"updatedAt",
-			"reviewsList",
-			"viewsList",
-			"wishList"
+			` + bookColumns + `
         FROM 
             "books"
         WHERE
-            "inLibrary" = true AND
-            "rating" != 0 AND "views" != 0 AND "wishlistCount" != 0 AND  "reviewCount" != 0
+            "inLibrary" = true AND "withdrawnAt" IS NULL
         ORDER BY 
-            ("rating" * $1) + ("views" * $2) + ("wishlistCount" * $3) + ("reviewCount" * $4) DESC -- orders by approximate demand points
+            "approximateDemand" DESC, "ID" ASC
         %s; -- limit and offset
     `
 
@@ -56,71 +33,31 @@ func (l *LibraryService) GetBooksByApproximateDemand(request *model.GetBooksByAp
 	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
 	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
 
-	rows, err := l.db.Query(sqlStatement, model.RatingWeight, model.ViewWeight, model.WishlistWeight, model.ReviewWeight)
+	rows, err := l.db.Query(sqlStatement)
 	if err != nil {
-		log.Error().Msgf("[Error] UpdateApproximateDemand(), db.Query err: %v", err)
-		return nil, 0, nil
+		log.Error().Msgf("[Error] GetBooksByApproximateDemand(), db.Query err: %v", err)
+		return nil, 0, ErrGetBooksByApproximateDemandFailed
 	}
 	defer rows.Close()
 
-	var books []model.Book
+	books := []model.Book{}
 	for rows.Next() {
-		var (
-			book       model.Book
-			updatedAt  sql.NullTime
-			reviewList pq.StringArray
-			viewList   pq.StringArray
-			wishList   pq.StringArray
-		)
-		err := rows.Scan(
-			&book.ID,
-			&book.ISBN,
-			&book.Title,
-			&book.Author,
-			&book.Genre,
-			&book.PublishedDate,
-			&book.Description,
-			&book.PreviewLink,
-			&book.CoverImage,
-			&book.ShelfNumber,
-			&book.InLibrary,
-			&book.Views,
-			&book.BooksLeft,
-			&book.WishlistCount,
-			&book.Rating,
-			&book.ReviewCount,
-			&book.ApproximateDemand,
-			&book.CreatedAt,
-			&updatedAt,
-			&reviewList,
-			&viewList,
-			&wishList,
-		)
+		book, err := scanBook(rows)
 		if err != nil {
-			log.Error().Msgf("[Error] UpdateApproximateDemand(), rows.Scan err: %v", err)
-			return nil, 0, nil
+			log.Error().Msgf("[Error] GetBooksByApproximateDemand(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetBooksByApproximateDemandFailed
 		}
-		book.UpdatedAt = &updatedAt.Time
-		book.ReviewsList = reviewList
-		book.ViewsList = viewList
-		book.WishList = wishList
-
 		// get ratings from helper
 		ratings, err := l.getAverageRating(book.ID)
-		if err != nil && errors.Is(err, ErrRatingNotFound) {
-			log.Error().Msgf("[Error] UpdateApproximateDemand(), getAverageRating err: %v", err)
-			return nil, 0, nil
+		if err != nil && !errors.Is(err, ErrRatingNotFound) {
+			log.Error().Msgf("[Error] GetBooksByApproximateDemand(), getAverageRating err: %v", err)
+			return nil, 0, ErrGetBooksByApproximateDemandFailed
+		}
+		if ratings != nil && ratings.Rating != nil {
+			book.Rating = *ratings.Rating
 		}
-		book.Rating = *ratings.Rating
-
-		// Calculate demand score
-		demandScore := l.calculateDemandScore(book)
-
-		// Use demand score to estimate approximate demand
-		approximateDemand := demandScore / model.RatingWeight // RatingWeight has the highest weight
-		book.ApproximateDemand = approximateDemand
 
-		books = append(books, book)
+		books = append(books, *book)
 	}
 
 	// total pages
@@ -130,20 +67,13 @@ func (l *LibraryService) GetBooksByApproximateDemand(request *model.GetBooksByAp
 	FROM 
 		"books"
 	WHERE
-		"inLibrary" = true AND
-		"rating" != 0 AND "views" != 0 AND "wishlistCount" != 0 AND  "reviewCount" != 0
-	%s; --LIMIT AND OFFSET 	
+		"inLibrary" = true AND "withdrawnAt" IS NULL;
 `
 
 	var totalRows uint
-	err = l.db.QueryRow(fmt.Sprintf(sqlStatementCount, limitOffset)).Scan(&totalRows)
-	// no rows
-	if errors.Is(err, sql.ErrNoRows) {
-		return []model.Book{}, 0, nil
-	}
-	if err != nil {
-		log.Error().Msgf("[Error] UpdateApproximateDemand(), count query err: %v", err)
-		return nil, 0, err
+	if err := l.db.QueryRow(sqlStatementCount).Scan(&totalRows); err != nil {
+		log.Error().Msgf("[Error] GetBooksByApproximateDemand(), count query err: %v", err)
+		return nil, 0, ErrGetBooksByApproximateDemandFailed
 	}
 	// Calculate total pages
 	totalPages := (uint32(totalRows) + request.Limit - 1) / request.Limit
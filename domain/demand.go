@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrRefreshDemandScoresFailed is an error when recomputing the approximate demand of the books failed
+	ErrRefreshDemandScoresFailed = errors.New("refresh demand scores failed")
+)
+
+// RefreshDemandScores recomputes and stores the approximate demand of every book and returns the number of
+// books updated. Checkouts and views within the window, holds waiting to be checked out and wishlist entries
+// lose half their weight every half life.
+func (l *LibraryService) RefreshDemandScores(weights model.DemandWeights) (int64, error) {
+	sqlStatement := `
+		WITH "checkouts" AS (
+			SELECT
+				"bookID",
+				SUM(POWER(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - "checkedOutOn")::float, 0) / $5::float)) AS "decayed"
+			FROM
+				"checkout_tickets"
+			WHERE
+				"checkedOutOn" IS NOT NULL AND "checkedOutOn" > NOW() - make_interval(secs => $6::float)
+			GROUP BY
+				"bookID"
+		),
+		"holds" AS (
+			SELECT
+				"bookID",
+				SUM(POWER(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - "reservedOn")::float, 0) / $5::float)) AS "decayed"
+			FROM
+				"checkout_tickets"
+			WHERE
+				"isCheckedOut" = false AND "isReturned" = false
+			GROUP BY
+				"bookID"
+		),
+		"wishlists" AS (
+			SELECT
+				"bookID",
+				SUM(POWER(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - "addedAt")::float, 0) / $5::float)) AS "decayed"
+			FROM
+				"wishlist_adds"
+			GROUP BY
+				"bookID"
+		),
+		"views" AS (
+			SELECT
+				"bookID",
+				SUM(POWER(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - "viewedAt")::float, 0) / $5::float)) AS "decayed"
+			FROM
+				"book_views"
+			WHERE
+				"viewedAt" > NOW() - make_interval(secs => $6::float)
+			GROUP BY
+				"bookID"
+		),
+		"scores" AS (
+			SELECT
+				b."ID",
+				COALESCE(c."decayed", 0) * $1::float +
+				COALESCE(h."decayed", 0) * $2::float +
+				COALESCE(w."decayed", 0) * $3::float +
+				COALESCE(v."decayed", 0) * $4::float AS "score"
+			FROM
+				"books" b
+			LEFT JOIN "checkouts" c ON c."bookID" = b."ID"
+			LEFT JOIN "holds" h ON h."bookID" = b."ID"
+			LEFT JOIN "wishlists" w ON w."bookID" = b."ID"
+			LEFT JOIN "views" v ON v."bookID" = b."ID"
+		)
+		UPDATE "books" SET
+			"approximateDemand" = ROUND("scores"."score"::numeric),
+			"demandComputedAt" = NOW()
+		FROM
+			"scores"
+		WHERE
+			"books"."ID" = "scores"."ID";
+	`
+
+	res, err := l.db.Exec(
+		sqlStatement,
+		weights.Checkout,
+		weights.Hold,
+		weights.Wishlist,
+		weights.View,
+		weights.HalfLife.Seconds(),
+		weights.Window.Seconds(),
+	)
+	if err != nil {
+		log.Error().Msgf("[Error] RefreshDemandScores(), db.Exec err: %v", err)
+		return 0, ErrRefreshDemandScoresFailed
+	}
+
+	updated, _ := res.RowsAffected()
+	return updated, nil
+}
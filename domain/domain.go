@@ -2,6 +2,7 @@ package domain
 
 import (
 	"database/sql"
+	"time"
 
 	"integrated-library-service/model"
 )
@@ -10,14 +11,22 @@ import (
 type Service interface {
 	DBStatus() (bool, error)
 	// user related
-	CreateUser(user *model.RegisterUserRequest) error
+	CreateUser(user *model.RegisterUserRequest, role model.RoleType) error
 	GetUserByEmail(email string) (*model.User, error)
 	GetUserWithBookDetails(userID string) (*model.User, error)
 	GetAllUsers(request *model.GetAllUsersRequest) ([]model.User, uint32, error)
 	GetAllUsersForSearch(request *model.SearchRequest) ([]model.User, uint, error)
-	UpdateUser(user *model.User, userID string) error
+	UpdateUserProfile(profile *model.UpdateProfileRequest, userID string) error
+	UpdateUser(user *model.AdminUpdateUserRequest, userID string) error
 	UpdateBookDetails(bookDetails *model.BookDetails, userID string) error
 	DeleteUser(userID string) error
+	GetUserRole(userID string) (model.RoleType, error)
+	GetUserDataExport(userID string) (*model.UserDataExport, error)
+	// credentials related
+	GetUserPasswordHash(userID string) (string, error)
+	ChangePassword(userID, passwordHash string) error
+	CreateEmailChangeRequest(userID, email string) (string, error)
+	ConfirmEmailChange(token string) (*model.EmailChange, error)
 	// book related
 	CreateBook(book *model.CreateBookRequest) error
 	GetBookByISBN(ISBN string) (*model.Book, error)
@@ -25,8 +34,25 @@ type Service interface {
 	GetAllBooksForSearch(request *model.SearchRequest) ([]model.Book, uint, error)
 	GetAllBooksByBookDetailsFrom(request *model.GetAllBooksByBookDetailsFromRequest) ([]model.Book, error)
 	GetAllBooksFromSpecific(request []string) ([]model.Book, error)
-	CreateBooksBatch(books []*model.CreateBookRequest) error
+	CreateBooksBatch(books []*model.CreateBookRequest) (*model.CreateBooksBatchResponse, error)
 	UpdateBook(book *model.UpdateBookRequest) error
+	RefreshBookSimilarities(perBook int) (int64, error)
+	GetSimilarBooks(bookID string, limit uint32) ([]model.SimilarBook, error)
+	// book withdrawal related
+	WithdrawBook(ISBN, librarianID string, request *model.WithdrawBookRequest) error
+	RestoreBook(ISBN, librarianID string, request *model.RestoreBookRequest) error
+	PurgeBook(ISBN, librarianID string, request *model.PurgeBookRequest) error
+	GetWithdrawnBooks(request *model.GetWithdrawnBooksRequest) ([]model.Book, uint, error)
+	GetBookWithdrawals(ISBN string) ([]model.BookWithdrawal, error)
+	// book import related
+	CreateBookImport(bookImport *model.BookImport) (string, error)
+	StartBookImport(importID string) error
+	ImportBook(book *model.CreateBookRequest, dryRun bool) (model.BookImportOutcome, error)
+	RecordBookImportRows(importID string, rows []model.BookImportRow) error
+	FinishBookImport(importID string, status model.BookImportStatus, errMessage string) error
+	GetBookImports(request *model.GetBookImportsRequest) ([]model.BookImport, uint, error)
+	GetBookImport(importID string) (*model.BookImport, error)
+	GetBookImportRows(importID string, request *model.GetBookImportRowsRequest) ([]model.BookImportRow, uint, error)
 	// checkout related
 	CreateCheckoutTicket(ticket *model.CreateCheckoutRequest) error
 	GetCheckoutTicketByID(ticketID string) (*model.CheckoutTicket, error)
@@ -41,11 +67,87 @@ type Service interface {
 	DeleteReview(reviewID string) error
 	GetReviewsByBookID(bookID string, sortPagination *model.ReviewSort) ([]model.Review, uint, error)
 	// dashboard  related
-	GetDashboardLineGraphData() ([]model.DashboardLineGraphData, error)
-	GetDashboardDataBoard() (*model.DashboardDataBoard, error)
+	GetDashboardLineGraphData(request *model.DashboardRequest) (*model.DashboardLineGraph, error)
+	GetDashboardDataBoard(request *model.DashboardRequest) (*model.DashboardDataBoard, error)
 	GetHighDemandBooks() (*model.HighDemandBooks, error)
+	// analytics rollup related
+	RefreshRollups(from, to time.Time) (int64, error)
+	RefreshRecentRollups(days int) (int64, error)
 	// dataanalysis related
 	GetBooksByApproximateDemand(request *model.GetBooksByApproximateDemandRequest) ([]model.Book, uint, error)
+	RefreshDemandScores(weights model.DemandWeights) (int64, error)
+	GetRecommendedBooksForUser(userID string, request *model.GetRecommendedBooksForUserRequest) ([]model.RecommendedBook, uint, error)
+	GetAcquisitionSuggestions(request *model.GetAcquisitionSuggestionsRequest) ([]model.AcquisitionSuggestion, uint, error)
+	// book view related
+	RecordBookView(bookID, userID string) (*model.RecordBookViewResponse, error)
+	GetBookViewsOverTime(request *model.GetBookViewsOverTimeRequest) ([]model.BookViewsPeriod, error)
+	GetTopViewedBooks(request *model.GetTopViewedBooksRequest) ([]model.TopViewedBook, error)
+	// wishlist related
+	AddToWishlist(userID, ISBN string) error
+	RemoveFromWishlist(userID, ISBN string) error
+	GetWishlist(userID string, request *model.GetWishlistRequest) ([]model.WishlistBook, uint, error)
+	// purchase request related
+	CreatePurchaseRequest(userID string, request *model.CreatePurchaseRequestRequest) (*model.PurchaseRequest, error)
+	GetPurchaseRequests(request *model.GetPurchaseRequestsRequest) ([]model.PurchaseRequest, uint, error)
+	GetPurchaseRequestByID(requestID string) (*model.PurchaseRequest, error)
+	UpdatePurchaseRequestStatus(requestID, librarianID string, request *model.UpdatePurchaseRequestStatusRequest) (*model.PurchaseRequest, error)
+	// notification related
+	GetNotifications(userID string, request *model.GetNotificationsRequest) ([]model.Notification, uint, error)
+	MarkNotificationRead(userID, notificationID string) error
+	// catalogue sync related
+	SyncBook(book *model.CreateBookRequest, staleBefore time.Time) (model.SyncOutcome, error)
+	GetStaleBookISBNs(staleBefore time.Time, limit int) ([]string, error)
+	MarkBookMetadataSynced(ISBN string) error
+	CreateCatalogueSyncRun(trigger string) (string, error)
+	FinishCatalogueSyncRun(run *model.CatalogueSyncRun) error
+	GetCatalogueSyncRuns(request *model.GetCatalogueSyncRunsRequest) ([]model.CatalogueSyncRun, uint, error)
+	// partial update related
+	PatchBook(bookID string, version int64, patch *model.BookPatch) (int64, error)
+	PatchUser(userID string, version int64, patch *model.UserPatch) (int64, error)
+	PatchCheckoutTicket(ticketID string, version int64, patch *model.CheckoutTicketPatch) (int64, error)
+	// patron import related
+	ImportPatron(patron *model.ImportPatron, updateExisting, dryRun bool) (*model.PatronImportRow, *model.UserInvite, error)
+	AcceptUserInvite(token, passwordHash string) error
+	IssueLibraryCard(userID string) (string, error)
+	GetUserIDByCardNumber(cardNumber string) (string, error)
+	// export related
+	ExportBooks(request *model.SearchRequest, write func(*model.BookExport) error) error
+	ExportUsers(request *model.SearchRequest, write func(*model.UserExport) error) error
+	ExportCheckouts(request *model.ExportCheckoutsRequest, write func(*model.CheckoutExport) error) error
+	// book copy and scan related
+	RegisterBookCopies(ISBN string, copies int64) ([]model.BookCopy, error)
+	GetBookCopies(ISBN string, copies int64) ([]model.BookCopy, error)
+	GetBookCopy(ISBN string, copyNumber int64) (*model.BookCopy, error)
+	GetBookCopyByBarcode(barcode string) (*model.BookCopy, error)
+	ScanCode(code string) (*model.ScanResult, error)
+	// kiosk related
+	CreateKioskDevice(librarianID, name string) (*model.KioskDeviceCredentials, error)
+	GetKioskDevices() ([]model.KioskDevice, error)
+	RevokeKioskDevice(librarianID, deviceID string) error
+	AuthenticateKioskDevice(token string) (string, error)
+	SetUserPin(userID, pinHash string) error
+	ClaimKioskPinAttempt(userID string) (*model.UserPin, error)
+	RecordKioskPinFailure(deviceID, userID string, attempts int64) error
+	StartKioskSession(deviceID, userID string) (*model.KioskSessionToken, error)
+	GetKioskSession(deviceID, token string) (*model.KioskSession, error)
+	EndKioskSession(session *model.KioskSession) error
+	KioskCheckout(session *model.KioskSession, barcode string) (*model.KioskLoan, error)
+	KioskReturn(session *model.KioskSession, barcode string) (*model.KioskLoan, error)
+	GetKioskReceipt(session *model.KioskSession) (*model.KioskReceipt, error)
+	// lost and damaged item related
+	DeclareCheckoutLost(librarianID, ticketID string, fees *model.IncidentFees, request *model.DeclareLostRequest) (*model.CheckoutIncident, error)
+	DeclareCheckoutDamaged(librarianID, ticketID string, fees *model.IncidentFees, request *model.DeclareDamagedRequest) (*model.CheckoutIncident, error)
+	MarkCheckoutFound(librarianID, ticketID string, request *model.MarkFoundRequest) (*model.CheckoutIncident, error)
+	RestockBookCopy(librarianID, barcode string, request *model.RestockCopyRequest) (*model.BookCopy, error)
+	// report related
+	GetOverdueReport(request *model.ReportRequest) ([]model.OverduePatron, uint, error)
+	GetDueReport(request *model.ReportRequest) ([]model.DueLoan, uint, error)
+	GetHoldShelfReport(request *model.ReportRequest) ([]model.HoldShelfItem, uint, error)
+	GetTopBooksReport(request *model.ReportRequest) ([]model.TopBook, uint, error)
+	GetTopAuthorsReport(request *model.ReportRequest) ([]model.TopAuthor, uint, error)
+	GetInactivePatronsReport(request *model.ReportRequest) ([]model.InactivePatron, uint, error)
+	// audit related
+	GetAuditLogs(request *model.GetAuditLogsRequest) ([]model.AuditLog, uint, error)
 }
 
 // LibraryService is a concrete service which implements Service
@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedExportBooks is an error when exporting books failed
+	ErrFailedExportBooks = errors.New("export books failed")
+	// ErrFailedExportUsers is an error when exporting users failed
+	ErrFailedExportUsers = errors.New("export users failed")
+	// ErrFailedExportCheckouts is an error when exporting checkouts failed
+	ErrFailedExportCheckouts = errors.New("export checkouts failed")
+)
+
+// ExportBooks passes every book matching a search to write, with its copies on loan and reserved.
+// The rows are streamed so memory stays bounded however big the catalogue is, an error of write
+// stops the export and is returned as is.
+func (l *LibraryService) ExportBooks(request *model.SearchRequest, write func(*model.BookExport) error) error {
+	sqlStatement := `
+		SELECT
+			` + bookColumns + `,
+			(
+				SELECT COUNT(*) FROM "checkout_tickets" ct
+				WHERE ct."bookID" = "books"."ID" AND ct."isCheckedOut" = true AND ct."isReturned" = false
+			) AS "onLoan",
+			(
+				SELECT COUNT(*) FROM "checkout_tickets" ct
+				WHERE ct."bookID" = "books"."ID" AND ct."isCheckedOut" = false AND ct."isReturned" = false
+			) AS "reserved"
+		FROM
+			"books"
+		WHERE
+			%s
+		ORDER BY
+			%s, "ID" ASC;
+	`
+
+	searchBy, orderBy, args := bookSearch(request)
+	sqlStatement = fmt.Sprintf(sqlStatement, searchBy, orderBy)
+
+	rows, err := l.db.Query(sqlStatement, args...)
+	if err != nil {
+		log.Error().Msgf("[Error] ExportBooks(), db.Query err: %v", err)
+		return ErrFailedExportBooks
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var export model.BookExport
+		book, err := scanBook(scanWithExtra(rows, &export.OnLoan, &export.Reserved))
+		if err != nil {
+			log.Error().Msgf("[Error] ExportBooks(), rows.Scan err: %v", err)
+			return ErrFailedExportBooks
+		}
+		export.Book = *book
+		// booksLeft counts the copies on the shelf
+		export.TotalCopies = book.BooksLeft + export.OnLoan
+		export.Available = book.InLibrary && book.BooksLeft > 0
+
+		if err := write(&export); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] ExportBooks(), rows.Err err: %v", err)
+		return ErrFailedExportBooks
+	}
+
+	return nil
+}
+
+// ExportUsers passes every user matching a search to write, without credentials. The rows are
+// streamed, an error of write stops the export and is returned as is.
+func (l *LibraryService) ExportUsers(request *model.SearchRequest, write func(*model.UserExport) error) error {
+	sqlStatement := `
+		SELECT
+			u."userID",
+			u."name",
+			u."email",
+			u."role",
+			u."dateOfBirth",
+			u."phoneNumber",
+			u."address",
+			u."country",
+			u."joinedDate",
+			u."fineAmount",
+			u."isPaymentDone",
+			bkd."reservedBooksCount",
+			bkd."checkedOutBooksCount",
+			bkd."completedBooksCount",
+			u."createdAt"
+		FROM
+			"users" as u INNER JOIN "book_details" as bkd
+				ON u."userID" = bkd."userID"
+		WHERE
+			%s
+		ORDER BY
+			%s, u."userID" ASC;
+	`
+
+	searchBy, orderBy, searchText := userSearch(request)
+	sqlStatement = fmt.Sprintf(sqlStatement, searchBy, orderBy)
+
+	rows, err := l.db.Query(sqlStatement, searchText)
+	if err != nil {
+		log.Error().Msgf("[Error] ExportUsers(), db.Query err: %v", err)
+		return ErrFailedExportUsers
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.UserExport
+		err := rows.Scan(
+			&user.UserID,
+			&user.Name,
+			&user.Email,
+			&user.Role,
+			&user.DateOfBirth,
+			&user.PhoneNumber,
+			&user.Address,
+			&user.Country,
+			&user.JoinedDate,
+			&user.FineAmount,
+			&user.IsPaymentDone,
+			&user.ReservedBooksCount,
+			&user.CheckedOutBooksCount,
+			&user.CompletedBooksCount,
+			&user.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] ExportUsers(), rows.Scan err: %v", err)
+			return ErrFailedExportUsers
+		}
+
+		if err := write(&user); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] ExportUsers(), rows.Err err: %v", err)
+		return ErrFailedExportUsers
+	}
+
+	return nil
+}
+
+// ExportCheckouts passes the loans of the checkout history to write, optionally narrowed to a user,
+// a book and a period of reservation. The rows are streamed, an error of write stops the export
+// and is returned as is.
+func (l *LibraryService) ExportCheckouts(request *model.ExportCheckoutsRequest, write func(*model.CheckoutExport) error) error {
+	sqlStatement := `
+		SELECT
+			ct."ID",
+			ct."userID",
+			u."name",
+			u."email",
+			ct."bookID",
+			b."ISBN",
+			b."title",
+			ct."isCheckedOut",
+			ct."isReturned",
+			ct."numberOfDays",
+			ct."fineAmount",
+			ct."reservedOn",
+			ct."checkedOutOn",
+			ct."returnedDate"
+		FROM
+			"checkout_tickets" ct
+		INNER JOIN
+			"users" u ON ct."userID" = u."userID"
+		INNER JOIN
+			"books" b ON ct."bookID" = b."ID"
+		WHERE
+			%s
+		ORDER BY
+			%s, ct."ID" ASC;
+	`
+
+	conditions := "TRUE"
+	args := []interface{}{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions += fmt.Sprintf(" AND "+condition, len(args))
+	}
+	if len(request.UserID) > 0 {
+		addCondition(`ct."userID" = $%d`, request.UserID)
+	}
+	if len(request.ISBN) > 0 {
+		addCondition(`(b."ISBN" = ANY($%[1]d) OR b."ISBN10" = ANY($%[1]d))`, pq.Array(isbnLookupKeys([]string{isbn.Normalize(request.ISBN)})))
+	}
+	if request.From != nil {
+		addCondition(`ct."reservedOn" >= $%d`, *request.From)
+	}
+	if request.To != nil {
+		// the whole last day is included
+		addCondition(`ct."reservedOn" < $%d::date + 1`, *request.To)
+	}
+	if request.IsReturned != nil {
+		addCondition(`ct."isReturned" = $%d`, *request.IsReturned)
+	}
+	sqlStatement = fmt.Sprintf(sqlStatement, conditions, checkoutOrderBy(request.SortBy, request.OrderBy))
+
+	rows, err := l.db.Query(sqlStatement, args...)
+	if err != nil {
+		log.Error().Msgf("[Error] ExportCheckouts(), db.Query err: %v", err)
+		return ErrFailedExportCheckouts
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var checkout model.CheckoutExport
+		err := rows.Scan(
+			&checkout.ID,
+			&checkout.UserID,
+			&checkout.UserName,
+			&checkout.UserEmail,
+			&checkout.BookID,
+			&checkout.ISBN,
+			&checkout.Title,
+			&checkout.IsCheckedOut,
+			&checkout.IsReturned,
+			&checkout.NumberOfDays,
+			&checkout.FineAmount,
+			&checkout.ReservedOn,
+			&checkout.CheckedOutOn,
+			&checkout.ReturnedDate,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] ExportCheckouts(), rows.Scan err: %v", err)
+			return ErrFailedExportCheckouts
+		}
+
+		if err := write(&checkout); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] ExportCheckouts(), rows.Err err: %v", err)
+		return ErrFailedExportCheckouts
+	}
+
+	return nil
+}
@@ -0,0 +1,876 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"integrated-library-service/itemcode"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedCreateKioskDevice is an error when registering a kiosk failed
+	ErrFailedCreateKioskDevice = errors.New("create kiosk device failed")
+	// ErrFailedGetKioskDevices is an error when get kiosk devices failed
+	ErrFailedGetKioskDevices = errors.New("get kiosk devices failed")
+	// ErrFailedRevokeKioskDevice is an error when revoking a kiosk failed
+	ErrFailedRevokeKioskDevice = errors.New("revoke kiosk device failed")
+	// ErrKioskDeviceNotFound is an error when a kiosk is unknown or already revoked
+	ErrKioskDeviceNotFound = errors.New("kiosk device not found")
+	// ErrKioskDeviceUnauthorized is an error when a kiosk token is unknown or revoked
+	ErrKioskDeviceUnauthorized = errors.New("kiosk device token is invalid or revoked")
+	// ErrFailedAuthenticateKioskDevice is an error when checking a kiosk token failed
+	ErrFailedAuthenticateKioskDevice = errors.New("authenticate kiosk device failed")
+	// ErrFailedSetPin is an error when setting a PIN failed
+	ErrFailedSetPin = errors.New("set pin failed")
+	// ErrFailedGetPin is an error when get pin failed
+	ErrFailedGetPin = errors.New("get pin failed")
+	// ErrPinNotSet is an error when a patron signs in at a kiosk without having set a PIN
+	ErrPinNotSet = errors.New("no PIN is set for this library card")
+	// ErrPinLocked is an error when a PIN is locked after too many wrong attempts
+	ErrPinLocked = errors.New("PIN is locked after too many wrong attempts, please set a new PIN in your account")
+	// ErrFailedStartKioskSession is an error when starting a kiosk session failed
+	ErrFailedStartKioskSession = errors.New("start kiosk session failed")
+	// ErrKioskSessionInvalid is an error when a kiosk session is unknown, ended or expired
+	ErrKioskSessionInvalid = errors.New("kiosk session is invalid or expired")
+	// ErrFailedGetKioskSession is an error when looking up a kiosk session failed
+	ErrFailedGetKioskSession = errors.New("get kiosk session failed")
+	// ErrFailedEndKioskSession is an error when ending a kiosk session failed
+	ErrFailedEndKioskSession = errors.New("end kiosk session failed")
+	// ErrFailedKioskCheckout is an error when checking out a copy at a kiosk failed
+	ErrFailedKioskCheckout = errors.New("kiosk checkout failed")
+	// ErrKioskCopyOnLoan is an error when a copy scanned for checkout is still on loan
+	ErrKioskCopyOnLoan = errors.New("copy is on loan, please return it first")
+	// ErrKioskCopyUnavailable is an error when a copy scanned for checkout is marked lost, damaged or withdrawn
+	ErrKioskCopyUnavailable = errors.New("copy is marked lost, damaged or withdrawn, please take it to the desk")
+	// ErrFailedKioskReturn is an error when returning a copy at a kiosk failed
+	ErrFailedKioskReturn = errors.New("kiosk return failed")
+	// ErrKioskLoanNotFound is an error when a copy scanned for return is not on loan
+	ErrKioskLoanNotFound = errors.New("copy is not on loan")
+	// ErrFailedGetKioskReceipt is an error when get kiosk receipt failed
+	ErrFailedGetKioskReceipt = errors.New("get kiosk receipt failed")
+)
+
+// CreateKioskDevice registers a kiosk and returns it with its token, only the hash of the token is stored
+func (l *LibraryService) CreateKioskDevice(librarianID, name string) (*model.KioskDeviceCredentials, error) {
+	token, err := newToken()
+	if err != nil {
+		log.Error().Msgf("[Error] CreateKioskDevice(), newToken err: %v", err)
+		return nil, ErrFailedCreateKioskDevice
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] CreateKioskDevice(), db.Begin err: %v", err)
+		return nil, ErrFailedCreateKioskDevice
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] CreateKioskDevice(), tx.Rollback err: %v", err)
+		}
+	}
+
+	device := model.KioskDevice{}
+	err = tx.QueryRow(`
+		INSERT INTO "kiosk_devices" ("name", "tokenHash", "createdBy") VALUES ($1, $2, $3)
+		RETURNING "ID", "name", "createdBy", "createdAt";
+	`, name, hashToken(token), librarianID).Scan(&device.ID, &device.Name, &device.CreatedBy, &device.CreatedAt)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] CreateKioskDevice(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedCreateKioskDevice
+	}
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionKioskDeviceCreate,
+		ActorID:    &librarianID,
+		DeviceID:   &device.ID,
+		EntityType: "kioskDevice",
+		EntityID:   device.ID,
+	}
+	if err := writeAuditLog(tx, &entry, map[string]interface{}{"name": name}); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] CreateKioskDevice(), writeAuditLog err: %v", err)
+		return nil, ErrFailedCreateKioskDevice
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] CreateKioskDevice(), tx.Commit err: %v", err)
+		return nil, ErrFailedCreateKioskDevice
+	}
+
+	return &model.KioskDeviceCredentials{Device: device, Token: token}, nil
+}
+
+// GetKioskDevices returns the registered kiosks, revoked ones last
+func (l *LibraryService) GetKioskDevices() ([]model.KioskDevice, error) {
+	rows, err := l.db.Query(`
+		SELECT "ID", "name", "createdBy", "lastSeenAt", "revokedAt", "createdAt"
+		FROM "kiosk_devices"
+		ORDER BY "revokedAt" IS NOT NULL, "name" ASC;
+	`)
+	if err != nil {
+		log.Error().Msgf("[Error] GetKioskDevices(), db.Query err: %v", err)
+		return nil, ErrFailedGetKioskDevices
+	}
+	defer rows.Close()
+
+	devices := []model.KioskDevice{}
+	for rows.Next() {
+		var (
+			device     model.KioskDevice
+			lastSeenAt sql.NullTime
+			revokedAt  sql.NullTime
+		)
+		if err := rows.Scan(&device.ID, &device.Name, &device.CreatedBy, &lastSeenAt, &revokedAt, &device.CreatedAt); err != nil {
+			log.Error().Msgf("[Error] GetKioskDevices(), rows.Scan err: %v", err)
+			return nil, ErrFailedGetKioskDevices
+		}
+		if lastSeenAt.Valid {
+			device.LastSeenAt = &lastSeenAt.Time
+		}
+		if revokedAt.Valid {
+			device.RevokedAt = &revokedAt.Time
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetKioskDevices(), rows.Err err: %v", err)
+		return nil, ErrFailedGetKioskDevices
+	}
+
+	return devices, nil
+}
+
+// RevokeKioskDevice stops a kiosk token from working and ends the sessions open at the kiosk
+func (l *LibraryService) RevokeKioskDevice(librarianID, deviceID string) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] RevokeKioskDevice(), db.Begin err: %v", err)
+		return ErrFailedRevokeKioskDevice
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] RevokeKioskDevice(), tx.Rollback err: %v", err)
+		}
+	}
+
+	res, err := tx.Exec(`
+		UPDATE "kiosk_devices" SET "revokedAt" = $2 WHERE "ID" = $1 AND "revokedAt" IS NULL;
+	`, deviceID, time.Now().UTC())
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RevokeKioskDevice(), tx.Exec err: %v", err)
+		return ErrFailedRevokeKioskDevice
+	}
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		rollback()
+		return ErrKioskDeviceNotFound
+	}
+
+	_, err = tx.Exec(`
+		UPDATE "kiosk_sessions" SET "endedAt" = $2 WHERE "deviceID" = $1 AND "endedAt" IS NULL;
+	`, deviceID, time.Now().UTC())
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RevokeKioskDevice(), tx.Exec err: %v", err)
+		return ErrFailedRevokeKioskDevice
+	}
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionKioskDeviceRevoke,
+		ActorID:    &librarianID,
+		DeviceID:   &deviceID,
+		EntityType: "kioskDevice",
+		EntityID:   deviceID,
+	}
+	if err := writeAuditLog(tx, &entry, nil); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RevokeKioskDevice(), writeAuditLog err: %v", err)
+		return ErrFailedRevokeKioskDevice
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] RevokeKioskDevice(), tx.Commit err: %v", err)
+		return ErrFailedRevokeKioskDevice
+	}
+
+	return nil
+}
+
+// AuthenticateKioskDevice returns the ID of the kiosk with the token and notes it was seen
+func (l *LibraryService) AuthenticateKioskDevice(token string) (string, error) {
+	var deviceID string
+	err := l.db.QueryRow(`
+		UPDATE "kiosk_devices" SET "lastSeenAt" = $2
+		WHERE "tokenHash" = $1 AND "revokedAt" IS NULL
+		RETURNING "ID";
+	`, hashToken(token), time.Now().UTC()).Scan(&deviceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrKioskDeviceUnauthorized
+		}
+		log.Error().Msgf("[Error] AuthenticateKioskDevice(), db.QueryRow err: %v", err)
+		return "", ErrFailedAuthenticateKioskDevice
+	}
+
+	return deviceID, nil
+}
+
+// SetUserPin replaces the kiosk PIN hash of a user and unlocks a PIN locked by wrong attempts
+func (l *LibraryService) SetUserPin(userID, pinHash string) error {
+	res, err := l.db.Exec(`
+		UPDATE "users" SET
+			"pinHash" = $1,
+			"pinFailedAttempts" = 0,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE
+			"userID" = $2 AND "deletedAt" IS NULL;
+	`, pinHash, userID)
+	if err != nil {
+		log.Error().Msgf("[Error] SetUserPin(), db.Exec err: %v", err)
+		return ErrFailedSetPin
+	}
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		return ErrGetUserPasswordNotFound
+	}
+
+	return nil
+}
+
+// ClaimKioskPinAttempt counts a PIN attempt at a kiosk before the PIN is checked and returns the PIN hash
+// with the attempts counted so far. Claiming the attempt in the same statement which checks the limit
+// keeps concurrent attempts from guessing past it, StartKioskSession resets the count once the PIN matched.
+func (l *LibraryService) ClaimKioskPinAttempt(userID string) (*model.UserPin, error) {
+	pin := model.UserPin{}
+	err := l.db.QueryRow(`
+		UPDATE "users" SET
+			"pinFailedAttempts" = "pinFailedAttempts" + 1
+		WHERE
+			"userID" = $1 AND "deletedAt" IS NULL AND COALESCE("pinHash", '') <> '' AND "pinFailedAttempts" < $2
+		RETURNING "pinHash", "pinFailedAttempts";
+	`, userID, model.KioskPinAttempts).Scan(&pin.Hash, &pin.FailedAttempts)
+	if err == nil {
+		return &pin, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Msgf("[Error] ClaimKioskPinAttempt(), db.QueryRow err: %v", err)
+		return nil, ErrFailedGetPin
+	}
+
+	// nothing was claimed, either there is no PIN to check or it is locked
+	var pinSet bool
+	err = l.db.QueryRow(`
+		SELECT COALESCE("pinHash", '') <> '' FROM "users" WHERE "userID" = $1 AND "deletedAt" IS NULL;
+	`, userID).Scan(&pinSet)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Msgf("[Error] ClaimKioskPinAttempt(), db.QueryRow err: %v", err)
+		return nil, ErrFailedGetPin
+	}
+	if !pinSet {
+		return nil, ErrPinNotSet
+	}
+	return nil, ErrPinLocked
+}
+
+// RecordKioskPinFailure records a wrong PIN entered at a kiosk, the attempt was counted when it was claimed
+func (l *LibraryService) RecordKioskPinFailure(deviceID, userID string, attempts int64) error {
+	entry := model.AuditLog{
+		Action:   model.AuditActionKioskPinFailed,
+		DeviceID: &deviceID,
+		UserID:   &userID,
+	}
+	if err := writeAuditLog(l.db, &entry, map[string]interface{}{"attempts": attempts}); err != nil {
+		log.Error().Msgf("[Error] RecordKioskPinFailure(), writeAuditLog err: %v", err)
+		return ErrFailedStartKioskSession
+	}
+
+	return nil
+}
+
+// StartKioskSession signs a patron in at a kiosk after their PIN matched, which resets the attempts
+// counted against it. A kiosk serves one patron at a time, so a session left open at the kiosk is ended.
+func (l *LibraryService) StartKioskSession(deviceID, userID string) (*model.KioskSessionToken, error) {
+	token, err := newToken()
+	if err != nil {
+		log.Error().Msgf("[Error] StartKioskSession(), newToken err: %v", err)
+		return nil, ErrFailedStartKioskSession
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] StartKioskSession(), db.Begin err: %v", err)
+		return nil, ErrFailedStartKioskSession
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] StartKioskSession(), tx.Rollback err: %v", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	_, err = tx.Exec(`
+		UPDATE "kiosk_sessions" SET "endedAt" = $2 WHERE "deviceID" = $1 AND "endedAt" IS NULL;
+	`, deviceID, now)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] StartKioskSession(), tx.Exec err: %v", err)
+		return nil, ErrFailedStartKioskSession
+	}
+
+	_, err = tx.Exec(`UPDATE "users" SET "pinFailedAttempts" = 0 WHERE "userID" = $1;`, userID)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] StartKioskSession(), tx.Exec err: %v", err)
+		return nil, ErrFailedStartKioskSession
+	}
+
+	session := model.KioskSession{DeviceID: deviceID, UserID: userID}
+	err = tx.QueryRow(`
+		INSERT INTO "kiosk_sessions" ("deviceID", "userID", "tokenHash", "expiresAt", "createdAt")
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING "ID", "expiresAt", "createdAt", (SELECT "name" FROM "users" WHERE "userID" = $2);
+	`, deviceID, userID, hashToken(token), now.Add(model.KioskSessionTTL), now).
+		Scan(&session.ID, &session.ExpiresAt, &session.CreatedAt, &session.Name)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] StartKioskSession(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedStartKioskSession
+	}
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionKioskSessionStart,
+		DeviceID:   &deviceID,
+		SessionID:  &session.ID,
+		UserID:     &userID,
+		EntityType: "kioskSession",
+		EntityID:   session.ID,
+	}
+	if err := writeAuditLog(tx, &entry, nil); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] StartKioskSession(), writeAuditLog err: %v", err)
+		return nil, ErrFailedStartKioskSession
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] StartKioskSession(), tx.Commit err: %v", err)
+		return nil, ErrFailedStartKioskSession
+	}
+
+	return &model.KioskSessionToken{Session: session, Token: token}, nil
+}
+
+// GetKioskSession returns the open session of the kiosk with the token
+func (l *LibraryService) GetKioskSession(deviceID, token string) (*model.KioskSession, error) {
+	session := model.KioskSession{}
+	err := l.db.QueryRow(`
+		SELECT s."ID", s."deviceID", s."userID", u."name", s."expiresAt", s."createdAt"
+		FROM "kiosk_sessions" s INNER JOIN "users" u ON u."userID" = s."userID"
+		WHERE
+			s."tokenHash" = $1 AND s."deviceID" = $2 AND s."endedAt" IS NULL AND s."expiresAt" > $3 AND
+			u."deletedAt" IS NULL;
+	`, hashToken(token), deviceID, time.Now().UTC()).Scan(
+		&session.ID,
+		&session.DeviceID,
+		&session.UserID,
+		&session.Name,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrKioskSessionInvalid
+		}
+		log.Error().Msgf("[Error] GetKioskSession(), db.QueryRow err: %v", err)
+		return nil, ErrFailedGetKioskSession
+	}
+
+	return &session, nil
+}
+
+// EndKioskSession signs the patron of a session out of the kiosk
+func (l *LibraryService) EndKioskSession(session *model.KioskSession) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] EndKioskSession(), db.Begin err: %v", err)
+		return ErrFailedEndKioskSession
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] EndKioskSession(), tx.Rollback err: %v", err)
+		}
+	}
+
+	res, err := tx.Exec(`
+		UPDATE "kiosk_sessions" SET "endedAt" = $2 WHERE "ID" = $1 AND "endedAt" IS NULL;
+	`, session.ID, time.Now().UTC())
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] EndKioskSession(), tx.Exec err: %v", err)
+		return ErrFailedEndKioskSession
+	}
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		rollback()
+		return ErrKioskSessionInvalid
+	}
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionKioskSessionEnd,
+		DeviceID:   &session.DeviceID,
+		SessionID:  &session.ID,
+		UserID:     &session.UserID,
+		EntityType: "kioskSession",
+		EntityID:   session.ID,
+	}
+	if err := writeAuditLog(tx, &entry, nil); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] EndKioskSession(), writeAuditLog err: %v", err)
+		return ErrFailedEndKioskSession
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] EndKioskSession(), tx.Commit err: %v", err)
+		return ErrFailedEndKioskSession
+	}
+
+	return nil
+}
+
+// kioskCopy is a scanned copy with its book
+type kioskCopy struct {
+	Barcode   string
+	BookID    string
+	ISBN      string
+	Title     string
+	Author    string
+	Withdrawn bool
+	Status    model.CopyStatus
+}
+
+// lockKioskCopy looks a scanned copy up and locks its book while a loan of it changes
+func lockKioskCopy(tx *sql.Tx, barcode string) (*kioskCopy, error) {
+	bookCopy := kioskCopy{}
+	err := tx.QueryRow(`
+		SELECT bc."barcode", b."ID", b."ISBN", b."title", b."author", b."withdrawnAt" IS NOT NULL, bc."status"
+		FROM "book_copies" bc INNER JOIN "books" b ON b."ID" = bc."bookID"
+		WHERE bc."barcode" = $1
+		FOR UPDATE OF b;
+	`, barcode).Scan(
+		&bookCopy.Barcode, &bookCopy.BookID, &bookCopy.ISBN, &bookCopy.Title, &bookCopy.Author, &bookCopy.Withdrawn, &bookCopy.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// loan returns the kiosk loan of the copy with the ticket, due numberOfDays after its checkout
+func (k *kioskCopy) loan(checkoutID string, checkedOutOn time.Time, numberOfDays int64, returnedOn *time.Time) *model.KioskLoan {
+	return &model.KioskLoan{
+		CheckoutID:   checkoutID,
+		Barcode:      k.Barcode,
+		ISBN:         k.ISBN,
+		Title:        k.Title,
+		Author:       k.Author,
+		CheckedOutOn: checkedOutOn,
+		DueDate:      checkedOutOn.AddDate(0, 0, int(numberOfDays)),
+		ReturnedOn:   returnedOn,
+	}
+}
+
+// touchKioskSession keeps a session open for another KioskSessionTTL after an action
+func touchKioskSession(ex execer, sessionID string) error {
+	_, err := ex.Exec(`
+		UPDATE "kiosk_sessions" SET "expiresAt" = $2 WHERE "ID" = $1;
+	`, sessionID, time.Now().UTC().Add(model.KioskSessionTTL))
+	return err
+}
+
+// KioskCheckout checks a scanned copy out to the patron of a session. A reservation of the book
+// by the patron becomes the loan, otherwise a new loan is created. The copy in hand shows it is
+// on the shelf, so no stock is checked.
+func (l *LibraryService) KioskCheckout(session *model.KioskSession, barcode string) (*model.KioskLoan, error) {
+	barcode, err := itemcode.Parse(barcode)
+	if err != nil {
+		return nil, ErrBookCopyNotFound
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] KioskCheckout(), db.Begin err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] KioskCheckout(), tx.Rollback err: %v", err)
+		}
+	}
+
+	bookCopy, err := lockKioskCopy(tx, barcode)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyNotFound
+		}
+		log.Error().Msgf("[Error] KioskCheckout(), lockKioskCopy err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+	if bookCopy.Withdrawn {
+		rollback()
+		return nil, ErrBookWithdrawn
+	}
+	if bookCopy.Status != model.CopyStatusAvailable {
+		rollback()
+		return nil, ErrKioskCopyUnavailable
+	}
+
+	var isPaymentDone bool
+	err = tx.QueryRow(`SELECT "isPaymentDone" FROM "users" WHERE "userID" = $1;`, session.UserID).Scan(&isPaymentDone)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskCheckout(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+	if !isPaymentDone {
+		rollback()
+		return nil, ErrPaymentPending
+	}
+
+	var onLoan bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM "checkout_tickets"
+			WHERE "copyBarcode" = $1 AND "isCheckedOut" = true AND "isReturned" = false
+		);
+	`, barcode).Scan(&onLoan)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskCheckout(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+	if onLoan {
+		rollback()
+		return nil, ErrKioskCopyOnLoan
+	}
+
+	var (
+		checkoutID   string
+		isCheckedOut bool
+	)
+	err = tx.QueryRow(`
+		SELECT "ID", "isCheckedOut" FROM "checkout_tickets"
+		WHERE "bookID" = $1 AND "userID" = $2 AND "isReturned" = false
+		ORDER BY "reservedOn" ASC
+		LIMIT 1
+		FOR UPDATE;
+	`, bookCopy.BookID, session.UserID).Scan(&checkoutID, &isCheckedOut)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		rollback()
+		log.Error().Msgf("[Error] KioskCheckout(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+	if isCheckedOut {
+		rollback()
+		return nil, ErrFailedCreateCheckoutTicketConflict
+	}
+
+	var (
+		checkedOutOn time.Time
+		numberOfDays int64
+		now          = time.Now().UTC()
+	)
+	if len(checkoutID) > 0 {
+		err = tx.QueryRow(`
+			UPDATE "checkout_tickets" SET
+				"isCheckedOut" = true,
+				"checkedOutOn" = $2,
+				"copyBarcode" = $3,
+				"numberOfDays" = CASE WHEN "numberOfDays" > 0 THEN "numberOfDays" ELSE $4 END,
+				"updatedAt" = $2,
+				"version" = "version" + 1
+			WHERE
+				"ID" = $1
+			RETURNING "checkedOutOn", "numberOfDays";
+		`, checkoutID, now, barcode, model.KioskLoanDays).Scan(&checkedOutOn, &numberOfDays)
+	} else {
+		err = tx.QueryRow(`
+			INSERT INTO "checkout_tickets" (
+				"bookID", "userID", "isCheckedOut", "numberOfDays", "reservedOn", "checkedOutOn", "copyBarcode"
+			) VALUES (
+				$1, $2, true, $3, $4, $4, $5
+			)
+			RETURNING "ID", "checkedOutOn", "numberOfDays";
+		`, bookCopy.BookID, session.UserID, model.KioskLoanDays, now, barcode).Scan(&checkoutID, &checkedOutOn, &numberOfDays)
+	}
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskCheckout(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+
+	// the copy leaves the shelf, at the desk librarians update the count by hand
+	if err := moveShelfCopies(tx, bookCopy.BookID, -1); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskCheckout(), moveShelfCopies err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+
+	loan := bookCopy.loan(checkoutID, checkedOutOn, numberOfDays, nil)
+	entry := model.AuditLog{
+		Action:     model.AuditActionKioskCheckout,
+		DeviceID:   &session.DeviceID,
+		SessionID:  &session.ID,
+		UserID:     &session.UserID,
+		EntityType: "checkoutTicket",
+		EntityID:   checkoutID,
+	}
+	details := map[string]interface{}{"barcode": barcode, "bookID": bookCopy.BookID, "dueDate": loan.DueDate}
+	if err := writeAuditLog(tx, &entry, details); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskCheckout(), writeAuditLog err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+	if err := touchKioskSession(tx, session.ID); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskCheckout(), touchKioskSession err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] KioskCheckout(), tx.Commit err: %v", err)
+		return nil, ErrFailedKioskCheckout
+	}
+
+	return loan, nil
+}
+
+// KioskReturn returns a scanned copy. The loan is the one of the copy, or for loans made at the
+// desk before copies had barcodes, the open loan of the book by the patron of the session.
+func (l *LibraryService) KioskReturn(session *model.KioskSession, barcode string) (*model.KioskLoan, error) {
+	barcode, err := itemcode.Parse(barcode)
+	if err != nil {
+		return nil, ErrBookCopyNotFound
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] KioskReturn(), db.Begin err: %v", err)
+		return nil, ErrFailedKioskReturn
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] KioskReturn(), tx.Rollback err: %v", err)
+		}
+	}
+
+	bookCopy, err := lockKioskCopy(tx, barcode)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyNotFound
+		}
+		log.Error().Msgf("[Error] KioskReturn(), lockKioskCopy err: %v", err)
+		return nil, ErrFailedKioskReturn
+	}
+
+	var (
+		checkoutID   string
+		borrowerID   string
+		checkedOutOn sql.NullTime
+		numberOfDays int64
+		returnedOn   = time.Now().UTC()
+	)
+	err = tx.QueryRow(`
+		UPDATE "checkout_tickets" SET
+			"isReturned" = true,
+			"returnedDate" = $4,
+			"copyBarcode" = $2,
+			"updatedAt" = $4,
+			"version" = "version" + 1
+		WHERE "ID" = (
+			SELECT "ID" FROM "checkout_tickets"
+			WHERE
+				"isCheckedOut" = true AND "isReturned" = false AND (
+					"copyBarcode" = $2 OR
+					("copyBarcode" IS NULL AND "bookID" = $1 AND "userID" = $3)
+				)
+			ORDER BY "copyBarcode" IS NULL, "checkedOutOn" ASC
+			LIMIT 1
+		)
+		RETURNING "ID", "userID", "checkedOutOn", "numberOfDays";
+	`, bookCopy.BookID, barcode, session.UserID, returnedOn).Scan(&checkoutID, &borrowerID, &checkedOutOn, &numberOfDays)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrKioskLoanNotFound
+		}
+		log.Error().Msgf("[Error] KioskReturn(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedKioskReturn
+	}
+
+	if err := moveShelfCopies(tx, bookCopy.BookID, 1); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskReturn(), moveShelfCopies err: %v", err)
+		return nil, ErrFailedKioskReturn
+	}
+
+	loan := bookCopy.loan(checkoutID, checkedOutOn.Time, numberOfDays, &returnedOn)
+	// a copy borrowed by someone else, e.g. a family member, is recorded for its borrower
+	entry := model.AuditLog{
+		Action:     model.AuditActionKioskReturn,
+		DeviceID:   &session.DeviceID,
+		SessionID:  &session.ID,
+		UserID:     &borrowerID,
+		EntityType: "checkoutTicket",
+		EntityID:   checkoutID,
+	}
+	details := map[string]interface{}{"barcode": barcode, "bookID": bookCopy.BookID, "returnedBy": session.UserID}
+	if err := writeAuditLog(tx, &entry, details); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskReturn(), writeAuditLog err: %v", err)
+		return nil, ErrFailedKioskReturn
+	}
+	if err := touchKioskSession(tx, session.ID); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] KioskReturn(), touchKioskSession err: %v", err)
+		return nil, ErrFailedKioskReturn
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] KioskReturn(), tx.Commit err: %v", err)
+		return nil, ErrFailedKioskReturn
+	}
+
+	return loan, nil
+}
+
+// GetKioskReceipt returns the receipt of the checkouts and returns of a kiosk session
+func (l *LibraryService) GetKioskReceipt(session *model.KioskSession) (*model.KioskReceipt, error) {
+	receipt := model.KioskReceipt{
+		SessionID:  session.ID,
+		Name:       session.Name,
+		StartedAt:  session.CreatedAt,
+		PrintedAt:  time.Now().UTC(),
+		CheckedOut: []model.KioskLoan{},
+		Returned:   []model.KioskLoan{},
+	}
+
+	var cardNumber sql.NullString
+	err := l.db.QueryRow(`
+		SELECT d."name", u."cardNumber"
+		FROM "kiosk_devices" d, "users" u
+		WHERE d."ID" = $1 AND u."userID" = $2;
+	`, session.DeviceID, session.UserID).Scan(&receipt.DeviceName, &cardNumber)
+	if err != nil {
+		log.Error().Msgf("[Error] GetKioskReceipt(), db.QueryRow err: %v", err)
+		return nil, ErrFailedGetKioskReceipt
+	}
+	receipt.CardNumber = maskCardNumber(cardNumber.String)
+
+	rows, err := l.db.Query(`
+		SELECT
+			a."action", ct."ID", COALESCE(ct."copyBarcode", ''), b."ISBN", b."title", b."author",
+			ct."checkedOutOn", ct."numberOfDays", ct."returnedDate"
+		FROM
+			"audit_logs" a
+			INNER JOIN "checkout_tickets" ct ON ct."ID"::TEXT = a."entityID"
+			INNER JOIN "books" b ON b."ID" = ct."bookID"
+		WHERE
+			a."sessionID" = $1 AND a."action" IN ($2, $3)
+		ORDER BY
+			a."createdAt" ASC;
+	`, session.ID, model.AuditActionKioskCheckout, model.AuditActionKioskReturn)
+	if err != nil {
+		log.Error().Msgf("[Error] GetKioskReceipt(), db.Query err: %v", err)
+		return nil, ErrFailedGetKioskReceipt
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			action       model.AuditAction
+			bookCopy     kioskCopy
+			checkoutID   string
+			checkedOutOn sql.NullTime
+			numberOfDays int64
+			returnedDate sql.NullTime
+		)
+		err := rows.Scan(
+			&action,
+			&checkoutID,
+			&bookCopy.Barcode,
+			&bookCopy.ISBN,
+			&bookCopy.Title,
+			&bookCopy.Author,
+			&checkedOutOn,
+			&numberOfDays,
+			&returnedDate,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetKioskReceipt(), rows.Scan err: %v", err)
+			return nil, ErrFailedGetKioskReceipt
+		}
+
+		if action == model.AuditActionKioskCheckout {
+			receipt.CheckedOut = append(receipt.CheckedOut, *bookCopy.loan(checkoutID, checkedOutOn.Time, numberOfDays, nil))
+			continue
+		}
+		var returnedOn *time.Time
+		if returnedDate.Valid {
+			returnedOn = &returnedDate.Time
+		}
+		receipt.Returned = append(receipt.Returned, *bookCopy.loan(checkoutID, checkedOutOn.Time, numberOfDays, returnedOn))
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetKioskReceipt(), rows.Err err: %v", err)
+		return nil, ErrFailedGetKioskReceipt
+	}
+
+	receipt.Lines = receiptLines(&receipt)
+	return &receipt, nil
+}
+
+// maskCardNumber keeps the last four digits of a card number for printing
+func maskCardNumber(cardNumber string) string {
+	if len(cardNumber) <= 4 {
+		return cardNumber
+	}
+	return strings.Repeat("*", len(cardNumber)-4) + cardNumber[len(cardNumber)-4:]
+}
+
+// receiptLines lays a receipt out as lines of text for receipt printers
+func receiptLines(receipt *model.KioskReceipt) []string {
+	const dateLayout = "2006-01-02"
+	lines := []string{
+		receipt.DeviceName,
+		receipt.PrintedAt.Format("2006-01-02 15:04"),
+		"Patron: " + receipt.Name,
+	}
+	if len(receipt.CardNumber) > 0 {
+		lines = append(lines, "Card: "+receipt.CardNumber)
+	}
+
+	if len(receipt.CheckedOut) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Checked out (%d)", len(receipt.CheckedOut)))
+		for _, loan := range receipt.CheckedOut {
+			lines = append(lines, loan.Title, fmt.Sprintf("  %s  due %s", loan.Barcode, loan.DueDate.Format(dateLayout)))
+		}
+	}
+	if len(receipt.Returned) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Returned (%d)", len(receipt.Returned)))
+		for _, loan := range receipt.Returned {
+			lines = append(lines, loan.Title, "  "+loan.Barcode)
+		}
+	}
+	if len(receipt.CheckedOut) == 0 && len(receipt.Returned) == 0 {
+		lines = append(lines, "", "No items")
+	}
+
+	return append(lines, "", "Thank you for visiting the library")
+}
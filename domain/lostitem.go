@@ -0,0 +1,527 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"integrated-library-service/itemcode"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedDeclareCheckoutLost is an error when declaring a loan lost failed
+	ErrFailedDeclareCheckoutLost = errors.New("declare checkout lost failed")
+	// ErrFailedDeclareCheckoutDamaged is an error when taking back a damaged copy failed
+	ErrFailedDeclareCheckoutDamaged = errors.New("declare checkout damaged failed")
+	// ErrFailedMarkCheckoutFound is an error when taking back a lost copy failed
+	ErrFailedMarkCheckoutFound = errors.New("mark checkout found failed")
+	// ErrCheckoutNotOnLoan is an error when a checkout declared lost or damaged is not on loan
+	ErrCheckoutNotOnLoan = errors.New("checkout is not on loan")
+	// ErrCheckoutNotLost is an error when a checkout marked found was not declared lost or was already found
+	ErrCheckoutNotLost = errors.New("checkout is not lost or was already found")
+	// ErrFailedRestockBookCopy is an error when putting a repaired copy back on the shelf failed
+	ErrFailedRestockBookCopy = errors.New("restock book copy failed")
+	// ErrCopyNotDamaged is an error when a copy to restock is not damaged
+	ErrCopyNotDamaged = errors.New("copy is not damaged")
+)
+
+// incidentTicket is a loan with the price of its book, locked while it is declared lost, damaged or found
+type incidentTicket struct {
+	ID           string
+	BookID       string
+	UserID       string
+	Barcode      sql.NullString
+	Price        sql.NullFloat64
+	IsCheckedOut bool
+	IsReturned   bool
+	LostAt       sql.NullTime
+	FoundAt      sql.NullTime
+	IncidentFee  float64
+}
+
+// onLoan reports whether the copy of the ticket is still with the patron
+func (t *incidentTicket) onLoan() bool {
+	return t.IsCheckedOut && !t.IsReturned
+}
+
+// lockIncidentTicket locks a checkout ticket and its book
+func lockIncidentTicket(tx *sql.Tx, ticketID string) (*incidentTicket, error) {
+	ticket := incidentTicket{}
+	err := tx.QueryRow(`
+		SELECT
+			ct."ID", ct."bookID", ct."userID", ct."copyBarcode", b."price", ct."isCheckedOut", ct."isReturned",
+			ct."lostAt", ct."foundAt", ct."incidentFee"
+		FROM
+			"checkout_tickets" ct INNER JOIN "books" b ON b."ID" = ct."bookID"
+		WHERE
+			ct."ID" = $1
+		FOR UPDATE OF ct, b;
+	`, ticketID).Scan(
+		&ticket.ID,
+		&ticket.BookID,
+		&ticket.UserID,
+		&ticket.Barcode,
+		&ticket.Price,
+		&ticket.IsCheckedOut,
+		&ticket.IsReturned,
+		&ticket.LostAt,
+		&ticket.FoundAt,
+		&ticket.IncidentFee,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// incidentFee is the fee given by the librarian, otherwise the part rate of the price of the book
+// or fallback for books without a price, rounded to cents
+func incidentFee(fee *float64, price sql.NullFloat64, rate, fallback float64) float64 {
+	amount := fallback
+	switch {
+	case fee != nil:
+		amount = *fee
+	case price.Valid:
+		amount = price.Float64 * rate
+	}
+	return math.Round(amount*100) / 100
+}
+
+// lockUserFine returns the fine of a patron and whether it is paid, locking the patron
+func lockUserFine(tx *sql.Tx, userID string) (float64, bool, error) {
+	var (
+		fineAmount    float64
+		isPaymentDone bool
+	)
+	err := tx.QueryRow(`
+		SELECT "fineAmount", "isPaymentDone" FROM "users" WHERE "userID" = $1 FOR UPDATE;
+	`, userID).Scan(&fineAmount, &isPaymentDone)
+	return fineAmount, isPaymentDone, err
+}
+
+// setUserFine stores the fine of a patron
+func setUserFine(ex execer, userID string, fineAmount float64, isPaymentDone bool) error {
+	_, err := ex.Exec(`
+		UPDATE "users" SET
+			"fineAmount" = $2,
+			"isPaymentDone" = $3,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE "userID" = $1;
+	`, userID, fineAmount, isPaymentDone)
+	return err
+}
+
+// chargeIncidentFee adds a fee to the fine of a patron and returns the new fine, a paid fine is
+// settled so the fee starts a new one
+func chargeIncidentFee(tx *sql.Tx, userID string, fee float64) (float64, error) {
+	fineAmount, isPaymentDone, err := lockUserFine(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if fee == 0 {
+		return fineAmount, nil
+	}
+	if isPaymentDone {
+		fineAmount = 0
+	}
+	fineAmount = math.Round((fineAmount+fee)*100) / 100
+	return fineAmount, setUserFine(tx, userID, fineAmount, false)
+}
+
+// refundIncidentFee takes a fee off the fine of a patron and returns the new fine with the part of
+// the fee which was already paid and is owed back to the patron
+func refundIncidentFee(tx *sql.Tx, userID string, fee float64) (float64, float64, error) {
+	fineAmount, isPaymentDone, err := lockUserFine(tx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if fee == 0 || isPaymentDone {
+		return fineAmount, fee, nil
+	}
+	waived := math.Min(fee, fineAmount)
+	fineAmount = math.Round((fineAmount-waived)*100) / 100
+	refundDue := math.Round((fee-waived)*100) / 100
+	return fineAmount, refundDue, setUserFine(tx, userID, fineAmount, fineAmount <= 0)
+}
+
+// setCopyStatus marks the copy of a ticket lost, damaged or available, loans of desk checkouts
+// have no copy
+func setCopyStatus(ex execer, ticket *incidentTicket, status model.CopyStatus) error {
+	if !ticket.Barcode.Valid {
+		return nil
+	}
+	_, err := ex.Exec(`UPDATE "book_copies" SET "status" = $2 WHERE "barcode" = $1;`, ticket.Barcode.String, status)
+	return err
+}
+
+// incident returns the incident of a ticket
+func (t *incidentTicket) incident(action model.AuditAction, status model.CopyStatus, at time.Time) *model.CheckoutIncident {
+	incident := model.CheckoutIncident{
+		CheckoutID: t.ID,
+		BookID:     t.BookID,
+		UserID:     t.UserID,
+		Action:     action,
+		At:         at,
+	}
+	if t.Barcode.Valid {
+		incident.Barcode = t.Barcode.String
+		incident.CopyStatus = status
+	}
+	return &incident
+}
+
+// DeclareCheckoutLost ends a loan with its copy lost. The replacement fee is added to the fine of
+// the ticket and the patron, the copy stays off the shelf.
+func (l *LibraryService) DeclareCheckoutLost(librarianID, ticketID string, fees *model.IncidentFees, request *model.DeclareLostRequest) (*model.CheckoutIncident, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] DeclareCheckoutLost(), db.Begin err: %v", err)
+		return nil, ErrFailedDeclareCheckoutLost
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] DeclareCheckoutLost(), tx.Rollback err: %v", err)
+		}
+	}
+
+	ticket, err := lockIncidentTicket(tx, ticketID)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGetCheckoutTicketByIDNotFound
+		}
+		log.Error().Msgf("[Error] DeclareCheckoutLost(), lockIncidentTicket err: %v", err)
+		return nil, ErrFailedDeclareCheckoutLost
+	}
+	if !ticket.onLoan() {
+		rollback()
+		return nil, ErrCheckoutNotOnLoan
+	}
+
+	lostAt := time.Now().UTC()
+	fee := incidentFee(request.Fee, ticket.Price, 1, fees.Replacement)
+	_, err = tx.Exec(`
+		UPDATE "checkout_tickets" SET
+			"isReturned" = true,
+			"returnedDate" = $2,
+			"lostAt" = $2,
+			"fineAmount" = "fineAmount" + $3,
+			"incidentFee" = $3,
+			"updatedAt" = $2,
+			"version" = "version" + 1
+		WHERE "ID" = $1;
+	`, ticket.ID, lostAt, fee)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutLost(), tx.Exec err: %v", err)
+		return nil, ErrFailedDeclareCheckoutLost
+	}
+
+	fineAmount, err := chargeIncidentFee(tx, ticket.UserID, fee)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutLost(), chargeIncidentFee err: %v", err)
+		return nil, ErrFailedDeclareCheckoutLost
+	}
+	if err := setCopyStatus(tx, ticket, model.CopyStatusLost); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutLost(), setCopyStatus err: %v", err)
+		return nil, ErrFailedDeclareCheckoutLost
+	}
+
+	incident := ticket.incident(model.AuditActionCheckoutLost, model.CopyStatusLost, lostAt)
+	incident.Fee = fee
+	incident.FineAmount = fineAmount
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionCheckoutLost,
+		ActorID:    &librarianID,
+		UserID:     &ticket.UserID,
+		EntityType: "checkoutTicket",
+		EntityID:   ticket.ID,
+	}
+	details := map[string]interface{}{"bookID": ticket.BookID, "barcode": incident.Barcode, "fee": fee, "note": request.Note}
+	if err := writeAuditLog(tx, &entry, details); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutLost(), writeAuditLog err: %v", err)
+		return nil, ErrFailedDeclareCheckoutLost
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] DeclareCheckoutLost(), tx.Commit err: %v", err)
+		return nil, ErrFailedDeclareCheckoutLost
+	}
+
+	return incident, nil
+}
+
+// DeclareCheckoutDamaged takes back a loan with its copy damaged. The repair fee is added to the
+// fine of the ticket and the patron, the copy goes back on the shelf only when it is restocked, right
+// away or by RestockBookCopy once it was repaired.
+func (l *LibraryService) DeclareCheckoutDamaged(librarianID, ticketID string, fees *model.IncidentFees, request *model.DeclareDamagedRequest) (*model.CheckoutIncident, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] DeclareCheckoutDamaged(), db.Begin err: %v", err)
+		return nil, ErrFailedDeclareCheckoutDamaged
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] DeclareCheckoutDamaged(), tx.Rollback err: %v", err)
+		}
+	}
+
+	ticket, err := lockIncidentTicket(tx, ticketID)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGetCheckoutTicketByIDNotFound
+		}
+		log.Error().Msgf("[Error] DeclareCheckoutDamaged(), lockIncidentTicket err: %v", err)
+		return nil, ErrFailedDeclareCheckoutDamaged
+	}
+	if !ticket.onLoan() {
+		rollback()
+		return nil, ErrCheckoutNotOnLoan
+	}
+
+	damagedAt := time.Now().UTC()
+	fee := incidentFee(request.Fee, ticket.Price, fees.RepairRate, fees.Repair)
+	_, err = tx.Exec(`
+		UPDATE "checkout_tickets" SET
+			"isReturned" = true,
+			"returnedDate" = $2,
+			"damagedAt" = $2,
+			"fineAmount" = "fineAmount" + $3,
+			"incidentFee" = $3,
+			"updatedAt" = $2,
+			"version" = "version" + 1
+		WHERE "ID" = $1;
+	`, ticket.ID, damagedAt, fee)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutDamaged(), tx.Exec err: %v", err)
+		return nil, ErrFailedDeclareCheckoutDamaged
+	}
+
+	fineAmount, err := chargeIncidentFee(tx, ticket.UserID, fee)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutDamaged(), chargeIncidentFee err: %v", err)
+		return nil, ErrFailedDeclareCheckoutDamaged
+	}
+
+	status := model.CopyStatusDamaged
+	if request.Restock {
+		status = model.CopyStatusAvailable
+		if err := moveShelfCopies(tx, ticket.BookID, 1); err != nil {
+			rollback()
+			log.Error().Msgf("[Error] DeclareCheckoutDamaged(), moveShelfCopies err: %v", err)
+			return nil, ErrFailedDeclareCheckoutDamaged
+		}
+	}
+	if err := setCopyStatus(tx, ticket, status); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutDamaged(), setCopyStatus err: %v", err)
+		return nil, ErrFailedDeclareCheckoutDamaged
+	}
+
+	incident := ticket.incident(model.AuditActionCheckoutDamaged, status, damagedAt)
+	incident.Fee = fee
+	incident.FineAmount = fineAmount
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionCheckoutDamaged,
+		ActorID:    &librarianID,
+		UserID:     &ticket.UserID,
+		EntityType: "checkoutTicket",
+		EntityID:   ticket.ID,
+	}
+	details := map[string]interface{}{
+		"bookID": ticket.BookID, "barcode": incident.Barcode, "fee": fee, "restock": request.Restock, "note": request.Note,
+	}
+	if err := writeAuditLog(tx, &entry, details); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] DeclareCheckoutDamaged(), writeAuditLog err: %v", err)
+		return nil, ErrFailedDeclareCheckoutDamaged
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] DeclareCheckoutDamaged(), tx.Commit err: %v", err)
+		return nil, ErrFailedDeclareCheckoutDamaged
+	}
+
+	return incident, nil
+}
+
+// MarkCheckoutFound takes back a copy which was declared lost. The replacement fee is taken off
+// the fine of the ticket and the patron, the part which was already paid is owed back to the
+// patron, and the copy goes back on the shelf.
+func (l *LibraryService) MarkCheckoutFound(librarianID, ticketID string, request *model.MarkFoundRequest) (*model.CheckoutIncident, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] MarkCheckoutFound(), db.Begin err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] MarkCheckoutFound(), tx.Rollback err: %v", err)
+		}
+	}
+
+	ticket, err := lockIncidentTicket(tx, ticketID)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGetCheckoutTicketByIDNotFound
+		}
+		log.Error().Msgf("[Error] MarkCheckoutFound(), lockIncidentTicket err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+	if !ticket.LostAt.Valid || ticket.FoundAt.Valid {
+		rollback()
+		return nil, ErrCheckoutNotLost
+	}
+
+	foundAt := time.Now().UTC()
+	fee := ticket.IncidentFee
+	_, err = tx.Exec(`
+		UPDATE "checkout_tickets" SET
+			"foundAt" = $2,
+			"fineAmount" = GREATEST("fineAmount" - "incidentFee", 0),
+			"incidentFee" = 0,
+			"updatedAt" = $2,
+			"version" = "version" + 1
+		WHERE "ID" = $1;
+	`, ticket.ID, foundAt)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] MarkCheckoutFound(), tx.Exec err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+
+	fineAmount, refundDue, err := refundIncidentFee(tx, ticket.UserID, fee)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] MarkCheckoutFound(), refundIncidentFee err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+	if err := moveShelfCopies(tx, ticket.BookID, 1); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] MarkCheckoutFound(), moveShelfCopies err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+	if err := setCopyStatus(tx, ticket, model.CopyStatusAvailable); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] MarkCheckoutFound(), setCopyStatus err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+
+	incident := ticket.incident(model.AuditActionCheckoutFound, model.CopyStatusAvailable, foundAt)
+	incident.Fee = fee
+	incident.RefundDue = refundDue
+	incident.FineAmount = fineAmount
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionCheckoutFound,
+		ActorID:    &librarianID,
+		UserID:     &ticket.UserID,
+		EntityType: "checkoutTicket",
+		EntityID:   ticket.ID,
+	}
+	details := map[string]interface{}{
+		"bookID": ticket.BookID, "barcode": incident.Barcode, "refund": fee, "refundDue": refundDue, "note": request.Note,
+	}
+	if err := writeAuditLog(tx, &entry, details); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] MarkCheckoutFound(), writeAuditLog err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] MarkCheckoutFound(), tx.Commit err: %v", err)
+		return nil, ErrFailedMarkCheckoutFound
+	}
+
+	return incident, nil
+}
+
+// RestockBookCopy puts a damaged copy back on the shelf once it was repaired, so it can be lent at the
+// desk and at kiosks again
+func (l *LibraryService) RestockBookCopy(librarianID, barcode string, request *model.RestockCopyRequest) (*model.BookCopy, error) {
+	barcode, err := itemcode.Parse(barcode)
+	if err != nil {
+		return nil, ErrBookCopyNotFound
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] RestockBookCopy(), db.Begin err: %v", err)
+		return nil, ErrFailedRestockBookCopy
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] RestockBookCopy(), tx.Rollback err: %v", err)
+		}
+	}
+
+	damaged, err := lockKioskCopy(tx, barcode)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyNotFound
+		}
+		log.Error().Msgf("[Error] RestockBookCopy(), lockKioskCopy err: %v", err)
+		return nil, ErrFailedRestockBookCopy
+	}
+	if damaged.Withdrawn {
+		rollback()
+		return nil, ErrBookWithdrawn
+	}
+	if damaged.Status != model.CopyStatusDamaged {
+		rollback()
+		return nil, ErrCopyNotDamaged
+	}
+
+	if _, err := tx.Exec(`UPDATE "book_copies" SET "status" = $2 WHERE "barcode" = $1;`, barcode, model.CopyStatusAvailable); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RestockBookCopy(), tx.Exec err: %v", err)
+		return nil, ErrFailedRestockBookCopy
+	}
+	if err := moveShelfCopies(tx, damaged.BookID, 1); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RestockBookCopy(), moveShelfCopies err: %v", err)
+		return nil, ErrFailedRestockBookCopy
+	}
+
+	entry := model.AuditLog{
+		Action:     model.AuditActionCopyRestocked,
+		ActorID:    &librarianID,
+		EntityType: "bookCopy",
+		EntityID:   barcode,
+	}
+	details := map[string]interface{}{"bookID": damaged.BookID, "note": request.Note}
+	if err := writeAuditLog(tx, &entry, details); err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RestockBookCopy(), writeAuditLog err: %v", err)
+		return nil, ErrFailedRestockBookCopy
+	}
+
+	bookCopy, err := scanBookCopy(tx.QueryRow(bookCopyByBarcodeSQL, barcode))
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] RestockBookCopy(), scanBookCopy err: %v", err)
+		return nil, ErrFailedRestockBookCopy
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] RestockBookCopy(), tx.Commit err: %v", err)
+		return nil, ErrFailedRestockBookCopy
+	}
+
+	return bookCopy, nil
+}
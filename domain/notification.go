@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedCreateNotification is an error when storing a notification failed
+	ErrFailedCreateNotification = errors.New("create notification failed")
+	// ErrFailedGetNotifications is an error when listing notifications failed
+	ErrFailedGetNotifications = errors.New("get notifications failed")
+	// ErrFailedMarkNotificationRead is an error when marking a notification read failed
+	ErrFailedMarkNotificationRead = errors.New("mark notification read failed")
+	// ErrNotificationNotFound is an error when the notification is not found for the user
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+// createNotification stores a notification for the user, exec lets it join the caller's transaction
+func createNotification(exec execer, userID string, notificationType model.NotificationType, message, referenceID string) error {
+	sqlStatement := `
+		INSERT INTO "notifications"("userID", "type", "message", "referenceID")
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid);
+	`
+
+	if _, err := exec.Exec(sqlStatement, userID, notificationType, message, referenceID); err != nil {
+		log.Error().Msgf("[Error] createNotification(), Exec err: %v", err)
+		return ErrFailedCreateNotification
+	}
+
+	return nil
+}
+
+// GetNotifications returns the notifications of the user, the most recent first
+func (l *LibraryService) GetNotifications(userID string, request *model.GetNotificationsRequest) ([]model.Notification, uint, error) {
+	sqlStatement := `
+		SELECT
+			"ID",
+			"userID",
+			"type",
+			"message",
+			"referenceID",
+			"readAt",
+			"createdAt",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"notifications"
+		WHERE
+			"userID" = $1 AND ($2 = false OR "readAt" IS NULL)
+		ORDER BY
+			"createdAt" DESC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement, userID, request.UnreadOnly)
+	if err != nil {
+		log.Error().Msgf("[Error] GetNotifications(), db.Query err: %v", err)
+		return nil, 0, ErrFailedGetNotifications
+	}
+	defer rows.Close()
+
+	var (
+		notifications = []model.Notification{}
+		totalRows     uint32
+	)
+	for rows.Next() {
+		var (
+			notification model.Notification
+			referenceID  sql.NullString
+			readAt       sql.NullTime
+		)
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.Message,
+			&referenceID,
+			&readAt,
+			&notification.CreatedAt,
+			&totalRows,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetNotifications(), rows.Scan err: %v", err)
+			return nil, 0, ErrFailedGetNotifications
+		}
+		if referenceID.Valid {
+			notification.ReferenceID = &referenceID.String
+		}
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, notification)
+	}
+
+	// Calculate total pages
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return notifications, uint(totalPages), nil
+}
+
+// MarkNotificationRead marks a notification of the user read, marking it again keeps the first read time
+func (l *LibraryService) MarkNotificationRead(userID, notificationID string) error {
+	sqlStatement := `
+		UPDATE "notifications" SET
+			"readAt" = COALESCE("readAt", NOW())
+		WHERE
+			"ID" = $1 AND "userID" = $2;
+	`
+
+	res, err := l.db.Exec(sqlStatement, notificationID, userID)
+	if err != nil {
+		log.Error().Msgf("[Error] MarkNotificationRead(), db.Exec err: %v", err)
+		return ErrFailedMarkNotificationRead
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
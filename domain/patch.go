@@ -0,0 +1,253 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrVersionConflict is an error when a row was changed since the version the caller read
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// PatchBook stores a merge patched book if it is still at version and returns its new version
+func (l *LibraryService) PatchBook(bookID string, version int64, patch *model.BookPatch) (int64, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] PatchBook(), db.Begin err: %v", err)
+		return 0, ErrFailedUpdateBook
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] PatchBook(), tx.Rollback err: %v", err)
+		}
+	}
+
+	var (
+		ISBN           string
+		currentVersion int64
+		wasAvailable   bool
+	)
+	err = tx.QueryRow(`
+		SELECT "ISBN", "version", "inLibrary" AND "booksLeft" > 0 FROM "books" WHERE "ID" = $1 FOR UPDATE;
+	`, bookID).Scan(&ISBN, &currentVersion, &wasAvailable)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrUpdateBookNotFound
+		}
+		log.Error().Msgf("[Error] PatchBook(), tx.QueryRow err: %v", err)
+		return 0, ErrFailedUpdateBook
+	}
+	if currentVersion != version {
+		rollback()
+		return 0, ErrVersionConflict
+	}
+
+	// the primary author and genre stay the first of their lists
+	authors := primaryFirst(patch.Author, uniqueNames(patch.Authors))
+	genres := primaryFirst(patch.Genre, uniqueNames(patch.Genres))
+	author := truncateRunes(patch.Author, 50)
+
+	sqlStatement := `
+		UPDATE "books" SET
+			"title" = $2,
+			"author" = $3,
+			"genre" = $4,
+			"publishedDate" = $5,
+			"desc" = $6,
+			"previewLink" = $7,
+			"coverImage" = $8,
+			"shelfNumber" = $9,
+			"inLibrary" = $10,
+			"booksLeft" = $11,
+			"subtitle" = NULLIF($12, ''),
+			"publisher" = NULLIF($13, ''),
+			"pageCount" = $14,
+			"language" = NULLIF($15, ''),
+			"maturityRating" = NULLIF($16, ''),
+			"price" = $17,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE
+			"ID" = $1
+		RETURNING "version";
+	`
+
+	var newVersion int64
+	err = tx.QueryRow(
+		sqlStatement,
+		bookID,
+		patch.Title,
+		author,
+		patch.Genre,
+		patch.PublishedDate,
+		patch.Description,
+		patch.PreviewLink,
+		patch.CoverImage,
+		patch.ShelfNumber,
+		patch.InLibrary,
+		patch.BooksLeft,
+		patch.Subtitle,
+		patch.Publisher,
+		patch.PageCount,
+		patch.Language,
+		patch.MaturityRating,
+		patch.Price,
+	).Scan(&newVersion)
+	if err != nil {
+		log.Error().Msgf("[Error] PatchBook(), tx.QueryRow update err: %v", err)
+		rollback()
+		return 0, ErrFailedUpdateBook
+	}
+
+	if err := setBookAuthorsAndGenres(tx, ISBN, authors, genres); err != nil {
+		log.Error().Msgf("[Error] PatchBook(), setBookAuthorsAndGenres err: %v", err)
+		rollback()
+		return 0, ErrFailedUpdateBook
+	}
+
+	if !wasAvailable && patch.InLibrary && patch.BooksLeft > 0 {
+		if err := notifyWishlistBackInStock(tx, bookID); err != nil {
+			rollback()
+			return 0, ErrFailedUpdateBook
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] PatchBook(), tx.Commit err: %v", err)
+		return 0, ErrFailedUpdateBook
+	}
+
+	return newVersion, nil
+}
+
+// PatchUser stores a merge patched user if it is still at version and returns its new version
+func (l *LibraryService) PatchUser(userID string, version int64, patch *model.UserPatch) (int64, error) {
+	sqlStatement := `
+		UPDATE "users" SET
+			"profileImageUrl" = $3,
+			"name" = $4,
+			"role" = $5,
+			"dateOfBirth" = $6,
+			"phoneNumber" = $7,
+			"address" = $8,
+			"country" = $9,
+			"fineAmount" = $10,
+			"isPaymentDone" = $11,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE
+			"userID" = $1 AND "version" = $2
+		RETURNING "version";
+	`
+
+	var newVersion int64
+	err := l.db.QueryRow(
+		sqlStatement,
+		userID,
+		version,
+		patch.ProfileImageUrl,
+		patch.Name,
+		patch.Role,
+		patch.DateOfBirth,
+		patch.PhoneNumber,
+		patch.Address,
+		patch.Country,
+		patch.FineAmount,
+		patch.IsPaymentDone,
+	).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, l.versionMismatch("PatchUser", `SELECT 1 FROM "users" WHERE "userID" = $1;`, userID,
+				ErrGetUserWithBookDetailsNotFound, ErrFailedUpdateUser)
+		}
+		log.Error().Msgf("[Error] PatchUser(), db.QueryRow err: %v", err)
+		return 0, ErrFailedUpdateUser
+	}
+
+	return newVersion, nil
+}
+
+// PatchCheckoutTicket stores a merge patched checkout ticket if it is still at version and returns its new version
+func (l *LibraryService) PatchCheckoutTicket(ticketID string, version int64, patch *model.CheckoutTicketPatch) (int64, error) {
+	sqlStatement := `
+		UPDATE "checkout_tickets" SET
+			"isCheckedOut" = $3,
+			"isReturned" = $4,
+			"numberOfDays" = $5,
+			"fineAmount" = $6,
+			"checkedOutOn" = $7,
+			"returnedDate" = $8,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE
+			"ID" = $1 AND "version" = $2
+		RETURNING "version";
+	`
+
+	var newVersion int64
+	err := l.db.QueryRow(
+		sqlStatement,
+		ticketID,
+		version,
+		patch.IsCheckedOut,
+		patch.IsReturned,
+		patch.NumberOfDays,
+		patch.FineAmount,
+		nullTime(patch.CheckedOutOn),
+		nullTime(patch.ReturnedDate),
+	).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, l.versionMismatch("PatchCheckoutTicket", `SELECT 1 FROM "checkout_tickets" WHERE "ID" = $1;`, ticketID,
+				ErrGetCheckoutTicketByIDNotFound, ErrFailedUpdateCheckoutTicket)
+		}
+		log.Error().Msgf("[Error] PatchCheckoutTicket(), db.QueryRow err: %v", err)
+		return 0, ErrFailedUpdateCheckoutTicket
+	}
+
+	return newVersion, nil
+}
+
+// versionMismatch tells a versioned update which matched no row because the row is gone
+// apart from one which matched no row because it was changed in between
+func (l *LibraryService) versionMismatch(caller, existsStatement, key string, notFound, failed error) error {
+	var exists int
+	err := l.db.QueryRow(existsStatement, key).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound
+		}
+		log.Error().Msgf("[Error] %s(), db.QueryRow exists err: %v", caller, err)
+		return failed
+	}
+	return ErrVersionConflict
+}
+
+// primaryFirst returns names with primary in front, unless names is empty
+func primaryFirst(primary string, names []string) []string {
+	if len(names) == 0 || len(primary) == 0 {
+		return names
+	}
+	ordered := []string{primary}
+	for _, name := range names {
+		if name != primary {
+			ordered = append(ordered, name)
+		}
+	}
+	return ordered
+}
+
+// nullTime stores missing and zero times as NULL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil || t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
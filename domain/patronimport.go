@@ -0,0 +1,351 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"integrated-library-service/librarycard"
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedImportPatron is an error when importing a patron failed
+	ErrFailedImportPatron = errors.New("import patron failed")
+	// ErrCardNumberInUse is an error when a library card number already belongs to another user
+	ErrCardNumberInUse = errors.New("library card number is already in use")
+	// ErrFailedAcceptUserInvite is an error when accepting a user invite failed
+	ErrFailedAcceptUserInvite = errors.New("accept user invite failed")
+	// ErrUserInviteTokenInvalid is an error when an invite token is unknown or expired
+	ErrUserInviteTokenInvalid = errors.New("invite token is invalid or expired")
+	// ErrFailedIssueLibraryCard is an error when issuing a library card failed
+	ErrFailedIssueLibraryCard = errors.New("issue library card failed")
+	// ErrIssueLibraryCardNotFound is an error when the user of a new library card is not found
+	ErrIssueLibraryCardNotFound = errors.New("issue library card user not found")
+	// ErrFailedGetUserByCardNumber is an error when looking up a library card failed
+	ErrFailedGetUserByCardNumber = errors.New("get user by card number failed")
+	// ErrLibraryCardNotFound is an error when no open account has the library card number
+	ErrLibraryCardNotFound = errors.New("library card not found")
+)
+
+// cardNumberAttempts is how often a random card number is drawn before giving up on collisions
+const cardNumberAttempts = 5
+
+// ImportPatron creates the account of a patron file row with a library card and an invite to set
+// a password. A row whose email has an account is skipped, or updates it when updateExisting is
+// set. The invite is returned for mailing, a dry run rolls back and returns none.
+func (l *LibraryService) ImportPatron(patron *model.ImportPatron, updateExisting, dryRun bool) (*model.PatronImportRow, *model.UserInvite, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] ImportPatron(), db.Begin err: %v", err)
+		return nil, nil, ErrFailedImportPatron
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] ImportPatron(), tx.Rollback err: %v", err)
+		}
+	}
+
+	row := &model.PatronImportRow{Email: patron.Email, Name: patron.Name}
+	var (
+		currentCard sql.NullString
+		password    string
+	)
+	err = tx.QueryRow(`
+		SELECT "userID", "cardNumber", "password" FROM "users" WHERE "email" = $1 AND "deletedAt" IS NULL FOR UPDATE;
+	`, patron.Email).Scan(&row.UserID, &currentCard, &password)
+	exists := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		rollback()
+		log.Error().Msgf("[Error] ImportPatron(), tx.QueryRow err: %v", err)
+		return nil, nil, ErrFailedImportPatron
+	}
+
+	if exists && !updateExisting {
+		rollback()
+		row.Outcome = model.PatronImportOutcomeSkipped
+		row.CardNumber = currentCard.String
+		return row, nil, nil
+	}
+
+	row.CardNumber, err = cardNumberFor(tx, row.UserID, currentCard.String, patron.CardNumber)
+	if err != nil {
+		rollback()
+		return nil, nil, err
+	}
+	issued := row.CardNumber != currentCard.String
+
+	if exists {
+		_, err = tx.Exec(`
+			UPDATE "users" SET
+				"name" = $2,
+				"phoneNumber" = COALESCE($3::numeric, "phoneNumber"),
+				"address" = COALESCE($4, "address"),
+				"country" = COALESCE($5, "country"),
+				"dateOfBirth" = COALESCE($6::timestamp, "dateOfBirth"),
+				"cardNumber" = $7,
+				"updatedAt" = NOW(),
+				"version" = "version" + 1
+			WHERE
+				"userID" = $1;
+		`, row.UserID, patron.Name, patron.PhoneNumber, patron.Address, patron.Country, patron.DateOfBirth, row.CardNumber)
+		row.Outcome = model.PatronImportOutcomeUpdated
+	} else {
+		// the empty password matches no login until the invite is accepted
+		err = tx.QueryRow(`
+			INSERT INTO "users"(
+				"profileImageUrl",
+				"name",
+				"email",
+				"role",
+				"password",
+				"phoneNumber",
+				"address",
+				"country",
+				"dateOfBirth",
+				"cardNumber"
+			) VALUES ('', $1, $2, 'patrons', '', $3, $4, $5, $6, $7)
+			RETURNING "userID";
+		`, patron.Name, patron.Email, patron.PhoneNumber, patron.Address, patron.Country, patron.DateOfBirth, row.CardNumber).
+			Scan(&row.UserID)
+		if err == nil {
+			_, err = tx.Exec(`INSERT INTO "book_details"("userID") VALUES ($1);`, row.UserID)
+		}
+		row.Outcome = model.PatronImportOutcomeCreated
+	}
+	if err != nil {
+		rollback()
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			if strings.Contains(pqErr.Constraint, "cardNumber") {
+				return nil, nil, ErrCardNumberInUse
+			}
+			return nil, nil, ErrEmailInUse
+		}
+		log.Error().Msgf("[Error] ImportPatron(), tx.Exec %s err: %v", row.Outcome, err)
+		return nil, nil, ErrFailedImportPatron
+	}
+
+	if dryRun {
+		rollback()
+		if issued {
+			row.CardNumber = ""
+		}
+		if !exists {
+			row.UserID = ""
+		}
+		return row, nil, nil
+	}
+
+	// users who never set a password get a fresh invite, the earlier one is replaced
+	var invite *model.UserInvite
+	if !exists || len(password) == 0 {
+		token, err := newToken()
+		if err != nil {
+			rollback()
+			log.Error().Msgf("[Error] ImportPatron(), newToken err: %v", err)
+			return nil, nil, ErrFailedImportPatron
+		}
+		_, err = tx.Exec(`
+			INSERT INTO "user_invites"("userID", "tokenHash", "expiresAt")
+			VALUES ($1, $2, $3)
+			ON CONFLICT ("userID")
+			DO UPDATE SET
+				"tokenHash" = EXCLUDED."tokenHash",
+				"expiresAt" = EXCLUDED."expiresAt",
+				"createdAt" = NOW();
+		`, row.UserID, hashToken(token), time.Now().UTC().Add(model.UserInviteTTL))
+		if err != nil {
+			rollback()
+			log.Error().Msgf("[Error] ImportPatron(), tx.Exec invite err: %v", err)
+			return nil, nil, ErrFailedImportPatron
+		}
+		invite = &model.UserInvite{
+			UserID:     row.UserID,
+			Email:      patron.Email,
+			Name:       patron.Name,
+			CardNumber: row.CardNumber,
+			Token:      token,
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] ImportPatron(), tx.Commit err: %v", err)
+		return nil, nil, ErrFailedImportPatron
+	}
+
+	return row, invite, nil
+}
+
+// cardNumberFor returns the card number a user keeps or gets: the requested one when it is free,
+// else the current one, else a new one
+func cardNumberFor(tx *sql.Tx, userID, current, requested string) (string, error) {
+	if len(requested) > 0 {
+		var owner string
+		err := tx.QueryRow(`SELECT "userID" FROM "users" WHERE "cardNumber" = $1;`, requested).Scan(&owner)
+		if err == nil && owner != userID {
+			return "", ErrCardNumberInUse
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Msgf("[Error] cardNumberFor(), tx.QueryRow err: %v", err)
+			return "", ErrFailedImportPatron
+		}
+		return requested, nil
+	}
+	if len(current) > 0 {
+		return current, nil
+	}
+	return newCardNumber(tx)
+}
+
+// newCardNumber draws a card number no user has yet
+func newCardNumber(tx *sql.Tx) (string, error) {
+	for attempt := 0; attempt < cardNumberAttempts; attempt++ {
+		cardNumber, err := librarycard.Generate()
+		if err != nil {
+			log.Error().Msgf("[Error] newCardNumber(), librarycard.Generate err: %v", err)
+			return "", ErrFailedIssueLibraryCard
+		}
+
+		var taken bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM "users" WHERE "cardNumber" = $1);`, cardNumber).Scan(&taken); err != nil {
+			log.Error().Msgf("[Error] newCardNumber(), tx.QueryRow err: %v", err)
+			return "", ErrFailedIssueLibraryCard
+		}
+		if !taken {
+			return cardNumber, nil
+		}
+	}
+
+	log.Error().Msgf("[Error] newCardNumber(), no free card number after %d attempts", cardNumberAttempts)
+	return "", ErrFailedIssueLibraryCard
+}
+
+// AcceptUserInvite sets the first password of an imported user with a valid invite token
+func (l *LibraryService) AcceptUserInvite(token, passwordHash string) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] AcceptUserInvite(), db.Begin err: %v", err)
+		return ErrFailedAcceptUserInvite
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] AcceptUserInvite(), tx.Rollback err: %v", err)
+		}
+	}
+
+	// the invite is consumed whether it is still valid or not
+	var (
+		userID    string
+		expiresAt time.Time
+	)
+	err = tx.QueryRow(`
+		DELETE FROM "user_invites" WHERE "tokenHash" = $1
+		RETURNING "userID", "expiresAt";
+	`, hashToken(token)).Scan(&userID, &expiresAt)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserInviteTokenInvalid
+		}
+		log.Error().Msgf("[Error] AcceptUserInvite(), tx.QueryRow err: %v", err)
+		return ErrFailedAcceptUserInvite
+	}
+	if time.Now().UTC().After(expiresAt) {
+		if err := tx.Commit(); err != nil {
+			log.Error().Msgf("[Error] AcceptUserInvite(), tx.Commit err: %v", err)
+		}
+		return ErrUserInviteTokenInvalid
+	}
+
+	res, err := tx.Exec(`
+		UPDATE "users" SET
+			"password" = $1,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE
+			"userID" = $2 AND "deletedAt" IS NULL;
+	`, passwordHash, userID)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] AcceptUserInvite(), tx.Exec err: %v", err)
+		return ErrFailedAcceptUserInvite
+	}
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		rollback()
+		return ErrUserInviteTokenInvalid
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] AcceptUserInvite(), tx.Commit err: %v", err)
+		return ErrFailedAcceptUserInvite
+	}
+
+	return nil
+}
+
+// IssueLibraryCard gives a user a new card number, a lost card stops working right away
+func (l *LibraryService) IssueLibraryCard(userID string) (string, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] IssueLibraryCard(), db.Begin err: %v", err)
+		return "", ErrFailedIssueLibraryCard
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] IssueLibraryCard(), tx.Rollback err: %v", err)
+		}
+	}
+
+	cardNumber, err := newCardNumber(tx)
+	if err != nil {
+		rollback()
+		return "", err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE "users" SET
+			"cardNumber" = $1,
+			"updatedAt" = NOW(),
+			"version" = "version" + 1
+		WHERE
+			"userID" = $2 AND "deletedAt" IS NULL;
+	`, cardNumber, userID)
+	if err != nil {
+		rollback()
+		log.Error().Msgf("[Error] IssueLibraryCard(), tx.Exec err: %v", err)
+		return "", ErrFailedIssueLibraryCard
+	}
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		rollback()
+		return "", ErrIssueLibraryCardNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] IssueLibraryCard(), tx.Commit err: %v", err)
+		return "", ErrFailedIssueLibraryCard
+	}
+
+	return cardNumber, nil
+}
+
+// GetUserIDByCardNumber returns the open account a library card belongs to
+func (l *LibraryService) GetUserIDByCardNumber(cardNumber string) (string, error) {
+	var userID string
+	err := l.db.QueryRow(`
+		SELECT "userID" FROM "users" WHERE "cardNumber" = $1 AND "deletedAt" IS NULL;
+	`, cardNumber).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrLibraryCardNotFound
+		}
+		log.Error().Msgf("[Error] GetUserIDByCardNumber(), db.QueryRow err: %v", err)
+		return "", ErrFailedGetUserByCardNumber
+	}
+
+	return userID, nil
+}
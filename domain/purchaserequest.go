@@ -0,0 +1,444 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedCreatePurchaseRequest is an error when filing a purchase request failed
+	ErrFailedCreatePurchaseRequest = errors.New("create purchase request failed")
+	// ErrPurchaseRequestExists is an error when the patron already has an open request for the book
+	ErrPurchaseRequestExists = errors.New("an open purchase request for this book already exists")
+	// ErrPurchaseRequestBookInLibrary is an error when the requested book is already in the library
+	ErrPurchaseRequestBookInLibrary = errors.New("book is already in the library")
+	// ErrPurchaseRequestBookNotFound is an error when the requested book ID is not in the catalogue
+	ErrPurchaseRequestBookNotFound = errors.New("purchase request book not found")
+	// ErrPurchaseRequestBookNotInCatalogue is an error when a received book has to be added to the catalogue first
+	ErrPurchaseRequestBookNotInCatalogue = errors.New("add the received book to the catalogue first")
+	// ErrFailedGetPurchaseRequests is an error when listing purchase requests failed
+	ErrFailedGetPurchaseRequests = errors.New("get purchase requests failed")
+	// ErrPurchaseRequestNotFound is an error when the purchase request is not found
+	ErrPurchaseRequestNotFound = errors.New("purchase request not found")
+	// ErrInvalidPurchaseRequestTransition is an error when the purchase request can not move to the status
+	ErrInvalidPurchaseRequestTransition = errors.New("purchase request can not move to this status")
+	// ErrFailedUpdatePurchaseRequest is an error when changing the status of a purchase request failed
+	ErrFailedUpdatePurchaseRequest = errors.New("update purchase request failed")
+)
+
+// purchaseRequestColumns is the column list scanned by scanPurchaseRequest
+const purchaseRequestColumns = `
+	"ID",
+	"userID",
+	"bookID",
+	"ISBN",
+	COALESCE("title", ''),
+	COALESCE("note", ''),
+	"status",
+	"holdID",
+	"createdAt",
+	"updatedAt"`
+
+// scanPurchaseRequest scans a row selected with purchaseRequestColumns
+func scanPurchaseRequest(row rowScanner) (*model.PurchaseRequest, error) {
+	var (
+		request   model.PurchaseRequest
+		bookID    sql.NullString
+		holdID    sql.NullString
+		updatedAt sql.NullTime
+	)
+	err := row.Scan(
+		&request.ID,
+		&request.UserID,
+		&bookID,
+		&request.ISBN,
+		&request.Title,
+		&request.Note,
+		&request.Status,
+		&holdID,
+		&request.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if bookID.Valid {
+		request.BookID = &bookID.String
+	}
+	if holdID.Valid {
+		request.HoldID = &holdID.String
+	}
+	if updatedAt.Valid {
+		request.UpdatedAt = &updatedAt.Time
+	}
+	return &request, nil
+}
+
+// CreatePurchaseRequest files a purchase request of the user for a catalogue book or a bare ISBN
+func (l *LibraryService) CreatePurchaseRequest(userID string, request *model.CreatePurchaseRequestRequest) (*model.PurchaseRequest, error) {
+	var (
+		ISBN      string
+		bookID    sql.NullString
+		title     = request.Title
+		inLibrary bool
+	)
+
+	if len(request.BookID) != 0 {
+		err := l.db.QueryRow(`SELECT "ID", "ISBN", "title", "inLibrary" FROM "books" WHERE "ID" = $1;`, request.BookID).
+			Scan(&bookID, &ISBN, &title, &inLibrary)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPurchaseRequestBookNotFound
+		}
+		if err != nil {
+			log.Error().Msgf("[Error] CreatePurchaseRequest(), db.QueryRow book err: %v", err)
+			return nil, ErrFailedCreatePurchaseRequest
+		}
+	} else {
+		ISBN13, ISBN10, err := isbn.Parse(request.ISBN)
+		if err != nil {
+			return nil, ErrInvalidISBN
+		}
+		ISBN = ISBN13
+
+		var bookTitle string
+		err = l.db.QueryRow(`SELECT "ID", "title", "inLibrary" FROM "books" WHERE "ISBN" = $1 OR "ISBN10" = NULLIF($2, '');`, ISBN13, ISBN10).
+			Scan(&bookID, &bookTitle, &inLibrary)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Msgf("[Error] CreatePurchaseRequest(), db.QueryRow book err: %v", err)
+			return nil, ErrFailedCreatePurchaseRequest
+		}
+		if len(title) == 0 {
+			title = bookTitle
+		}
+	}
+
+	if inLibrary {
+		return nil, ErrPurchaseRequestBookInLibrary
+	}
+	title = truncateRunes(title, 100)
+
+	sqlStatement := `
+		INSERT INTO "purchase_requests"("userID", "bookID", "ISBN", "title", "note")
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+		ON CONFLICT ("userID", "ISBN") WHERE "status" NOT IN ('received', 'rejected')
+		DO NOTHING
+		RETURNING ` + purchaseRequestColumns + `;
+	`
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] CreatePurchaseRequest(), db.Begin err: %v", err)
+		return nil, ErrFailedCreatePurchaseRequest
+	}
+
+	created, err := scanPurchaseRequest(tx.QueryRow(sqlStatement, userID, bookID, ISBN, title, request.Note))
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] CreatePurchaseRequest(), tx.Rollback err: %v", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPurchaseRequestExists
+		}
+		log.Error().Msgf("[Error] CreatePurchaseRequest(), tx.QueryRow err: %v", err)
+		return nil, ErrFailedCreatePurchaseRequest
+	}
+
+	if err := addPurchaseRequestEvent(tx, created.ID, created.Status, "", userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] CreatePurchaseRequest(), tx.Rollback err: %v", err)
+		}
+		return nil, ErrFailedCreatePurchaseRequest
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] CreatePurchaseRequest(), tx.Commit err: %v", err)
+		return nil, ErrFailedCreatePurchaseRequest
+	}
+
+	return created, nil
+}
+
+// GetPurchaseRequests returns the purchase requests, optionally of a single patron or status, the oldest first
+// so librarians handle them in order
+func (l *LibraryService) GetPurchaseRequests(request *model.GetPurchaseRequestsRequest) ([]model.PurchaseRequest, uint, error) {
+	sqlStatement := `
+		SELECT
+			` + purchaseRequestColumns + `,
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"purchase_requests"
+		WHERE
+			%s
+		ORDER BY
+			"createdAt" ASC
+		%s; -- limit and offset
+	`
+
+	args := []interface{}{}
+	conditions := []string{"TRUE"}
+	if len(request.UserID) != 0 {
+		args = append(args, request.UserID)
+		conditions = append(conditions, fmt.Sprintf(`"userID" = $%d`, len(args)))
+	}
+	if len(request.Status) != 0 {
+		args = append(args, request.Status)
+		conditions = append(conditions, fmt.Sprintf(`"status" = $%d`, len(args)))
+	}
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, strings.Join(conditions, " AND "), limitOffset)
+
+	rows, err := l.db.Query(sqlStatement, args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetPurchaseRequests(), db.Query err: %v", err)
+		return nil, 0, ErrFailedGetPurchaseRequests
+	}
+	defer rows.Close()
+
+	var (
+		requests  = []model.PurchaseRequest{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		purchaseRequest, err := scanPurchaseRequest(scanWithExtra(rows, &totalRows))
+		if err != nil {
+			log.Error().Msgf("[Error] GetPurchaseRequests(), rows.Scan err: %v", err)
+			return nil, 0, ErrFailedGetPurchaseRequests
+		}
+		requests = append(requests, *purchaseRequest)
+	}
+
+	// Calculate total pages
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return requests, uint(totalPages), nil
+}
+
+// GetPurchaseRequestByID returns a purchase request with its status history
+func (l *LibraryService) GetPurchaseRequestByID(requestID string) (*model.PurchaseRequest, error) {
+	sqlStatement := `
+		SELECT ` + purchaseRequestColumns + ` FROM "purchase_requests" WHERE "ID" = $1;
+	`
+
+	purchaseRequest, err := scanPurchaseRequest(l.db.QueryRow(sqlStatement, requestID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPurchaseRequestNotFound
+	}
+	if err != nil {
+		log.Error().Msgf("[Error] GetPurchaseRequestByID(), db.QueryRow err: %v", err)
+		return nil, ErrFailedGetPurchaseRequests
+	}
+
+	rows, err := l.db.Query(`
+		SELECT "status", COALESCE("comment", ''), "changedBy", "createdAt"
+		FROM "purchase_request_events"
+		WHERE "requestID" = $1
+		ORDER BY "createdAt" ASC;
+	`, requestID)
+	if err != nil {
+		log.Error().Msgf("[Error] GetPurchaseRequestByID(), db.Query history err: %v", err)
+		return nil, ErrFailedGetPurchaseRequests
+	}
+	defer rows.Close()
+
+	purchaseRequest.History = []model.PurchaseRequestEvent{}
+	for rows.Next() {
+		var (
+			event     model.PurchaseRequestEvent
+			changedBy sql.NullString
+		)
+		if err := rows.Scan(&event.Status, &event.Comment, &changedBy, &event.CreatedAt); err != nil {
+			log.Error().Msgf("[Error] GetPurchaseRequestByID(), rows.Scan err: %v", err)
+			return nil, ErrFailedGetPurchaseRequests
+		}
+		if changedBy.Valid {
+			event.ChangedBy = &changedBy.String
+		}
+		purchaseRequest.History = append(purchaseRequest.History, event)
+	}
+
+	return purchaseRequest, nil
+}
+
+// UpdatePurchaseRequestStatus moves a purchase request to its next status, records the librarian's comment
+// and notifies the requester. A received book is put in the library with the received copies and the
+// requester gets a hold on it, all in the same transaction.
+func (l *LibraryService) UpdatePurchaseRequestStatus(requestID, librarianID string, request *model.UpdatePurchaseRequestStatusRequest) (*model.PurchaseRequest, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), db.Begin err: %v", err)
+		return nil, ErrFailedUpdatePurchaseRequest
+	}
+
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), tx.Rollback err: %v", err)
+		}
+	}
+
+	current, err := scanPurchaseRequest(tx.QueryRow(`
+		SELECT `+purchaseRequestColumns+` FROM "purchase_requests" WHERE "ID" = $1 FOR UPDATE;
+	`, requestID))
+	if errors.Is(err, sql.ErrNoRows) {
+		rollback()
+		return nil, ErrPurchaseRequestNotFound
+	}
+	if err != nil {
+		log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), tx.QueryRow err: %v", err)
+		rollback()
+		return nil, ErrFailedUpdatePurchaseRequest
+	}
+
+	if !current.Status.CanMoveTo(request.Status) {
+		rollback()
+		return nil, ErrInvalidPurchaseRequestTransition
+	}
+
+	var bookID, holdID sql.NullString
+	if current.BookID != nil {
+		bookID = sql.NullString{String: *current.BookID, Valid: true}
+	}
+
+	if request.Status == model.PurchaseRequestStatusReceived {
+		if !bookID.Valid {
+			err := tx.QueryRow(`SELECT "ID" FROM "books" WHERE "ISBN" = $1;`, current.ISBN).Scan(&bookID)
+			if errors.Is(err, sql.ErrNoRows) {
+				rollback()
+				return nil, ErrPurchaseRequestBookNotInCatalogue
+			}
+			if err != nil {
+				log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), tx.QueryRow book err: %v", err)
+				rollback()
+				return nil, ErrFailedUpdatePurchaseRequest
+			}
+		}
+
+		copies := request.Copies
+		if copies <= 0 {
+			copies = 1
+		}
+		var wasAvailable bool
+		err := tx.QueryRow(`
+			UPDATE "books" SET
+				"inLibrary" = true,
+				"booksLeft" = "books"."booksLeft" + $2,
+				"updatedAt" = NOW(),
+				"version" = "books"."version" + 1
+			FROM (
+				SELECT "inLibrary" AND "booksLeft" > 0 AS "wasAvailable" FROM "books" WHERE "ID" = $1 FOR UPDATE
+			) AS "previous"
+			WHERE
+				"books"."ID" = $1
+			RETURNING "previous"."wasAvailable";
+		`, bookID.String, copies).Scan(&wasAvailable)
+		if err != nil {
+			log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), tx.QueryRow book err: %v", err)
+			rollback()
+			return nil, ErrFailedUpdatePurchaseRequest
+		}
+
+		// a requester who already has an open ticket for the book keeps it instead of a second hold
+		err = tx.QueryRow(`
+			SELECT "ID" FROM "checkout_tickets"
+			WHERE "bookID" = $1 AND "userID" = $2 AND "isReturned" = false
+			LIMIT 1;
+		`, bookID.String, current.UserID).Scan(&holdID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tx.QueryRow(`
+				INSERT INTO "checkout_tickets"("bookID", "userID", "numberOfDays", "reservedOn")
+				VALUES ($1, $2, $3, NOW())
+				RETURNING "ID";
+			`, bookID.String, current.UserID, model.PurchaseRequestHoldDays).Scan(&holdID)
+			if err == nil {
+				// the held copy is kept for the requester and leaves the shelf
+				err = moveShelfCopies(tx, bookID.String, -1)
+			}
+		}
+		if err != nil {
+			log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), hold err: %v", err)
+			rollback()
+			return nil, ErrFailedUpdatePurchaseRequest
+		}
+
+		// only the copies left after the hold are announced to wishlists
+		if !wasAvailable {
+			if err := notifyWishlistBackInStock(tx, bookID.String); err != nil {
+				rollback()
+				return nil, ErrFailedUpdatePurchaseRequest
+			}
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE "purchase_requests" SET
+			"status" = $2,
+			"bookID" = $3,
+			"holdID" = COALESCE($4, "holdID"),
+			"updatedAt" = NOW()
+		WHERE
+			"ID" = $1;
+	`, requestID, request.Status, bookID, holdID)
+	if err != nil {
+		log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), tx.Exec err: %v", err)
+		rollback()
+		return nil, ErrFailedUpdatePurchaseRequest
+	}
+
+	if err := addPurchaseRequestEvent(tx, requestID, request.Status, request.Comment, librarianID); err != nil {
+		rollback()
+		return nil, ErrFailedUpdatePurchaseRequest
+	}
+
+	message := purchaseRequestMessage(current, request.Status, request.Comment)
+	if err := createNotification(tx, current.UserID, model.NotificationTypePurchaseRequest, message, requestID); err != nil {
+		rollback()
+		return nil, ErrFailedUpdatePurchaseRequest
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] UpdatePurchaseRequestStatus(), tx.Commit err: %v", err)
+		return nil, ErrFailedUpdatePurchaseRequest
+	}
+
+	return l.GetPurchaseRequestByID(requestID)
+}
+
+// addPurchaseRequestEvent records a status of a purchase request in its history
+func addPurchaseRequestEvent(exec execer, requestID string, status model.PurchaseRequestStatus, comment, changedBy string) error {
+	sqlStatement := `
+		INSERT INTO "purchase_request_events"("requestID", "status", "comment", "changedBy")
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, '')::uuid);
+	`
+
+	if _, err := exec.Exec(sqlStatement, requestID, status, comment, changedBy); err != nil {
+		log.Error().Msgf("[Error] addPurchaseRequestEvent(), Exec err: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// purchaseRequestMessage is the notification sent to the requester when their request changes status
+func purchaseRequestMessage(request *model.PurchaseRequest, status model.PurchaseRequestStatus, comment string) string {
+	book := request.Title
+	if len(book) == 0 {
+		book = "ISBN " + request.ISBN
+	}
+
+	message := fmt.Sprintf("Your purchase request for %s is now %s.", book, strings.ReplaceAll(string(status), "_", " "))
+	if status == model.PurchaseRequestStatusReceived {
+		message += " The book is in the library and a hold has been placed for you."
+	}
+	if len(comment) != 0 {
+		message += " Librarian comment: " + comment
+	}
+	return message
+}
@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrGetRecommendedBooksFailed is an error when computing recommendations failed
+	ErrGetRecommendedBooksFailed = errors.New("get recommended books failed")
+)
+
+// recommendationInteractions is every patron to book signal with its weight and the kind of its strongest
+// signal. A returned loan counts the most, a review counts by how much the patron liked the book and a
+// wishlist entry is a weak signal, every signal loses half its weight every half life in seconds of the
+// parameter $6. Low ratings weigh nothing, the book is still never recommended back to the patron.
+const recommendationInteractions = `
+	"interactions" AS (
+		SELECT
+			"userID",
+			"bookID",
+			MAX("weight") AS "weight",
+			(ARRAY_AGG("kind" ORDER BY "weight" DESC, "kind"))[1] AS "kind"
+		FROM (
+			SELECT
+				"userID",
+				"bookID",
+				CASE WHEN "isReturned" THEN 3 WHEN "isCheckedOut" THEN 2 ELSE 1 END::float *
+				POWER(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - COALESCE("returnedDate", "checkedOutOn", "reservedOn"))::float, 0) / $6::float) AS "weight",
+				CASE WHEN "isReturned" THEN 'read' WHEN "isCheckedOut" THEN 'borrowed' ELSE 'reserved' END AS "kind"
+			FROM "checkout_tickets"
+			UNION ALL
+			SELECT
+				"userID",
+				"bookID",
+				GREATEST("rating" - 2, 0)::float * POWER(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - "createdAt")::float, 0) / $6::float),
+				'reviewed'
+			FROM "reviews"
+			UNION ALL
+			SELECT
+				"userID",
+				"bookID",
+				POWER(0.5, GREATEST(EXTRACT(EPOCH FROM NOW() - "addedAt")::float, 0) / $6::float),
+				'wishlisted'
+			FROM "wishlist_adds"
+		) AS "signals"
+		GROUP BY "userID", "bookID"
+	)`
+
+// recommendationExplanations words why a book is recommended by how the patron came across the seed book
+var recommendationExplanations = map[model.RecommendationSeed]string{
+	model.RecommendationSeedRead:       "Because you read %s",
+	model.RecommendationSeedBorrowed:   "Because you are reading %s",
+	model.RecommendationSeedReserved:   "Because you reserved %s",
+	model.RecommendationSeedReviewed:   "Because you reviewed %s",
+	model.RecommendationSeedWishlisted: "Because you wishlisted %s",
+}
+
+// GetRecommendedBooksForUser recommends books by combining item-item collaborative filtering over what other
+// patrons borrowed, reviewed and wishlisted with content similarity on shared authors and genres. Books the
+// user already read or wishlisted are never recommended. Each book carries the book of the user which
+// contributed most to its score and how the user came across it, the list is padded with books popular with other patrons and ties are broken by ID
+// so the order is stable between calls.
+func (l *LibraryService) GetRecommendedBooksForUser(userID string, request *model.GetRecommendedBooksForUserRequest) ([]model.RecommendedBook, uint, error) {
+	sqlStatement := `
+		WITH ` + recommendationInteractions + `,
+		"seeds" AS (
+			SELECT "bookID", "weight", "kind" FROM "interactions" WHERE "userID" = $1
+		),
+		"popularity" AS (
+			SELECT "bookID", COUNT(*)::float AS "readers" FROM "interactions" GROUP BY "bookID"
+		),
+		-- patrons who interacted with a seed book and what else they interacted with
+		"collaborative" AS (
+			SELECT
+				other."bookID" AS "candidateID",
+				s."bookID" AS "seedID",
+				s."weight" * COUNT(*)::float / SQRT(ps."readers" * pc."readers") AS "score"
+			FROM "seeds" s
+			JOIN "interactions" peer ON peer."bookID" = s."bookID" AND peer."userID" <> $1 AND peer."weight" > 0
+			JOIN "interactions" other ON other."userID" = peer."userID" AND other."bookID" <> s."bookID" AND other."weight" > 0
+			JOIN "popularity" ps ON ps."bookID" = s."bookID"
+			JOIN "popularity" pc ON pc."bookID" = other."bookID"
+			WHERE s."weight" > 0
+			GROUP BY other."bookID", s."bookID", s."weight", ps."readers", pc."readers"
+		),
+		-- books sharing an author or a genre with a seed book
+		"content" AS (
+			SELECT m."candidateID", m."seedID", s."weight" * SUM(m."match") AS "score"
+			FROM (
+				SELECT ca."bookID" AS "candidateID", sa."bookID" AS "seedID", $2::float AS "match"
+				FROM "book_authors" sa
+				JOIN "book_authors" ca ON ca."authorID" = sa."authorID" AND ca."bookID" <> sa."bookID"
+				WHERE sa."bookID" IN (SELECT "bookID" FROM "seeds" WHERE "weight" > 0)
+				UNION ALL
+				SELECT cg."bookID", sg."bookID", $3::float
+				FROM "book_genres" sg
+				JOIN "book_genres" cg ON cg."genreID" = sg."genreID" AND cg."bookID" <> sg."bookID"
+				WHERE sg."bookID" IN (SELECT "bookID" FROM "seeds" WHERE "weight" > 0)
+			) AS m
+			JOIN "seeds" s ON s."bookID" = m."seedID"
+			GROUP BY m."candidateID", m."seedID", s."weight"
+		),
+		"pairs" AS (
+			SELECT "candidateID", "seedID", SUM("score") AS "score"
+			FROM (
+				SELECT "candidateID", "seedID", "score" * $4::float AS "score" FROM "collaborative"
+				UNION ALL
+				SELECT "candidateID", "seedID", "score" * $5::float FROM "content"
+			) AS "scored"
+			GROUP BY "candidateID", "seedID"
+		),
+		"ranked" AS (
+			SELECT
+				"candidateID",
+				SUM("score") AS "score",
+				(ARRAY_AGG("seedID" ORDER BY "score" DESC, "seedID"))[1] AS "becauseID"
+			FROM "pairs"
+			WHERE "candidateID" NOT IN (SELECT "bookID" FROM "interactions" WHERE "userID" = $1)
+			GROUP BY "candidateID"
+		),
+		-- the rest of what other patrons read, most read first, pads the list and covers new patrons
+		"results" AS (
+			SELECT "candidateID", "score", "becauseID" FROM "ranked"
+			UNION ALL
+			SELECT "bookID", SUM("weight"), NULL::uuid
+			FROM "interactions"
+			WHERE
+				"bookID" NOT IN (SELECT "bookID" FROM "interactions" WHERE "userID" = $1) AND
+				"bookID" NOT IN (SELECT "candidateID" FROM "ranked")
+			GROUP BY "bookID"
+		)
+		SELECT
+			` + bookColumns + `,
+			"results"."score",
+			"because"."becauseISBN",
+			"because"."becauseTitle",
+			"becauseSeed"."kind",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"results"
+		JOIN "books" ON "books"."ID" = "results"."candidateID"
+		LEFT JOIN LATERAL (
+			SELECT "ISBN" AS "becauseISBN", "title" AS "becauseTitle" FROM "books" AS "seedBooks" WHERE "seedBooks"."ID" = "results"."becauseID"
+		) AS "because" ON true
+		LEFT JOIN "seeds" AS "becauseSeed" ON "becauseSeed"."bookID" = "results"."becauseID"
+		WHERE
+			"books"."withdrawnAt" IS NULL
+		ORDER BY
+			"results"."becauseID" IS NULL, "results"."score" DESC, "books"."ID" ASC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(
+		sqlStatement,
+		userID,
+		model.RecommendationAuthorMatch,
+		model.RecommendationGenreMatch,
+		model.RecommendationCollaborativeWeight,
+		model.RecommendationContentWeight,
+		model.RecommendationHalfLife.Seconds(),
+	)
+	if err != nil {
+		log.Error().Msgf("[Error] GetRecommendedBooksForUser(), db.Query err: %v", err)
+		return nil, 0, ErrGetRecommendedBooksFailed
+	}
+	defer rows.Close()
+
+	var (
+		books     = []model.RecommendedBook{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		var (
+			recommended  model.RecommendedBook
+			becauseISBN  sql.NullString
+			becauseTitle sql.NullString
+			becauseKind  sql.NullString
+		)
+		book, err := scanBook(scanWithExtra(rows, &recommended.Score, &becauseISBN, &becauseTitle, &becauseKind, &totalRows))
+		if err != nil {
+			log.Error().Msgf("[Error] GetRecommendedBooksForUser(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetRecommendedBooksFailed
+		}
+		recommended.Book = *book
+		recommended.BecauseYouReadISBN = becauseISBN.String
+		recommended.BecauseYouReadTitle = becauseTitle.String
+		recommended.BecauseOf = model.RecommendationSeed(becauseKind.String)
+		recommended.Explanation = "Popular with other readers"
+		if explanation, ok := recommendationExplanations[recommended.BecauseOf]; ok && becauseTitle.Valid {
+			recommended.Explanation = fmt.Sprintf(explanation, becauseTitle.String)
+		}
+		books = append(books, recommended)
+	}
+
+	// Calculate total pages
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return books, uint(totalPages), nil
+}
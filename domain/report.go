@@ -0,0 +1,618 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrGetReportFailed is an error when building a report failed
+	ErrGetReportFailed = errors.New("get report failed")
+	// ErrReportRangeInvalid is an error when the last day of a report is before its first day
+	ErrReportRangeInvalid = errors.New("to must not be before from")
+)
+
+// loanDueDate is the day a loan is due, numberOfDays after its checkout
+const loanDueDate = `(ct."checkedOutOn" + make_interval(days => ct."numberOfDays"::int))::date`
+
+// reportFilter collects the conditions of a report query with their arguments
+type reportFilter struct {
+	conditions string
+	args       []interface{}
+}
+
+// newReportFilter returns a filter of the conditions, the arguments are numbered from $1
+func newReportFilter(conditions string, args ...interface{}) *reportFilter {
+	return &reportFilter{conditions: conditions, args: args}
+}
+
+// add adds a condition on the next argument
+func (f *reportFilter) add(condition string, value interface{}) {
+	f.args = append(f.args, value)
+	f.conditions += fmt.Sprintf(" AND "+condition, len(f.args))
+}
+
+// days narrows column to the days from and to, the whole last day is included
+func (f *reportFilter) days(column string, from, to *time.Time) {
+	if from != nil {
+		f.add(column+` >= $%d::date`, from.Format("2006-01-02"))
+	}
+	if to != nil {
+		f.add(column+` < $%d::date + 1`, to.Format("2006-01-02"))
+	}
+}
+
+// reportToday is the current day in UTC, the day reports count days overdue and waiting from
+func reportToday() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// reportDays returns the first and last day of a report, the days of the request replace the
+// defaults and nil days leave the report open on that end
+func reportDays(request *model.ReportRequest, from, to *time.Time) (*time.Time, *time.Time, error) {
+	if request.From != nil {
+		from = request.From
+	}
+	if request.To != nil {
+		to = request.To
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, nil, ErrReportRangeInvalid
+	}
+	return from, to, nil
+}
+
+// reportLimit returns the limit and offset of a report page, a zero limit returns every row
+func reportLimit(request *model.ReportRequest) string {
+	if request.Limit == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` LIMIT %d OFFSET %d`, request.Limit, (request.Page-1)*(request.Limit))
+}
+
+// reportPages returns the number of pages of a report, a report without a limit is a single page
+func reportPages(request *model.ReportRequest, totalRows uint32) uint {
+	if request.Limit == 0 {
+		return 1
+	}
+	return uint((totalRows + request.Limit - 1) / request.Limit)
+}
+
+// GetOverdueReport returns the patrons with loans past their due date with those loans, the
+// patrons with the most overdue loan first. The days narrow the due dates of the loans.
+func (l *LibraryService) GetOverdueReport(request *model.ReportRequest) ([]model.OverduePatron, uint, error) {
+	from, to, err := reportDays(request, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := newReportFilter(
+		`ct."isCheckedOut" = true AND ct."isReturned" = false AND `+loanDueDate+` < $1::date`,
+		reportToday().Format("2006-01-02"),
+	)
+	filter.days(loanDueDate, from, to)
+
+	sqlStatement := `
+		SELECT
+			u."userID",
+			u."name",
+			u."email",
+			COALESCE(u."cardNumber", ''),
+			u."fineAmount",
+			u."isPaymentDone",
+			MAX($1::date - ` + loanDueDate + `) AS "maxDaysOverdue",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"checkout_tickets" ct
+		INNER JOIN
+			"users" u ON u."userID" = ct."userID"
+		WHERE
+			%s
+		GROUP BY
+			u."userID"
+		ORDER BY
+			"maxDaysOverdue" DESC, u."name" ASC, u."userID" ASC
+		%s; -- limit and offset
+	`
+	sqlStatement = fmt.Sprintf(sqlStatement, filter.conditions, reportLimit(request))
+
+	rows, err := l.db.Query(sqlStatement, filter.args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetOverdueReport(), db.Query err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	defer rows.Close()
+
+	var (
+		patrons   = []model.OverduePatron{}
+		userIDs   = []string{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		patron := model.OverduePatron{Loans: []model.OverdueLoan{}}
+		err := rows.Scan(
+			&patron.UserID,
+			&patron.Name,
+			&patron.Email,
+			&patron.CardNumber,
+			&patron.FineAmount,
+			&patron.IsPaymentDone,
+			&patron.MaxDaysOverdue,
+			&totalRows,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetOverdueReport(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetReportFailed
+		}
+		patrons = append(patrons, patron)
+		userIDs = append(userIDs, patron.UserID)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetOverdueReport(), rows.Err err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	if len(patrons) == 0 {
+		return patrons, reportPages(request, totalRows), nil
+	}
+
+	filter.add(`ct."userID" = ANY($%d)`, pq.Array(userIDs))
+	loansStatement := `
+		SELECT
+			ct."userID",
+			ct."ID",
+			b."ISBN",
+			b."title",
+			b."author",
+			COALESCE(ct."copyBarcode", ''),
+			ct."checkedOutOn",
+			` + loanDueDate + `::timestamp,
+			$1::date - ` + loanDueDate + `,
+			ct."fineAmount"
+		FROM
+			"checkout_tickets" ct
+		INNER JOIN
+			"books" b ON b."ID" = ct."bookID"
+		WHERE
+			%s
+		ORDER BY
+			ct."checkedOutOn" ASC, ct."ID" ASC;
+	`
+	loansStatement = fmt.Sprintf(loansStatement, filter.conditions)
+
+	loanRows, err := l.db.Query(loansStatement, filter.args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetOverdueReport(), loans db.Query err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	defer loanRows.Close()
+
+	byUser := make(map[string]*model.OverduePatron, len(patrons))
+	for i := range patrons {
+		byUser[patrons[i].UserID] = &patrons[i]
+	}
+	for loanRows.Next() {
+		var (
+			userID string
+			loan   model.OverdueLoan
+		)
+		err := loanRows.Scan(
+			&userID,
+			&loan.CheckoutID,
+			&loan.ISBN,
+			&loan.Title,
+			&loan.Author,
+			&loan.Barcode,
+			&loan.CheckedOutOn,
+			&loan.DueDate,
+			&loan.DaysOverdue,
+			&loan.FineAmount,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetOverdueReport(), loans rows.Scan err: %v", err)
+			return nil, 0, ErrGetReportFailed
+		}
+		if patron, ok := byUser[userID]; ok {
+			patron.Loans = append(patron.Loans, loan)
+		}
+	}
+	if err := loanRows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetOverdueReport(), loans rows.Err err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+
+	return patrons, reportPages(request, totalRows), nil
+}
+
+// GetDueReport returns the loans due in the days of the request, today and the next six days when
+// no days are given, the earliest due first
+func (l *LibraryService) GetDueReport(request *model.ReportRequest) ([]model.DueLoan, uint, error) {
+	today := reportToday()
+	lastDay := today.AddDate(0, 0, model.ReportDueDays-1)
+	from, to, err := reportDays(request, &today, &lastDay)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := newReportFilter(`ct."isCheckedOut" = true AND ct."isReturned" = false`, today.Format("2006-01-02"))
+	filter.days(loanDueDate, from, to)
+
+	sqlStatement := `
+		SELECT
+			ct."ID",
+			u."userID",
+			u."name",
+			u."email",
+			b."ISBN",
+			b."title",
+			b."author",
+			COALESCE(ct."copyBarcode", ''),
+			ct."checkedOutOn",
+			` + loanDueDate + `::timestamp,
+			` + loanDueDate + ` - $1::date,
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"checkout_tickets" ct
+		INNER JOIN
+			"users" u ON u."userID" = ct."userID"
+		INNER JOIN
+			"books" b ON b."ID" = ct."bookID"
+		WHERE
+			%s
+		ORDER BY
+			` + loanDueDate + ` ASC, u."name" ASC, ct."ID" ASC
+		%s; -- limit and offset
+	`
+	sqlStatement = fmt.Sprintf(sqlStatement, filter.conditions, reportLimit(request))
+
+	rows, err := l.db.Query(sqlStatement, filter.args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetDueReport(), db.Query err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	defer rows.Close()
+
+	var (
+		loans     = []model.DueLoan{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		var loan model.DueLoan
+		err := rows.Scan(
+			&loan.CheckoutID,
+			&loan.UserID,
+			&loan.Name,
+			&loan.Email,
+			&loan.ISBN,
+			&loan.Title,
+			&loan.Author,
+			&loan.Barcode,
+			&loan.CheckedOutOn,
+			&loan.DueDate,
+			&loan.DaysLeft,
+			&totalRows,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetDueReport(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetReportFailed
+		}
+		loans = append(loans, loan)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetDueReport(), rows.Err err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+
+	return loans, reportPages(request, totalRows), nil
+}
+
+// GetHoldShelfReport returns the reservations waiting to be picked up, the ones with a copy on the
+// shelf first and then the longest waiting. The days narrow the days of the reservations.
+func (l *LibraryService) GetHoldShelfReport(request *model.ReportRequest) ([]model.HoldShelfItem, uint, error) {
+	from, to, err := reportDays(request, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := newReportFilter(
+		`ct."isCheckedOut" = false AND ct."isReturned" = false`,
+		reportToday().Format("2006-01-02"),
+	)
+	filter.days(`ct."reservedOn"`, from, to)
+
+	sqlStatement := `
+		SELECT
+			ct."ID",
+			u."userID",
+			u."name",
+			u."email",
+			b."ISBN",
+			b."title",
+			b."author",
+			b."shelfNumber",
+			ct."reservedOn",
+			$1::date - ct."reservedOn"::date,
+			b."booksLeft",
+			b."inLibrary" AND b."booksLeft" > 0 AS "ready",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"checkout_tickets" ct
+		INNER JOIN
+			"users" u ON u."userID" = ct."userID"
+		INNER JOIN
+			"books" b ON b."ID" = ct."bookID"
+		WHERE
+			%s
+		ORDER BY
+			"ready" DESC, ct."reservedOn" ASC, ct."ID" ASC
+		%s; -- limit and offset
+	`
+	sqlStatement = fmt.Sprintf(sqlStatement, filter.conditions, reportLimit(request))
+
+	rows, err := l.db.Query(sqlStatement, filter.args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetHoldShelfReport(), db.Query err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	defer rows.Close()
+
+	var (
+		items     = []model.HoldShelfItem{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		var item model.HoldShelfItem
+		err := rows.Scan(
+			&item.CheckoutID,
+			&item.UserID,
+			&item.Name,
+			&item.Email,
+			&item.ISBN,
+			&item.Title,
+			&item.Author,
+			&item.ShelfNumber,
+			&item.ReservedOn,
+			&item.DaysWaiting,
+			&item.BooksLeft,
+			&item.Ready,
+			&totalRows,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetHoldShelfReport(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetReportFailed
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetHoldShelfReport(), rows.Err err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+
+	return items, reportPages(request, totalRows), nil
+}
+
+// topReportDays returns the days the top books and authors are counted in, the last 30 days when
+// no days are given
+func topReportDays(request *model.ReportRequest) (*time.Time, *time.Time, error) {
+	today := reportToday()
+	firstDay := today.AddDate(0, 0, 1-model.ReportTopDays)
+	return reportDays(request, &firstDay, &today)
+}
+
+// GetTopBooksReport returns the books checked out most in the days of the request
+func (l *LibraryService) GetTopBooksReport(request *model.ReportRequest) ([]model.TopBook, uint, error) {
+	from, to, err := topReportDays(request)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := newReportFilter(`ct."isCheckedOut" = true`)
+	filter.days(`ct."checkedOutOn"`, from, to)
+
+	sqlStatement := `
+		SELECT
+			b."ISBN",
+			b."title",
+			b."author",
+			COUNT(*) AS "checkouts",
+			COUNT(DISTINCT ct."userID") AS "borrowers",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"checkout_tickets" ct
+		INNER JOIN
+			"books" b ON b."ID" = ct."bookID"
+		WHERE
+			%s
+		GROUP BY
+			b."ID"
+		ORDER BY
+			"checkouts" DESC, "borrowers" DESC, b."title" ASC, b."ID" ASC
+		%s; -- limit and offset
+	`
+	sqlStatement = fmt.Sprintf(sqlStatement, filter.conditions, reportLimit(request))
+
+	rows, err := l.db.Query(sqlStatement, filter.args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetTopBooksReport(), db.Query err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	defer rows.Close()
+
+	var (
+		books     = []model.TopBook{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		var book model.TopBook
+		err := rows.Scan(&book.ISBN, &book.Title, &book.Author, &book.Checkouts, &book.Borrowers, &totalRows)
+		if err != nil {
+			log.Error().Msgf("[Error] GetTopBooksReport(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetReportFailed
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetTopBooksReport(), rows.Err err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+
+	return books, reportPages(request, totalRows), nil
+}
+
+// GetTopAuthorsReport returns the authors whose books were checked out most in the days of the
+// request, a checkout of a book by several authors counts for each of them
+func (l *LibraryService) GetTopAuthorsReport(request *model.ReportRequest) ([]model.TopAuthor, uint, error) {
+	from, to, err := topReportDays(request)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := newReportFilter(`ct."isCheckedOut" = true`)
+	filter.days(`ct."checkedOutOn"`, from, to)
+
+	// books without linked authors count for the author they were catalogued with
+	sqlStatement := `
+		SELECT
+			COALESCE(a."name", b."author") AS "authorName",
+			COUNT(DISTINCT b."ID") AS "books",
+			COUNT(*) AS "checkouts",
+			COUNT(DISTINCT ct."userID") AS "borrowers",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"checkout_tickets" ct
+		INNER JOIN
+			"books" b ON b."ID" = ct."bookID"
+		LEFT JOIN
+			"book_authors" ba ON ba."bookID" = b."ID"
+		LEFT JOIN
+			"authors" a ON a."ID" = ba."authorID"
+		WHERE
+			%s
+		GROUP BY
+			"authorName"
+		ORDER BY
+			"checkouts" DESC, "borrowers" DESC, "authorName" ASC
+		%s; -- limit and offset
+	`
+	sqlStatement = fmt.Sprintf(sqlStatement, filter.conditions, reportLimit(request))
+
+	rows, err := l.db.Query(sqlStatement, filter.args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetTopAuthorsReport(), db.Query err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	defer rows.Close()
+
+	var (
+		authors   = []model.TopAuthor{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		var author model.TopAuthor
+		err := rows.Scan(&author.Author, &author.Books, &author.Checkouts, &author.Borrowers, &totalRows)
+		if err != nil {
+			log.Error().Msgf("[Error] GetTopAuthorsReport(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetReportFailed
+		}
+		authors = append(authors, author)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetTopAuthorsReport(), rows.Err err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+
+	return authors, reportPages(request, totalRows), nil
+}
+
+// GetInactivePatronsReport returns the patrons who registered before the days of the request and
+// reserved, borrowed or returned nothing in them, the last year when no days are given. Activity is
+// counted like the dashboard's active patrons. The longest inactive
+// come first, patrons who never borrowed a book before them.
+func (l *LibraryService) GetInactivePatronsReport(request *model.ReportRequest) ([]model.InactivePatron, uint, error) {
+	today := reportToday()
+	firstDay := today.AddDate(0, 0, 1-model.ReportInactiveDays)
+	from, to, err := reportDays(request, &firstDay, &today)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// from and to always have a default, so the activity is bounded on both ends
+	args := []interface{}{model.Patrons, from.Format("2006-01-02"), to.Format("2006-01-02")}
+
+	sqlStatement := `
+		WITH ` + circulationActivity("$2", "$3") + `
+		SELECT
+			u."userID",
+			u."name",
+			u."email",
+			COALESCE(u."cardNumber", ''),
+			u."createdAt",
+			h."lastCheckoutAt",
+			COALESCE(h."checkouts", 0),
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"users" u
+		LEFT JOIN LATERAL (
+			SELECT MAX(ct."checkedOutOn") AS "lastCheckoutAt", COUNT(ct."checkedOutOn") AS "checkouts"
+			FROM "checkout_tickets" ct
+			WHERE ct."userID" = u."userID" AND ct."isCheckedOut" = true
+		) h ON true
+		WHERE
+			u."role" = $1 AND
+			u."deletedAt" IS NULL AND
+			u."createdAt" < $2::date AND
+			NOT EXISTS (SELECT 1 FROM "activity" a WHERE a."userID" = u."userID")
+		ORDER BY
+			h."lastCheckoutAt" ASC NULLS FIRST, u."createdAt" ASC, u."userID" ASC
+		%s; -- limit and offset
+	`
+	sqlStatement = fmt.Sprintf(sqlStatement, reportLimit(request))
+
+	rows, err := l.db.Query(sqlStatement, args...)
+	if err != nil {
+		log.Error().Msgf("[Error] GetInactivePatronsReport(), db.Query err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+	defer rows.Close()
+
+	var (
+		patrons   = []model.InactivePatron{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		var (
+			patron         model.InactivePatron
+			lastCheckoutAt sql.NullTime
+		)
+		err := rows.Scan(
+			&patron.UserID,
+			&patron.Name,
+			&patron.Email,
+			&patron.CardNumber,
+			&patron.CreatedAt,
+			&lastCheckoutAt,
+			&patron.Checkouts,
+			&totalRows,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetInactivePatronsReport(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetReportFailed
+		}
+		if lastCheckoutAt.Valid {
+			patron.LastCheckoutAt = &lastCheckoutAt.Time
+		}
+		patrons = append(patrons, patron)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetInactivePatronsReport(), rows.Err err: %v", err)
+		return nil, 0, ErrGetReportFailed
+	}
+
+	return patrons, reportPages(request, totalRows), nil
+}
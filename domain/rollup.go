@@ -0,0 +1,239 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrRefreshRollupsFailed is an error when recomputing the daily analytics rollups failed
+	ErrRefreshRollupsFailed = errors.New("refresh rollups failed")
+	// ErrRollupRangeInvalid is an error when the last day to roll up is before the first one
+	ErrRollupRangeInvalid = errors.New("to must not be before from")
+)
+
+// RefreshRollups recomputes the daily rollups of the days from and to, both included, and returns the
+// number of days stored. It backfills days which were never rolled up and repairs older days, e.g. after
+// checkout tickets or books were deleted.
+func (l *LibraryService) RefreshRollups(from, to time.Time) (int64, error) {
+	if to.Before(from) {
+		return 0, ErrRollupRangeInvalid
+	}
+
+	daysSQL := `
+		SELECT
+			"day"::date
+		FROM
+			generate_series($1::date, $2::date, '1 day') AS "series"("day");
+	`
+	return l.refreshRollups("RefreshRollups", daysSQL, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+// rollupRefreshMargin is how long before a refresh started a checkout ticket must have been updated for
+// the refresh to have counted it. updatedAt is often set by the clock of the service and a ticket can be
+// committed while a refresh runs, so tickets updated within the margin are rolled up again by the next run.
+const rollupRefreshMargin = "15 minutes"
+
+// RefreshRecentRollups recomputes the daily rollups of the last days up to today, of every day since the
+// first checkout ticket, user, book or view which was never rolled up, e.g. when the rollups are still
+// empty, and of every older day with a checkout ticket updated since the day was rolled up, e.g. when a
+// fine was charged. It returns the number of days stored.
+func (l *LibraryService) RefreshRecentRollups(days int) (int64, error) {
+	daysSQL := `
+		SELECT
+			"day"::date
+		FROM
+			generate_series(CURRENT_DATE - ($1::int - 1), CURRENT_DATE, '1 day') AS "series"("day")
+		UNION
+		SELECT
+			"series"."day"::date
+		FROM
+			generate_series(
+				LEAST(
+					(SELECT MIN("createdAt") FROM "checkout_tickets"),
+					(SELECT MIN("createdAt") FROM "users"),
+					(SELECT MIN("createdAt") FROM "books"),
+					(SELECT MIN("viewedAt") FROM "book_views")
+				)::date,
+				CURRENT_DATE,
+				'1 day'
+			) AS "series"("day")
+		WHERE
+			NOT EXISTS (SELECT 1 FROM "daily_rollups" r WHERE r."day" = "series"."day"::date)
+		UNION
+		SELECT
+			d."day"
+		FROM
+			"checkout_tickets" t
+		CROSS JOIN LATERAL (VALUES (t."createdAt"::date), (t."checkedOutOn"::date), (t."returnedDate"::date)) AS d("day")
+		JOIN "daily_rollups" r ON r."day" = d."day"
+		WHERE
+			t."updatedAt" > (SELECT MIN("refreshedAt") FROM "daily_rollups") - $2::interval AND
+			t."updatedAt" > r."refreshedAt" - $2::interval;
+	`
+	return l.refreshRollups("RefreshRecentRollups", daysSQL, days, rollupRefreshMargin)
+}
+
+// refreshRollups recomputes the daily rollups of the days daysSQL selects in one transaction, so the
+// dashboard keeps reading the previous rollups until the new ones are committed
+func (l *LibraryService) refreshRollups(caller, daysSQL string, args ...interface{}) (int64, error) {
+	firstDay, lastDay := `(SELECT MIN("day") FROM "rollup_days")`, `(SELECT MAX("day") FROM "rollup_days")`
+
+	activeUsersSQL := `
+		WITH ` + circulationActivity(firstDay, lastDay) + `
+		INSERT INTO "daily_active_users"("day", "userID")
+		SELECT DISTINCT
+			a."at"::date, a."userID"
+		FROM
+			"activity" a
+		JOIN "rollup_days" d ON d."day" = a."at"::date;
+	`
+
+	bookViewsSQL := `
+		INSERT INTO "daily_book_views"("day", "bookID", "views", "uniqueViewers")
+		SELECT
+			v."viewedAt"::date,
+			v."bookID",
+			COUNT(*),
+			COUNT(DISTINCT v."userID")
+		FROM
+			"book_views" v
+		JOIN "rollup_days" d ON d."day" = v."viewedAt"::date
+		WHERE
+			v."viewedAt" >= ` + firstDay + ` AND v."viewedAt" < ` + lastDay + ` + 1
+		GROUP BY
+			v."viewedAt"::date, v."bookID";
+	`
+
+	rollupsSQL := `
+		WITH "range" AS (
+			SELECT ` + firstDay + ` AS "first", ` + lastDay + ` + 1 AS "last"
+		),
+		"tickets" AS (
+			SELECT t."createdAt"::date AS "day", COUNT(*) AS "count", COALESCE(SUM(t."fineAmount"), 0) AS "fineAmount"
+			FROM "checkout_tickets" t, "range" r
+			WHERE t."createdAt" >= r."first" AND t."createdAt" < r."last"
+			GROUP BY 1
+		),
+		"checkouts" AS (
+			SELECT t."checkedOutOn"::date AS "day", COUNT(*) AS "count"
+			FROM "checkout_tickets" t, "range" r
+			WHERE t."isCheckedOut" = true AND t."checkedOutOn" >= r."first" AND t."checkedOutOn" < r."last"
+			GROUP BY 1
+		),
+		"returns" AS (
+			SELECT t."returnedDate"::date AS "day", COUNT(*) AS "count"
+			FROM "checkout_tickets" t, "range" r
+			WHERE t."isReturned" = true AND t."returnedDate" >= r."first" AND t."returnedDate" < r."last"
+			GROUP BY 1
+		),
+		"newUsers" AS (
+			SELECT u."createdAt"::date AS "day", COUNT(*) AS "count"
+			FROM "users" u, "range" r
+			WHERE u."createdAt" >= r."first" AND u."createdAt" < r."last"
+			GROUP BY 1
+		),
+		"newBooks" AS (
+			SELECT b."createdAt"::date AS "day", COUNT(*) AS "count"
+			FROM "books" b, "range" r
+			WHERE b."createdAt" >= r."first" AND b."createdAt" < r."last"
+			GROUP BY 1
+		),
+		"views" AS (
+			SELECT v."day", SUM(v."views") AS "count"
+			FROM "daily_book_views" v
+			JOIN "rollup_days" d ON d."day" = v."day"
+			GROUP BY 1
+		)
+		INSERT INTO "daily_rollups"(
+			"day",
+			"checkoutTickets",
+			"checkouts",
+			"returns",
+			"newUsers",
+			"newBooks",
+			"fineAmount",
+			"views",
+			"refreshedAt"
+		)
+		SELECT
+			d."day",
+			COALESCE(t."count", 0),
+			COALESCE(c."count", 0),
+			COALESCE(rt."count", 0),
+			COALESCE(u."count", 0),
+			COALESCE(b."count", 0),
+			COALESCE(t."fineAmount", 0),
+			COALESCE(v."count", 0),
+			NOW() -- the start of the refresh, tickets committed after it are found again by their updatedAt
+		FROM
+			"rollup_days" d
+		LEFT JOIN "tickets" t ON t."day" = d."day"
+		LEFT JOIN "checkouts" c ON c."day" = d."day"
+		LEFT JOIN "returns" rt ON rt."day" = d."day"
+		LEFT JOIN "newUsers" u ON u."day" = d."day"
+		LEFT JOIN "newBooks" b ON b."day" = d."day"
+		LEFT JOIN "views" v ON v."day" = d."day"
+		ON CONFLICT ("day") DO UPDATE SET
+			"checkoutTickets" = EXCLUDED."checkoutTickets",
+			"checkouts" = EXCLUDED."checkouts",
+			"returns" = EXCLUDED."returns",
+			"newUsers" = EXCLUDED."newUsers",
+			"newBooks" = EXCLUDED."newBooks",
+			"fineAmount" = EXCLUDED."fineAmount",
+			"views" = EXCLUDED."views",
+			"refreshedAt" = EXCLUDED."refreshedAt";
+	`
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] %s(), db.Begin err: %v", caller, err)
+		return 0, ErrRefreshRollupsFailed
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] %s(), tx.Rollback err: %v", caller, err)
+		}
+	}
+
+	if _, err := tx.Exec(`CREATE TEMPORARY TABLE "rollup_days"("day" DATE PRIMARY KEY) ON COMMIT DROP;`); err != nil {
+		log.Error().Msgf("[Error] %s(), create rollup days err: %v", caller, err)
+		rollback()
+		return 0, ErrRefreshRollupsFailed
+	}
+	if _, err := tx.Exec(`INSERT INTO "rollup_days"("day") `+daysSQL, args...); err != nil {
+		log.Error().Msgf("[Error] %s(), select rollup days err: %v", caller, err)
+		rollback()
+		return 0, ErrRefreshRollupsFailed
+	}
+
+	for _, statement := range []string{
+		`DELETE FROM "daily_active_users" WHERE "day" IN (SELECT "day" FROM "rollup_days");`,
+		`DELETE FROM "daily_book_views" WHERE "day" IN (SELECT "day" FROM "rollup_days");`,
+		activeUsersSQL,
+		bookViewsSQL,
+	} {
+		if _, err := tx.Exec(statement); err != nil {
+			log.Error().Msgf("[Error] %s(), tx.Exec err: %v", caller, err)
+			rollback()
+			return 0, ErrRefreshRollupsFailed
+		}
+	}
+
+	res, err := tx.Exec(rollupsSQL)
+	if err != nil {
+		log.Error().Msgf("[Error] %s(), refresh daily rollups err: %v", caller, err)
+		rollback()
+		return 0, ErrRefreshRollupsFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] %s(), tx.Commit err: %v", caller, err)
+		return 0, ErrRefreshRollupsFailed
+	}
+
+	stored, _ := res.RowsAffected()
+	return stored, nil
+}
@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"errors"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrRefreshBookSimilaritiesFailed is an error when recomputing the book similarities failed
+	ErrRefreshBookSimilaritiesFailed = errors.New("refresh book similarities failed")
+	// ErrGetSimilarBooksFailed is an error when get similar books failed
+	ErrGetSimilarBooksFailed = errors.New("get similar books failed")
+)
+
+// RefreshBookSimilarities recomputes the "book_similarities" table keeping the perBook most similar books of
+// every book, and returns the number of stored pairs. A pair is scored on shared authors, the Jaccard index
+// of their genres, the trigram similarity of their descriptions and how many patrons borrowed both.
+func (l *LibraryService) RefreshBookSimilarities(perBook int) (int64, error) {
+	sqlStatement := `
+		WITH "authorPairs" AS (
+			SELECT a."bookID" AS "bookID", c."bookID" AS "similarBookID", 1::float AS "authorScore"
+			FROM "book_authors" a
+			JOIN "book_authors" c ON c."authorID" = a."authorID" AND c."bookID" <> a."bookID"
+			GROUP BY a."bookID", c."bookID"
+		),
+		"genreCounts" AS (
+			SELECT "bookID", COUNT(*)::float AS "genres" FROM "book_genres" GROUP BY "bookID"
+		),
+		"genrePairs" AS (
+			SELECT
+				a."bookID" AS "bookID",
+				c."bookID" AS "similarBookID",
+				COUNT(*)::float / (ga."genres" + gc."genres" - COUNT(*)::float) AS "genreScore"
+			FROM "book_genres" a
+			JOIN "book_genres" c ON c."genreID" = a."genreID" AND c."bookID" <> a."bookID"
+			JOIN "genreCounts" ga ON ga."bookID" = a."bookID"
+			JOIN "genreCounts" gc ON gc."bookID" = c."bookID"
+			GROUP BY a."bookID", c."bookID", ga."genres", gc."genres"
+		),
+		"descriptionPairs" AS (
+			SELECT a."ID" AS "bookID", c."ID" AS "similarBookID", similarity(a."desc", c."desc")::float AS "descriptionScore"
+			FROM "books" a
+			JOIN "books" c ON a."desc" % c."desc" AND c."ID" <> a."ID"
+			WHERE LENGTH(a."desc") > 0
+		),
+		"readers" AS (
+			SELECT "bookID", COUNT(DISTINCT "userID")::float AS "readers" FROM "checkout_tickets" GROUP BY "bookID"
+		),
+		"coBorrowPairs" AS (
+			SELECT
+				a."bookID" AS "bookID",
+				c."bookID" AS "similarBookID",
+				COUNT(DISTINCT a."userID")::float / SQRT(ra."readers" * rc."readers") AS "coBorrowScore"
+			FROM "checkout_tickets" a
+			JOIN "checkout_tickets" c ON c."userID" = a."userID" AND c."bookID" <> a."bookID"
+			JOIN "readers" ra ON ra."bookID" = a."bookID"
+			JOIN "readers" rc ON rc."bookID" = c."bookID"
+			GROUP BY a."bookID", c."bookID", ra."readers", rc."readers"
+		),
+		"pairs" AS (
+			SELECT
+				"bookID",
+				"similarBookID",
+				MAX("authorScore") AS "authorScore",
+				MAX("genreScore") AS "genreScore",
+				MAX("descriptionScore") AS "descriptionScore",
+				MAX("coBorrowScore") AS "coBorrowScore"
+			FROM (
+				SELECT "bookID", "similarBookID", "authorScore", 0 AS "genreScore", 0 AS "descriptionScore", 0 AS "coBorrowScore" FROM "authorPairs"
+				UNION ALL
+				SELECT "bookID", "similarBookID", 0, "genreScore", 0, 0 FROM "genrePairs"
+				UNION ALL
+				SELECT "bookID", "similarBookID", 0, 0, "descriptionScore", 0 FROM "descriptionPairs"
+				UNION ALL
+				SELECT "bookID", "similarBookID", 0, 0, 0, "coBorrowScore" FROM "coBorrowPairs"
+			) AS "components"
+			GROUP BY "bookID", "similarBookID"
+		),
+		"scored" AS (
+			SELECT
+				*,
+				"authorScore" * $2::float + "genreScore" * $3::float + "descriptionScore" * $4::float + "coBorrowScore" * $5::float AS "score"
+			FROM "pairs"
+		),
+		"ranked" AS (
+			SELECT
+				*,
+				ROW_NUMBER() OVER (PARTITION BY "bookID" ORDER BY "score" DESC, "similarBookID") AS "rank"
+			FROM "scored"
+		)
+		INSERT INTO "book_similarities"(
+			"bookID",
+			"similarBookID",
+			"score",
+			"authorScore",
+			"genreScore",
+			"descriptionScore",
+			"coBorrowScore",
+			"computedAt"
+		)
+		SELECT
+			"bookID", "similarBookID", "score", "authorScore", "genreScore", "descriptionScore", "coBorrowScore", NOW()
+		FROM
+			"ranked"
+		WHERE
+			"rank" <= $1;
+	`
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] RefreshBookSimilarities(), db.Begin err: %v", err)
+		return 0, ErrRefreshBookSimilaritiesFailed
+	}
+
+	// readers keep seeing the previous similarities until the new ones are committed
+	for _, statement := range []string{
+		`SET LOCAL pg_trgm.similarity_threshold = 0.2;`,
+		`DELETE FROM "book_similarities";`,
+	} {
+		if _, err := tx.Exec(statement); err != nil {
+			log.Error().Msgf("[Error] RefreshBookSimilarities(), tx.Exec err: %v", err)
+			if err := tx.Rollback(); err != nil {
+				log.Error().Msgf("[Error] RefreshBookSimilarities(), tx.Rollback err: %v", err)
+			}
+			return 0, ErrRefreshBookSimilaritiesFailed
+		}
+	}
+
+	res, err := tx.Exec(
+		sqlStatement,
+		perBook,
+		model.SimilarityAuthorWeight,
+		model.SimilarityGenreWeight,
+		model.SimilarityDescriptionWeight,
+		model.SimilarityCoBorrowWeight,
+	)
+	if err != nil {
+		log.Error().Msgf("[Error] RefreshBookSimilarities(), tx.Exec err: %v", err)
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] RefreshBookSimilarities(), tx.Rollback err: %v", err)
+		}
+		return 0, ErrRefreshBookSimilaritiesFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] RefreshBookSimilarities(), tx.Commit err: %v", err)
+		return 0, ErrRefreshBookSimilaritiesFailed
+	}
+
+	stored, _ := res.RowsAffected()
+	return stored, nil
+}
+
+// GetSimilarBooks returns the precomputed most similar books of a book, the most similar first
+func (l *LibraryService) GetSimilarBooks(bookID string, limit uint32) ([]model.SimilarBook, error) {
+	sqlStatement := `
+		SELECT
+			` + bookColumns + `,
+			"book_similarities"."score",
+			"book_similarities"."authorScore",
+			"book_similarities"."genreScore",
+			"book_similarities"."descriptionScore",
+			"book_similarities"."coBorrowScore"
+		FROM
+			"book_similarities"
+		JOIN "books" ON "books"."ID" = "book_similarities"."similarBookID"
+		WHERE
+			"book_similarities"."bookID" = $1 AND
+			"books"."withdrawnAt" IS NULL
+		ORDER BY
+			"book_similarities"."score" DESC, "books"."ID" ASC
+		LIMIT $2;
+	`
+
+	rows, err := l.db.Query(sqlStatement, bookID, limit)
+	if err != nil {
+		log.Error().Msgf("[Error] GetSimilarBooks(), db.Query err: %v", err)
+		return nil, ErrGetSimilarBooksFailed
+	}
+	defer rows.Close()
+
+	books := []model.SimilarBook{}
+	for rows.Next() {
+		var (
+			similar                                                  model.SimilarBook
+			authorScore, genreScore, descriptionScore, coBorrowScore float64
+		)
+		book, err := scanBook(scanWithExtra(rows, &similar.Score, &authorScore, &genreScore, &descriptionScore, &coBorrowScore))
+		if err != nil {
+			log.Error().Msgf("[Error] GetSimilarBooks(), rows.Scan err: %v", err)
+			return nil, ErrGetSimilarBooksFailed
+		}
+		similar.Book = *book
+		similar.Reasons = []string{}
+		if authorScore > 0 {
+			similar.Reasons = append(similar.Reasons, model.SimilarityReasonAuthor)
+		}
+		if genreScore > 0 {
+			similar.Reasons = append(similar.Reasons, model.SimilarityReasonGenre)
+		}
+		if descriptionScore > 0 {
+			similar.Reasons = append(similar.Reasons, model.SimilarityReasonDescription)
+		}
+		if coBorrowScore > 0 {
+			similar.Reasons = append(similar.Reasons, model.SimilarityReasonCoBorrow)
+		}
+		books = append(books, similar)
+	}
+
+	return books, nil
+}
@@ -26,14 +26,26 @@ var (
 	ErrGetUserWithBookDetailsNotFound = errors.New("get user with book details not found")
 	// ErrFailedUpdateUser is an error when update user failed
 	ErrFailedUpdateUser = errors.New("update user failed")
+	// ErrUpdateUserNotFound is an error when the user to update is not found
+	ErrUpdateUserNotFound = errors.New("update user not found")
 	// ErrFailedUpdateBookDetails is an error when update book details failed
 	ErrFailedUpdateBookDetails = errors.New("update book details failed")
 	// ErrFailedDeleteUser is an error when delete user failed
 	ErrFailedDeleteUser = errors.New("delete user failed")
+	// ErrDeleteUserNotFound is an error when the account to close is not found or already closed
+	ErrDeleteUserNotFound = errors.New("delete user not found")
+	// ErrUserHasOpenLoans is an error when an account with unreturned loans is closed
+	ErrUserHasOpenLoans = errors.New("user has loans which are not returned")
+	// ErrUserHasOutstandingFines is an error when an account with unpaid fines is closed
+	ErrUserHasOutstandingFines = errors.New("user has outstanding fines")
+	// ErrFailedGetUserRole is an error when get user role failed
+	ErrFailedGetUserRole = errors.New("get user role failed")
+	// ErrGetUserRoleNotFound is an error when the user of get user role is not found
+	ErrGetUserRoleNotFound = errors.New("get user role not found")
 )
 
-// create user creates new user
-func (l *LibraryService) CreateUser(user *model.RegisterUserRequest) error {
+// create user creates new user with the role, an email which is already registered is never taken over
+func (l *LibraryService) CreateUser(user *model.RegisterUserRequest, role model.RoleType) error {
 	sqlStatement := `
 						INSERT INTO "users"(
 									"profileImageUrl",
@@ -42,15 +54,14 @@ func (l *LibraryService) CreateUser(user *model.RegisterUserRequest) error {
 									"role",
 									"password"
 								) VALUES ($1, $2, $3, $4, $5)
-					ON CONFLICT ("email") 
-						DO UPDATE SET 
-								"profileImageUrl" = EXCLUDED."profileImageUrl",
-								"name" = EXCLUDED."name",
-								"role" = EXCLUDED."role"
+					ON CONFLICT ("email") DO NOTHING
 					RETURNING "userID";
 					`
 	var userID string
-	if err := l.db.QueryRow(sqlStatement, user.ProfileImageUrl, user.Name, user.Email, user.Role, user.Password).Scan(&userID); err != nil {
+	if err := l.db.QueryRow(sqlStatement, user.ProfileImageUrl, user.Name, user.Email, role, user.Password).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEmailInUse
+		}
 		log.Error().Msgf("[Error] CreateUser(), db.Exec err: %v", err)
 		return ErrFailedCreateUser
 	}
@@ -161,6 +172,8 @@ func (l *LibraryService) GetUserWithBookDetails(userID string) (*model.User, err
 			u."views",
 			u."fineAmount",
 			u."isPaymentDone",
+			u."cardNumber",
+			u."version",
 			u."createdAt",
 			u."updatedAt",
 			bkd."reservedBooksCount",
@@ -206,6 +219,8 @@ func (l *LibraryService) GetUserWithBookDetails(userID string) (*model.User, err
 		&user.Views,
 		&user.FineAmount,
 		&user.IsPaymentDone,
+		&user.CardNumber,
+		&user.Version,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.BookDetails.ReservedBooksCount,
@@ -276,6 +291,8 @@ func (l *LibraryService) GetAllUsers(request *model.GetAllUsersRequest) ([]model
 		FROM 
 			"users" as u INNER JOIN "book_details" as bkd 
 				ON u."userID" = bkd."userID"
+		WHERE
+			u."deletedAt" IS NULL
 		ORDER BY 
 				%s -- orderby
 		%s; -- criteria for limit and offset 
@@ -371,11 +388,12 @@ func (l *LibraryService) GetAllUsers(request *model.GetAllUsersRequest) ([]model
 		FROM 
 			"users" as u INNER JOIN "book_details" as bkd 
 				ON u."userID" = bkd."userID"
-		%s; --LIMIT AND OFFSET 	
+		WHERE
+			u."deletedAt" IS NULL;
 	`
 
 	var totalRows uint
-	err = l.db.QueryRow(fmt.Sprintf(sqlStatementCount, limitOffset)).Scan(&totalRows)
+	err = l.db.QueryRow(sqlStatementCount).Scan(&totalRows)
 	// no rows
 	if errors.Is(err, sql.ErrNoRows) {
 		return []model.User{}, 0, nil
@@ -430,37 +448,7 @@ func (l *LibraryService) GetAllUsersForSearch(request *model.SearchRequest) ([]m
 				%s -- orderby
 		%s; -- criteria for limit and offset 
 	`
-	orderBy := `%s ASC`
-
-	if request.OrderBy == "descending" {
-		orderBy = `%s DESC`
-	}
-
-	switch request.SortBy {
-	case "name":
-		orderBy = fmt.Sprintf(orderBy, `u."name"`)
-	case "reserved":
-		orderBy = fmt.Sprintf(orderBy, `bkd."reservedBooksCount"`)
-	case "checkedOut":
-		orderBy = fmt.Sprintf(orderBy, `bkd."checkedOutBooksCount"`)
-	case "wishLists":
-		orderBy = fmt.Sprintf(orderBy, `array_length(bkd."wishlistBooks", 1)`)
-	case "completed":
-		orderBy = fmt.Sprintf(orderBy, `bkd."completedBooksCount"`)
-	default:
-		orderBy = fmt.Sprintf(orderBy, `u."name"`)
-	}
-
-	searchText := "%" + request.SearchText + "%"
-	searchBy := `%s`
-	switch request.SearchBy {
-	case "email":
-		searchBy = fmt.Sprintf(searchBy, `(LOWER(u."email") LIKE LOWER($1))`)
-	case "username":
-		searchBy = fmt.Sprintf(searchBy, `(LOWER(u."name") LIKE LOWER($1))`)
-	default:
-		searchBy = fmt.Sprintf(searchBy, `(LOWER(u."email") LIKE LOWER($1) OR LOWER(u."name") LIKE LOWER($1))`)
-	}
+	searchBy, orderBy, searchText := userSearch(request)
 
 	limitOffset := ` LIMIT %d OFFSET %d`
 	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
@@ -548,30 +536,109 @@ func (l *LibraryService) GetAllUsersForSearch(request *model.SearchRequest) ([]m
 	return users, uint(totalPages), nil
 }
 
-// UpdateUser updates an existing user in the "users" table
-func (l *LibraryService) UpdateUser(user *model.User, userID string) error {
+// userSearch returns the WHERE conditions, the ORDER BY and the $1 argument of a user search, closed
+// accounts never match
+func userSearch(request *model.SearchRequest) (string, string, string) {
+	orderBy := `%s ASC`
+
+	if request.OrderBy == "descending" {
+		orderBy = `%s DESC`
+	}
+
+	switch request.SortBy {
+	case "name":
+		orderBy = fmt.Sprintf(orderBy, `u."name"`)
+	case "reserved":
+		orderBy = fmt.Sprintf(orderBy, `bkd."reservedBooksCount"`)
+	case "checkedOut":
+		orderBy = fmt.Sprintf(orderBy, `bkd."checkedOutBooksCount"`)
+	case "wishLists":
+		orderBy = fmt.Sprintf(orderBy, `array_length(bkd."wishlistBooks", 1)`)
+	case "completed":
+		orderBy = fmt.Sprintf(orderBy, `bkd."completedBooksCount"`)
+	default:
+		orderBy = fmt.Sprintf(orderBy, `u."name"`)
+	}
+
+	searchText := "%" + request.SearchText + "%"
+	searchBy := `%s`
+	switch request.SearchBy {
+	case "email":
+		searchBy = fmt.Sprintf(searchBy, `(LOWER(u."email") LIKE LOWER($1))`)
+	case "username":
+		searchBy = fmt.Sprintf(searchBy, `(LOWER(u."name") LIKE LOWER($1))`)
+	default:
+		searchBy = fmt.Sprintf(searchBy, `(LOWER(u."email") LIKE LOWER($1) OR LOWER(u."name") LIKE LOWER($1))`)
+	}
+	// closed accounts are only kept for the circulation history
+	searchBy += ` AND u."deletedAt" IS NULL`
+
+	return searchBy, orderBy, searchText
+}
+
+// UpdateUserProfile updates the profile fields a user can change on their own
+func (l *LibraryService) UpdateUserProfile(profile *model.UpdateProfileRequest, userID string) error {
 	sqlStatement := `
 		UPDATE "users" SET
-			"email" = $1,
-			"profileImageUrl" = $2,
-			"name" = $3,
-			"role" = $4,
-			"dateOfBirth" = $5,
-			"phoneNumber" = $6,
-			"address" = $7,
-			"joinedDate" = $8,
-			"country" = $9,
-			"views" = $10,
-			"fineAmount" = $11,
-			"isPaymentDone" = $12,
-			"updatedAt" = $13
+			"profileImageUrl" = $1,
+			"name" = $2,
+			"dateOfBirth" = $3,
+			"phoneNumber" = $4,
+			"address" = $5,
+			"country" = $6,
+			"updatedAt" = $7,
+			"version" = "version" + 1
 		WHERE
-			"userID" = $14;
+			"userID" = $8;
+	`
+	updatedAt := time.Now().UTC().Format(time.RFC3339)
+	res, err := l.db.Exec(
+		sqlStatement,
+		profile.ProfileImageUrl,
+		profile.Name,
+		profile.DateOfBirth,
+		profile.PhoneNumber,
+		profile.Address,
+		profile.Country,
+		updatedAt,
+		userID,
+	)
+
+	if err != nil {
+		log.Error().Msgf("[Error] UpdateUserProfile(), db.Exec err: %v", err)
+		return ErrFailedUpdateUser
+	}
+
+	if rowsEffected, err := res.RowsAffected(); err != nil || rowsEffected == 0 {
+		log.Error().Msgf("[error] UpdateUserProfile(), [No rows affected]  : %v", err)
+		return ErrFailedUpdateUser
+	}
+
+	return nil
+}
+
+// UpdateUser updates an existing user in the "users" table including the fields only librarians can change
+func (l *LibraryService) UpdateUser(user *model.AdminUpdateUserRequest, userID string) error {
+	sqlStatement := `
+		UPDATE "users" SET
+			"profileImageUrl" = $1,
+			"name" = $2,
+			"role" = $3,
+			"dateOfBirth" = $4,
+			"phoneNumber" = $5,
+			"address" = $6,
+			"joinedDate" = $7,
+			"country" = $8,
+			"fineAmount" = $9,
+			"isPaymentDone" = $10,
+			"updatedAt" = $11,
+			"version" = "version" + 1
+		WHERE
+			"userID" = $12;
 	`
 	updatedAt := time.Now().UTC().Format(time.RFC3339)
 	res, err := l.db.Exec(
 		sqlStatement,
-		user.Email,
 		user.ProfileImageUrl,
 		user.Name,
 		user.Role,
@@ -580,7 +647,6 @@ func (l *LibraryService) UpdateUser(user *model.User, userID string) error {
 		user.Address,
 		user.JoinedDate,
 		user.Country,
-		user.Views,
 		user.FineAmount,
 		user.IsPaymentDone,
 		updatedAt,
@@ -594,7 +660,7 @@ func (l *LibraryService) UpdateUser(user *model.User, userID string) error {
 
 	if rowsEffected, err := res.RowsAffected(); err != nil || rowsEffected == 0 {
 		log.Error().Msgf("[error] UpdateUser(), [No rows affected]  : %v", err)
-		return ErrFailedUpdateUser
+		return ErrUpdateUserNotFound
 	}
 
 	return nil
@@ -648,15 +714,120 @@ func (l *LibraryService) UpdateBookDetails(bookDetails *model.BookDetails, userI
 	return nil
 }
 
-// DeleteUser deletes a user from the "users" table based on userID
-func (l *LibraryService) DeleteUser(userID string) error {
+// GetUserRole returns the role of the user with the given ID
+func (l *LibraryService) GetUserRole(userID string) (model.RoleType, error) {
 	sqlStatement := `
-		DELETE FROM "users" WHERE "userID" = $1;
+		SELECT "role" FROM "users" WHERE "userID" = $1 AND "deletedAt" IS NULL;
 	`
 
-	_, err := l.db.Exec(sqlStatement, userID)
+	var role model.RoleType
+	if err := l.db.QueryRow(sqlStatement, userID).Scan(&role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrGetUserRoleNotFound
+		}
+		log.Error().Msgf("[Error] GetUserRole(), db.QueryRow err: %v", err)
+		return "", ErrFailedGetUserRole
+	}
+
+	return role, nil
+}
+
+// DeleteUser closes an account once its loans are returned and its fines are paid. The user is
+// anonymised in place instead of deleted so its loans and reviews stay in the circulation history.
+func (l *LibraryService) DeleteUser(userID string) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] DeleteUser(), db.Begin err: %v", err)
+		return ErrFailedDeleteUser
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] DeleteUser(), tx.Rollback err: %v", err)
+		}
+	}
+
+	var (
+		fineAmount    float64
+		isPaymentDone bool
+		openLoans     int64
+	)
+	err = tx.QueryRow(`
+		SELECT "fineAmount", "isPaymentDone" FROM "users" WHERE "userID" = $1 AND "deletedAt" IS NULL FOR UPDATE;
+	`, userID).Scan(&fineAmount, &isPaymentDone)
 	if err != nil {
-		log.Error().Msgf("[Error] DeleteUser(), db.Exec err: %v", err)
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDeleteUserNotFound
+		}
+		log.Error().Msgf("[Error] DeleteUser(), tx.QueryRow err: %v", err)
+		return ErrFailedDeleteUser
+	}
+
+	err = tx.QueryRow(`SELECT COUNT(*) FROM "checkout_tickets" WHERE "userID" = $1 AND "isReturned" = false;`, userID).
+		Scan(&openLoans)
+	if err != nil {
+		log.Error().Msgf("[Error] DeleteUser(), tx.QueryRow loans err: %v", err)
+		rollback()
+		return ErrFailedDeleteUser
+	}
+	if openLoans > 0 {
+		rollback()
+		return ErrUserHasOpenLoans
+	}
+	if fineAmount > 0 && !isPaymentDone {
+		rollback()
+		return ErrUserHasOutstandingFines
+	}
+
+	statements := []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{"anonymise", `
+			UPDATE "users" SET
+				"profileImageUrl" = '',
+				"name" = $2,
+				"email" = REPLACE("userID"::text, '-', '') || '@' || $3,
+				"role" = 'patrons',
+				"dateOfBirth" = NULL,
+				"phoneNumber" = NULL,
+				"address" = NULL,
+				"country" = NULL,
+				"views" = NULL,
+				"password" = '',
+				"cardNumber" = NULL,
+				"pinHash" = NULL,
+				"pinFailedAttempts" = 0,
+				"deletedAt" = NOW(),
+				"updatedAt" = NOW(),
+				"version" = "version" + 1
+			WHERE
+				"userID" = $1;
+		`, []interface{}{userID, model.DeletedUserName, model.DeletedUserEmailDomain}},
+		{"wishlists", `
+			UPDATE "books" SET
+				"wishList" = array_remove("wishList", $1::varchar),
+				"wishlistCount" = COALESCE(array_length(array_remove("wishList", $1::varchar), 1), 0)
+			WHERE
+				$1::varchar = ANY("wishList");
+		`, []interface{}{userID}},
+		{"book details", `UPDATE "book_details" SET "wishlistBooks" = '{}' WHERE "userID" = $1;`, []interface{}{userID}},
+		{"notifications", `DELETE FROM "notifications" WHERE "userID" = $1;`, []interface{}{userID}},
+		{"email changes", `DELETE FROM "email_change_requests" WHERE "userID" = $1;`, []interface{}{userID}},
+		{"invites", `DELETE FROM "user_invites" WHERE "userID" = $1;`, []interface{}{userID}},
+		{"kiosk sessions", `UPDATE "kiosk_sessions" SET "endedAt" = NOW() WHERE "userID" = $1 AND "endedAt" IS NULL;`, []interface{}{userID}},
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.args...); err != nil {
+			log.Error().Msgf("[Error] DeleteUser(), tx.Exec %s err: %v", statement.name, err)
+			rollback()
+			return ErrFailedDeleteUser
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] DeleteUser(), tx.Commit err: %v", err)
 		return ErrFailedDeleteUser
 	}
 
@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrGetUserDataExportFailed is an error when get user data export failed
+	ErrGetUserDataExportFailed = errors.New("get user data export failed")
+	// ErrGetUserDataExportNotFound is an error when the user of a data export is not found
+	ErrGetUserDataExportNotFound = errors.New("get user data export not found")
+)
+
+// GetUserDataExport collects the profile, loans, fines and reviews of a user
+func (l *LibraryService) GetUserDataExport(userID string) (*model.UserDataExport, error) {
+	export := model.UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Loans:      []model.UserDataLoan{},
+		Reviews:    []model.UserDataReview{},
+		Fines:      model.UserDataFines{FinedLoans: []model.UserDataLoan{}},
+	}
+
+	profile := &export.Profile
+	err := l.db.QueryRow(`
+		SELECT
+			"userID",
+			"name",
+			"email",
+			"role",
+			"profileImageUrl",
+			"dateOfBirth",
+			"phoneNumber",
+			"address",
+			"country",
+			"joinedDate",
+			"fineAmount",
+			"isPaymentDone",
+			"createdAt",
+			"updatedAt"
+		FROM
+			"users"
+		WHERE
+			"userID" = $1 AND "deletedAt" IS NULL;
+	`, userID).Scan(
+		&profile.UserID,
+		&profile.Name,
+		&profile.Email,
+		&profile.Role,
+		&profile.ProfileImageUrl,
+		&profile.DateOfBirth,
+		&profile.PhoneNumber,
+		&profile.Address,
+		&profile.Country,
+		&profile.JoinedDate,
+		&export.Fines.Outstanding,
+		&export.Fines.PaymentDone,
+		&profile.CreatedAt,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGetUserDataExportNotFound
+		}
+		log.Error().Msgf("[Error] GetUserDataExport(), db.QueryRow err: %v", err)
+		return nil, ErrGetUserDataExportFailed
+	}
+
+	loanRows, err := l.db.Query(`
+		SELECT
+			ct."ID",
+			ct."bookID",
+			b."ISBN",
+			b."title",
+			ct."isCheckedOut",
+			ct."isReturned",
+			ct."numberOfDays",
+			ct."fineAmount",
+			ct."reservedOn",
+			ct."checkedOutOn",
+			ct."returnedDate"
+		FROM
+			"checkout_tickets" ct
+		JOIN "books" b ON b."ID" = ct."bookID"
+		WHERE
+			ct."userID" = $1
+		ORDER BY
+			ct."reservedOn" DESC NULLS LAST, ct."ID" ASC;
+	`, userID)
+	if err != nil {
+		log.Error().Msgf("[Error] GetUserDataExport(), db.Query loans err: %v", err)
+		return nil, ErrGetUserDataExportFailed
+	}
+	defer loanRows.Close()
+
+	for loanRows.Next() {
+		loan := model.UserDataLoan{}
+		err := loanRows.Scan(
+			&loan.ID,
+			&loan.BookID,
+			&loan.ISBN,
+			&loan.Title,
+			&loan.IsCheckedOut,
+			&loan.IsReturned,
+			&loan.NumberOfDays,
+			&loan.FineAmount,
+			&loan.ReservedOn,
+			&loan.CheckedOutOn,
+			&loan.ReturnedDate,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetUserDataExport(), loanRows.Scan err: %v", err)
+			return nil, ErrGetUserDataExportFailed
+		}
+		export.Loans = append(export.Loans, loan)
+		if loan.FineAmount > 0 {
+			export.Fines.FinedLoans = append(export.Fines.FinedLoans, loan)
+		}
+	}
+	if err := loanRows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetUserDataExport(), loanRows.Err err: %v", err)
+		return nil, ErrGetUserDataExportFailed
+	}
+
+	reviewRows, err := l.db.Query(`
+		SELECT
+			r."ID",
+			r."bookID",
+			b."ISBN",
+			b."title",
+			r."commentHeading",
+			r."comment",
+			r."rating",
+			r."createdAt",
+			r."updatedAt"
+		FROM
+			"reviews" r
+		JOIN "books" b ON b."ID" = r."bookID"
+		WHERE
+			r."userID" = $1
+		ORDER BY
+			r."createdAt" DESC, r."ID" ASC;
+	`, userID)
+	if err != nil {
+		log.Error().Msgf("[Error] GetUserDataExport(), db.Query reviews err: %v", err)
+		return nil, ErrGetUserDataExportFailed
+	}
+	defer reviewRows.Close()
+
+	for reviewRows.Next() {
+		review := model.UserDataReview{}
+		err := reviewRows.Scan(
+			&review.ID,
+			&review.BookID,
+			&review.ISBN,
+			&review.Title,
+			&review.CommentHeading,
+			&review.Comment,
+			&review.Rating,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetUserDataExport(), reviewRows.Scan err: %v", err)
+			return nil, ErrGetUserDataExportFailed
+		}
+		export.Reviews = append(export.Reviews, review)
+	}
+	if err := reviewRows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetUserDataExport(), reviewRows.Err err: %v", err)
+		return nil, ErrGetUserDataExportFailed
+	}
+
+	return &export, nil
+}
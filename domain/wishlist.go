@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrFailedUpdateWishlist is an error when adding or removing a wishlist book failed
+	ErrFailedUpdateWishlist = errors.New("update wishlist failed")
+	// ErrWishlistBookNotFound is an error when the book to wishlist is not in the catalogue
+	ErrWishlistBookNotFound = errors.New("wishlist book not found")
+	// ErrWishlistUserNotFound is an error when the user has no book details to keep a wishlist in
+	ErrWishlistUserNotFound = errors.New("wishlist user not found")
+	// ErrFailedGetWishlist is an error when listing the wishlist failed
+	ErrFailedGetWishlist = errors.New("get wishlist failed")
+)
+
+// AddToWishlist adds a book to the user's wishlist, adding it twice is a no-op. The user's
+// "wishlistBooks", the book's "wishList" and "wishlistCount" and the dated "wishlist_adds" entry
+// are updated in one transaction.
+func (l *LibraryService) AddToWishlist(userID, ISBN string) error {
+	return l.updateWishlist("AddToWishlist", userID, ISBN, true)
+}
+
+// RemoveFromWishlist removes a book from the user's wishlist, removing a book which is not
+// wishlisted is a no-op. Both sides are updated in one transaction.
+func (l *LibraryService) RemoveFromWishlist(userID, ISBN string) error {
+	return l.updateWishlist("RemoveFromWishlist", userID, ISBN, false)
+}
+
+// updateWishlist adds or removes the book on both the user's and the book's side
+func (l *LibraryService) updateWishlist(caller, userID, ISBN string, add bool) error {
+	ISBN13, ISBN10, err := isbn.Parse(ISBN)
+	if err != nil {
+		return ErrInvalidISBN
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] %s(), db.Begin err: %v", caller, err)
+		return ErrFailedUpdateWishlist
+	}
+
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] %s(), tx.Rollback err: %v", caller, err)
+		}
+	}
+
+	var bookID sql.NullString
+	err = tx.QueryRow(`
+		SELECT "ID" FROM "books" WHERE "ISBN" = $1 FOR UPDATE;
+	`, ISBN13).Scan(&bookID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Msgf("[Error] %s(), tx.QueryRow book err: %v", caller, err)
+		rollback()
+		return ErrFailedUpdateWishlist
+	}
+	// a book which left the catalogue can still be removed from the wishlist
+	if add && !bookID.Valid {
+		rollback()
+		return ErrWishlistBookNotFound
+	}
+
+	// the wishlist may hold the ISBN-10 the book was added under before ISBNs were normalised
+	userSide := `
+		UPDATE "book_details" SET
+			"wishlistBooks" = array_remove(array_remove("wishlistBooks", $2::varchar), $3::varchar),
+			"updatedAt" = NOW()
+		WHERE
+			"userID" = $1;
+	`
+	bookSide := `
+		UPDATE "books" SET
+			"wishList" = "list",
+			"wishlistCount" = COALESCE(array_length("list", 1), 0)
+		FROM (
+			SELECT array_remove("wishList", $2::varchar) AS "list" FROM "books" WHERE "ID" = $1
+		) AS "wishlist"
+		WHERE
+			"ID" = $1;
+	`
+	if add {
+		userSide = `
+			UPDATE "book_details" SET
+				"wishlistBooks" = array_append(array_remove(array_remove("wishlistBooks", $2::varchar), $3::varchar), $2::varchar),
+				"updatedAt" = NOW()
+			WHERE
+				"userID" = $1;
+		`
+		bookSide = `
+			UPDATE "books" SET
+				"wishList" = "list",
+				"wishlistCount" = COALESCE(array_length("list", 1), 0)
+			FROM (
+				SELECT array_append(array_remove("wishList", $2::varchar), $2::varchar) AS "list" FROM "books" WHERE "ID" = $1
+			) AS "wishlist"
+			WHERE
+				"ID" = $1;
+		`
+	}
+
+	res, err := tx.Exec(userSide, userID, ISBN13, ISBN10)
+	if err != nil {
+		log.Error().Msgf("[Error] %s(), tx.Exec book_details err: %v", caller, err)
+		rollback()
+		return ErrFailedUpdateWishlist
+	}
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		rollback()
+		return ErrWishlistUserNotFound
+	}
+
+	if bookID.Valid {
+		if _, err := tx.Exec(bookSide, bookID.String, userID); err != nil {
+			log.Error().Msgf("[Error] %s(), tx.Exec books err: %v", caller, err)
+			rollback()
+			return ErrFailedUpdateWishlist
+		}
+
+		// adding a wishlisted book again keeps the time it was first added
+		datedSide := `DELETE FROM "wishlist_adds" WHERE "userID" = $1 AND "bookID" = $2;`
+		if add {
+			datedSide = `INSERT INTO "wishlist_adds"("userID", "bookID") VALUES ($1, $2) ON CONFLICT DO NOTHING;`
+		}
+		if _, err := tx.Exec(datedSide, userID, bookID.String); err != nil {
+			log.Error().Msgf("[Error] %s(), tx.Exec wishlist_adds err: %v", caller, err)
+			rollback()
+			return ErrFailedUpdateWishlist
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] %s(), tx.Commit err: %v", caller, err)
+		return ErrFailedUpdateWishlist
+	}
+
+	return nil
+}
+
+// GetWishlist returns the user's wishlisted books, the ones which can be borrowed right now first. Withdrawn
+// books are left out until they are restored.
+func (l *LibraryService) GetWishlist(userID string, request *model.GetWishlistRequest) ([]model.WishlistBook, uint, error) {
+	var wishlistBooks pq.StringArray
+	err := l.db.QueryRow(`SELECT "wishlistBooks" FROM "book_details" WHERE "userID" = $1;`, userID).Scan(&wishlistBooks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, ErrWishlistUserNotFound
+	}
+	if err != nil {
+		log.Error().Msgf("[Error] GetWishlist(), db.QueryRow err: %v", err)
+		return nil, 0, ErrFailedGetWishlist
+	}
+
+	sqlStatement := `
+		SELECT
+			` + bookColumns + `,
+			("inLibrary" AND "booksLeft" > 0) AS "available",
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"books"
+		WHERE
+			("ISBN" = ANY($1) OR "ISBN10" = ANY($1)) AND "withdrawnAt" IS NULL
+		ORDER BY
+			"available" DESC, "title" ASC, "ID" ASC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement, pq.Array(isbnLookupKeys(wishlistBooks)))
+	if err != nil {
+		log.Error().Msgf("[Error] GetWishlist(), db.Query err: %v", err)
+		return nil, 0, ErrFailedGetWishlist
+	}
+	defer rows.Close()
+
+	var (
+		books     = []model.WishlistBook{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		var wishlistBook model.WishlistBook
+		book, err := scanBook(scanWithExtra(rows, &wishlistBook.Available, &totalRows))
+		if err != nil {
+			log.Error().Msgf("[Error] GetWishlist(), rows.Scan err: %v", err)
+			return nil, 0, ErrFailedGetWishlist
+		}
+		wishlistBook.Book = *book
+		books = append(books, wishlistBook)
+	}
+
+	// Calculate total pages
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return books, uint(totalPages), nil
+}
+
+// notifyWishlistBackInStock notifies every user wishlisting the book that it can be borrowed, callers only
+// call it when the book was not available before their change. Nothing is sent while it is still unavailable
+// or withdrawn.
+func notifyWishlistBackInStock(exec execer, bookID string) error {
+	sqlStatement := `
+		INSERT INTO "notifications"("userID", "type", "message", "referenceID")
+		SELECT
+			bd."userID",
+			$2,
+			'A book on your wishlist, ' || b."title" || ', is available to borrow.',
+			b."ID"
+		FROM
+			"books" b
+		JOIN "book_details" bd ON b."ISBN" = ANY(bd."wishlistBooks") OR b."ISBN10" = ANY(bd."wishlistBooks")
+		WHERE
+			b."ID" = $1 AND b."inLibrary" = true AND b."booksLeft" > 0 AND b."withdrawnAt" IS NULL;
+	`
+
+	if _, err := exec.Exec(sqlStatement, bookID, model.NotificationTypeWishlistStock); err != nil {
+		log.Error().Msgf("[Error] notifyWishlistBackInStock(), Exec err: %v", err)
+		return ErrFailedCreateNotification
+	}
+
+	return nil
+}
@@ -0,0 +1,534 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"integrated-library-service/itemcode"
+	"integrated-library-service/model"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrWithdrawalBookNotFound is an error when the book to withdraw, restore or purge is not found
+	ErrWithdrawalBookNotFound = errors.New("book not found")
+	// ErrBookWithdrawn is an error when a book is withdrawn from circulation
+	ErrBookWithdrawn = errors.New("book is withdrawn")
+	// ErrBookNotWithdrawn is an error when a book to restore or purge is not withdrawn
+	ErrBookNotWithdrawn = errors.New("book is not withdrawn")
+	// ErrNotEnoughCopiesToWithdraw is an error when more copies are withdrawn than are on the shelf
+	ErrNotEnoughCopiesToWithdraw = errors.New("not enough copies on the shelf to withdraw")
+	// ErrBookHasOpenLoans is an error when a book still has reservations or checkouts which are not returned
+	ErrBookHasOpenLoans = errors.New("book has open loans")
+	// ErrCopyOnLoan is an error when a copy to withdraw is on loan
+	ErrCopyOnLoan = errors.New("copy is on loan, it can be withdrawn once returned")
+	// ErrCopyWithdrawn is an error when a copy to withdraw is already withdrawn
+	ErrCopyWithdrawn = errors.New("copy is withdrawn")
+	// ErrCopyNotWithdrawn is an error when a copy to restore is not withdrawn
+	ErrCopyNotWithdrawn = errors.New("copy is not withdrawn")
+	// ErrFailedWithdrawBook is an error when withdraw book failed
+	ErrFailedWithdrawBook = errors.New("withdraw book failed")
+	// ErrFailedRestoreBook is an error when restore book failed
+	ErrFailedRestoreBook = errors.New("restore book failed")
+	// ErrGetWithdrawnBooksFailed is an error when get withdrawn books failed
+	ErrGetWithdrawnBooksFailed = errors.New("get withdrawn books failed")
+	// ErrGetBookWithdrawalsFailed is an error when get book withdrawals failed
+	ErrGetBookWithdrawalsFailed = errors.New("get book withdrawals failed")
+)
+
+// withdrawalBook is the state of a book a withdrawal operation works on
+type withdrawalBook struct {
+	ID        string
+	ISBN      string
+	Title     string
+	InLibrary bool
+	BooksLeft int64
+	Withdrawn bool
+	OpenLoans int64
+}
+
+// lockWithdrawalBook locks a book by either ISBN for a withdrawal operation, purged books are not found
+func lockWithdrawalBook(tx *sql.Tx, ISBN string) (*withdrawalBook, error) {
+	book := withdrawalBook{}
+	err := tx.QueryRow(`
+		SELECT "ID", "ISBN", "title", "inLibrary", "booksLeft", "withdrawnAt" IS NOT NULL
+		FROM "books"
+		WHERE ("ISBN" = ANY($1) OR "ISBN10" = ANY($1)) AND "purgedAt" IS NULL
+		FOR UPDATE;
+	`, pq.Array(isbnLookupKeys([]string{ISBN}))).
+		Scan(&book.ID, &book.ISBN, &book.Title, &book.InLibrary, &book.BooksLeft, &book.Withdrawn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`SELECT COUNT(*) FROM "checkout_tickets" WHERE "bookID" = $1 AND "isReturned" = false;`, book.ID).
+		Scan(&book.OpenLoans)
+	if err != nil {
+		return nil, err
+	}
+
+	return &book, nil
+}
+
+// withdrawalCopy is a copy of a book a withdrawal operation works on
+type withdrawalCopy struct {
+	Barcode string
+	Status  model.CopyStatus
+	OnLoan  bool
+}
+
+// lockWithdrawalCopies locks the copies of a book with the barcodes, a barcode of no copy of the book
+// is not found
+func lockWithdrawalCopies(tx *sql.Tx, bookID string, barcodes []string) ([]withdrawalCopy, error) {
+	copies := make([]withdrawalCopy, 0, len(barcodes))
+	for _, barcode := range barcodes {
+		barcode, err := itemcode.Parse(barcode)
+		if err != nil {
+			return nil, ErrBookCopyNotFound
+		}
+
+		bookCopy := withdrawalCopy{Barcode: barcode}
+		err = tx.QueryRow(`
+			SELECT
+				bc."status",
+				EXISTS (
+					SELECT 1 FROM "checkout_tickets" ct
+					WHERE ct."copyBarcode" = bc."barcode" AND ct."isCheckedOut" = true AND ct."isReturned" = false
+				)
+			FROM "book_copies" bc
+			WHERE bc."barcode" = $1 AND bc."bookID" = $2
+			FOR UPDATE OF bc;
+		`, barcode, bookID).Scan(&bookCopy.Status, &bookCopy.OnLoan)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBookCopyNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, bookCopy)
+	}
+	return copies, nil
+}
+
+// setCopiesStatus sets the status of the copies with the barcodes
+func setCopiesStatus(exec execer, barcodes []string, status model.CopyStatus) error {
+	_, err := exec.Exec(`UPDATE "book_copies" SET "status" = $2 WHERE "barcode" = ANY($1);`, pq.Array(barcodes), status)
+	return err
+}
+
+// recordBookWithdrawal adds an entry to the withdrawal history of a book
+func recordBookWithdrawal(exec execer, book *withdrawalBook, action model.BookWithdrawalAction, barcodes []string, reason, librarianID string) error {
+	var copies interface{}
+	if len(barcodes) > 0 {
+		copies = pq.Array(barcodes)
+	}
+	_, err := exec.Exec(`
+		INSERT INTO "book_withdrawals"("bookID", "ISBN", "title", "action", "copies", "barcodes", "reason", "librarianID")
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, NULLIF($7, ''), $8);
+	`, book.ID, book.ISBN, book.Title, action, len(barcodes), copies, reason, librarianID)
+	return err
+}
+
+// WithdrawBook takes the copies of a book with the barcodes out of circulation, or hides the whole book
+// from the catalogue and checkout when no barcodes are given. Loans and reviews of the book are kept.
+func (l *LibraryService) WithdrawBook(ISBN, librarianID string, request *model.WithdrawBookRequest) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] WithdrawBook(), db.Begin err: %v", err)
+		return ErrFailedWithdrawBook
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] WithdrawBook(), tx.Rollback err: %v", err)
+		}
+	}
+
+	book, err := lockWithdrawalBook(tx, ISBN)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWithdrawalBookNotFound
+		}
+		log.Error().Msgf("[Error] WithdrawBook(), lockWithdrawalBook err: %v", err)
+		return ErrFailedWithdrawBook
+	}
+	if book.Withdrawn {
+		rollback()
+		return ErrBookWithdrawn
+	}
+
+	var barcodes []string
+	if len(request.Barcodes) > 0 {
+		copies, err := lockWithdrawalCopies(tx, book.ID, request.Barcodes)
+		if err != nil {
+			rollback()
+			if errors.Is(err, ErrBookCopyNotFound) {
+				return err
+			}
+			log.Error().Msgf("[Error] WithdrawBook(), lockWithdrawalCopies err: %v", err)
+			return ErrFailedWithdrawBook
+		}
+
+		// only copies on the shelf can be withdrawn, borrowed ones are withdrawn once returned. Lost and
+		// damaged copies are written off without touching the shelf count.
+		var shelved int64
+		for _, bookCopy := range copies {
+			switch {
+			case bookCopy.Status == model.CopyStatusWithdrawn:
+				rollback()
+				return ErrCopyWithdrawn
+			case bookCopy.OnLoan:
+				rollback()
+				return ErrCopyOnLoan
+			case bookCopy.Status == model.CopyStatusAvailable:
+				shelved++
+			}
+			barcodes = append(barcodes, bookCopy.Barcode)
+		}
+		if shelved > book.BooksLeft {
+			rollback()
+			return ErrNotEnoughCopiesToWithdraw
+		}
+
+		if err := setCopiesStatus(tx, barcodes, model.CopyStatusWithdrawn); err != nil {
+			log.Error().Msgf("[Error] WithdrawBook(), setCopiesStatus err: %v", err)
+			rollback()
+			return ErrFailedWithdrawBook
+		}
+		if err := moveShelfCopies(tx, book.ID, -shelved); err != nil {
+			log.Error().Msgf("[Error] WithdrawBook(), moveShelfCopies err: %v", err)
+			rollback()
+			return ErrFailedWithdrawBook
+		}
+	} else {
+		_, err := tx.Exec(`
+			UPDATE "books" SET
+				"withdrawnAt" = NOW(),
+				"withdrawalReason" = $2,
+				"updatedAt" = NOW(),
+				"version" = "version" + 1
+			WHERE
+				"ID" = $1;
+		`, book.ID, request.Reason)
+		if err != nil {
+			log.Error().Msgf("[Error] WithdrawBook(), tx.Exec err: %v", err)
+			rollback()
+			return ErrFailedWithdrawBook
+		}
+	}
+
+	if err := recordBookWithdrawal(tx, book, model.BookWithdrawalActionWithdraw, barcodes, request.Reason, librarianID); err != nil {
+		log.Error().Msgf("[Error] WithdrawBook(), recordBookWithdrawal err: %v", err)
+		rollback()
+		return ErrFailedWithdrawBook
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] WithdrawBook(), tx.Commit err: %v", err)
+		return ErrFailedWithdrawBook
+	}
+
+	return nil
+}
+
+// RestoreBook puts the withdrawn copies of a book with the barcodes back on the shelf, or a withdrawn
+// book back into the catalogue once it has no open loans when no barcodes are given
+func (l *LibraryService) RestoreBook(ISBN, librarianID string, request *model.RestoreBookRequest) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] RestoreBook(), db.Begin err: %v", err)
+		return ErrFailedRestoreBook
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] RestoreBook(), tx.Rollback err: %v", err)
+		}
+	}
+
+	book, err := lockWithdrawalBook(tx, ISBN)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWithdrawalBookNotFound
+		}
+		log.Error().Msgf("[Error] RestoreBook(), lockWithdrawalBook err: %v", err)
+		return ErrFailedRestoreBook
+	}
+
+	var barcodes []string
+	if len(request.Barcodes) > 0 {
+		copies, err := lockWithdrawalCopies(tx, book.ID, request.Barcodes)
+		if err != nil {
+			rollback()
+			if errors.Is(err, ErrBookCopyNotFound) {
+				return err
+			}
+			log.Error().Msgf("[Error] RestoreBook(), lockWithdrawalCopies err: %v", err)
+			return ErrFailedRestoreBook
+		}
+		for _, bookCopy := range copies {
+			if bookCopy.Status != model.CopyStatusWithdrawn {
+				rollback()
+				return ErrCopyNotWithdrawn
+			}
+			barcodes = append(barcodes, bookCopy.Barcode)
+		}
+
+		if err := setCopiesStatus(tx, barcodes, model.CopyStatusAvailable); err != nil {
+			log.Error().Msgf("[Error] RestoreBook(), setCopiesStatus err: %v", err)
+			rollback()
+			return ErrFailedRestoreBook
+		}
+		if err := moveShelfCopies(tx, book.ID, int64(len(barcodes))); err != nil {
+			log.Error().Msgf("[Error] RestoreBook(), moveShelfCopies err: %v", err)
+			rollback()
+			return ErrFailedRestoreBook
+		}
+	} else {
+		if !book.Withdrawn {
+			rollback()
+			return ErrBookNotWithdrawn
+		}
+		if book.OpenLoans > 0 {
+			rollback()
+			return ErrBookHasOpenLoans
+		}
+
+		_, err = tx.Exec(`
+			UPDATE "books" SET
+				"withdrawnAt" = NULL,
+				"withdrawalReason" = NULL,
+				"updatedAt" = NOW(),
+				"version" = "version" + 1
+			WHERE
+				"ID" = $1;
+		`, book.ID)
+		if err != nil {
+			log.Error().Msgf("[Error] RestoreBook(), tx.Exec err: %v", err)
+			rollback()
+			return ErrFailedRestoreBook
+		}
+
+		if book.InLibrary && book.BooksLeft > 0 {
+			if err := notifyWishlistBackInStock(tx, book.ID); err != nil {
+				rollback()
+				return ErrFailedRestoreBook
+			}
+		}
+	}
+
+	if err := recordBookWithdrawal(tx, book, model.BookWithdrawalActionRestore, barcodes, request.Reason, librarianID); err != nil {
+		log.Error().Msgf("[Error] RestoreBook(), recordBookWithdrawal err: %v", err)
+		rollback()
+		return ErrFailedRestoreBook
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] RestoreBook(), tx.Commit err: %v", err)
+		return ErrFailedRestoreBook
+	}
+
+	return nil
+}
+
+// PurgeBook permanently removes a withdrawn book without open loans from the catalogue. The book keeps
+// its row with only its ISBN, title and author, so its past loans and reviews stay, while its catalogue
+// details, copies and wishlist entries are cleared. Purged books can't be restored.
+func (l *LibraryService) PurgeBook(ISBN, librarianID string, request *model.PurgeBookRequest) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		log.Error().Msgf("[Error] PurgeBook(), db.Begin err: %v", err)
+		return ErrFailedDeleteBook
+	}
+	rollback := func() {
+		if err := tx.Rollback(); err != nil {
+			log.Error().Msgf("[Error] PurgeBook(), tx.Rollback err: %v", err)
+		}
+	}
+
+	book, err := lockWithdrawalBook(tx, ISBN)
+	if err != nil {
+		rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWithdrawalBookNotFound
+		}
+		log.Error().Msgf("[Error] PurgeBook(), lockWithdrawalBook err: %v", err)
+		return ErrFailedDeleteBook
+	}
+	if !book.Withdrawn {
+		rollback()
+		return ErrBookNotWithdrawn
+	}
+	if book.OpenLoans > 0 {
+		rollback()
+		return ErrBookHasOpenLoans
+	}
+
+	if err := recordBookWithdrawal(tx, book, model.BookWithdrawalActionPurge, nil, request.Reason, librarianID); err != nil {
+		log.Error().Msgf("[Error] PurgeBook(), recordBookWithdrawal err: %v", err)
+		rollback()
+		return ErrFailedDeleteBook
+	}
+
+	for _, statement := range []string{
+		`
+			UPDATE "books" SET
+				"purgedAt" = NOW(),
+				"desc" = NULL,
+				"previewLink" = NULL,
+				"coverImage" = '',
+				"subtitle" = NULL,
+				"publisher" = NULL,
+				"price" = NULL,
+				"inLibrary" = false,
+				"booksLeft" = 0,
+				"shelfNumber" = 0,
+				"wishList" = '{}',
+				"wishlistCount" = 0,
+				"viewsList" = '{}',
+				"updatedAt" = NOW(),
+				"version" = "version" + 1
+			WHERE
+				"ID" = $1;
+		`,
+		`
+			UPDATE "book_details" SET
+				"wishlistBooks" = array_remove(array_remove("wishlistBooks", b."ISBN"), COALESCE(b."ISBN10", '')),
+				"updatedAt" = NOW()
+			FROM
+				"books" b
+			WHERE
+				b."ID" = $1 AND (b."ISBN" = ANY("wishlistBooks") OR b."ISBN10" = ANY("wishlistBooks"));
+		`,
+		`DELETE FROM "wishlist_adds" WHERE "bookID" = $1;`,
+	} {
+		if _, err := tx.Exec(statement, book.ID); err != nil {
+			log.Error().Msgf("[Error] PurgeBook(), tx.Exec err: %v", err)
+			rollback()
+			return ErrFailedDeleteBook
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE "book_copies" SET "status" = $2 WHERE "bookID" = $1;`, book.ID, model.CopyStatusWithdrawn)
+	if err != nil {
+		log.Error().Msgf("[Error] PurgeBook(), tx.Exec book_copies err: %v", err)
+		rollback()
+		return ErrFailedDeleteBook
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Msgf("[Error] PurgeBook(), tx.Commit err: %v", err)
+		return ErrFailedDeleteBook
+	}
+
+	return nil
+}
+
+// GetWithdrawnBooks returns the withdrawn books, the most recently withdrawn first
+func (l *LibraryService) GetWithdrawnBooks(request *model.GetWithdrawnBooksRequest) ([]model.Book, uint, error) {
+	sqlStatement := `
+		SELECT
+			` + bookColumns + `,
+			COUNT(*) OVER () AS "totalRows"
+		FROM
+			"books"
+		WHERE
+			"withdrawnAt" IS NOT NULL AND "purgedAt" IS NULL
+		ORDER BY
+			"withdrawnAt" DESC, "ID" ASC
+		%s; -- limit and offset
+	`
+
+	limitOffset := ` LIMIT %d OFFSET %d`
+	limitOffset = fmt.Sprintf(limitOffset, request.Limit, (request.Page-1)*(request.Limit))
+	sqlStatement = fmt.Sprintf(sqlStatement, limitOffset)
+
+	rows, err := l.db.Query(sqlStatement)
+	if err != nil {
+		log.Error().Msgf("[Error] GetWithdrawnBooks(), db.Query err: %v", err)
+		return nil, 0, ErrGetWithdrawnBooksFailed
+	}
+	defer rows.Close()
+
+	var (
+		books     = []model.Book{}
+		totalRows uint32
+	)
+	for rows.Next() {
+		book, err := scanBook(scanWithExtra(rows, &totalRows))
+		if err != nil {
+			log.Error().Msgf("[Error] GetWithdrawnBooks(), rows.Scan err: %v", err)
+			return nil, 0, ErrGetWithdrawnBooksFailed
+		}
+		books = append(books, *book)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetWithdrawnBooks(), rows.Err err: %v", err)
+		return nil, 0, ErrGetWithdrawnBooksFailed
+	}
+
+	totalPages := (totalRows + request.Limit - 1) / request.Limit
+
+	return books, uint(totalPages), nil
+}
+
+// GetBookWithdrawals returns the withdrawal history of a book by either ISBN, also after it was purged
+func (l *LibraryService) GetBookWithdrawals(ISBN string) ([]model.BookWithdrawal, error) {
+	sqlStatement := `
+		SELECT
+			"ID",
+			"bookID",
+			"ISBN",
+			"title",
+			"action",
+			"copies",
+			COALESCE("barcodes", '{}'),
+			COALESCE("reason", ''),
+			"librarianID",
+			"createdAt"
+		FROM
+			"book_withdrawals"
+		WHERE
+			"ISBN" = ANY($1)
+		ORDER BY
+			"createdAt" DESC;
+	`
+
+	rows, err := l.db.Query(sqlStatement, pq.Array(isbnLookupKeys([]string{ISBN})))
+	if err != nil {
+		log.Error().Msgf("[Error] GetBookWithdrawals(), db.Query err: %v", err)
+		return nil, ErrGetBookWithdrawalsFailed
+	}
+	defer rows.Close()
+
+	withdrawals := []model.BookWithdrawal{}
+	for rows.Next() {
+		withdrawal := model.BookWithdrawal{}
+		err := rows.Scan(
+			&withdrawal.ID,
+			&withdrawal.BookID,
+			&withdrawal.ISBN,
+			&withdrawal.Title,
+			&withdrawal.Action,
+			&withdrawal.Copies,
+			pq.Array(&withdrawal.Barcodes),
+			&withdrawal.Reason,
+			&withdrawal.LibrarianID,
+			&withdrawal.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Msgf("[Error] GetBookWithdrawals(), rows.Scan err: %v", err)
+			return nil, ErrGetBookWithdrawalsFailed
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Msgf("[Error] GetBookWithdrawals(), rows.Err err: %v", err)
+		return nil, ErrGetBookWithdrawalsFailed
+	}
+
+	return withdrawals, nil
+}
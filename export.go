@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"integrated-library-service/domain"
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+const exportUsage = `usage: %s export books|users|checkouts [options]
+
+Streams books, users or the checkout history to a file or the standard output, with the
+filters of the export endpoints.
+
+`
+
+// runExport runs the export subcommand and returns the exit code of the program
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), exportUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	var (
+		format         = flags.String("format", "csv", "csv, jsonl or marcxml for books")
+		out            = flags.String("out", "-", "file to write, - for the standard output")
+		sortBy         = flags.String("sortBy", "", "field to sort by")
+		orderBy        = flags.String("orderBy", "", "asc or desc")
+		searchBy       = flags.String("searchBy", "", "books and users: field searched for searchText")
+		searchText     = flags.String("searchText", "", "books and users: text to search")
+		language       = flags.String("language", "", "books: language")
+		publisher      = flags.String("publisher", "", "books: publisher")
+		maturityRating = flags.String("maturityRating", "", "books: NOT_MATURE or MATURE")
+		minPageCount   = flags.Uint("minPageCount", 0, "books: minimum page count")
+		maxPageCount   = flags.Uint("maxPageCount", 0, "books: maximum page count")
+		userID         = flags.String("userID", "", "checkouts: loans of a user")
+		ISBN           = flags.String("isbn", "", "checkouts: loans of a book")
+		from           = flags.String("from", "", "checkouts: reserved on or after, 2006-01-02")
+		to             = flags.String("to", "", "checkouts: reserved on or before, 2006-01-02")
+		returned       = flags.String("returned", "", "checkouts: true for returned loans, false for open ones")
+	)
+
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+	kind := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	db, err := openDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error connecting DB: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	libraryService := domain.NewLibraryService(db)
+
+	var run func(w io.Writer) error
+	switch kind {
+	case "books":
+		req := &model.ExportBooksRequest{
+			Format:         model.ExportFormat(*format),
+			SortBy:         *sortBy,
+			OrderBy:        *orderBy,
+			SearchBy:       *searchBy,
+			SearchText:     *searchText,
+			Language:       *language,
+			Publisher:      *publisher,
+			MaturityRating: *maturityRating,
+			MinPageCount:   uint32(*minPageCount),
+			MaxPageCount:   uint32(*maxPageCount),
+		}
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid options: %v\n", err)
+			return 2
+		}
+		run = func(w io.Writer) error {
+			writer, err := export.NewBookWriter(w, req.Format)
+			if err != nil {
+				return err
+			}
+			if err := libraryService.ExportBooks(req.SearchRequest(), writer.WriteBook); err != nil {
+				return err
+			}
+			return writer.Close()
+		}
+	case "users":
+		req := &model.ExportUsersRequest{
+			Format:     model.ExportFormat(*format),
+			SortBy:     *sortBy,
+			OrderBy:    *orderBy,
+			SearchBy:   *searchBy,
+			SearchText: *searchText,
+		}
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid options: %v\n", err)
+			return 2
+		}
+		run = func(w io.Writer) error {
+			writer, err := export.NewUserWriter(w, req.Format)
+			if err != nil {
+				return err
+			}
+			if err := libraryService.ExportUsers(req.SearchRequest(), writer.WriteUser); err != nil {
+				return err
+			}
+			return writer.Close()
+		}
+	case "checkouts":
+		req := &model.ExportCheckoutsRequest{
+			Format:  model.ExportFormat(*format),
+			SortBy:  *sortBy,
+			OrderBy: *orderBy,
+			UserID:  *userID,
+			ISBN:    *ISBN,
+		}
+		if req.From, err = dateOption("from", *from); err == nil {
+			req.To, err = dateOption("to", *to)
+		}
+		if err == nil && len(*returned) > 0 {
+			var isReturned bool
+			isReturned, err = strconv.ParseBool(*returned)
+			req.IsReturned = &isReturned
+		}
+		if err == nil {
+			err = binding.Validator.ValidateStruct(req)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid options: %v\n", err)
+			return 2
+		}
+		run = func(w io.Writer) error {
+			writer, err := export.NewCheckoutWriter(w, req.Format)
+			if err != nil {
+				return err
+			}
+			if err := libraryService.ExportCheckouts(req, writer.WriteCheckout); err != nil {
+				return err
+			}
+			return writer.Close()
+		}
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	if err := exportTo(*out, run); err != nil {
+		fmt.Fprintf(os.Stderr, "export failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// exportTo runs an export into the file at path, a failed export removes the incomplete file
+func exportTo(path string, run func(w io.Writer) error) error {
+	if path == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := run(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if err = run(w); err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// dateOption parses a date option, nil when it is not given
+func dateOption(name, value string) (*time.Time, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a date like 2006-01-02", name)
+	}
+	return &t, nil
+}
@@ -0,0 +1,51 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// formulaPrefixes start a cell a spreadsheet would evaluate as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// CSVWriter is a csv.Writer which neutralises spreadsheet formulas in every cell it writes, every CSV
+// the service hands out goes through it since titles, names and search terms come from patrons
+type CSVWriter struct {
+	*csv.Writer
+}
+
+// NewCSVWriter returns a CSVWriter writing to w
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{Writer: csv.NewWriter(w)}
+}
+
+// Write writes a record with every cell escaped by EscapeCSVCell
+func (w *CSVWriter) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, cell := range record {
+		escaped[i] = EscapeCSVCell(cell)
+	}
+	return w.Writer.Write(escaped)
+}
+
+// EscapeCSVCell prefixes a cell starting like a formula with a quote so spreadsheets show it as text,
+// plain numbers like -5 are left as they are
+func EscapeCSVCell(cell string) string {
+	if len(cell) == 0 || !strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
+
+// UnescapeCSVCell reverses EscapeCSVCell, so exported files can be imported again as they are
+func UnescapeCSVCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
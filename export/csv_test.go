@@ -0,0 +1,52 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeCSVCell(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"", ""},
+		{"The Hobbit", "The Hobbit"},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1+1", "'+1+1"},
+		{"-Bob", "'-Bob"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"-5", "-5"},
+		{"+3.25", "+3.25"},
+		{"-1e3", "-1e3"},
+		{"a=b", "a=b"},
+		{"'quoted", "'quoted"},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeCSVCell(tt.cell); got != tt.want {
+			t.Errorf("EscapeCSVCell(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+		if got := UnescapeCSVCell(EscapeCSVCell(tt.cell)); got != tt.cell {
+			t.Errorf("UnescapeCSVCell(EscapeCSVCell(%q)) = %q, want it back", tt.cell, got)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewCSVWriter(&out)
+	if err := writer.Write([]string{"title", "=1+1", "-5"}); err != nil {
+		t.Fatalf("Write() err = %v", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		t.Fatalf("Flush() err = %v", err)
+	}
+
+	if got, want := out.String(), "title,'=1+1,-5\n"; got != want {
+		t.Errorf("written CSV = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,306 @@
+// Package export writes books, users and checkouts as CSV, JSON Lines or MARCXML files
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"integrated-library-service/marc"
+	"integrated-library-service/model"
+)
+
+// ErrUnsupportedFormat is an error when a kind of record can not be written in a format
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// listSeparator joins the authors and genres columns, the catalogue import splits them again
+const listSeparator = "; "
+
+// bookCSVHeader is the header row of exported books, the columns are named like the fields of the
+// catalogue import so an export can be imported as it is
+var bookCSVHeader = []string{
+	"ID",
+	"ISBN",
+	"ISBN10",
+	"title",
+	"subtitle",
+	"author",
+	"authors",
+	"genre",
+	"genres",
+	"publisher",
+	"publishedDate",
+	"language",
+	"pageCount",
+	"maturityRating",
+	"desc",
+	"previewLink",
+	"coverImage",
+	"shelfNumber",
+	"price",
+	"inLibrary",
+	"booksLeft",
+	"onLoan",
+	"reserved",
+	"totalCopies",
+	"available",
+	"rating",
+	"createdAt",
+}
+
+// userCSVHeader is the header row of exported users
+var userCSVHeader = []string{
+	"userID",
+	"name",
+	"email",
+	"role",
+	"dateOfBirth",
+	"phoneNumber",
+	"address",
+	"country",
+	"joinedDate",
+	"fineAmount",
+	"isPaymentDone",
+	"reservedBooksCount",
+	"checkedOutBooksCount",
+	"completedBooksCount",
+	"createdAt",
+}
+
+// checkoutCSVHeader is the header row of exported checkouts
+var checkoutCSVHeader = []string{
+	"ID",
+	"userID",
+	"userName",
+	"userEmail",
+	"bookID",
+	"ISBN",
+	"title",
+	"isCheckedOut",
+	"isReturned",
+	"numberOfDays",
+	"fineAmount",
+	"reservedOn",
+	"checkedOutOn",
+	"returnedDate",
+}
+
+// Writer writes one kind of record in a format. Records are written as they come so memory stays
+// bounded, Close must be called to complete the file.
+type Writer struct {
+	format model.ExportFormat
+	header []string
+	csv    *CSVWriter
+	json   *json.Encoder
+	marc   *marc.XMLWriter
+}
+
+// NewBookWriter returns a Writer of books in CSV, JSON Lines or MARCXML
+func NewBookWriter(w io.Writer, format model.ExportFormat) (*Writer, error) {
+	return newWriter(w, format, bookCSVHeader, true)
+}
+
+// NewUserWriter returns a Writer of users in CSV or JSON Lines
+func NewUserWriter(w io.Writer, format model.ExportFormat) (*Writer, error) {
+	return newWriter(w, format, userCSVHeader, false)
+}
+
+// NewCheckoutWriter returns a Writer of checkouts in CSV or JSON Lines
+func NewCheckoutWriter(w io.Writer, format model.ExportFormat) (*Writer, error) {
+	return newWriter(w, format, checkoutCSVHeader, false)
+}
+
+func newWriter(w io.Writer, format model.ExportFormat, header []string, marcAllowed bool) (*Writer, error) {
+	writer := &Writer{format: format, header: header}
+	switch {
+	case format == model.ExportFormatCSV:
+		writer.csv = NewCSVWriter(w)
+	case format == model.ExportFormatJSONL:
+		writer.json = json.NewEncoder(w)
+	case format == model.ExportFormatMARCXML && marcAllowed:
+		writer.marc = marc.NewXMLWriter(w)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	return writer, nil
+}
+
+// ContentType returns the media type of a format
+func ContentType(format model.ExportFormat) string {
+	switch format {
+	case model.ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case model.ExportFormatJSONL:
+		return "application/x-ndjson"
+	case model.ExportFormatMARCXML:
+		return "application/marcxml+xml"
+	}
+	return "application/octet-stream"
+}
+
+// Extension returns the file extension of a format
+func Extension(format model.ExportFormat) string {
+	if format == model.ExportFormatMARCXML {
+		return ".xml"
+	}
+	return "." + string(format)
+}
+
+// WriteBook writes a book
+func (w *Writer) WriteBook(book *model.BookExport) error {
+	if w.marc != nil {
+		return w.marc.Write(bookRecord(book))
+	}
+	return w.write(book, func() []string {
+		return []string{
+			book.ID,
+			book.ISBN,
+			book.ISBN10,
+			book.Title,
+			book.Subtitle,
+			book.Author,
+			strings.Join(book.Authors, listSeparator),
+			book.Genre,
+			strings.Join(book.Genres, listSeparator),
+			book.Publisher,
+			book.PublishedDate.Format("2006-01-02"),
+			book.Language,
+			strconv.FormatInt(book.PageCount, 10),
+			book.MaturityRating,
+			book.Description,
+			book.PreviewLink,
+			book.CoverImage,
+			strconv.FormatInt(book.ShelfNumber, 10),
+			formatPrice(book.Price),
+			strconv.FormatBool(book.InLibrary),
+			strconv.FormatInt(book.BooksLeft, 10),
+			strconv.FormatInt(book.OnLoan, 10),
+			strconv.FormatInt(book.Reserved, 10),
+			strconv.FormatInt(book.TotalCopies, 10),
+			strconv.FormatBool(book.Available),
+			strconv.FormatFloat(book.Rating, 'f', 2, 64),
+			book.CreatedAt.Format(time.RFC3339),
+		}
+	})
+}
+
+// WriteUser writes a user
+func (w *Writer) WriteUser(user *model.UserExport) error {
+	return w.write(user, func() []string {
+		return []string{
+			user.UserID,
+			user.Name,
+			user.Email,
+			string(user.Role),
+			formatDate(user.DateOfBirth),
+			formatString(user.PhoneNumber),
+			formatString(user.Address),
+			formatString(user.Country),
+			user.JoinedDate.Format(time.RFC3339),
+			strconv.FormatFloat(user.FineAmount, 'f', 2, 64),
+			strconv.FormatBool(user.IsPaymentDone),
+			strconv.FormatInt(user.ReservedBooksCount, 10),
+			strconv.FormatInt(user.CheckedOutBooksCount, 10),
+			strconv.FormatInt(user.CompletedBooksCount, 10),
+			user.CreatedAt.Format(time.RFC3339),
+		}
+	})
+}
+
+// WriteCheckout writes a checkout
+func (w *Writer) WriteCheckout(checkout *model.CheckoutExport) error {
+	return w.write(checkout, func() []string {
+		return []string{
+			checkout.ID,
+			checkout.UserID,
+			checkout.UserName,
+			checkout.UserEmail,
+			checkout.BookID,
+			checkout.ISBN,
+			checkout.Title,
+			strconv.FormatBool(checkout.IsCheckedOut),
+			strconv.FormatBool(checkout.IsReturned),
+			strconv.FormatInt(checkout.NumberOfDays, 10),
+			strconv.FormatFloat(checkout.FineAmount, 'f', 2, 64),
+			formatTime(checkout.ReservedOn),
+			formatTime(checkout.CheckedOutOn),
+			formatTime(checkout.ReturnedDate),
+		}
+	})
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (w *Writer) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Close completes the file, an export without records still gets its CSV header or an empty
+// MARCXML collection. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.marc != nil {
+		return w.marc.Close()
+	}
+	return w.Flush()
+}
+
+// write writes value as a JSON line or the CSV row returned by row
+func (w *Writer) write(value interface{}, row func() []string) error {
+	switch {
+	case w.json != nil:
+		return w.json.Encode(value)
+	case w.csv != nil:
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+		return w.csv.Write(row())
+	}
+	return ErrUnsupportedFormat
+}
+
+// writeHeader writes the CSV header before the first row
+func (w *Writer) writeHeader() error {
+	if w.header == nil {
+		return nil
+	}
+	header := w.header
+	w.header = nil
+	return w.csv.Write(header)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatPrice(p *float64) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*p, 'f', 2, 64)
+}
+
+func formatString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
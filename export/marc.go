@@ -0,0 +1,110 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"integrated-library-service/marc"
+	"integrated-library-service/model"
+)
+
+// bookLeader is the leader of a monograph, its lengths are left to the systems reading the records
+const bookLeader = "00000nam a2200000 i 4500"
+
+// bookRecord maps a book to a MARC 21 bibliographic record, the copies and their availability go
+// to the location and a local note
+func bookRecord(book *model.BookExport) *marc.Record {
+	record := &marc.Record{
+		Leader:        bookLeader,
+		ControlFields: map[string]string{"001": book.ID, "008": fixedData(book)},
+		DataFields:    []marc.DataField{},
+	}
+	add := func(tag, ind1, ind2 string, subfields ...marc.Subfield) {
+		record.DataFields = append(record.DataFields, marc.DataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: subfields})
+	}
+
+	add("020", " ", " ", marc.Subfield{Code: "a", Value: book.ISBN})
+	if len(book.ISBN10) > 0 {
+		add("020", " ", " ", marc.Subfield{Code: "a", Value: book.ISBN10})
+	}
+	if len(book.Language) > 0 {
+		add("041", " ", " ", marc.Subfield{Code: "a", Value: book.Language})
+	}
+
+	titleAdded := "0"
+	if len(book.Author) > 0 {
+		titleAdded = "1"
+		add("100", "1", " ", marc.Subfield{Code: "a", Value: book.Author})
+	}
+	title := []marc.Subfield{{Code: "a", Value: book.Title}}
+	if len(book.Subtitle) > 0 {
+		title = append(title, marc.Subfield{Code: "b", Value: book.Subtitle})
+	}
+	add("245", titleAdded, "0", title...)
+
+	publication := []marc.Subfield{}
+	if len(book.Publisher) > 0 {
+		publication = append(publication, marc.Subfield{Code: "b", Value: book.Publisher})
+	}
+	if !book.PublishedDate.IsZero() {
+		publication = append(publication, marc.Subfield{Code: "c", Value: book.PublishedDate.Format("2006")})
+	}
+	if len(publication) > 0 {
+		add("264", " ", "1", publication...)
+	}
+
+	if book.PageCount > 0 {
+		add("300", " ", " ", marc.Subfield{Code: "a", Value: fmt.Sprintf("%d pages", book.PageCount)})
+	}
+	if len(book.Description) > 0 {
+		add("520", " ", " ", marc.Subfield{Code: "a", Value: book.Description})
+	}
+
+	available := "no"
+	if book.Available {
+		available = "yes"
+	}
+	add("590", " ", " ", marc.Subfield{
+		Code:  "a",
+		Value: fmt.Sprintf("Copies: %d, on loan: %d, reserved: %d, available: %s", book.TotalCopies, book.OnLoan, book.Reserved, available),
+	})
+
+	genres := book.Genres
+	if len(genres) == 0 && len(book.Genre) > 0 {
+		genres = []string{book.Genre}
+	}
+	for _, genre := range genres {
+		add("650", " ", "4", marc.Subfield{Code: "a", Value: genre})
+	}
+
+	for _, author := range book.Authors {
+		if author != book.Author {
+			add("700", "1", " ", marc.Subfield{Code: "a", Value: author})
+		}
+	}
+
+	add("852", " ", " ",
+		marc.Subfield{Code: "h", Value: strconv.FormatInt(book.ShelfNumber, 10)},
+		marc.Subfield{Code: "t", Value: strconv.FormatInt(book.TotalCopies, 10)},
+	)
+	if len(book.PreviewLink) > 0 {
+		add("856", "4", "0", marc.Subfield{Code: "u", Value: book.PreviewLink})
+	}
+
+	return record
+}
+
+// fixedData returns the 008 field of a book, languages which are not a three letter MARC code are
+// left undetermined and given in 041 instead
+func fixedData(book *model.BookExport) string {
+	language := "und"
+	if len(book.Language) == 3 {
+		language = book.Language
+	}
+	year := "    "
+	if !book.PublishedDate.IsZero() {
+		year = book.PublishedDate.Format("2006")
+	}
+	return fmt.Sprintf("%ss%s    xx "+strings.Repeat(" ", 11)+"000 0 %s d", book.CreatedAt.Format("060102"), year, language)
+}
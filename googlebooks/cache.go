@@ -0,0 +1,132 @@
+package googlebooks
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"integrated-library-service/model"
+)
+
+// cacheEntry is a single cached google response
+type cacheEntry struct {
+	books     []*model.CreateBookRequest
+	total     int
+	expiresAt time.Time
+}
+
+// responseCache is an in memory TTL cache of google responses keyed by normalised query
+type responseCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]cacheEntry
+}
+
+// newResponseCache returns a cache holding at most size entries
+func newResponseCache(size int) *responseCache {
+	return &responseCache{
+		size:    size,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// normaliseQuery lower cases the query and collapses its whitespace so equivalent searches share a key
+func normaliseQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// cacheKey builds the cache key of an operation, its normalised query and page
+func cacheKey(operation, query string, page, limit uint32) string {
+	return fmt.Sprintf("%s|%s|%d|%d", operation, normaliseQuery(query), page, limit)
+}
+
+// get returns the cached response for the key if it is not expired
+func (rc *responseCache) get(key string) ([]*model.CreateBookRequest, int, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(rc.entries, key)
+		return nil, 0, false
+	}
+
+	return copyBooks(entry.books), entry.total, true
+}
+
+// copyBooks returns a copy of the books with copied elements, callers edit the books they are given
+// so the cache must not share them
+func copyBooks(books []*model.CreateBookRequest) []*model.CreateBookRequest {
+	copied := make([]*model.CreateBookRequest, len(books))
+	for i, book := range books {
+		if book == nil {
+			continue
+		}
+		bookCopy := *book
+		bookCopy.ShelfNumber = copyValue(book.ShelfNumber)
+		bookCopy.InLibrary = copyValue(book.InLibrary)
+		bookCopy.Views = copyValue(book.Views)
+		bookCopy.BooksLeft = copyValue(book.BooksLeft)
+		bookCopy.Price = copyValue(book.Price)
+		bookCopy.Authors = copyValues(book.Authors)
+		bookCopy.Genres = copyValues(book.Genres)
+		bookCopy.WishList = copyValues(book.WishList)
+		bookCopy.ReviewsList = copyValues(book.ReviewsList)
+		bookCopy.ViewsList = copyValues(book.ViewsList)
+		bookCopy.WishlistCount = copyValue(book.WishlistCount)
+		bookCopy.Rating = copyValue(book.Rating)
+		bookCopy.ReviewCount = copyValue(book.ReviewCount)
+		bookCopy.ApproximateDemand = copyValue(book.ApproximateDemand)
+		copied[i] = &bookCopy
+	}
+	return copied
+}
+
+// copyValue returns a pointer to a copy of the value p points to
+func copyValue[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	value := *p
+	return &value
+}
+
+// copyValues returns a copy of values, nil stays nil
+func copyValues(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+// set stores the response for ttl, expired entries are swept when the cache is full
+func (rc *responseCache) set(key string, books []*model.CreateBookRequest, total int, ttl time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := time.Now()
+	if len(rc.entries) >= rc.size {
+		for k, entry := range rc.entries {
+			if now.After(entry.expiresAt) {
+				delete(rc.entries, k)
+			}
+		}
+	}
+	// still full, drop an arbitrary entry to stay bounded
+	if len(rc.entries) >= rc.size {
+		for k := range rc.entries {
+			delete(rc.entries, k)
+			break
+		}
+	}
+
+	rc.entries[key] = cacheEntry{
+		books:     copyBooks(books),
+		total:     total,
+		expiresAt: now.Add(ttl),
+	}
+}
@@ -1,30 +1,55 @@
 package googlebooks
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
+	"integrated-library-service/bookprovider"
 	"integrated-library-service/model"
 )
 
+var (
+	// ErrCircuitOpen is an error when google calls are short circuited after repeated failures
+	ErrCircuitOpen = errors.New("google books circuit is open")
+	// errRequestTimeout is the cause of a call to google running out of its RequestTimeout, unlike a
+	// caller giving up it counts as a failure of google
+	errRequestTimeout = errors.New("google books request timed out")
+)
+
 // Client ...
 type Client interface {
-	GetGoogleBooks(request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error)
-	SearchGoogleBooks(request *model.SearchRequest) ([]*model.CreateBookRequest, int, error)
+	GetGoogleBooks(ctx context.Context, request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error)
+	SearchGoogleBooks(ctx context.Context, request *model.SearchRequest) ([]*model.CreateBookRequest, int, error)
+	GetGoogleBookByISBN(ctx context.Context, ISBN string) (*model.CreateBookRequest, error)
 }
 
 // GoogleBooksClient
 type GoogleBooksClient struct {
-	url    string
-	apiKey string
-	client *http.Client
+	url     string
+	apiKey  string
+	client  *http.Client
+	options Options
+	cache   *responseCache
+	breaker *bookprovider.CircuitBreaker
 }
 
 // New returns new instance of NewGoogleService
-func NewGoogleService(URL string, apiKey string, client *http.Client) *GoogleBooksClient {
-	return &GoogleBooksClient{client: client, url: URL, apiKey: apiKey}
+func NewGoogleService(URL string, apiKey string, client *http.Client, options Options) *GoogleBooksClient {
+	options = options.withDefaults()
+	return &GoogleBooksClient{
+		client:  client,
+		url:     URL,
+		apiKey:  apiKey,
+		options: options,
+		cache:   newResponseCache(options.CacheSize),
+		breaker: bookprovider.NewCircuitBreaker(options.FailureThreshold, options.OpenDuration),
+	}
 }
 
 // GetClient returns new generated http.Client for Google.client
@@ -45,7 +70,9 @@ func (googleBooksClient *GoogleBooksClient) parseURL(URL string) (*url.URL, erro
 	return fullURL, nil
 }
 
-// do modify request by attaching baseURL and basic Authentication keys
+// do modify request by attaching baseURL and basic Authentication keys, 429 and 5xx responses are
+// retried with exponential backoff and repeated failures, including 403 quota errors, open the circuit
+// breaker
 func (googleBooksClient *GoogleBooksClient) do(request *http.Request) (*WebResponse, error) {
 	fullURL, err := googleBooksClient.parseURL(request.URL.String())
 	if err != nil {
@@ -57,10 +84,89 @@ func (googleBooksClient *GoogleBooksClient) do(request *http.Request) (*WebRespo
 		request.Header.Set("Content-Type", "application/json")
 	}
 
-	res, err := googleBooksClient.client.Do(request)
-	if err != nil {
-		return nil, err
+	if !googleBooksClient.breaker.Allow() {
+		return nil, ErrCircuitOpen
 	}
 
-	return NewWebResponse(res), nil
+	ctx := request.Context()
+	for attempt := 0; ; attempt++ {
+		res, err := googleBooksClient.client.Do(request.Clone(ctx))
+		if err != nil {
+			if callerGaveUp(ctx) {
+				googleBooksClient.breaker.Abandoned()
+			} else {
+				googleBooksClient.breaker.Failure()
+			}
+			return nil, err
+		}
+
+		if !isRetryable(res.StatusCode) {
+			switch {
+			case isAnswered(res.StatusCode):
+				googleBooksClient.breaker.Success()
+			case res.StatusCode == http.StatusForbidden:
+				// google answers an exhausted quota or rate limit with 403, keep calling it only once
+				// the circuit lets a trial call through
+				googleBooksClient.breaker.Failure()
+			default:
+				// any other client error is of the request, it says nothing about google
+				googleBooksClient.breaker.Abandoned()
+			}
+			return NewWebResponse(res), nil
+		}
+
+		if attempt >= googleBooksClient.options.MaxRetries {
+			googleBooksClient.breaker.Failure()
+			return NewWebResponse(res), nil
+		}
+
+		delay := googleBooksClient.backoff(attempt, res.Header.Get("Retry-After"))
+		res.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			if callerGaveUp(ctx) {
+				googleBooksClient.breaker.Abandoned()
+			} else {
+				googleBooksClient.breaker.Failure()
+			}
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// callerGaveUp reports whether the caller cancelled the call or its own deadline passed, which says
+// nothing about google and must not open the circuit for everyone
+func callerGaveUp(ctx context.Context) bool {
+	return ctx.Err() != nil && !errors.Is(context.Cause(ctx), errRequestTimeout)
+}
+
+// isAnswered reports whether google answered the call, with the result or without the book
+func isAnswered(statusCode int) bool {
+	return statusCode == http.StatusNotFound || (statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices)
+}
+
+// isRetryable reports whether google should be called again for the status code
+func isRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before the next retry, a Retry-After header in seconds takes precedence
+func (googleBooksClient *GoogleBooksClient) backoff(attempt int, retryAfter string) time.Duration {
+	maxDelay := googleBooksClient.options.RetryMaxDelay
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay := time.Duration(seconds) * time.Second
+		if delay > maxDelay {
+			return maxDelay
+		}
+		return delay
+	}
+
+	delay := googleBooksClient.options.RetryBaseDelay << uint(attempt)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	// full jitter keeps concurrent retries from hitting google in lock step
+	return time.Duration(rand.Int63n(int64(delay)) + 1) // #nosec G404 -- jitter does not need crypto randomness
 }
@@ -0,0 +1,70 @@
+package googlebooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"integrated-library-service/bookprovider"
+	"integrated-library-service/model"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrGoogleBookNotFound is an error when google has no volume for the given ISBN, it wraps
+	// bookprovider.ErrBookNotFound so the provider chain moves on without counting a failure
+	ErrGoogleBookNotFound = fmt.Errorf("google: %w", bookprovider.ErrBookNotFound)
+)
+
+// GetGoogleBookByISBN gets the google volume matching the given ISBN
+func (g *GoogleBooksClient) GetGoogleBookByISBN(ctx context.Context, ISBN string) (*model.CreateBookRequest, error) {
+	key := cacheKey("isbn", ISBN, 1, 1)
+	if books, _, ok := g.cache.get(key); ok {
+		if len(books) == 0 {
+			return nil, ErrGoogleBookNotFound
+		}
+		return books[0], nil
+	}
+
+	ctx, cancel := context.WithTimeoutCause(ctx, g.options.RequestTimeout, errRequestTimeout)
+	defer cancel()
+
+	url := fmt.Sprintf("/v1/volumes?q=%s&maxResults=%v&key=%v", url.QueryEscape("isbn:"+ISBN), 1, g.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		log.Error().Msgf("http request error : %v ", err)
+		return nil, ErrGetGoogleBooks
+	}
+
+	resp, err := g.do(req)
+	if err != nil {
+		log.Error().Msgf("response error : %v ", err)
+		return nil, ErrGetGoogleBooks
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Error().Msgf("getGoogleBookByISBN error : %v , status : %v ", bodyBytes, resp.StatusCode)
+		return nil, ErrGetGoogleBooks
+	}
+
+	var googleBooks model.GoogleBookResponse
+	if err = json.NewDecoder(resp.Body).Decode(&googleBooks); err != nil {
+		log.Error().Msgf("decode body error : %v ", err)
+		return nil, ErrGetGoogleBooks
+	}
+
+	for _, googleBook := range googleBooks.Items {
+		if book, ok := toCreateBookRequest(googleBook.VolumeInfo); ok {
+			g.cache.set(key, []*model.CreateBookRequest{book}, 1, g.options.ISBNCacheTTL)
+			return book, nil
+		}
+	}
+	g.cache.set(key, []*model.CreateBookRequest{}, 0, g.options.ISBNCacheTTL)
+
+	return nil, ErrGoogleBookNotFound
+}
@@ -1,6 +1,7 @@
 package googlebooks
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,15 +19,18 @@ var (
 )
 
 // GetGoogleBooks gets required google books
-func (g *GoogleBooksClient) GetGoogleBooks(request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error) {
-	// requestBody, err := json.Marshal(&createCustomerAccountRequest)
-	// if err != nil {
-	// 	log.Error().Msgf("marshal error : %v ", err)
-	// 	return nil, &CustomerAccountCreateResponse{Response: ErrorResponse(err, false)}
-	// }
+func (g *GoogleBooksClient) GetGoogleBooks(ctx context.Context, request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error) {
+	key := cacheKey("newest", "", request.Page, request.Limit)
+	if books, total, ok := g.cache.get(key); ok {
+		return books, total, nil
+	}
+
+	ctx, cancel := context.WithTimeoutCause(ctx, g.options.RequestTimeout, errRequestTimeout)
+	defer cancel()
+
 	startIndex := ((request.Page) - 1) * (request.Limit)
 	url := fmt.Sprintf("/v1/volumes?q=orderBy=%v&startIndex=%v&maxResults=%v&key=%v", "newest", startIndex, request.Limit, g.apiKey)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		log.Error().Msgf("http request error : %v ", err)
 		return nil, 0, ErrGetGoogleBooks
@@ -37,6 +41,7 @@ func (g *GoogleBooksClient) GetGoogleBooks(request *model.GetAllBooksRequest) ([
 		log.Error().Msgf("response error : %v ", err)
 		return nil, 0, ErrGetGoogleBooks
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
@@ -52,55 +57,13 @@ func (g *GoogleBooksClient) GetGoogleBooks(request *model.GetAllBooksRequest) ([
 
 	books := []*model.CreateBookRequest{}
 	for _, googleBook := range googleBooks.Items {
-		book := model.CreateBookRequest{
-			Title:       googleBook.VolumeInfo.Title,
-			CoverImage:  googleBook.VolumeInfo.ImageLinks.Thumbnail,
-			Description: googleBook.VolumeInfo.Subtitle,
-			PreviewLink: googleBook.VolumeInfo.PreviewLink,
-		}
-		if len(googleBook.VolumeInfo.IndustryIdentifiers) > 0 {
-			book.ISBN = googleBook.VolumeInfo.IndustryIdentifiers[0].Identifier
-		} else {
+		book, ok := toCreateBookRequest(googleBook.VolumeInfo)
+		if !ok {
 			continue
 		}
-
-		if len(googleBook.VolumeInfo.Categories) > 0 {
-			book.Genre = googleBook.VolumeInfo.Categories[0]
-		} else {
-			book.Genre = "other"
-		}
-
-		if len(googleBook.VolumeInfo.Authors) > 0 {
-			book.Author = googleBook.VolumeInfo.Authors[0]
-		} else {
-			book.Author = "unknown"
-		}
-
-		t, err := convertAndFormatDate(googleBook.VolumeInfo.PublishedDate)
-		if err != nil {
-			log.Error().Msgf("Error while converting string to data %v", err)
-		} else {
-			book.PublishedDate = *t
-		}
-
-		// declaring default values
-		mockvalue := int64(0)
-		mockFloat := float64(0)
-		mockBool := false
-		book.BooksLeft = &mockvalue
-		book.ShelfNumber = &mockvalue
-		book.InLibrary = &mockBool
-		book.Rating = &mockFloat
-		book.ReviewCount = &mockvalue
-		book.Views = &mockvalue
-		book.ReviewsList = []string{}
-		book.ViewsList = []string{}
-		book.WishList = []string{}
-		book.WishlistCount = &mockvalue
-		book.ApproximateDemand = &mockvalue
-
-		books = append(books, &book)
+		books = append(books, book)
 	}
+	g.cache.set(key, books, googleBooks.TotalItems, g.options.CacheTTL)
 
 	return books, googleBooks.TotalItems, nil
 }
@@ -0,0 +1,83 @@
+package googlebooks
+
+import (
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// pickISBN returns the canonical ISBN-13 of a volume, preferring its ISBN_13 identifier and falling back
+// to a converted ISBN_10, identifiers of other types and identifiers with a bad check digit are skipped
+func pickISBN(identifiers []model.Identifier) (string, bool) {
+	for _, wanted := range []string{"ISBN_13", "ISBN_10"} {
+		for _, identifier := range identifiers {
+			if identifier.Type != wanted {
+				continue
+			}
+			if ISBN13, _, err := isbn.Parse(identifier.Identifier); err == nil {
+				return ISBN13, true
+			}
+		}
+	}
+	return "", false
+}
+
+// toCreateBookRequest maps a google volume into a CreateBookRequest, it returns false when the volume has no valid ISBN
+func toCreateBookRequest(googleBook model.GoogleBook) (*model.CreateBookRequest, bool) {
+	book := model.CreateBookRequest{
+		Title:          googleBook.Title,
+		Subtitle:       googleBook.Subtitle,
+		CoverImage:     googleBook.ImageLinks.Thumbnail,
+		Description:    googleBook.Description,
+		PreviewLink:    googleBook.PreviewLink,
+		Publisher:      googleBook.Publisher,
+		PageCount:      int64(googleBook.PageCount),
+		Language:       googleBook.Language,
+		MaturityRating: googleBook.MaturityRating,
+		Authors:        googleBook.Authors,
+		Genres:         googleBook.Categories,
+	}
+	ISBN, ok := pickISBN(googleBook.IndustryIdentifiers)
+	if !ok {
+		return nil, false
+	}
+	book.ISBN = ISBN
+
+	if len(googleBook.Categories) > 0 {
+		book.Genre = googleBook.Categories[0]
+	} else {
+		book.Genre = "other"
+	}
+
+	if len(googleBook.Authors) > 0 {
+		book.Author = googleBook.Authors[0]
+	} else {
+		book.Author = "unknown"
+	}
+
+	t, err := convertAndFormatDate(googleBook.PublishedDate)
+	if err != nil {
+		log.Error().Msgf("Error while converting string to data %v", err)
+	} else {
+		book.PublishedDate = *t
+	}
+
+	// declaring default values
+	mockvalue := int64(0)
+	mockFloat := float64(0)
+	mockBool := false
+	book.BooksLeft = &mockvalue
+	book.ShelfNumber = &mockvalue
+	book.InLibrary = &mockBool
+	book.Rating = &mockFloat
+	book.ReviewCount = &mockvalue
+	book.Views = &mockvalue
+	book.ReviewsList = []string{}
+	book.ViewsList = []string{}
+	book.WishList = []string{}
+	book.WishlistCount = &mockvalue
+	book.ApproximateDemand = &mockvalue
+
+	return &book, true
+}
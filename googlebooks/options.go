@@ -0,0 +1,72 @@
+package googlebooks
+
+import "time"
+
+const (
+	defaultCacheTTL         = 10 * time.Minute
+	defaultISBNCacheTTL     = 24 * time.Hour
+	defaultCacheSize        = 1000
+	defaultRequestTimeout   = 5 * time.Second
+	defaultMaxRetries       = 2
+	defaultRetryBaseDelay   = 200 * time.Millisecond
+	defaultRetryMaxDelay    = 2 * time.Second
+	defaultFailureThreshold = 5
+	defaultOpenDuration     = 30 * time.Second
+)
+
+// Options configures caching, retries and the circuit breaker of the GoogleBooksClient,
+// zero values fall back to the defaults
+type Options struct {
+	// CacheTTL is how long search and new release responses are cached
+	CacheTTL time.Duration
+	// ISBNCacheTTL is how long single volume lookups are cached
+	ISBNCacheTTL time.Duration
+	// CacheSize is the maximum number of cached responses
+	CacheSize int
+	// RequestTimeout bounds a single call to google including its retries
+	RequestTimeout time.Duration
+	// MaxRetries is the number of retries on 429 and 5xx responses
+	MaxRetries int
+	// RetryBaseDelay is the first backoff delay, doubled on every retry
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps a single backoff delay
+	RetryMaxDelay time.Duration
+	// FailureThreshold is the number of consecutive failures which opens the circuit
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before a trial call is let through
+	OpenDuration time.Duration
+}
+
+// withDefaults returns a copy of the options with zero values replaced by defaults
+func (o Options) withDefaults() Options {
+	if o.CacheTTL <= 0 {
+		o.CacheTTL = defaultCacheTTL
+	}
+	if o.ISBNCacheTTL <= 0 {
+		o.ISBNCacheTTL = defaultISBNCacheTTL
+	}
+	if o.CacheSize <= 0 {
+		o.CacheSize = defaultCacheSize
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = defaultRequestTimeout
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	} else if o.MaxRetries == 0 {
+		o.MaxRetries = defaultMaxRetries
+	}
+	if o.RetryBaseDelay <= 0 {
+		o.RetryBaseDelay = defaultRetryBaseDelay
+	}
+	if o.RetryMaxDelay <= 0 {
+		o.RetryMaxDelay = defaultRetryMaxDelay
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = defaultFailureThreshold
+	}
+	if o.OpenDuration <= 0 {
+		o.OpenDuration = defaultOpenDuration
+	}
+	return o
+}
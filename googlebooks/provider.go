@@ -0,0 +1,30 @@
+package googlebooks
+
+import (
+	"context"
+
+	"integrated-library-service/model"
+)
+
+// ProviderName is the name google books is registered with in the provider priority list
+const ProviderName = "google"
+
+// Name returns the provider name of the google books client
+func (g *GoogleBooksClient) Name() string {
+	return ProviderName
+}
+
+// Search searches google books with the given search request
+func (g *GoogleBooksClient) Search(ctx context.Context, request *model.SearchRequest) ([]*model.CreateBookRequest, int, error) {
+	return g.SearchGoogleBooks(ctx, request)
+}
+
+// GetByISBN gets a single google book by its ISBN
+func (g *GoogleBooksClient) GetByISBN(ctx context.Context, ISBN string) (*model.CreateBookRequest, error) {
+	return g.GetGoogleBookByISBN(ctx, ISBN)
+}
+
+// ListNewReleases lists the newest google books
+func (g *GoogleBooksClient) ListNewReleases(ctx context.Context, request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error) {
+	return g.GetGoogleBooks(ctx, request)
+}
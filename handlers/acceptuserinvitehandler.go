@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// AcceptUserInviteHandler sets the first password of an imported user with the token of their invite
+func (th *LibraryHandler) AcceptUserInviteHandler(c *gin.Context) {
+	req := model.AcceptUserInviteRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Error while hashing the password",
+		})
+		return
+	}
+
+	if err := th.domain.AcceptUserInvite(req.Token, string(hashedPassword)); err != nil {
+		if errors.Is(err, domain.ErrUserInviteTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "password set successfully",
+	})
+}
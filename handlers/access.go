@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// ownerOrLibrarian tells if userID may see a resource owned by ownerID, other patrons' resources are
+// reported as missing with notFound so their existence is not leaked
+func (th *LibraryHandler) ownerOrLibrarian(c *gin.Context, userID, ownerID string, notFound error) bool {
+	if ownerID == userID {
+		return true
+	}
+
+	role, err := th.domain.GetUserRole(userID)
+	if err != nil && !errors.Is(err, domain.ErrGetUserRoleNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return false
+	}
+	if role != model.Librarian {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": notFound.Error(),
+		})
+		return false
+	}
+	return true
+}
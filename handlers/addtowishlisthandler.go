@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// AddToWishlistHandler adds a book to the logged in user's wishlist
+func (th *LibraryHandler) AddToWishlistHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	req := model.WishlistRequest{}
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	if err := th.domain.AddToWishlist(userID, req.ISBN); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidISBN):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrWishlistBookNotFound), errors.Is(err, domain.ErrWishlistUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "book added to wishlist",
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/label"
+	"integrated-library-service/model"
+)
+
+// bindCodeRequest binds how a code is rendered, Code 128 as PNG by default
+func bindCodeRequest(c *gin.Context) (*model.GetCodeRequest, bool) {
+	req := model.GetCodeRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return nil, false
+	}
+	if req.Symbology == "" {
+		req.Symbology = model.CodeSymbologyCode128
+	}
+	if req.Format == "" {
+		req.Format = model.CodeFormatPNG
+	}
+	if req.Scale == 0 {
+		req.Scale = label.DefaultScale
+	}
+	return &req, true
+}
+
+// renderCode answers with content encoded as the code asked for, name is the file name without extension
+func renderCode(c *gin.Context, content, name string, req *model.GetCodeRequest) {
+	code, err := label.Encode(content, req.Symbology)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var image bytes.Buffer
+	if err := code.Write(&image, req.Format, req.Scale); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", name+"."+string(req.Format)))
+	c.Data(http.StatusOK, label.ContentType(req.Format), image.Bytes())
+}
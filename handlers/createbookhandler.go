@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
 	"integrated-library-service/model"
 )
 
@@ -21,6 +23,12 @@ func (th *LibraryHandler) CreateBookHandler(c *gin.Context) {
 
 	// create book is an upsert operation
 	if err := th.domain.CreateBook(&req); err != nil {
+		if errors.Is(err, domain.ErrInvalidISBN) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 		})
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/bookimport"
+	"integrated-library-service/jobs"
+	"integrated-library-service/model"
+)
+
+// CreateBookImportHandler accepts a CSV, MARC or MARCXML catalogue file as the "file" form field and
+// imports it in the background, the import and its per row report are fetched by the returned ID
+func (th *LibraryHandler) CreateBookImportHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	req := model.CreateBookImportRequest{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "file is required: " + err.Error(),
+		})
+		return
+	}
+
+	format := req.Format
+	if len(format) == 0 {
+		if format, err = bookimport.DetectFormat(fileHeader.Filename); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "format must be csv, marc or marcxml",
+			})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	// the file is parsed up front so unreadable files and bad column mappings are refused right away
+	rows, err := bookimport.Read(file, format, c.PostFormMap("mapping"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, bookimport.ErrNoRows) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	importID, err := th.domain.CreateBookImport(&model.BookImport{
+		Format:      format,
+		Filename:    fileHeader.Filename,
+		DryRun:      req.DryRun,
+		TotalRows:   int64(len(rows)),
+		LibrarianID: &userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := th.jobRunner.Go(jobs.NewBookImport(th.domain, importID, rows, req.DryRun)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Location", "/bookimports/"+importID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "book import started",
+		"importID":  importID,
+		"totalRows": len(rows),
+		"dryRun":    req.DryRun,
+	})
+}
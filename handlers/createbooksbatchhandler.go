@@ -6,10 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
 	"integrated-library-service/model"
 )
 
-// CreateBooksBatchHandler creates multiple books at once
+// CreateBooksBatchHandler creates multiple books at once, books with an invalid ISBN are skipped and
+// reported, the batch is refused when none of them is valid
 func (th *LibraryHandler) CreateBooksBatchHandler(c *gin.Context) {
 	var req []*model.CreateBookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -20,14 +22,23 @@ func (th *LibraryHandler) CreateBooksBatchHandler(c *gin.Context) {
 	}
 
 	// create books in batch
-	if err := th.domain.CreateBooksBatch(req); err != nil {
+	result, err := th.domain.CreateBooksBatch(req)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 		})
 		return
 	}
+	if len(req) > 0 && len(result.Skipped) == len(req) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": domain.ErrInvalidISBN.Error(),
+			"result":  result,
+		})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "books created successfully",
+		"result":  result,
 	})
 }
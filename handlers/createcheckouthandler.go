@@ -29,6 +29,18 @@ func (th *LibraryHandler) CreateCheckoutHandler(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrBookWithdrawn) || errors.Is(err, domain.ErrOutOfStock) {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrLibraryCardNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		if errors.Is(domain.ErrPaymentPending, err) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"message": err.Error(),
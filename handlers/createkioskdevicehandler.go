@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// CreateKioskDeviceHandler registers a self-service kiosk and returns its device token once
+func (th *LibraryHandler) CreateKioskDeviceHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	req := model.CreateKioskDeviceRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	credentials, err := th.domain.CreateKioskDevice(userID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, credentials)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// CreatePurchaseRequestHandler files a purchase request of the logged in patron for a book the library does not hold
+func (th *LibraryHandler) CreatePurchaseRequestHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	req := model.CreatePurchaseRequestRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	purchaseRequest, err := th.domain.CreatePurchaseRequest(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidISBN):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrPurchaseRequestBookNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrPurchaseRequestExists), errors.Is(err, domain.ErrPurchaseRequestBookInLibrary):
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"purchaseRequest": purchaseRequest,
+	})
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// CreateUserByAdminHandler lets a librarian open an account with any role, including another librarian
+func (th *LibraryHandler) CreateUserByAdminHandler(c *gin.Context) {
+	req := model.CreateUserByAdminRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	th.createUser(c, &req.RegisterUserRequest, req.Role)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+)
+
+// DeleteUserByAdminHandler lets a librarian close the account of any user, anonymising it
+func (th *LibraryHandler) DeleteUserByAdminHandler(c *gin.Context) {
+	uri := struct {
+		UserID string `json:"userid" uri:"userid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	if err := th.domain.DeleteUser(uri.UserID); err != nil {
+		deleteUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{})
+}
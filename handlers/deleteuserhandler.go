@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/domain"
 )
 
-// delete user handler deletes user with book details
+// delete user handler closes the account of the current user, anonymising it
 func (th *LibraryHandler) DeleteUserHandler(c *gin.Context) {
 	userIDInterface, ok := c.Get("userID")
 	if !ok {
@@ -24,13 +27,30 @@ func (th *LibraryHandler) DeleteUserHandler(c *gin.Context) {
 		return
 	}
 
-	// delete user
+	// close account
 	if err := th.domain.DeleteUser(userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": err.Error(),
-		})
+		deleteUserError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, gin.H{})
 }
+
+// deleteUserError writes the response of an account closure which failed
+func deleteUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrDeleteUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+		})
+	case errors.Is(err, domain.ErrUserHasOpenLoans),
+		errors.Is(err, domain.ErrUserHasOutstandingFines):
+		c.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/domain"
+)
+
+// EndKioskSessionHandler signs the patron out of the kiosk and returns the receipt of the session
+func (th *LibraryHandler) EndKioskSessionHandler(c *gin.Context) {
+	session, ok := th.kioskSession(c)
+	if !ok {
+		return
+	}
+
+	receipt, err := th.domain.GetKioskReceipt(session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := th.domain.EndKioskSession(session); err != nil {
+		if errors.Is(err, domain.ErrKioskSessionInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"receipt": receipt,
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+)
+
+// exportFlushEvery is the number of records sent between flushes of a streamed export
+const exportFlushEvery = 500
+
+// attachment streams an export to the client. The headers are only sent with the first bytes, so
+// an export failing before any record can still be answered with an error status.
+type attachment struct {
+	c           *gin.Context
+	filename    string
+	contentType string
+	records     int
+}
+
+// newAttachment returns an attachment named after what is exported and the day of the export
+func newAttachment(c *gin.Context, name string, format model.ExportFormat) *attachment {
+	return &attachment{
+		c:           c,
+		filename:    fmt.Sprintf("%s-%s%s", name, time.Now().UTC().Format("2006-01-02"), export.Extension(format)),
+		contentType: export.ContentType(format),
+	}
+}
+
+// Write sends p to the client, after the headers when nothing was sent yet
+func (a *attachment) Write(p []byte) (int, error) {
+	if !a.c.Writer.Written() {
+		a.c.Header("Content-Type", a.contentType)
+		a.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.filename))
+		a.c.Status(http.StatusOK)
+	}
+	return a.c.Writer.Write(p)
+}
+
+// written counts a record written by w and flushes the records to the client regularly
+func (a *attachment) written(w *export.Writer) error {
+	a.records++
+	if a.records%exportFlushEvery != 0 {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	a.c.Writer.Flush()
+	return nil
+}
+
+// finish completes the export. An error before anything was sent is answered with a server
+// error, after that the status is already sent and the response is cut short instead.
+func (a *attachment) finish(w *export.Writer, err error) {
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		a.c.Writer.Flush()
+		return
+	}
+
+	if !a.c.Writer.Written() {
+		a.c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+	log.Error().Msgf("[Error] export %s after %d records: %v", a.filename, a.records, err)
+	a.c.Abort()
+}
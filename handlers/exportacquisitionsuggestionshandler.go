@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+)
+
+// acquisitionSuggestionsCSVHeader is the header row of the exported acquisition suggestions
+var acquisitionSuggestionsCSVHeader = []string{
+	"kind",
+	"ISBN",
+	"title",
+	"author",
+	"inLibrary",
+	"booksLeft",
+	"holds",
+	"wishlistCount",
+	"approximateDemand",
+	"searchMisses",
+	"copiesNeeded",
+	"score",
+}
+
+// ExportAcquisitionSuggestionsHandler returns every acquisition suggestion as a CSV attachment
+func (th *LibraryHandler) ExportAcquisitionSuggestionsHandler(c *gin.Context) {
+	suggestions, _, err := th.domain.GetAcquisitionSuggestions(&model.GetAcquisitionSuggestionsRequest{Page: 1})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("acquisition-suggestions-%s.csv", time.Now().UTC().Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	writer := export.NewCSVWriter(c.Writer)
+	_ = writer.Write(acquisitionSuggestionsCSVHeader)
+	for _, suggestion := range suggestions {
+		_ = writer.Write([]string{
+			string(suggestion.Kind),
+			suggestion.ISBN,
+			suggestion.Title,
+			suggestion.Author,
+			strconv.FormatBool(suggestion.InLibrary),
+			strconv.FormatInt(suggestion.BooksLeft, 10),
+			strconv.FormatInt(suggestion.Holds, 10),
+			strconv.FormatInt(suggestion.WishlistCount, 10),
+			strconv.FormatInt(suggestion.ApproximateDemand, 10),
+			strconv.FormatInt(suggestion.SearchMisses, 10),
+			strconv.FormatInt(suggestion.CopiesNeeded, 10),
+			strconv.FormatFloat(suggestion.Score, 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+)
+
+// ExportBooksHandler streams the books matching a search with their copies and availability as
+// CSV, JSON Lines or MARCXML
+func (th *LibraryHandler) ExportBooksHandler(c *gin.Context) {
+	req := model.ExportBooksRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	out := newAttachment(c, "books", req.Format)
+	writer, err := export.NewBookWriter(out, req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = th.domain.ExportBooks(req.SearchRequest(), func(book *model.BookExport) error {
+		if err := writer.WriteBook(book); err != nil {
+			return err
+		}
+		return out.written(writer)
+	})
+	out.finish(writer, err)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+)
+
+// ExportCheckoutsHandler streams the checkout history as CSV or JSON Lines, optionally narrowed to
+// a user, a book, a period and returned or open loans
+func (th *LibraryHandler) ExportCheckoutsHandler(c *gin.Context) {
+	req := model.ExportCheckoutsRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	out := newAttachment(c, "checkouts", req.Format)
+	writer, err := export.NewCheckoutWriter(out, req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = th.domain.ExportCheckouts(&req, func(checkout *model.CheckoutExport) error {
+		if err := writer.WriteCheckout(checkout); err != nil {
+			return err
+		}
+		return out.written(writer)
+	})
+	out.finish(writer, err)
+}
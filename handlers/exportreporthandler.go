@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+)
+
+// ExportReportHandler streams every row of a librarian report as a CSV attachment. The report is
+// fetched a page at a time and every page is flushed to the client before the next one is fetched.
+func (th *LibraryHandler) ExportReportHandler(c *gin.Context) {
+	uri := model.ReportURI{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	req := model.ExportReportRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	report := reports[uri.Report]
+	request := model.ReportRequest{Page: 1, Limit: exportFlushEvery, From: req.From, To: req.To}
+	_, records, totalPages, err := report.run(th.domain, &request)
+	if err != nil {
+		if errors.Is(err, domain.ErrReportRangeInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-report-%s.csv", uri.Report, time.Now().UTC().Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	writer := export.NewCSVWriter(c.Writer)
+	err = writer.Write(report.header)
+	for err == nil {
+		if err = writeReportRecords(c, writer, records()); err != nil {
+			break
+		}
+		if uint(request.Page) >= totalPages {
+			return
+		}
+		request.Page++
+		_, records, _, err = report.run(th.domain, &request)
+	}
+
+	// the status is already sent, so the response is cut short
+	log.Error().Msgf("[Error] export %s at page %d: %v", filename, request.Page, err)
+	c.Abort()
+}
+
+// writeReportRecords writes a page of a report and flushes it to the client
+func writeReportRecords(c *gin.Context, writer *export.CSVWriter, records [][]string) error {
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
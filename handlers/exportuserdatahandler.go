@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// userDataLoansCSVHeader is the header row of the exported loans and fined loans
+var userDataLoansCSVHeader = []string{
+	"ID",
+	"bookID",
+	"ISBN",
+	"title",
+	"isCheckedOut",
+	"isReturned",
+	"numberOfDays",
+	"fineAmount",
+	"reservedOn",
+	"checkedOutOn",
+	"returnedDate",
+}
+
+// ExportUserDataHandler returns everything stored about the current user as a JSON attachment,
+// or as a zip archive with a CSV file per section
+func (th *LibraryHandler) ExportUserDataHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	req := model.ExportUserDataRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	export, err := th.domain.GetUserDataExport(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrGetUserDataExportNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("user-data-%s", export.ExportedAt.Format("2006-01-02"))
+	if req.Format == model.UserDataExportFormatCSV {
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+		c.Status(http.StatusOK)
+		if err := writeUserDataArchive(c.Writer, export); err != nil {
+			_ = c.Error(err)
+		}
+		return
+	}
+
+	body, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// writeUserDataArchive writes the profile, loans, fines and reviews of an export as CSV files of a zip archive
+func writeUserDataArchive(w http.ResponseWriter, export *model.UserDataExport) error {
+	archive := zip.NewWriter(w)
+	profile := export.Profile
+
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"profile.csv", [][]string{
+			{"userID", "name", "email", "role", "profileImageUrl", "dateOfBirth", "phoneNumber", "address", "country", "joinedDate", "createdAt", "updatedAt"},
+			{
+				profile.UserID,
+				profile.Name,
+				profile.Email,
+				string(profile.Role),
+				profile.ProfileImageUrl,
+				csvTime(profile.DateOfBirth),
+				csvString(profile.PhoneNumber),
+				csvString(profile.Address),
+				csvString(profile.Country),
+				csvTime(&profile.JoinedDate),
+				csvTime(&profile.CreatedAt),
+				csvTime(profile.UpdatedAt),
+			},
+		}},
+		{"loans.csv", userDataLoanRows(export.Loans)},
+		{"fines.csv", append([][]string{
+			{"outstanding", "paymentDone"},
+			{strconv.FormatFloat(export.Fines.Outstanding, 'f', 2, 64), strconv.FormatBool(export.Fines.PaymentDone)},
+			{},
+		}, userDataLoanRows(export.Fines.FinedLoans)...)},
+		{"reviews.csv", userDataReviewRows(export.Reviews)},
+	}
+
+	for _, file := range files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		writer := csv.NewWriter(entry)
+		if err := writer.WriteAll(file.rows); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// userDataLoanRows returns the CSV rows of loans with their header
+func userDataLoanRows(loans []model.UserDataLoan) [][]string {
+	rows := [][]string{userDataLoansCSVHeader}
+	for _, loan := range loans {
+		rows = append(rows, []string{
+			loan.ID,
+			loan.BookID,
+			loan.ISBN,
+			loan.Title,
+			strconv.FormatBool(loan.IsCheckedOut),
+			strconv.FormatBool(loan.IsReturned),
+			strconv.FormatInt(loan.NumberOfDays, 10),
+			strconv.FormatFloat(loan.FineAmount, 'f', 2, 64),
+			csvTime(loan.ReservedOn),
+			csvTime(loan.CheckedOutOn),
+			csvTime(loan.ReturnedDate),
+		})
+	}
+	return rows
+}
+
+// userDataReviewRows returns the CSV rows of reviews with their header
+func userDataReviewRows(reviews []model.UserDataReview) [][]string {
+	rows := [][]string{{"ID", "bookID", "ISBN", "title", "commentHeading", "comment", "rating", "createdAt", "updatedAt"}}
+	for _, review := range reviews {
+		rows = append(rows, []string{
+			review.ID,
+			review.BookID,
+			review.ISBN,
+			review.Title,
+			review.CommentHeading,
+			review.Comment,
+			strconv.FormatFloat(review.Rating, 'f', -1, 64),
+			csvTime(&review.CreatedAt),
+			csvTime(review.UpdatedAt),
+		})
+	}
+	return rows
+}
+
+// csvTime formats a time for a CSV cell, missing times stay empty
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// csvString returns the value of an optional string for a CSV cell
+func csvString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
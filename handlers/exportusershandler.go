@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/export"
+	"integrated-library-service/model"
+)
+
+// ExportUsersHandler streams the users matching a search as CSV or JSON Lines
+func (th *LibraryHandler) ExportUsersHandler(c *gin.Context) {
+	req := model.ExportUsersRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	out := newAttachment(c, "users", req.Format)
+	writer, err := export.NewUserWriter(out, req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = th.domain.ExportUsers(req.SearchRequest(), func(user *model.UserExport) error {
+		if err := writer.WriteUser(user); err != nil {
+			return err
+		}
+		return out.written(writer)
+	})
+	out.finish(writer, err)
+}
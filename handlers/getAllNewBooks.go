@@ -9,9 +9,9 @@ import (
 	"integrated-library-service/model"
 )
 
-// get all books handler retrieves all books from a given specific list
+// GetAllNewBooksHandler returns the catalogue newest first, new releases are pulled into the
+// catalogue by the catalogue sync job
 func (th *LibraryHandler) GetAllNewBooksHandler(c *gin.Context) {
-	// sort things need to be added
 	req := model.GetAllBooksRequest{}
 	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -20,43 +20,10 @@ func (th *LibraryHandler) GetAllNewBooksHandler(c *gin.Context) {
 		return
 	}
 
-	// get books from google
-	googleBooks, totalPages, err := th.googleBooksService.GetGoogleBooks(&req)
-	if err != nil {
-		// temp solution
-		books, totalPages, err := th.domain.GetAllBooks(&req)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"totalPages": totalPages,
-			"books":      books,
-		})
-		return
-		// c.JSON(http.StatusInternalServerError, gin.H{
-		// 	"message": err.Error(),
-		// })
-		// return
-	}
-
-	// create books in batch
-	if err := th.domain.CreateBooksBatch(googleBooks); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": err.Error(),
-		})
-		return
-	}
-
-	bookList := []string{}
-	for _, book := range googleBooks {
-		bookList = append(bookList, book.ISBN)
-	}
+	req.SortBy = "publishedDate"
+	req.OrderBy = "descending"
 
-	// Retrieve all books from the domain
-	books, err := th.domain.GetAllBooksFromSpecific(bookList)
+	books, totalPages, err := th.domain.GetAllBooks(&req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -64,7 +31,6 @@ func (th *LibraryHandler) GetAllNewBooksHandler(c *gin.Context) {
 		return
 	}
 
-	// Return the list of books in the response
 	c.JSON(http.StatusOK, gin.H{
 		"totalPages": totalPages,
 		"books":      books,
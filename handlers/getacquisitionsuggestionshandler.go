@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// GetAcquisitionSuggestionsHandler returns the paginated titles to acquire or add copies of, the most wanted first
+func (th *LibraryHandler) GetAcquisitionSuggestionsHandler(c *gin.Context) {
+	req := model.GetAcquisitionSuggestionsRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	suggestions, totalPages, err := th.domain.GetAcquisitionSuggestions(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"totalPages":  totalPages,
+		"suggestions": suggestions,
+	})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// GetAllPurchaseRequestsHandler returns the paginated purchase requests of every patron, the oldest first
+func (th *LibraryHandler) GetAllPurchaseRequestsHandler(c *gin.Context) {
+	req := model.GetPurchaseRequestsRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	requests, totalPages, err := th.domain.GetPurchaseRequests(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"totalPages":       totalPages,
+		"purchaseRequests": requests,
+	})
+}
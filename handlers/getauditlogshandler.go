@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// GetAuditLogsHandler returns a page of the audit trail, filtered by kiosk, patron or action
+func (th *LibraryHandler) GetAuditLogsHandler(c *gin.Context) {
+	req := model.GetAuditLogsRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	entries, totalPages, err := th.domain.GetAuditLogs(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"totalPages": totalPages,
+		"auditLogs":  entries,
+	})
+}
@@ -38,6 +38,7 @@ func (th *LibraryHandler) GetBookByISBNHandler(c *gin.Context) {
 	}
 
 	// Return the book details in the response
+	c.Header("ETag", versionETag(book.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"book": book,
 	})
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// GetBookCopyCodeHandler renders the barcode of a book copy as a barcode or QR code
+func (th *LibraryHandler) GetBookCopyCodeHandler(c *gin.Context) {
+	uri := model.GetBookCopyCodeRequest{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	req, ok := bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	bookCopy, err := th.domain.GetBookCopy(uri.ISBN, uri.CopyNumber)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookCopyBookNotFound) || errors.Is(err, domain.ErrBookCopyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrBookWithdrawn) {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	renderCode(c, bookCopy.Barcode, "copy-"+bookCopy.Barcode, req)
+}
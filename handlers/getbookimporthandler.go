@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+)
+
+// GetBookImportHandler returns a book import with its progress and counts
+func (th *LibraryHandler) GetBookImportHandler(c *gin.Context) {
+	uri := struct {
+		ImportID string `json:"importid" uri:"importid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	bookImport, err := th.domain.GetBookImport(uri.ImportID)
+	if err != nil {
+		if errors.Is(err, domain.ErrGetBookImportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, bookImport)
+}
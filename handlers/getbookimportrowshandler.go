@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// GetBookImportRowsHandler returns the per row report of a book import, optionally only the rows with one outcome
+func (th *LibraryHandler) GetBookImportRowsHandler(c *gin.Context) {
+	uri := struct {
+		ImportID string `json:"importid" uri:"importid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	req := model.GetBookImportRowsRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	rows, totalPages, err := th.domain.GetBookImportRows(uri.ImportID, &req)
+	if err != nil {
+		if errors.Is(err, domain.ErrGetBookImportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"totalPages": totalPages,
+		"rows":       rows,
+	})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// GetBookImportsHandler returns the book imports with their progress and counts, the most recent first
+func (th *LibraryHandler) GetBookImportsHandler(c *gin.Context) {
+	req := model.GetBookImportsRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	imports, totalPages, err := th.domain.GetBookImports(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"totalPages": totalPages,
+		"imports":    imports,
+	})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// GetBookViewsOverTimeHandler returns the book views per day, week or month of the requested dates
+func (th *LibraryHandler) GetBookViewsOverTimeHandler(c *gin.Context) {
+	req := model.GetBookViewsOverTimeRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	periods, err := th.domain.GetBookViewsOverTime(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"views": periods,
+	})
+}
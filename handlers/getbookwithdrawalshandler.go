@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// GetBookWithdrawalsHandler returns the withdrawal history of a book, also after it was purged
+func (th *LibraryHandler) GetBookWithdrawalsHandler(c *gin.Context) {
+	uri := model.GetBookByISBNRequest{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	withdrawals, err := th.domain.GetBookWithdrawals(uri.ISBN)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"withdrawals": withdrawals,
+	})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/model"
+)
+
+// GetCatalogueSyncRunsHandler returns the catalogue sync runs with their counts, the most recent first
+func (th *LibraryHandler) GetCatalogueSyncRunsHandler(c *gin.Context) {
+	req := model.GetCatalogueSyncRunsRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	runs, totalPages, err := th.domain.GetCatalogueSyncRuns(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"totalPages": totalPages,
+		"runs":       runs,
+	})
+}
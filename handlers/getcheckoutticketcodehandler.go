@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+)
+
+// GetCheckoutTicketCodeHandler renders the ID of a checkout ticket as a barcode or QR code for the
+// borrower to show at the desk
+func (th *LibraryHandler) GetCheckoutTicketCodeHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	uri := struct {
+		CheckoutID string `uri:"checkoutid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	req, ok := bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := th.domain.GetCheckoutTicketByID(uri.CheckoutID)
+	if err != nil {
+		if errors.Is(err, domain.ErrGetCheckoutTicketByIDNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if !th.ownerOrLibrarian(c, userID, ticket.UserID, domain.ErrGetCheckoutTicketByIDNotFound) {
+		return
+	}
+
+	renderCode(c, ticket.ID, "checkout-"+ticket.ID, req)
+}
@@ -1,15 +1,34 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
 )
 
-// GetDashboardDataBoardHandler gets dashboard data
+// GetDashboardDataBoardHandler gets dashboard data of the requested days
 func (th *LibraryHandler) GetDashboardDataBoardHandler(c *gin.Context) {
-	dashboardData, err := th.domain.GetDashboardDataBoard()
+	req := model.DashboardRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	dashboardData, err := th.domain.GetDashboardDataBoard(&req)
 	if err != nil {
+		if errors.Is(err, domain.ErrDashboardRangeInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 		})
@@ -1,22 +1,39 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
 )
 
-// GetDashboardLineGraphDataHandler gets line graph data
+// GetDashboardLineGraphDataHandler gets line graph data of the requested days per day, week, month or year
 func (th *LibraryHandler) GetDashboardLineGraphDataHandler(c *gin.Context) {
-	graphData, err := th.domain.GetDashboardLineGraphData()
+	req := model.DashboardRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	graph, err := th.domain.GetDashboardLineGraphData(&req)
 	if err != nil {
+		if errors.Is(err, domain.ErrDashboardRangeInvalid) || errors.Is(err, domain.ErrDashboardTooManyPeriods) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"graphData": graphData,
-	})
+	c.JSON(http.StatusOK, graph)
 }
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetKioskDevicesHandler lists the registered kiosks
+func (th *LibraryHandler) GetKioskDevicesHandler(c *gin.Context) {
+	devices, err := th.domain.GetKioskDevices()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"devices": devices,
+	})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetKioskReceiptHandler returns the receipt of the checkouts and returns of the open kiosk session
+func (th *LibraryHandler) GetKioskReceiptHandler(c *gin.Context) {
+	session, ok := th.kioskSession(c)
+	if !ok {
+		return
+	}
+
+	receipt, err := th.domain.GetKioskReceipt(session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"receipt": receipt,
+	})
+}
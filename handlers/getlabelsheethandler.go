@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/label"
+	"integrated-library-service/model"
+)
+
+// GetLabelSheetHandler renders printable sheets of shelf labels for the registered copies of the given books
+func (th *LibraryHandler) GetLabelSheetHandler(c *gin.Context) {
+	req := model.GetLabelSheetRequest{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	labels := []label.Label{}
+	for _, ISBN := range req.ISBNs {
+		copies, err := th.domain.GetBookCopies(ISBN, req.Copies)
+		if err != nil {
+			if errors.Is(err, domain.ErrBookCopyBookNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{
+					"message": fmt.Sprintf("%s: %s", ISBN, err.Error()),
+				})
+				return
+			}
+			if errors.Is(err, domain.ErrBookWithdrawn) {
+				c.JSON(http.StatusConflict, gin.H{
+					"message": fmt.Sprintf("%s: %s", ISBN, err.Error()),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		for _, bookCopy := range copies {
+			labels = append(labels, label.Label{
+				Code: bookCopy.Barcode,
+				Lines: []string{
+					bookCopy.Title,
+					bookCopy.Author,
+					"Shelf " + strconv.FormatInt(bookCopy.ShelfNumber, 10) + " · Copy " + strconv.FormatInt(bookCopy.CopyNumber, 10),
+				},
+			})
+		}
+	}
+
+	var sheet bytes.Buffer
+	if err := label.Sheet(&sheet, labels); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", sheet.Bytes())
+}
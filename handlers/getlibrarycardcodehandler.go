@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+)
+
+// GetLibraryCardCodeHandler renders the library card number of a user as a barcode or QR code for
+// the user or a librarian
+func (th *LibraryHandler) GetLibraryCardCodeHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	uri := struct {
+		UserID string `uri:"userid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	req, ok := bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	if !th.ownerOrLibrarian(c, userID, uri.UserID, domain.ErrLibraryCardNotFound) {
+		return
+	}
+
+	user, err := th.domain.GetUserWithBookDetails(uri.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrGetUserWithBookDetailsNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": domain.ErrLibraryCardNotFound.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+	if user.CardNumber == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": domain.ErrLibraryCardNotFound.Error(),
+		})
+		return
+	}
+
+	renderCode(c, *user.CardNumber, "library-card-"+*user.CardNumber, req)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+)
+
+// GetPurchaseRequestByIDHandler returns a purchase request with its status history to its requester or a librarian
+func (th *LibraryHandler) GetPurchaseRequestByIDHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	req := struct {
+		RequestID string `json:"requestid" uri:"requestid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	purchaseRequest, err := th.domain.GetPurchaseRequestByID(req.RequestID)
+	if err != nil {
+		if errors.Is(err, domain.ErrPurchaseRequestNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if !th.ownerOrLibrarian(c, userID, purchaseRequest.UserID, domain.ErrPurchaseRequestNotFound) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"purchaseRequest": purchaseRequest,
+	})
+}
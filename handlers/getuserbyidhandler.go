@@ -37,5 +37,6 @@ func (th *LibraryHandler) GetUserByIDHandler(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", versionETag(user.Version))
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
@@ -6,7 +6,9 @@ import (
 
 	"integrated-library-service/apperror"
 	"integrated-library-service/domain"
-	"integrated-library-service/googlebooks"
+	"integrated-library-service/jobs"
+	"integrated-library-service/mailer"
+	"integrated-library-service/model"
 )
 
 var (
@@ -22,19 +24,37 @@ type Handler interface {
 	HealthHandler(c *gin.Context)
 	// user related
 	RegisterUserHandler(c *gin.Context)
+	CreateUserByAdminHandler(c *gin.Context)
 	LoginUserHandler(c *gin.Context)
 	GetUserHandler(c *gin.Context)
 	GetUserByIDHandler(c *gin.Context)
 	GetAllUsersHandler(c *gin.Context)
 	UpdateUserHandler(c *gin.Context)
+	UpdateUserByAdminHandler(c *gin.Context)
+	RequestEmailChangeHandler(c *gin.Context)
+	VerifyEmailChangeHandler(c *gin.Context)
+	ChangePasswordHandler(c *gin.Context)
 	UpdateBookDetailsHandler(c *gin.Context)
 	DeleteUserHandler(c *gin.Context)
+	DeleteUserByAdminHandler(c *gin.Context)
+	ExportUserDataHandler(c *gin.Context)
 	// book related
 	CreateBookHandler(c *gin.Context)
 	CreateBooksBatchHandler(c *gin.Context)
 	GetBookByISBNHandler(c *gin.Context)
 	GetAllBooksHandler(c *gin.Context)
 	GetAllNewBooksHandler(c *gin.Context)
+	// book withdrawal related
+	WithdrawBookHandler(c *gin.Context)
+	RestoreBookHandler(c *gin.Context)
+	PurgeBookHandler(c *gin.Context)
+	GetWithdrawnBooksHandler(c *gin.Context)
+	GetBookWithdrawalsHandler(c *gin.Context)
+	// book import related
+	CreateBookImportHandler(c *gin.Context)
+	GetBookImportsHandler(c *gin.Context)
+	GetBookImportHandler(c *gin.Context)
+	GetBookImportRowsHandler(c *gin.Context)
 	// checkout related
 	CreateCheckoutHandler(c *gin.Context)
 	GetCheckoutsByUserIDHandler(c *gin.Context)
@@ -57,22 +77,92 @@ type Handler interface {
 	SimilarBooksHandler(c *gin.Context)
 	// data analysis related
 	GetApproximateDemandHandler(c *gin.Context)
+	GetAcquisitionSuggestionsHandler(c *gin.Context)
+	ExportAcquisitionSuggestionsHandler(c *gin.Context)
+	// book view related
+	RecordBookViewHandler(c *gin.Context)
+	GetBookViewsOverTimeHandler(c *gin.Context)
+	GetTopViewedBooksHandler(c *gin.Context)
+	// wishlist related
+	AddToWishlistHandler(c *gin.Context)
+	RemoveFromWishlistHandler(c *gin.Context)
+	GetWishlistHandler(c *gin.Context)
+	// purchase request related
+	CreatePurchaseRequestHandler(c *gin.Context)
+	GetPurchaseRequestsHandler(c *gin.Context)
+	GetAllPurchaseRequestsHandler(c *gin.Context)
+	GetPurchaseRequestByIDHandler(c *gin.Context)
+	UpdatePurchaseRequestStatusHandler(c *gin.Context)
+	// notification related
+	GetNotificationsHandler(c *gin.Context)
+	MarkNotificationReadHandler(c *gin.Context)
+	// catalogue sync related
+	TriggerCatalogueSyncHandler(c *gin.Context)
+	GetCatalogueSyncRunsHandler(c *gin.Context)
+	// partial update related
+	PatchBookHandler(c *gin.Context)
+	PatchUserHandler(c *gin.Context)
+	PatchCheckoutTicketHandler(c *gin.Context)
+	// patron import related
+	ImportPatronsHandler(c *gin.Context)
+	AcceptUserInviteHandler(c *gin.Context)
+	IssueLibraryCardHandler(c *gin.Context)
+	// export related
+	ExportBooksHandler(c *gin.Context)
+	ExportUsersHandler(c *gin.Context)
+	ExportCheckoutsHandler(c *gin.Context)
+	// code and label related
+	GetLibraryCardCodeHandler(c *gin.Context)
+	GetCheckoutTicketCodeHandler(c *gin.Context)
+	RegisterBookCopiesHandler(c *gin.Context)
+	GetBookCopiesHandler(c *gin.Context)
+	GetBookCopyCodeHandler(c *gin.Context)
+	GetLabelSheetHandler(c *gin.Context)
+	ScanCodeHandler(c *gin.Context)
+	// kiosk related
+	CreateKioskDeviceHandler(c *gin.Context)
+	GetKioskDevicesHandler(c *gin.Context)
+	RevokeKioskDeviceHandler(c *gin.Context)
+	SetPinHandler(c *gin.Context)
+	StartKioskSessionHandler(c *gin.Context)
+	EndKioskSessionHandler(c *gin.Context)
+	GetKioskReceiptHandler(c *gin.Context)
+	KioskCheckoutHandler(c *gin.Context)
+	KioskReturnHandler(c *gin.Context)
+	// lost and damaged item related
+	DeclareCheckoutLostHandler(c *gin.Context)
+	DeclareCheckoutDamagedHandler(c *gin.Context)
+	MarkCheckoutFoundHandler(c *gin.Context)
+	RestockBookCopyHandler(c *gin.Context)
+	// report related
+	GetReportHandler(c *gin.Context)
+	ExportReportHandler(c *gin.Context)
+	// audit related
+	GetAuditLogsHandler(c *gin.Context)
 	// empty related
 	EmptyHandler(c *gin.Context)
 }
 
 type LibraryHandler struct {
-	domain             domain.Service
-	secretKey          string
-	googleBooksService *googlebooks.GoogleBooksClient
+	domain         domain.Service
+	secretKey      string
+	jobRunner      *jobs.Runner
+	mailer         mailer.Mailer
+	emailVerifyURL string
+	inviteURL      string
+	incidentFees   model.IncidentFees
 }
 
 // NewLibraryHandler returns new instance of Handler.
-func NewLibraryHandler(domain domain.Service, secretKey string, googleBooksService *googlebooks.GoogleBooksClient) *LibraryHandler {
+func NewLibraryHandler(domain domain.Service, secretKey string, jobRunner *jobs.Runner, mailer mailer.Mailer, emailVerifyURL, inviteURL string, incidentFees model.IncidentFees) *LibraryHandler {
 	h := &LibraryHandler{
-		domain:             domain,
-		secretKey:          secretKey,
-		googleBooksService: googleBooksService,
+		domain:         domain,
+		secretKey:      secretKey,
+		jobRunner:      jobRunner,
+		mailer:         mailer,
+		emailVerifyURL: emailVerifyURL,
+		inviteURL:      inviteURL,
+		incidentFees:   incidentFees,
 	}
 
 	return h
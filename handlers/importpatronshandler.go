@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/jobs"
+	"integrated-library-service/model"
+	"integrated-library-service/patronimport"
+)
+
+// ImportPatronsHandler creates users with a library card from a CSV file in the "file" form field
+// and mails them an invite to set a password. Rows whose email has an account are skipped, or
+// update it with onExisting=update. The response reports every row.
+func (th *LibraryHandler) ImportPatronsHandler(c *gin.Context) {
+	req := model.ImportPatronsRequest{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "file is required: " + err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	rows, err := patronimport.ReadCSV(file)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, patronimport.ErrNoRows) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	report := make([]model.PatronImportRow, 0, len(rows))
+	invites := []model.UserInvite{}
+	counts := map[model.PatronImportOutcome]int{}
+	for _, row := range rows {
+		reportRow := &model.PatronImportRow{}
+		if row.Err == nil {
+			var invite *model.UserInvite
+			reportRow, invite, err = th.domain.ImportPatron(row.Patron, req.OnExisting == "update", req.DryRun)
+			if err != nil {
+				reportRow = &model.PatronImportRow{}
+				row.Err = err
+			}
+			if invite != nil {
+				invites = append(invites, *invite)
+			}
+		}
+		if row.Err != nil {
+			reportRow.Outcome = model.PatronImportOutcomeError
+			reportRow.Message = row.Err.Error()
+			if row.Patron != nil {
+				reportRow.Email, reportRow.Name = row.Patron.Email, row.Patron.Name
+			}
+		}
+
+		reportRow.RowNumber = row.Number
+		counts[reportRow.Outcome]++
+		report = append(report, *reportRow)
+	}
+
+	// the accounts are stored already, invites which can not be mailed are sent again by a new import
+	if len(invites) > 0 {
+		if err := th.jobRunner.Go(jobs.NewUserInvites(th.mailer, th.inviteURL, invites)); err != nil {
+			log.Error().Msgf("[Error] ImportPatronsHandler(), jobRunner.Go err: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dryRun":      req.DryRun,
+		"totalRows":   len(rows),
+		"created":     counts[model.PatronImportOutcomeCreated],
+		"updated":     counts[model.PatronImportOutcomeUpdated],
+		"skipped":     counts[model.PatronImportOutcomeSkipped],
+		"failed":      counts[model.PatronImportOutcomeError],
+		"invitesSent": len(invites),
+		"rows":        report,
+	})
+}
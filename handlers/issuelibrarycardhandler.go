@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// IssueLibraryCardHandler gives a user a new library card number, replacing a lost card
+func (th *LibraryHandler) IssueLibraryCardHandler(c *gin.Context) {
+	uri := struct {
+		UserID string `uri:"userid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	cardNumber, err := th.domain.IssueLibraryCard(uri.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrIssueLibraryCardNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.LibraryCard{
+		UserID:     uri.UserID,
+		CardNumber: cardNumber,
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// kioskSessionHeader carries the token of the patron session at a kiosk
+const kioskSessionHeader = "Session"
+
+// kioskDeviceID returns the kiosk the request was authenticated for
+func kioskDeviceID(c *gin.Context) (string, bool) {
+	deviceIDInterface, ok := c.Get("kioskDeviceID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "kioskDeviceID not found",
+		})
+		return "", false
+	}
+
+	deviceID, ok := deviceIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "kioskDeviceID is not of type string",
+		})
+		return "", false
+	}
+	return deviceID, true
+}
+
+// kioskSession returns the open patron session of the kiosk given in the Session header
+func (th *LibraryHandler) kioskSession(c *gin.Context) (*model.KioskSession, bool) {
+	deviceID, ok := kioskDeviceID(c)
+	if !ok {
+		return nil, false
+	}
+
+	token := c.GetHeader(kioskSessionHeader)
+	if len(token) == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized. Kiosk session required, please scan your library card.",
+		})
+		return nil, false
+	}
+
+	session, err := th.domain.GetKioskSession(deviceID, token)
+	if err != nil {
+		if errors.Is(err, domain.ErrKioskSessionInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": err.Error(),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return nil, false
+	}
+	return session, true
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// KioskCheckoutHandler checks a scanned copy out to the patron of the kiosk session
+func (th *LibraryHandler) KioskCheckoutHandler(c *gin.Context) {
+	session, ok := th.kioskSession(c)
+	if !ok {
+		return
+	}
+
+	req := model.KioskItemRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	loan, err := th.domain.KioskCheckout(session, req.Barcode)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookCopyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrBookWithdrawn) || errors.Is(err, domain.ErrKioskCopyOnLoan) ||
+			errors.Is(err, domain.ErrKioskCopyUnavailable) ||
+			errors.Is(err, domain.ErrFailedCreateCheckoutTicketConflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrPaymentPending) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"loan": loan,
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// KioskReturnHandler returns a copy scanned at a kiosk
+func (th *LibraryHandler) KioskReturnHandler(c *gin.Context) {
+	session, ok := th.kioskSession(c)
+	if !ok {
+		return
+	}
+
+	req := model.KioskItemRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	loan, err := th.domain.KioskReturn(session, req.Barcode)
+	if err != nil {
+		if errors.Is(err, domain.ErrBookCopyNotFound) || errors.Is(err, domain.ErrKioskLoanNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"loan": loan,
+	})
+}
@@ -11,6 +11,7 @@ import (
 
 	"integrated-library-service/apperror"
 	"integrated-library-service/domain"
+	"integrated-library-service/librarycard"
 	"integrated-library-service/model"
 
 	"github.com/rs/zerolog/log"
@@ -26,6 +27,11 @@ func (th *LibraryHandler) LoginUserHandler(c *gin.Context) {
 		return
 	}
 
+	if len(req.Email) == 0 {
+		th.loginWithCardNumber(c, &req)
+		return
+	}
+
 	user, err := th.domain.GetUserByEmail(req.Email)
 	if err != nil {
 		if errors.Is(err, domain.ErrFailedGetUserByEmailNotFound) {
@@ -69,6 +75,59 @@ func (th *LibraryHandler) LoginUserHandler(c *gin.Context) {
 	})
 }
 
+// loginWithCardNumber signs in the holder of a library card
+func (th *LibraryHandler) loginWithCardNumber(c *gin.Context, req *model.LoginUserRequest) {
+	cardNumber, err := librarycard.Parse(req.CardNumber)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID, err := th.domain.GetUserIDByCardNumber(cardNumber)
+	if err != nil {
+		if errors.Is(err, domain.ErrLibraryCardNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	passwordHash, err := th.domain.GetUserPasswordHash(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "password doesn't match",
+		})
+		return
+	}
+
+	token, err := generateToken(userID, th.secretKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token": token,
+	})
+}
+
 func generateToken(userID string, secretKey string) (string, error) {
 	tokenClaims := jwt.MapClaims{
 		"sub": userID,
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/mergepatch"
+)
+
+var (
+	// errInvalidIfMatch is an error when the If-Match header is not a version ETag
+	errInvalidIfMatch = errors.New("If-Match must be a version ETag")
+	// errIfMatchRequired is an error when a patch does not name the version it was made from
+	errIfMatchRequired = errors.New("If-Match with the version ETag is required to patch")
+)
+
+// versionETag formats the version of an entity as its ETag
+func versionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion returns the version of the request's If-Match header, ok is false when the
+// header is missing or "*" which does not name a version
+func ifMatchVersion(c *gin.Context) (version int64, ok bool, err error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, false, errInvalidIfMatch
+	}
+	version, err = strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return 0, false, errInvalidIfMatch
+	}
+	return version, true, nil
+}
+
+// expectedVersion returns the version a patch is applied to, writing 400 for a malformed If-Match,
+// 428 when it is missing or "*" so patches never blindly overwrite, and 412 when it does not match
+// the current version
+func expectedVersion(c *gin.Context, current int64) (int64, bool) {
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return 0, false
+	}
+	if !ok {
+		c.Header("ETag", versionETag(current))
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"message": errIfMatchRequired.Error(),
+		})
+		return 0, false
+	}
+	if version != current {
+		c.Header("ETag", versionETag(current))
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"message": "resource was modified, fetch it again before patching",
+		})
+		return 0, false
+	}
+	return current, true
+}
+
+// applyMergePatch applies the request body as a JSON merge patch to current and decodes the
+// result into patched. Members outside writable are refused with 403, malformed patches and
+// invalid results with 400; false is returned once a response was written.
+func applyMergePatch(c *gin.Context, current interface{}, writable []string, patched interface{}) bool {
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return false
+	}
+
+	keys, err := mergepatch.Keys(patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid merge patch: " + err.Error(),
+		})
+		return false
+	}
+
+	allowed := make(map[string]bool, len(writable))
+	for _, field := range writable {
+		allowed[field] = true
+	}
+	var refused []string
+	for _, key := range keys {
+		if !allowed[key] {
+			refused = append(refused, key)
+		}
+	}
+	if len(refused) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "fields are not writable",
+			"fields":  refused,
+		})
+		return false
+	}
+
+	document, err := json.Marshal(current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return false
+	}
+	merged, err := mergepatch.Apply(document, patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid merge patch: " + err.Error(),
+		})
+		return false
+	}
+
+	if err := json.Unmarshal(merged, patched); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return false
+	}
+	if err := binding.Validator.ValidateStruct(patched); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return false
+	}
+	return true
+}
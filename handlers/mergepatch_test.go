@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/model"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// patchContext returns a test context of a PATCH request with the body and If-Match header
+func patchContext(body, ifMatch string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	if len(ifMatch) != 0 {
+		c.Request.Header.Set("If-Match", ifMatch)
+	}
+	return c, recorder
+}
+
+func TestExpectedVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantOK     bool
+		wantStatus int
+		wantETag   string
+	}{
+		{name: "matching version", ifMatch: `"3"`, wantOK: true},
+		{name: "matching weak version", ifMatch: `W/"3"`, wantOK: true},
+		{name: "missing If-Match", wantStatus: http.StatusPreconditionRequired, wantETag: `"3"`},
+		{name: "any version", ifMatch: "*", wantStatus: http.StatusPreconditionRequired, wantETag: `"3"`},
+		{name: "stale version", ifMatch: `"2"`, wantStatus: http.StatusPreconditionFailed, wantETag: `"3"`},
+		{name: "unquoted version", ifMatch: "3", wantStatus: http.StatusBadRequest},
+		{name: "not a version", ifMatch: `"abc"`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := patchContext("", tt.ifMatch)
+
+			version, ok := expectedVersion(c, 3)
+			if ok != tt.wantOK {
+				t.Fatalf("expectedVersion() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok {
+				if version != 3 {
+					t.Errorf("expectedVersion() = %d, want 3", version)
+				}
+				return
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
+
+// patchedProfile is the part of a user profile the merge patch tests patch
+type patchedProfile struct {
+	Name    string  `json:"name" binding:"required"`
+	Country *string `json:"country"`
+	Role    string  `json:"role"`
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	country := "NZ"
+	current := patchedProfile{Name: "Ann", Country: &country, Role: string(model.Patrons)}
+
+	tests := []struct {
+		name        string
+		writable    []string
+		patch       string
+		wantOK      bool
+		wantStatus  int
+		wantRefused []string
+		want        patchedProfile
+	}{
+		{
+			name:     "writable fields are patched",
+			writable: model.UserPatchWritableFields,
+			patch:    `{"name": "Anna"}`,
+			wantOK:   true,
+			want:     patchedProfile{Name: "Anna", Country: &country, Role: string(model.Patrons)},
+		},
+		{
+			name:     "null removes a field",
+			writable: model.UserPatchWritableFields,
+			patch:    `{"country": null}`,
+			wantOK:   true,
+			want:     patchedProfile{Name: "Ann", Role: string(model.Patrons)},
+		},
+		{
+			name:        "a patron can not patch their role",
+			writable:    model.UserPatchWritableFields,
+			patch:       `{"name": "Anna", "role": "librarian", "fineAmount": 0}`,
+			wantStatus:  http.StatusForbidden,
+			wantRefused: []string{"fineAmount", "role"},
+		},
+		{
+			name:     "a librarian can patch the role",
+			writable: model.UserPatchLibrarianWritableFields,
+			patch:    `{"role": "librarian"}`,
+			wantOK:   true,
+			want:     patchedProfile{Name: "Ann", Country: &country, Role: string(model.Librarian)},
+		},
+		{
+			name:       "the patched document is validated",
+			writable:   model.UserPatchWritableFields,
+			patch:      `{"name": null}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "a patch must be an object",
+			writable:   model.UserPatchWritableFields,
+			patch:      `["name"]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := patchContext(tt.patch, "")
+
+			var patched patchedProfile
+			ok := applyMergePatch(c, current, tt.writable, &patched)
+			if ok != tt.wantOK {
+				t.Fatalf("applyMergePatch() ok = %v, want %v, response %s", ok, tt.wantOK, recorder.Body.String())
+			}
+			if ok {
+				if !reflect.DeepEqual(patched, tt.want) {
+					t.Errorf("patched = %+v, want %+v", patched, tt.want)
+				}
+				return
+			}
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantRefused != nil {
+				var response struct {
+					Fields []string `json:"fields"`
+				}
+				if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+					t.Fatalf("response %s err = %v", recorder.Body.String(), err)
+				}
+				if !reflect.DeepEqual(response.Fields, tt.wantRefused) {
+					t.Errorf("refused fields = %v, want %v", response.Fields, tt.wantRefused)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// PatchBookHandler applies a JSON merge patch to a book, guarded by its version
+func (th *LibraryHandler) PatchBookHandler(c *gin.Context) {
+	uri := model.GetBookByISBNRequest{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	book, err := th.domain.GetBookByISBN(uri.ISBN)
+	if err != nil {
+		if errors.Is(err, domain.ErrGetBookByIDNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Book not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	version, ok := expectedVersion(c, book.Version)
+	if !ok {
+		return
+	}
+
+	patch := model.BookPatch{}
+	if !applyMergePatch(c, book, model.BookPatchWritableFields, &patch) {
+		return
+	}
+
+	newVersion, err := th.domain.PatchBook(book.ID, version, &patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrUpdateBookNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Book not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(newVersion))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "book updated successfully",
+		"version": newVersion,
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// PatchCheckoutTicketHandler applies a JSON merge patch to a checkout ticket, guarded by its version
+func (th *LibraryHandler) PatchCheckoutTicketHandler(c *gin.Context) {
+	uri := struct {
+		CheckoutID string `json:"checkoutid" uri:"checkoutid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	ticket, err := th.domain.GetCheckoutTicketByID(uri.CheckoutID)
+	if err != nil {
+		if errors.Is(err, domain.ErrGetCheckoutTicketByIDNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	version, ok := expectedVersion(c, ticket.Version)
+	if !ok {
+		return
+	}
+
+	patch := model.CheckoutTicketPatch{}
+	if !applyMergePatch(c, ticket, model.CheckoutTicketPatchWritableFields, &patch) {
+		return
+	}
+
+	newVersion, err := th.domain.PatchCheckoutTicket(uri.CheckoutID, version, &patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrGetCheckoutTicketByIDNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(newVersion))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "checkout ticket updated successfully",
+		"version": newVersion,
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// PatchUserHandler applies a JSON merge patch to a user, guarded by its version. Patrons can only
+// patch their own profile fields, librarians can patch any user including role and fines.
+func (th *LibraryHandler) PatchUserHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	uri := struct {
+		UserID string `json:"userid" uri:"userid" binding:"required,uuid"`
+	}{}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	role, err := th.domain.GetUserRole(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	writable := model.UserPatchWritableFields
+	if role == model.Librarian {
+		writable = model.UserPatchLibrarianWritableFields
+	} else if uri.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "patrons can only update their own profile",
+		})
+		return
+	}
+
+	user, err := th.domain.GetUserWithBookDetails(uri.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrFailedGetUserByEmailNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	version, ok := expectedVersion(c, user.Version)
+	if !ok {
+		return
+	}
+
+	patch := model.UserPatch{}
+	if !applyMergePatch(c, user, writable, &patch) {
+		return
+	}
+
+	newVersion, err := th.domain.PatchUser(uri.UserID, version, &patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrGetUserWithBookDetailsNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.Header("ETag", versionETag(newVersion))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "updated user successfully",
+		"version": newVersion,
+	})
+}
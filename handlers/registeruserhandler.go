@@ -1,17 +1,18 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 
 	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
 	"integrated-library-service/model"
 )
 
-// register user handler creates new user with given data
+// register user handler creates new patron with given data
 func (th *LibraryHandler) RegisterUserHandler(c *gin.Context) {
 	req := model.RegisterUserRequest{}
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -21,6 +22,11 @@ func (th *LibraryHandler) RegisterUserHandler(c *gin.Context) {
 		return
 	}
 
+	th.createUser(c, &req, model.Patrons)
+}
+
+// createUser creates a user with the role and writes the response
+func (th *LibraryHandler) createUser(c *gin.Context, req *model.RegisterUserRequest, role model.RoleType) {
 	// encrypting the password of the user before stroign
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -31,10 +37,14 @@ func (th *LibraryHandler) RegisterUserHandler(c *gin.Context) {
 	}
 	req.Password = string(hashedPassword)
 
-	fmt.Println(req.Password)
+	if err := th.domain.CreateUser(req, role); err != nil {
+		if errors.Is(err, domain.ErrEmailInUse) {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 
-	// create user is an upsert operation
-	if err := th.domain.CreateUser(&req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 		})
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// report runs a librarian report, records returns its rows as CSV records below header
+type report struct {
+	header []string
+	run    func(d domain.Service, request *model.ReportRequest) (rows interface{}, records func() [][]string, totalPages uint, err error)
+}
+
+// reports are the librarian reports by name
+var reports = map[model.ReportName]report{
+	model.ReportOverdue: {
+		header: []string{
+			"userID", "name", "email", "cardNumber", "userFineAmount", "isPaymentDone",
+			"checkoutID", "ISBN", "title", "author", "barcode", "checkedOutOn", "dueDate", "daysOverdue", "fineAmount",
+		},
+		run: func(d domain.Service, request *model.ReportRequest) (interface{}, func() [][]string, uint, error) {
+			patrons, totalPages, err := d.GetOverdueReport(request)
+			return patrons, func() [][]string {
+				records := [][]string{}
+				for _, patron := range patrons {
+					for _, loan := range patron.Loans {
+						records = append(records, []string{
+							patron.UserID,
+							patron.Name,
+							patron.Email,
+							patron.CardNumber,
+							formatAmount(patron.FineAmount),
+							strconv.FormatBool(patron.IsPaymentDone),
+							loan.CheckoutID,
+							loan.ISBN,
+							loan.Title,
+							loan.Author,
+							loan.Barcode,
+							loan.CheckedOutOn.Format(time.RFC3339),
+							loan.DueDate.Format("2006-01-02"),
+							strconv.FormatInt(loan.DaysOverdue, 10),
+							formatAmount(loan.FineAmount),
+						})
+					}
+				}
+				return records
+			}, totalPages, err
+		},
+	},
+	model.ReportDue: {
+		header: []string{
+			"checkoutID", "userID", "name", "email", "ISBN", "title", "author", "barcode", "checkedOutOn", "dueDate", "daysLeft",
+		},
+		run: func(d domain.Service, request *model.ReportRequest) (interface{}, func() [][]string, uint, error) {
+			loans, totalPages, err := d.GetDueReport(request)
+			return loans, func() [][]string {
+				records := make([][]string, 0, len(loans))
+				for _, loan := range loans {
+					records = append(records, []string{
+						loan.CheckoutID,
+						loan.UserID,
+						loan.Name,
+						loan.Email,
+						loan.ISBN,
+						loan.Title,
+						loan.Author,
+						loan.Barcode,
+						loan.CheckedOutOn.Format(time.RFC3339),
+						loan.DueDate.Format("2006-01-02"),
+						strconv.FormatInt(loan.DaysLeft, 10),
+					})
+				}
+				return records
+			}, totalPages, err
+		},
+	},
+	model.ReportHoldShelf: {
+		header: []string{
+			"checkoutID", "userID", "name", "email", "ISBN", "title", "author", "shelfNumber", "reservedOn",
+			"daysWaiting", "booksLeft", "ready",
+		},
+		run: func(d domain.Service, request *model.ReportRequest) (interface{}, func() [][]string, uint, error) {
+			items, totalPages, err := d.GetHoldShelfReport(request)
+			return items, func() [][]string {
+				records := make([][]string, 0, len(items))
+				for _, item := range items {
+					records = append(records, []string{
+						item.CheckoutID,
+						item.UserID,
+						item.Name,
+						item.Email,
+						item.ISBN,
+						item.Title,
+						item.Author,
+						strconv.FormatInt(item.ShelfNumber, 10),
+						item.ReservedOn.Format(time.RFC3339),
+						strconv.FormatInt(item.DaysWaiting, 10),
+						strconv.FormatInt(item.BooksLeft, 10),
+						strconv.FormatBool(item.Ready),
+					})
+				}
+				return records
+			}, totalPages, err
+		},
+	},
+	model.ReportTopBooks: {
+		header: []string{"ISBN", "title", "author", "checkouts", "borrowers"},
+		run: func(d domain.Service, request *model.ReportRequest) (interface{}, func() [][]string, uint, error) {
+			books, totalPages, err := d.GetTopBooksReport(request)
+			return books, func() [][]string {
+				records := make([][]string, 0, len(books))
+				for _, book := range books {
+					records = append(records, []string{
+						book.ISBN,
+						book.Title,
+						book.Author,
+						strconv.FormatInt(book.Checkouts, 10),
+						strconv.FormatInt(book.Borrowers, 10),
+					})
+				}
+				return records
+			}, totalPages, err
+		},
+	},
+	model.ReportTopAuthors: {
+		header: []string{"author", "books", "checkouts", "borrowers"},
+		run: func(d domain.Service, request *model.ReportRequest) (interface{}, func() [][]string, uint, error) {
+			authors, totalPages, err := d.GetTopAuthorsReport(request)
+			return authors, func() [][]string {
+				records := make([][]string, 0, len(authors))
+				for _, author := range authors {
+					records = append(records, []string{
+						author.Author,
+						strconv.FormatInt(author.Books, 10),
+						strconv.FormatInt(author.Checkouts, 10),
+						strconv.FormatInt(author.Borrowers, 10),
+					})
+				}
+				return records
+			}, totalPages, err
+		},
+	},
+	model.ReportInactivePatrons: {
+		header: []string{"userID", "name", "email", "cardNumber", "createdAt", "lastCheckoutAt", "checkouts"},
+		run: func(d domain.Service, request *model.ReportRequest) (interface{}, func() [][]string, uint, error) {
+			patrons, totalPages, err := d.GetInactivePatronsReport(request)
+			return patrons, func() [][]string {
+				records := make([][]string, 0, len(patrons))
+				for _, patron := range patrons {
+					lastCheckoutAt := ""
+					if patron.LastCheckoutAt != nil {
+						lastCheckoutAt = patron.LastCheckoutAt.Format(time.RFC3339)
+					}
+					records = append(records, []string{
+						patron.UserID,
+						patron.Name,
+						patron.Email,
+						patron.CardNumber,
+						patron.CreatedAt.Format(time.RFC3339),
+						lastCheckoutAt,
+						strconv.FormatInt(patron.Checkouts, 10),
+					})
+				}
+				return records
+			}, totalPages, err
+		},
+	},
+}
+
+// formatAmount formats a fine with two decimals
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// RequestEmailChangeHandler mails a verification link to the new address, the email of the
+// signed in user only changes once the link is followed
+func (th *LibraryHandler) RequestEmailChangeHandler(c *gin.Context) {
+	userIDInterface, ok := c.Get("userID")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID not found",
+		})
+		return
+	}
+
+	userID, ok := userIDInterface.(string)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "userID is not of type string",
+		})
+		return
+	}
+
+	req := model.ChangeEmailRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	token, err := th.domain.CreateEmailChangeRequest(userID, req.Email)
+	if err != nil {
+		if errors.Is(err, domain.ErrEmailInUse) {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	link := th.emailVerifyURL + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(
+		"Please confirm your new email address for the library by opening the link below.\n\n%s\n\nThe link expires in %s. If you did not ask for this change you can ignore this email.",
+		link, model.EmailChangeTTL,
+	)
+	if err := th.mailer.Send(req.Email, "Confirm your new email address", body); err != nil {
+		log.Error().Msgf("[Error] RequestEmailChangeHandler(), mailer.Send err: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "sending the verification email failed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "verification email sent",
+	})
+}
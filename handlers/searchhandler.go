@@ -9,7 +9,7 @@ import (
 	"integrated-library-service/model"
 )
 
-// search handler returns either user data or book data from DB or google books respectively
+// search handler returns either user data or book data from DB
 func (th *LibraryHandler) SearchHandler(c *gin.Context) {
 	// sort things need to be added
 	req := model.SearchRequest{}
@@ -21,38 +21,7 @@ func (th *LibraryHandler) SearchHandler(c *gin.Context) {
 	}
 
 	if req.Type == model.SearchRequestTypeBook {
-		// get books from google
-		googleBooks, totalPages, err := th.googleBooksService.SearchGoogleBooks(&req)
-		if err != nil {
-			books, totalPages, err := th.domain.GetAllBooksForSearch(&req)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"message": err.Error(),
-				})
-				return
-			}
-			c.JSON(http.StatusOK, gin.H{
-				"totalPages": totalPages,
-				"books":      books,
-			})
-			return
-		}
-
-		// create books in batch
-		if err := th.domain.CreateBooksBatch(googleBooks); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": err.Error(),
-			})
-			return
-		}
-
-		bookList := []string{}
-		for _, book := range googleBooks {
-			bookList = append(bookList, book.ISBN)
-		}
-
-		// Retrieve all books from the domain
-		books, err := th.domain.GetAllBooksFromSpecific(bookList)
+		books, totalPages, err := th.domain.GetAllBooksForSearch(&req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"message": err.Error(),
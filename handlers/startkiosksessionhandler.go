@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/librarycard"
+	"integrated-library-service/model"
+)
+
+// StartKioskSessionHandler signs a patron in at a kiosk with their scanned library card and PIN
+func (th *LibraryHandler) StartKioskSessionHandler(c *gin.Context) {
+	deviceID, ok := kioskDeviceID(c)
+	if !ok {
+		return
+	}
+
+	req := model.StartKioskSessionRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	userID, err := th.domain.GetUserIDByCardNumber(librarycard.Normalize(req.CardNumber))
+	if err != nil {
+		if errors.Is(err, domain.ErrLibraryCardNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// the attempt is counted before the PIN is checked, so parallel guesses can't outrun the lock
+	pin, err := th.domain.ClaimKioskPinAttempt(userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrPinNotSet) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrPinLocked) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(pin.Hash), []byte(req.Pin)); err != nil {
+		if err := th.domain.RecordKioskPinFailure(deviceID, userID, pin.FailedAttempts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "pin doesn't match",
+		})
+		return
+	}
+
+	session, err := th.domain.StartKioskSession(deviceID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/jobs"
+)
+
+// TriggerCatalogueSyncHandler starts a catalogue sync without waiting for its schedule
+func (th *LibraryHandler) TriggerCatalogueSyncHandler(c *gin.Context) {
+	if err := th.jobRunner.RunNow(jobs.CatalogueSyncJobName); err != nil {
+		if errors.Is(err, jobs.ErrJobRunning) {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "catalogue sync started",
+	})
+}
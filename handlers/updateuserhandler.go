@@ -9,7 +9,7 @@ import (
 	"integrated-library-service/model"
 )
 
-// UpdateUserHandler updates user details
+// UpdateUserHandler updates the profile of the signed in user, privileged fields are left to librarians
 func (th *LibraryHandler) UpdateUserHandler(c *gin.Context) {
 	userIDInterface, ok := c.Get("userID")
 	if !ok {
@@ -27,7 +27,7 @@ func (th *LibraryHandler) UpdateUserHandler(c *gin.Context) {
 		return
 	}
 
-	req := model.User{}
+	req := model.UpdateProfileRequest{}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": apperror.CustomValidationError(err),
@@ -36,7 +36,7 @@ func (th *LibraryHandler) UpdateUserHandler(c *gin.Context) {
 	}
 
 	// update user operation
-	if err := th.domain.UpdateUser(&req, userID); err != nil {
+	if err := th.domain.UpdateUserProfile(&req, userID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 		})
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"integrated-library-service/apperror"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// VerifyEmailChangeHandler applies a pending email change with the token mailed to the new address
+// and lets the old address know about it
+func (th *LibraryHandler) VerifyEmailChangeHandler(c *gin.Context) {
+	req := model.VerifyEmailChangeRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": apperror.CustomValidationError(err),
+		})
+		return
+	}
+
+	change, err := th.domain.ConfirmEmailChange(req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrEmailChangeTokenInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+		case errors.Is(err, domain.ErrEmailInUse):
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	body := "The email address of your library account was changed to " + change.NewEmail +
+		". If you did not make this change please contact the library."
+	if err := th.mailer.Send(change.OldEmail, "Your email address was changed", body); err != nil {
+		log.Error().Msgf("[Error] VerifyEmailChangeHandler(), mailer.Send err: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "email changed successfully",
+	})
+}
@@ -0,0 +1,130 @@
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidISBN is an error when a value is neither a valid ISBN-10 nor a valid ISBN-13
+	ErrInvalidISBN = errors.New("invalid ISBN")
+)
+
+// Normalize strips hyphens and spaces and upper cases the ISBN-10 check character
+func Normalize(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.ToUpper(value), "ISBN")
+	value = strings.TrimPrefix(value, ":")
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// IsValid10 reports whether the normalised value is an ISBN-10 with a valid check digit
+func IsValid10(value string) bool {
+	if len(value) != 10 {
+		return false
+	}
+
+	sum := 0
+	for i, r := range value {
+		var digit int
+		switch {
+		case r >= '0' && r <= '9':
+			digit = int(r - '0')
+		case r == 'X' && i == 9:
+			digit = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * digit
+	}
+
+	return sum%11 == 0
+}
+
+// IsValid13 reports whether the normalised value is an ISBN-13 with a valid check digit
+func IsValid13(value string) bool {
+	if len(value) != 13 || !(strings.HasPrefix(value, "978") || strings.HasPrefix(value, "979")) {
+		return false
+	}
+
+	sum := 0
+	for i, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(r-'0')
+	}
+
+	return sum%10 == 0
+}
+
+// To13 converts a valid ISBN-10 into its ISBN-13 form
+func To13(isbn10 string) (string, error) {
+	if !IsValid10(isbn10) {
+		return "", ErrInvalidISBN
+	}
+
+	body := "978" + isbn10[:9]
+	sum := 0
+	for i, r := range body {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(r-'0')
+	}
+	check := (10 - sum%10) % 10
+
+	return body + string(rune('0'+check)), nil
+}
+
+// To10 converts a valid 978 prefixed ISBN-13 into its ISBN-10 form, 979 prefixed ISBNs have no ISBN-10
+func To10(isbn13 string) (string, error) {
+	if !IsValid13(isbn13) || !strings.HasPrefix(isbn13, "978") {
+		return "", ErrInvalidISBN
+	}
+
+	body := isbn13[3:12]
+	sum := 0
+	for i, r := range body {
+		sum += (10 - i) * int(r-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X", nil
+	}
+
+	return body + string(rune('0'+check)), nil
+}
+
+// Parse validates an ISBN-10 or ISBN-13 in any common notation and returns the canonical
+// ISBN-13 together with the ISBN-10 alias, which is empty for 979 prefixed ISBNs
+func Parse(value string) (isbn13 string, isbn10 string, err error) {
+	value = Normalize(value)
+
+	switch {
+	case IsValid13(value):
+		isbn13 = value
+	case IsValid10(value):
+		if isbn13, err = To13(value); err != nil {
+			return "", "", err
+		}
+	default:
+		return "", "", ErrInvalidISBN
+	}
+
+	if isbn10, err = To10(isbn13); err != nil {
+		isbn10 = ""
+	}
+
+	return isbn13, isbn10, nil
+}
@@ -0,0 +1,41 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want13  string
+		want10  string
+		wantErr error
+	}{
+		{name: "ISBN-13", value: "9780306406157", want13: "9780306406157", want10: "0306406152"},
+		{name: "hyphenated ISBN-13", value: "978-0-306-40615-7", want13: "9780306406157", want10: "0306406152"},
+		{name: "ISBN-10", value: "0306406152", want13: "9780306406157", want10: "0306406152"},
+		{name: "ISBN-10 with prefix and spaces", value: " ISBN: 0 306 40615 2 ", want13: "9780306406157", want10: "0306406152"},
+		{name: "ISBN-10 with X check", value: "080442957x", want13: "9780804429573", want10: "080442957X"},
+		{name: "979 ISBN-13 has no ISBN-10", value: "9791034303847", want13: "9791034303847"},
+		{name: "wrong ISBN-13 check digit", value: "9780306406158", wantErr: ErrInvalidISBN},
+		{name: "wrong ISBN-10 check digit", value: "0306406153", wantErr: ErrInvalidISBN},
+		{name: "X inside ISBN-10", value: "03064X6152", wantErr: ErrInvalidISBN},
+		{name: "not a bookland prefix", value: "9770306406155", wantErr: ErrInvalidISBN},
+		{name: "too short", value: "978030640615", wantErr: ErrInvalidISBN},
+		{name: "empty", value: "", wantErr: ErrInvalidISBN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got13, got10, err := Parse(tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) err = %v, want %v", tt.value, err, tt.wantErr)
+			}
+			if got13 != tt.want13 || got10 != tt.want10 {
+				t.Errorf("Parse(%q) = %q, %q, want %q, %q", tt.value, got13, got10, tt.want13, tt.want10)
+			}
+		})
+	}
+}
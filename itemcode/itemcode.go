@@ -0,0 +1,68 @@
+// Package itemcode formats and checks the barcodes of book copies. Like library card numbers they
+// are 14 digits with a Luhn check digit, starting with the item prefix 3 instead of the patron
+// prefix 2, so a scanner can tell copies and cards apart.
+package itemcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Length is the number of digits of an item barcode
+	Length = 14
+	// itemPrefix starts the barcodes of book copies
+	itemPrefix = "3"
+)
+
+var (
+	// ErrInvalidItemCode is an error when a value is not an item barcode with a valid check digit
+	ErrInvalidItemCode = errors.New("invalid item barcode")
+)
+
+// Format returns the barcode of the copy with the given sequence number
+func Format(sequence int64) string {
+	body := fmt.Sprintf("%s%0*d", itemPrefix, Length-2, sequence)
+	return body + string(checkDigit(body))
+}
+
+// IsValid reports whether value is an item barcode with a valid check digit
+func IsValid(value string) bool {
+	if len(value) != Length || !strings.HasPrefix(value, itemPrefix) {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return checkDigit(value[:Length-1]) == value[Length-1]
+}
+
+// Parse trims value and checks it is an item barcode
+func Parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if !IsValid(value) {
+		return "", ErrInvalidItemCode
+	}
+	return value, nil
+}
+
+// checkDigit returns the Luhn check digit of the digits in body
+func checkDigit(body string) byte {
+	sum := 0
+	double := true
+	for i := len(body) - 1; i >= 0; i-- {
+		digit := int(body[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
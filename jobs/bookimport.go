@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+
+	"integrated-library-service/bookimport"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BookImportJobName prefixes the names of the book import runs
+const BookImportJobName = "book-import"
+
+// BookImport stores the parsed rows of an uploaded catalogue file and reports every row
+type BookImport struct {
+	domain   domain.Service
+	importID string
+	rows     []bookimport.Row
+	dryRun   bool
+}
+
+// NewBookImport returns a new BookImport of the recorded import with the given ID
+func NewBookImport(domain domain.Service, importID string, rows []bookimport.Row, dryRun bool) *BookImport {
+	return &BookImport{
+		domain:   domain,
+		importID: importID,
+		rows:     rows,
+		dryRun:   dryRun,
+	}
+}
+
+// Name returns the job name
+func (bi *BookImport) Name() string {
+	return BookImportJobName + "-" + bi.importID
+}
+
+// Run imports the rows one by one, a row which fails is reported and the next one is tried. The
+// report is stored in chunks so the progress of big files can be followed.
+func (bi *BookImport) Run(ctx context.Context, trigger string) error {
+	if err := bi.domain.StartBookImport(bi.importID); err != nil {
+		return err
+	}
+
+	runErr := bi.run(ctx)
+	status, message := model.BookImportStatusSucceeded, ""
+	if runErr != nil {
+		status, message = model.BookImportStatusFailed, runErr.Error()
+	}
+
+	if err := bi.domain.FinishBookImport(bi.importID, status, message); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("[Info] book import %s finished with %d rows, dry run %t", bi.importID, len(bi.rows), bi.dryRun)
+	return runErr
+}
+
+// run imports the rows and records their report
+func (bi *BookImport) run(ctx context.Context) error {
+	report := make([]model.BookImportRow, 0, model.BookImportRowsChunk)
+	for _, row := range bi.rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		reportRow := model.BookImportRow{
+			RowNumber: row.Number,
+			ISBN:      row.ISBN(),
+			Title:     row.Title(),
+		}
+		if row.Err != nil {
+			reportRow.Outcome = model.BookImportOutcomeError
+			reportRow.Message = row.Err.Error()
+		} else {
+			outcome, err := bi.domain.ImportBook(row.Book, bi.dryRun)
+			if err != nil {
+				reportRow.Outcome = model.BookImportOutcomeError
+				reportRow.Message = err.Error()
+			} else {
+				reportRow.Outcome = outcome
+			}
+		}
+
+		report = append(report, reportRow)
+		if len(report) == model.BookImportRowsChunk {
+			if err := bi.domain.RecordBookImportRows(bi.importID, report); err != nil {
+				return err
+			}
+			report = report[:0]
+		}
+	}
+
+	return bi.domain.RecordBookImportRows(bi.importID, report)
+}
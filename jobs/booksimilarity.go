@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+
+	"integrated-library-service/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BookSimilarityJobName is the name the book similarity refresh is scheduled under
+const BookSimilarityJobName = "book-similarity"
+
+// BookSimilarity recomputes the precomputed similar books of every book
+type BookSimilarity struct {
+	domain  domain.Service
+	perBook int
+}
+
+// NewBookSimilarity returns a new BookSimilarity keeping perBook similar books per book
+func NewBookSimilarity(domain domain.Service, perBook int) *BookSimilarity {
+	if perBook <= 0 {
+		perBook = 20
+	}
+	return &BookSimilarity{
+		domain:  domain,
+		perBook: perBook,
+	}
+}
+
+// Name returns the job name
+func (bs *BookSimilarity) Name() string {
+	return BookSimilarityJobName
+}
+
+// Run recomputes the similarities in a single transaction
+func (bs *BookSimilarity) Run(ctx context.Context, trigger string) error {
+	stored, err := bs.domain.RefreshBookSimilarities(bs.perBook)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("[Info] book similarity %s run stored %d pairs", trigger, stored)
+	return nil
+}
@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"integrated-library-service/bookprovider"
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CatalogueSyncJobName is the name the catalogue sync is scheduled under
+const CatalogueSyncJobName = "catalogue-sync"
+
+// CatalogueSyncOptions configures what the catalogue sync pulls from the book providers
+type CatalogueSyncOptions struct {
+	// Subjects are searched as "subject:<subject>"
+	Subjects []string
+	// Queries are searched as free text
+	Queries []string
+	// Pages is the number of result pages pulled per subject, query and for the new releases
+	Pages int
+	// PageSize is the number of books per page
+	PageSize int
+	// StaleAfter is how long synced metadata stays fresh before it is refreshed
+	StaleAfter time.Duration
+	// RefreshLimit is the maximum number of stale books refreshed by ISBN per run
+	RefreshLimit int
+}
+
+// withDefaults fills the zero values of the options
+func (o CatalogueSyncOptions) withDefaults() CatalogueSyncOptions {
+	if o.Pages <= 0 {
+		o.Pages = 2
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = 20
+	}
+	if o.StaleAfter <= 0 {
+		o.StaleAfter = 7 * 24 * time.Hour
+	}
+	if o.RefreshLimit <= 0 {
+		o.RefreshLimit = 50
+	}
+	return o
+}
+
+// CatalogueSync pulls new releases, the configured subjects and queries from the book providers into
+// the local catalogue and refreshes stale metadata of existing books
+type CatalogueSync struct {
+	domain   domain.Service
+	provider bookprovider.Provider
+	options  CatalogueSyncOptions
+}
+
+// NewCatalogueSync returns a new CatalogueSync
+func NewCatalogueSync(domain domain.Service, provider bookprovider.Provider, options CatalogueSyncOptions) *CatalogueSync {
+	return &CatalogueSync{
+		domain:   domain,
+		provider: provider,
+		options:  options.withDefaults(),
+	}
+}
+
+// Name returns the job name
+func (cs *CatalogueSync) Name() string {
+	return CatalogueSyncJobName
+}
+
+// Run runs a single sync and records it in the catalogue sync runs
+func (cs *CatalogueSync) Run(ctx context.Context, trigger string) error {
+	runID, err := cs.domain.CreateCatalogueSyncRun(trigger)
+	if err != nil {
+		return err
+	}
+
+	run := &model.CatalogueSyncRun{
+		ID:      runID,
+		Trigger: trigger,
+		Status:  model.SyncRunStatusSucceeded,
+	}
+	staleBefore := time.Now().UTC().Add(-cs.options.StaleAfter)
+
+	syncErr := cs.sync(ctx, run, staleBefore)
+	if syncErr != nil {
+		run.Status = model.SyncRunStatusFailed
+		run.Error = syncErr.Error()
+	}
+
+	if err := cs.domain.FinishCatalogueSyncRun(run); err != nil {
+		return err
+	}
+
+	log.Info().Msgf("[Info] catalogue sync %s: added %d, updated %d, skipped %d, not found %d, failed %d", run.ID, run.Added, run.Updated, run.Skipped, run.NotFound, run.Failed)
+	return syncErr
+}
+
+// sync pulls every configured source, a source which fails is counted and the next one is tried
+func (cs *CatalogueSync) sync(ctx context.Context, run *model.CatalogueSyncRun, staleBefore time.Time) error {
+	sourceErrors := 0
+	sources := 0
+
+	for page := 1; page <= cs.options.Pages; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		sources++
+		books, _, err := cs.provider.ListNewReleases(ctx, &model.GetAllBooksRequest{
+			Page:    uint32(page),
+			Limit:   uint32(cs.options.PageSize),
+			SortBy:  "publishedDate",
+			OrderBy: "descending",
+		})
+		if err != nil {
+			log.Error().Msgf("[Error] CatalogueSync.sync(), ListNewReleases page %d err: %v", page, err)
+			sourceErrors++
+			continue
+		}
+		cs.store(run, books, staleBefore)
+	}
+
+	searches := []model.SearchRequest{}
+	for _, subject := range cs.options.Subjects {
+		searches = append(searches, model.SearchRequest{SearchBy: "subject", SearchText: subject})
+	}
+	for _, query := range cs.options.Queries {
+		searches = append(searches, model.SearchRequest{SearchBy: "all", SearchText: query})
+	}
+
+	for _, search := range searches {
+		for page := 1; page <= cs.options.Pages; page++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			sources++
+			request := search
+			request.Page = uint32(page)
+			request.Limit = uint32(cs.options.PageSize)
+			request.SortBy = "title"
+			request.OrderBy = "ascending"
+			request.Type = model.SearchRequestTypeBook
+
+			books, _, err := cs.provider.Search(ctx, &request)
+			if err != nil {
+				log.Error().Msgf("[Error] CatalogueSync.sync(), Search %s %q page %d err: %v", search.SearchBy, search.SearchText, page, err)
+				sourceErrors++
+				continue
+			}
+			cs.store(run, books, staleBefore)
+		}
+	}
+
+	// books which none of the sources returned are refreshed one by one
+	ISBNs, err := cs.domain.GetStaleBookISBNs(staleBefore, cs.options.RefreshLimit)
+	if err != nil {
+		return err
+	}
+	for _, ISBN := range ISBNs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		book, err := cs.provider.GetByISBN(ctx, ISBN)
+		if errors.Is(err, bookprovider.ErrBookNotFound) {
+			// mark it checked so it neither comes back on every run nor uses up the refresh limit
+			if err := cs.domain.MarkBookMetadataSynced(ISBN); err != nil {
+				run.Failed++
+				continue
+			}
+			run.NotFound++
+			continue
+		}
+		if err != nil {
+			log.Error().Msgf("[Error] CatalogueSync.sync(), GetByISBN %s err: %v", ISBN, err)
+			run.Failed++
+			continue
+		}
+		cs.store(run, []*model.CreateBookRequest{book}, staleBefore)
+	}
+
+	if sources > 0 && sourceErrors == sources {
+		return fmt.Errorf("all %d catalogue sources failed", sources)
+	}
+	return nil
+}
+
+// store upserts the books and counts the outcomes
+func (cs *CatalogueSync) store(run *model.CatalogueSyncRun, books []*model.CreateBookRequest, staleBefore time.Time) {
+	for _, book := range books {
+		outcome, err := cs.domain.SyncBook(book, staleBefore)
+		if err != nil {
+			run.Failed++
+			continue
+		}
+
+		switch outcome {
+		case model.SyncOutcomeAdded:
+			run.Added++
+		case model.SyncOutcomeUpdated:
+			run.Updated++
+		default:
+			run.Skipped++
+		}
+	}
+}
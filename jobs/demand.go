@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DemandJobName is the name the demand score refresh is scheduled under
+const DemandJobName = "demand-scores"
+
+// Demand recomputes the approximate demand of every book
+type Demand struct {
+	domain  domain.Service
+	weights model.DemandWeights
+}
+
+// NewDemand returns a new Demand, a non positive half life or window falls back to two weeks and 90 days
+func NewDemand(domain domain.Service, weights model.DemandWeights) *Demand {
+	if weights.HalfLife <= 0 {
+		weights.HalfLife = 14 * 24 * time.Hour
+	}
+	if weights.Window <= 0 {
+		weights.Window = 90 * 24 * time.Hour
+	}
+	return &Demand{
+		domain:  domain,
+		weights: weights,
+	}
+}
+
+// Name returns the job name
+func (d *Demand) Name() string {
+	return DemandJobName
+}
+
+// Run recomputes and stores the demand scores
+func (d *Demand) Run(ctx context.Context, trigger string) error {
+	updated, err := d.domain.RefreshDemandScores(d.weights)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("[Info] demand %s run updated %d books", trigger, updated)
+	return nil
+}
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RollupsJobName is the name the analytics rollup refresh is scheduled under
+const RollupsJobName = "analytics-rollups"
+
+// Rollups keeps the daily analytics rollups the dashboard reads current
+type Rollups struct {
+	domain       domain.Service
+	lookbackDays int
+}
+
+// NewRollups returns a new Rollups, a non positive lookback falls back to RollupLookbackDays
+func NewRollups(domain domain.Service, lookbackDays int) *Rollups {
+	if lookbackDays <= 0 {
+		lookbackDays = model.RollupLookbackDays
+	}
+	return &Rollups{
+		domain:       domain,
+		lookbackDays: lookbackDays,
+	}
+}
+
+// Name returns the job name
+func (r *Rollups) Name() string {
+	return RollupsJobName
+}
+
+// Run recomputes the rollups of the last days, of the days never rolled up and of the older days whose
+// checkout tickets changed
+func (r *Rollups) Run(ctx context.Context, trigger string) error {
+	refreshed, err := r.domain.RefreshRecentRollups(r.lookbackDays)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("[Info] rollups %s run refreshed %d days", trigger, refreshed)
+	return nil
+}
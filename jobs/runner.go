@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// TriggerScheduled marks a run started by the runner's schedule
+	TriggerScheduled = "scheduled"
+	// TriggerManual marks a run started on request, e.g. by a librarian
+	TriggerManual = "manual"
+)
+
+var (
+	// ErrJobNotFound is an error when no job with the given name is scheduled
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is an error when the job is already running
+	ErrJobRunning = errors.New("job is already running")
+	// ErrRunnerNotStarted is an error when a job is triggered before the runner was started
+	ErrRunnerNotStarted = errors.New("job runner not started")
+)
+
+// Job is a unit of background work run by the Runner
+type Job interface {
+	Name() string
+	Run(ctx context.Context, trigger string) error
+}
+
+// scheduledJob is a job with its interval, a job never runs twice at the same time
+type scheduledJob struct {
+	job      Job
+	interval time.Duration
+	running  atomic.Bool
+}
+
+// Runner runs its jobs every interval and on demand
+type Runner struct {
+	mu   sync.Mutex
+	ctx  context.Context
+	jobs map[string]*scheduledJob
+	wg   sync.WaitGroup
+}
+
+// NewRunner returns a new Runner without jobs
+func NewRunner() *Runner {
+	return &Runner{
+		jobs: map[string]*scheduledJob{},
+	}
+}
+
+// Schedule adds a job to run every interval once the runner is started, a non positive interval
+// only allows the job to be run on demand
+func (r *Runner) Schedule(job Job, interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.Name()] = &scheduledJob{
+		job:      job,
+		interval: interval,
+	}
+}
+
+// Start starts the schedules of all jobs, they stop when ctx is done
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ctx = ctx
+	for _, scheduled := range r.jobs {
+		if scheduled.interval <= 0 {
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(scheduled *scheduledJob) {
+			defer r.wg.Done()
+
+			ticker := time.NewTicker(scheduled.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					r.run(ctx, scheduled, TriggerScheduled)
+				}
+			}
+		}(scheduled)
+	}
+}
+
+// RunNow starts the named job in the background without waiting for its schedule
+func (r *Runner) RunNow(name string) error {
+	r.mu.Lock()
+	ctx := r.ctx
+	scheduled, ok := r.jobs[name]
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrJobNotFound
+	}
+	if ctx == nil {
+		return ErrRunnerNotStarted
+	}
+	if scheduled.running.Load() {
+		return ErrJobRunning
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx, scheduled, TriggerManual)
+	}()
+
+	return nil
+}
+
+// Go runs a one-off job in the background, e.g. an uploaded import, it is not scheduled and can not
+// be run again by name
+func (r *Runner) Go(job Job) error {
+	r.mu.Lock()
+	ctx := r.ctx
+	r.mu.Unlock()
+
+	if ctx == nil {
+		return ErrRunnerNotStarted
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx, &scheduledJob{job: job}, TriggerManual)
+	}()
+
+	return nil
+}
+
+// Wait blocks until every running job has returned
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// run runs the job unless it is already running
+func (r *Runner) run(ctx context.Context, scheduled *scheduledJob, trigger string) {
+	if !scheduled.running.CompareAndSwap(false, true) {
+		log.Info().Msgf("[Info] job %s is already running, skipping %s run", scheduled.job.Name(), trigger)
+		return
+	}
+	defer scheduled.running.Store(false)
+
+	started := time.Now()
+	if err := scheduled.job.Run(ctx, trigger); err != nil {
+		log.Error().Msgf("[Error] job %s failed after %v: %v", scheduled.job.Name(), time.Since(started), err)
+		return
+	}
+	log.Info().Msgf("[Info] job %s finished in %v", scheduled.job.Name(), time.Since(started))
+}
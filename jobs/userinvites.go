@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"integrated-library-service/mailer"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// UserInvitesJobName names the runs mailing the invites of imported users
+const UserInvitesJobName = "user-invites"
+
+// UserInvites mails imported users their library card number and the link to set a password
+type UserInvites struct {
+	mailer    mailer.Mailer
+	inviteURL string
+	invites   []model.UserInvite
+}
+
+// NewUserInvites returns a new UserInvites, the token of each invite is added to inviteURL as ?token=
+func NewUserInvites(mailer mailer.Mailer, inviteURL string, invites []model.UserInvite) *UserInvites {
+	return &UserInvites{
+		mailer:    mailer,
+		inviteURL: inviteURL,
+		invites:   invites,
+	}
+}
+
+// Name returns the job name
+func (ui *UserInvites) Name() string {
+	return UserInvitesJobName
+}
+
+// Run mails every invite, an invite which can not be sent is logged and the next one is tried.
+// Users whose mail was lost get a new invite by importing their row again.
+func (ui *UserInvites) Run(ctx context.Context, trigger string) error {
+	failed := 0
+	for _, invite := range ui.invites {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		link := ui.inviteURL + "?token=" + url.QueryEscape(invite.Token)
+		body := fmt.Sprintf(
+			"Hello %s,\n\nan account at the library was opened for you. Your library card number is %s, you can borrow books with it and use it to sign in.\n\nPlease choose a password by opening the link below.\n\n%s\n\nThe link expires in %s.",
+			invite.Name, invite.CardNumber, link, model.UserInviteTTL,
+		)
+		if err := ui.mailer.Send(invite.Email, "Your library account", body); err != nil {
+			log.Error().Msgf("[Error] user invite of %s not sent: %v", invite.UserID, err)
+			failed++
+		}
+	}
+
+	log.Info().Msgf("[Info] user invites sent: %d of %d", len(ui.invites)-failed, len(ui.invites))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d user invites not sent", failed, len(ui.invites))
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package label renders Code 128 barcodes and QR codes as PNG or SVG images and prints sheets of
+// shelf labels
+package label
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+
+	"integrated-library-service/model"
+)
+
+// ErrUnsupportedCode is an error when a code is asked for in an unknown symbology or format
+var ErrUnsupportedCode = errors.New("unsupported code symbology or format")
+
+const (
+	// DefaultScale is the width of a module in pixels when none is given
+	DefaultScale = 4
+	// linearHeight is the height of the bars of a Code 128 barcode in modules
+	linearHeight = 40
+	// linearQuietZone is the blank margin scanners need left and right of a Code 128 barcode
+	linearQuietZone = 10
+	// qrQuietZone is the blank margin around a QR code
+	qrQuietZone = 4
+)
+
+// Code is an encoded barcode or QR code as a grid of dark and light modules
+type Code struct {
+	symbology model.CodeSymbology
+	width     int
+	height    int
+	quietZone int
+	dark      []bool
+}
+
+// Encode encodes content in symbology
+func Encode(content string, symbology model.CodeSymbology) (*Code, error) {
+	var (
+		bc        barcode.Barcode
+		err       error
+		height    int
+		quietZone int
+	)
+	switch symbology {
+	case model.CodeSymbologyCode128:
+		bc, err = code128.Encode(content)
+		quietZone = linearQuietZone
+	case model.CodeSymbologyQR:
+		bc, err = qr.Encode(content, qr.M, qr.Auto)
+		quietZone = qrQuietZone
+	default:
+		return nil, ErrUnsupportedCode
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", symbology, err)
+	}
+
+	bounds := bc.Bounds()
+	width := bounds.Dx()
+	height = bounds.Dy()
+	if symbology == model.CodeSymbologyCode128 {
+		// a linear barcode is one row of modules, the bars are drawn linearHeight modules high
+		height = linearHeight
+	}
+
+	code := &Code{
+		symbology: symbology,
+		width:     width,
+		height:    height,
+		quietZone: quietZone,
+		dark:      make([]bool, width*height),
+	}
+	for y := 0; y < height; y++ {
+		row := y
+		if symbology == model.CodeSymbologyCode128 {
+			row = 0
+		}
+		for x := 0; x < width; x++ {
+			gray := color.GrayModel.Convert(bc.At(bounds.Min.X+x, bounds.Min.Y+row)).(color.Gray)
+			code.dark[y*width+x] = gray.Y < 128
+		}
+	}
+
+	return code, nil
+}
+
+// isDark reports whether the module at x, y of the code without its quiet zone is dark
+func (c *Code) isDark(x, y int) bool {
+	return c.dark[y*c.width+x]
+}
+
+// size returns the width and height of the code including its quiet zone in modules
+func (c *Code) size() (int, int) {
+	if c.symbology == model.CodeSymbologyCode128 {
+		// linear barcodes only need the quiet zone left and right of the bars
+		return c.width + 2*c.quietZone, c.height
+	}
+	return c.width + 2*c.quietZone, c.height + 2*c.quietZone
+}
+
+// offset returns the position of the first module of the code inside its quiet zone
+func (c *Code) offset() (int, int) {
+	if c.symbology == model.CodeSymbologyCode128 {
+		return c.quietZone, 0
+	}
+	return c.quietZone, c.quietZone
+}
+
+// Write renders the code in format to w, scale is the size of a module in pixels for PNG images
+func (c *Code) Write(w io.Writer, format model.CodeFormat, scale int) error {
+	if scale < 1 {
+		scale = DefaultScale
+	}
+	switch format {
+	case model.CodeFormatPNG:
+		return c.writePNG(w, scale)
+	case model.CodeFormatSVG:
+		return c.writeSVG(w, scale)
+	}
+	return ErrUnsupportedCode
+}
+
+// writePNG draws every module as a square of scale pixels
+func (c *Code) writePNG(w io.Writer, scale int) error {
+	width, height := c.size()
+	offsetX, offsetY := c.offset()
+
+	img := image.NewGray(image.Rect(0, 0, width*scale, height*scale))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			if !c.isDark(x, y) {
+				continue
+			}
+			for py := 0; py < scale; py++ {
+				row := ((offsetY+y)*scale + py) * img.Stride
+				for px := 0; px < scale; px++ {
+					img.Pix[row+(offsetX+x)*scale+px] = 0
+				}
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// writeSVG writes the dark modules of each row as runs of rectangles in module units, the image is
+// scaled by its width and height only so it stays sharp when printed larger
+func (c *Code) writeSVG(w io.Writer, scale int) error {
+	width, height := c.size()
+	offsetX, offsetY := c.offset()
+
+	if _, err := fmt.Fprintf(w,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="#fff"/><g fill="#000">`,
+		width*scale, height*scale, width, height,
+	); err != nil {
+		return err
+	}
+	if err := c.writeSVGModules(w, offsetX, offsetY); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "</g></svg>")
+	return err
+}
+
+// writeSVGModules writes the rectangles of the dark modules, bars of a linear barcode span its
+// whole height
+func (c *Code) writeSVGModules(w io.Writer, offsetX, offsetY int) error {
+	rows := c.height
+	if c.symbology == model.CodeSymbologyCode128 {
+		rows = 1
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < c.width; {
+			if !c.isDark(x, y) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < c.width && c.isDark(x+run, y) {
+				run++
+			}
+			barHeight := 1
+			if c.symbology == model.CodeSymbologyCode128 {
+				barHeight = c.height
+			}
+			if _, err := fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d"/>`, offsetX+x, offsetY+y, run, barHeight); err != nil {
+				return err
+			}
+			x += run
+		}
+	}
+	return nil
+}
+
+// ContentType returns the media type of images in format
+func ContentType(format model.CodeFormat) string {
+	if format == model.CodeFormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
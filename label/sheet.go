@@ -0,0 +1,80 @@
+package label
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"integrated-library-service/model"
+)
+
+// Label is a shelf label with a barcode of Code above its number and the lines of text below
+type Label struct {
+	Code  string
+	Lines []string
+}
+
+// sheetLabel is a label with its rendered barcode
+type sheetLabel struct {
+	Code    string
+	Lines   []string
+	Barcode template.HTML
+}
+
+// sheetTemplate lays labels out on A4 pages of three columns and eight rows of 70 by 37 mm labels,
+// the common size of adhesive label sheets
+var sheetTemplate = template.Must(template.New("sheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Shelf labels</title>
+<style>
+@page { size: A4; margin: 0; }
+body { margin: 0; font-family: sans-serif; }
+.page { width: 210mm; height: 296mm; display: grid; grid-template-columns: repeat(3, 70mm); grid-auto-rows: 37mm; page-break-after: always; }
+.page:last-child { page-break-after: auto; }
+.label { box-sizing: border-box; padding: 2mm 3mm; overflow: hidden; text-align: center; }
+.label svg { width: 100%; height: 14mm; }
+.code { font-family: monospace; font-size: 9pt; letter-spacing: 1px; }
+.line { font-size: 8pt; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+</style>
+</head>
+<body>
+{{- range .}}
+<div class="page">
+{{- range .}}
+<div class="label">{{.Barcode}}<div class="code">{{.Code}}</div>{{range .Lines}}<div class="line">{{.}}</div>{{end}}</div>
+{{- end}}
+</div>
+{{- end}}
+</body>
+</html>
+`))
+
+// Sheet writes an HTML page of labels with Code 128 barcodes to w, ready to print on label sheets
+func Sheet(w io.Writer, labels []Label) error {
+	pages := make([][]sheetLabel, 0, (len(labels)+model.LabelsPerSheet-1)/model.LabelsPerSheet)
+	for i, l := range labels {
+		code, err := Encode(l.Code, model.CodeSymbologyCode128)
+		if err != nil {
+			return err
+		}
+		var svg bytes.Buffer
+		if err := code.writeSVG(&svg, 1); err != nil {
+			return err
+		}
+
+		if i%model.LabelsPerSheet == 0 {
+			pages = append(pages, make([]sheetLabel, 0, model.LabelsPerSheet))
+		}
+		page := len(pages) - 1
+		// the svg is generated from the code modules only, the text of the label is escaped by the template
+		pages[page] = append(pages[page], sheetLabel{
+			Code:    l.Code,
+			Lines:   l.Lines,
+			Barcode: template.HTML(svg.String()),
+		})
+	}
+
+	return sheetTemplate.Execute(w, pages)
+}
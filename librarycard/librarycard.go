@@ -0,0 +1,86 @@
+// Package librarycard generates and checks library card numbers. A card number is 14 digits like
+// the patron barcodes of most library systems: the patron prefix 2, 12 random digits and a Luhn
+// check digit, so it prints as a barcode and a mistyped digit is caught before any lookup.
+package librarycard
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	// Length is the number of digits of a card number
+	Length = 14
+	// patronPrefix starts the card numbers of patrons
+	patronPrefix = "2"
+)
+
+var (
+	// ErrInvalidCardNumber is an error when a value is not a card number with a valid check digit
+	ErrInvalidCardNumber = errors.New("invalid library card number")
+)
+
+// Generate returns a new random card number, uniqueness is up to the caller
+func Generate() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(Length-2), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	digits := n.String()
+	body := patronPrefix + strings.Repeat("0", Length-2-len(digits)) + digits
+	return body + string(checkDigit(body)), nil
+}
+
+// Normalize strips the spaces and hyphens card numbers are printed with
+func Normalize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
+
+// IsValid reports whether the normalised value is a card number with a valid check digit
+func IsValid(value string) bool {
+	if len(value) != Length || !strings.HasPrefix(value, patronPrefix) {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return checkDigit(value[:Length-1]) == value[Length-1]
+}
+
+// Parse normalises value and checks it is a card number
+func Parse(value string) (string, error) {
+	value = Normalize(value)
+	if !IsValid(value) {
+		return "", ErrInvalidCardNumber
+	}
+	return value, nil
+}
+
+// checkDigit returns the Luhn check digit of the digits in body
+func checkDigit(body string) byte {
+	sum := 0
+	double := true
+	for i := len(body) - 1; i >= 0; i-- {
+		digit := int(body[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
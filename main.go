@@ -1,19 +1,28 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
 
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
+	"integrated-library-service/bookprovider"
 	"integrated-library-service/domain"
 	"integrated-library-service/googlebooks"
 	"integrated-library-service/handlers"
+	"integrated-library-service/jobs"
+	"integrated-library-service/mailer"
 	"integrated-library-service/middleware"
+	"integrated-library-service/model"
+	"integrated-library-service/openlibrary"
 	"integrated-library-service/routes"
 
 	"github.com/gin-gonic/gin"
@@ -35,9 +44,31 @@ var (
 	port string
 
 	// other variables
-	secretKey        string
-	googleAPIKey     string
-	googleAPIBaseUrl string
+	secretKey                      string
+	googleAPIKey                   string
+	googleAPIBaseUrl               string
+	openLibraryBaseUrl             string
+	openLibraryBreakerThreshold    int
+	openLibraryBreakerOpenDuration time.Duration
+	bookProviderOrder              string
+	bookProviderFixture            string
+	googleBooksOptions             googlebooks.Options
+	catalogueSyncEvery             time.Duration
+	catalogueSync                  jobs.CatalogueSyncOptions
+	similarityEvery                time.Duration
+	similarityPerBook              int
+	demandEvery                    time.Duration
+	demandWeights                  model.DemandWeights
+	rollupsEvery                   time.Duration
+	rollupLookbackDays             int
+	smtpHost                       string
+	smtpPort                       int
+	smtpUsername                   string
+	smtpPassword                   string
+	mailFrom                       string
+	emailVerifyURL                 string
+	userInviteURL                  string
+	incidentFees                   model.IncidentFees
 
 	// program controller
 	done      = make(chan struct{})
@@ -54,12 +85,135 @@ func init() {
 	secretKey = os.Getenv("JWT_SECRET_KEY")
 	googleAPIKey = os.Getenv("GOOGLE_BOOKS_API_KEY")
 	googleAPIBaseUrl = os.Getenv("GOOGLE_BOOKS_BASE_URL")
+	openLibraryBaseUrl = os.Getenv("OPEN_LIBRARY_BASE_URL")
+	openLibraryBreakerThreshold = intFromEnv("OPEN_LIBRARY_BREAKER_THRESHOLD")
+	openLibraryBreakerOpenDuration = durationFromEnv("OPEN_LIBRARY_BREAKER_OPEN_DURATION")
+	bookProviderFixture = os.Getenv("BOOK_PROVIDER_FIXTURE")
+	bookProviderOrder = os.Getenv("BOOK_PROVIDER_PRIORITY")
+	if len(bookProviderOrder) == 0 {
+		bookProviderOrder = "google,openlibrary"
+	}
+	googleBooksOptions = googlebooks.Options{
+		CacheTTL:         durationFromEnv("GOOGLE_BOOKS_CACHE_TTL"),
+		ISBNCacheTTL:     durationFromEnv("GOOGLE_BOOKS_ISBN_CACHE_TTL"),
+		RequestTimeout:   durationFromEnv("GOOGLE_BOOKS_REQUEST_TIMEOUT"),
+		MaxRetries:       intFromEnv("GOOGLE_BOOKS_MAX_RETRIES"),
+		FailureThreshold: intFromEnv("GOOGLE_BOOKS_BREAKER_THRESHOLD"),
+		OpenDuration:     durationFromEnv("GOOGLE_BOOKS_BREAKER_OPEN_DURATION"),
+	}
+
+	catalogueSyncEvery = durationFromEnv("CATALOGUE_SYNC_INTERVAL")
+	if catalogueSyncEvery == 0 {
+		catalogueSyncEvery = 6 * time.Hour
+	}
+	catalogueSync = jobs.CatalogueSyncOptions{
+		Subjects:     listFromEnv("CATALOGUE_SYNC_SUBJECTS"),
+		Queries:      listFromEnv("CATALOGUE_SYNC_QUERIES"),
+		Pages:        intFromEnv("CATALOGUE_SYNC_PAGES"),
+		PageSize:     intFromEnv("CATALOGUE_SYNC_PAGE_SIZE"),
+		StaleAfter:   durationFromEnv("CATALOGUE_SYNC_STALE_AFTER"),
+		RefreshLimit: intFromEnv("CATALOGUE_SYNC_REFRESH_LIMIT"),
+	}
+
+	similarityEvery = durationFromEnv("BOOK_SIMILARITY_INTERVAL")
+	if similarityEvery == 0 {
+		similarityEvery = 24 * time.Hour
+	}
+	similarityPerBook = intFromEnv("BOOK_SIMILARITY_PER_BOOK")
+
+	demandEvery = durationFromEnv("DEMAND_INTERVAL")
+	if demandEvery == 0 {
+		demandEvery = time.Hour
+	}
+	demandWeights = model.DemandWeights{
+		Checkout: floatFromEnv("DEMAND_CHECKOUT_WEIGHT", 10),
+		Hold:     floatFromEnv("DEMAND_HOLD_WEIGHT", 6),
+		Wishlist: floatFromEnv("DEMAND_WISHLIST_WEIGHT", 3),
+		View:     floatFromEnv("DEMAND_VIEW_WEIGHT", 1),
+		HalfLife: durationFromEnv("DEMAND_HALF_LIFE"),
+		Window:   durationFromEnv("DEMAND_WINDOW"),
+	}
+
+	rollupsEvery = durationFromEnv("ROLLUP_INTERVAL")
+	if rollupsEvery == 0 {
+		rollupsEvery = 15 * time.Minute
+	}
+	rollupLookbackDays = intFromEnv("ROLLUP_LOOKBACK_DAYS")
+
+	smtpHost = os.Getenv("SMTP_HOST")
+	smtpPort = intFromEnv("SMTP_PORT")
+	if smtpPort == 0 {
+		smtpPort = 587
+	}
+	smtpUsername = os.Getenv("SMTP_USERNAME")
+	smtpPassword = os.Getenv("SMTP_PASSWORD")
+	mailFrom = os.Getenv("MAIL_FROM")
+	emailVerifyURL = os.Getenv("EMAIL_VERIFY_URL")
+	userInviteURL = os.Getenv("USER_INVITE_URL")
+	incidentFees = model.IncidentFees{
+		Replacement: floatFromEnv("DEFAULT_REPLACEMENT_FEE", 25),
+		Repair:      floatFromEnv("DEFAULT_REPAIR_FEE", 5),
+		RepairRate:  floatFromEnv("REPAIR_FEE_RATE", 0.25),
+	}
 
 	flag.BoolVar(&versionFlag, "version", false, "show current version and exit")
 	flag.BoolVar(&helpFlag, "help", false, "show usage and exit")
 	flag.StringVar(&port, "port", ":8000", "server port")
 }
 
+// durationFromEnv parses a duration like "10m" from the environment, zero when unset or invalid
+func durationFromEnv(key string) time.Duration {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return 0
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid duration for %s: %v", key, err)
+		return 0
+	}
+	return duration
+}
+
+// intFromEnv parses an integer from the environment, zero when unset or invalid
+func intFromEnv(key string) int {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return 0
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("invalid number for %s: %v", key, err)
+		return 0
+	}
+	return number
+}
+
+// floatFromEnv parses a number like "0.5" from the environment, fallback when unset or invalid
+func floatFromEnv(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return fallback
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("invalid number for %s: %v", key, err)
+		return fallback
+	}
+	return number
+}
+
+// listFromEnv splits a comma separated list from the environment, empty entries are dropped
+func listFromEnv(key string) []string {
+	list := []string{}
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); len(value) != 0 {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
 func setBuildVariables() {
 	if buildRevision == "" {
 		buildRevision = "dev"
@@ -118,8 +272,47 @@ func openDB() (*sql.DB, error) {
 	return db, nil
 }
 
+// newBookProvider builds the book provider chain in the configured priority order
+func newBookProvider(httpClient *http.Client) (bookprovider.Provider, error) {
+	providers := []bookprovider.Provider{}
+	for _, name := range bookprovider.ParsePriority(bookProviderOrder) {
+		switch name {
+		case googlebooks.ProviderName:
+			providers = append(providers, googlebooks.NewGoogleService(googleAPIBaseUrl, googleAPIKey, httpClient, googleBooksOptions))
+		case openlibrary.ProviderName:
+			providers = append(providers, openlibrary.NewOpenLibraryService(openLibraryBaseUrl, httpClient, openLibraryBreakerThreshold, openLibraryBreakerOpenDuration))
+		case bookprovider.FakeProviderName:
+			fakeProvider, err := bookprovider.NewFakeProviderFromFixture(bookProviderFixture)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, fakeProvider)
+		default:
+			return nil, fmt.Errorf("unknown book provider: %s", name)
+		}
+	}
+
+	return bookprovider.NewChain(providers...), nil
+}
+
+// newMailer sends through the configured SMTP server, or only logs the emails when there is none
+func newMailer() mailer.Mailer {
+	if len(smtpHost) == 0 {
+		log.Println("SMTP_HOST is not set, emails are written to the log")
+		return mailer.LogMailer{}
+	}
+	return mailer.NewSMTPMailer(smtpHost, smtpPort, smtpUsername, smtpPassword, mailFrom)
+}
+
 func main() {
 	setBuildVariables()
+	// subcommands run instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(runExport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backfill-rollups" {
+		os.Exit(runBackfillRollups(os.Args[2:]))
+	}
 	parseFlags()
 
 	go handleInterrupts()
@@ -129,8 +322,6 @@ func main() {
 	server.Use(middleware.CORS())
 	// server.SetTrustedProxies([]string{"127.0.0.1", "127.0.0.1:3000"})
 	ilmGroup := server.Group("ilm-service/v1")
-	authMiddleware := middleware.NewAuthMiddleware(secretKey)
-
 	db, err := openDB()
 	if err != nil {
 		log.Printf("error connecting DB: %v", err)
@@ -139,14 +330,39 @@ func main() {
 	log.Println("DB connection is successful")
 	defer db.Close()
 
-	// google api client
-	googleClient := googlebooks.GetClient(time.Minute)
-	googleBooksService := googlebooks.NewGoogleService(googleAPIBaseUrl, googleAPIKey, googleClient)
+	// book metadata providers
+	bookProvider, err := newBookProvider(googlebooks.GetClient(time.Minute))
+	if err != nil {
+		log.Printf("error creating book providers: %v", err)
+		return
+	}
 
 	// create library service
 	libraryService := domain.NewLibraryService(db)
+	authMiddleware := middleware.NewAuthMiddleware(secretKey, libraryService, libraryService)
+
+	// background jobs, a negative interval only allows triggering them by hand
+	jobRunner := jobs.NewRunner()
+	jobRunner.Schedule(jobs.NewCatalogueSync(libraryService, bookProvider, catalogueSync), catalogueSyncEvery)
+	jobRunner.Schedule(jobs.NewBookSimilarity(libraryService, similarityPerBook), similarityEvery)
+	jobRunner.Schedule(jobs.NewDemand(libraryService, demandWeights), demandEvery)
+	jobRunner.Schedule(jobs.NewRollups(libraryService, rollupLookbackDays), rollupsEvery)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobRunner.Start(jobsCtx)
+	// similar books are served from the precomputed table only, so fill it right away
+	if err := jobRunner.RunNow(jobs.BookSimilarityJobName); err != nil {
+		log.Printf("error starting book similarity job: %v", err)
+	}
+	if err := jobRunner.RunNow(jobs.DemandJobName); err != nil {
+		log.Printf("error starting demand job: %v", err)
+	}
+	// the dashboard is served from the rollups, so catch up on the days missed while stopped
+	if err := jobRunner.RunNow(jobs.RollupsJobName); err != nil {
+		log.Printf("error starting rollups job: %v", err)
+	}
 
-	libraryHandler := handlers.NewLibraryHandler(libraryService, secretKey, googleBooksService)
+	libraryHandler := handlers.NewLibraryHandler(libraryService, secretKey, jobRunner, newMailer(), emailVerifyURL, userInviteURL, incidentFees)
 	apiRoutes := routes.NewRoutes(libraryHandler)
 	routes.AttachRoutes(ilmGroup, apiRoutes, authMiddleware)
 
@@ -161,6 +377,10 @@ func main() {
 		log.Println("shutting down server ...")
 	}
 
+	// let running jobs record their runs before the DB is closed
+	stopJobs()
+	jobRunner.Wait()
+
 	time.AfterFunc(1*time.Second, func() {
 		close(done)
 		close(errc)
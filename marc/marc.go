@@ -0,0 +1,238 @@
+// Package marc reads MARC 21 bibliographic records in the ISO 2709 transmission format and as MARCXML
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	leaderLength        = 24
+	directoryEntryLen   = 12
+	fieldTerminator     = 0x1E
+	recordTerminator    = 0x1D
+	subfieldDelimiter   = 0x1F
+	maxRecordLength     = 99999
+	recordLengthDigits  = 5
+	baseAddressPosition = 12
+)
+
+var (
+	// ErrInvalidRecordLength is an error when the leader does not start with a record length,
+	// the records after it can not be found anymore
+	ErrInvalidRecordLength = errors.New("invalid MARC record length")
+	// ErrInvalidRecord is an error when a single record is malformed
+	ErrInvalidRecord = errors.New("invalid MARC record")
+)
+
+// Subfield is a coded value of a data field
+type Subfield struct {
+	Code  string
+	Value string
+}
+
+// DataField is a variable data field with its indicators and subfields
+type DataField struct {
+	Tag       string
+	Ind1      string
+	Ind2      string
+	Subfields []Subfield
+}
+
+// Record is a MARC 21 bibliographic record
+type Record struct {
+	Leader        string
+	ControlFields map[string]string
+	DataFields    []DataField
+}
+
+// Control returns the value of the control field with the given tag
+func (r *Record) Control(tag string) string {
+	return r.ControlFields[tag]
+}
+
+// Fields returns the data fields with the given tag in record order
+func (r *Record) Fields(tag string) []DataField {
+	fields := []DataField{}
+	for _, field := range r.DataFields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Values returns the values of the subfields with the given code in every field with the given tag
+func (r *Record) Values(tag, code string) []string {
+	values := []string{}
+	for _, field := range r.Fields(tag) {
+		values = append(values, field.Values(code)...)
+	}
+	return values
+}
+
+// Value returns the first value of the subfield with the given code in the fields with the given tag
+func (r *Record) Value(tag, code string) string {
+	if values := r.Values(tag, code); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Values returns the values of the subfields with the given code
+func (f DataField) Values(code string) []string {
+	values := []string{}
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			values = append(values, subfield.Value)
+		}
+	}
+	return values
+}
+
+// Reader reads records in the ISO 2709 transmission format one at a time
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a new Reader reading from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record and io.EOF after the last one. A malformed record returns an
+// error wrapping ErrInvalidRecord and the next call continues with the following record,
+// ErrInvalidRecordLength ends the reading.
+func (mr *Reader) Read() (*Record, error) {
+	// line breaks and padding between records are skipped
+	for {
+		b, err := mr.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != '\n' && b != '\r' && b != ' ' && b != recordTerminator {
+			if err := mr.r.UnreadByte(); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	lengthDigits := make([]byte, recordLengthDigits)
+	if _, err := io.ReadFull(mr.r, lengthDigits); err != nil {
+		return nil, ErrInvalidRecordLength
+	}
+	length, ok := parseDigits(lengthDigits)
+	if !ok || length <= leaderLength || length > maxRecordLength {
+		return nil, ErrInvalidRecordLength
+	}
+
+	data := make([]byte, length)
+	copy(data, lengthDigits)
+	if _, err := io.ReadFull(mr.r, data[recordLengthDigits:]); err != nil {
+		return nil, ErrInvalidRecordLength
+	}
+
+	return Parse(data)
+}
+
+// Parse parses a single record in the ISO 2709 transmission format
+func Parse(data []byte) (*Record, error) {
+	if len(data) < leaderLength {
+		return nil, fmt.Errorf("%w: record shorter than its leader", ErrInvalidRecord)
+	}
+
+	leader := data[:leaderLength]
+	baseAddress, ok := parseDigits(leader[baseAddressPosition : baseAddressPosition+5])
+	if !ok || baseAddress <= leaderLength || baseAddress > len(data) {
+		return nil, fmt.Errorf("%w: invalid base address of data", ErrInvalidRecord)
+	}
+
+	directory := data[leaderLength : baseAddress-1]
+	if len(directory)%directoryEntryLen != 0 {
+		return nil, fmt.Errorf("%w: invalid directory length %d", ErrInvalidRecord, len(directory))
+	}
+
+	record := &Record{
+		Leader:        string(leader),
+		ControlFields: map[string]string{},
+		DataFields:    []DataField{},
+	}
+	body := data[baseAddress:]
+	for entry := 0; entry < len(directory); entry += directoryEntryLen {
+		tag := string(directory[entry : entry+3])
+		fieldLength, ok := parseDigits(directory[entry+3 : entry+7])
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid length of field %s", ErrInvalidRecord, tag)
+		}
+		start, ok := parseDigits(directory[entry+7 : entry+12])
+		if !ok || start+fieldLength > len(body) {
+			return nil, fmt.Errorf("%w: invalid position of field %s", ErrInvalidRecord, tag)
+		}
+
+		field := bytes.TrimRight(body[start:start+fieldLength], string([]byte{fieldTerminator, recordTerminator}))
+		if isControlTag(tag) {
+			record.ControlFields[tag] = decode(field)
+			continue
+		}
+		record.DataFields = append(record.DataFields, parseDataField(tag, field))
+	}
+
+	return record, nil
+}
+
+// parseDigits parses a zero padded number of the leader or directory. Anything but digits makes it
+// invalid, so a sign can't point a field before the start of the record.
+func parseDigits(digits []byte) (int, bool) {
+	if len(digits) == 0 {
+		return 0, false
+	}
+	number := 0
+	for _, digit := range digits {
+		if digit < '0' || digit > '9' {
+			return 0, false
+		}
+		number = number*10 + int(digit-'0')
+	}
+	return number, true
+}
+
+// parseDataField splits a data field into its indicators and subfields
+func parseDataField(tag string, field []byte) DataField {
+	dataField := DataField{Tag: tag, Ind1: " ", Ind2: " ", Subfields: []Subfield{}}
+	if len(field) >= 2 && field[0] != subfieldDelimiter {
+		dataField.Ind1 = string(field[0])
+		dataField.Ind2 = string(field[1])
+		field = field[2:]
+	}
+
+	for _, part := range bytes.Split(field, []byte{subfieldDelimiter}) {
+		if len(part) == 0 {
+			continue
+		}
+		dataField.Subfields = append(dataField.Subfields, Subfield{
+			Code:  string(part[0]),
+			Value: decode(part[1:]),
+		})
+	}
+	return dataField
+}
+
+// isControlTag reports whether the tag is one of the control fields 001 to 009
+func isControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+// decode returns the field data as a string. MARC-8 encoded records are not transcoded, their
+// non ASCII bytes which are not valid UTF-8 are replaced.
+func decode(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return strings.ToValidUTF8(string(data), "�")
+}
@@ -0,0 +1,149 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// field is a tag and data of a record built by buildRecord
+type field struct {
+	tag  string
+	data string
+}
+
+// buildRecord returns a well formed ISO 2709 record of the fields
+func buildRecord(fields ...field) []byte {
+	var directory, body bytes.Buffer
+	for _, f := range fields {
+		data := f.data + string(rune(fieldTerminator))
+		fmt.Fprintf(&directory, "%s%04d%05d", f.tag, len(data), body.Len())
+		body.WriteString(data)
+	}
+	directory.WriteByte(fieldTerminator)
+	body.WriteByte(recordTerminator)
+
+	baseAddress := leaderLength + directory.Len()
+	length := baseAddress + body.Len()
+	leader := fmt.Sprintf("%05dnam a22%05d   4500", length, baseAddress)
+
+	return append(append([]byte(leader), directory.Bytes()...), body.Bytes()...)
+}
+
+// subfields joins subfield codes and values into the data of a data field with blank indicators
+func subfields(codesAndValues ...string) string {
+	data := "  "
+	for i := 0; i+1 < len(codesAndValues); i += 2 {
+		data += string(rune(subfieldDelimiter)) + codesAndValues[i] + codesAndValues[i+1]
+	}
+	return data
+}
+
+func hobbitRecord() []byte {
+	return buildRecord(
+		field{"001", "ocm00012345"},
+		field{"020", subfields("a", "9780547928227")},
+		field{"100", subfields("a", "Tolkien, J. R. R.")},
+		field{"245", subfields("a", "The Hobbit", "b", "or There and Back Again")},
+	)
+}
+
+func TestParse(t *testing.T) {
+	record, err := Parse(hobbitRecord())
+	if err != nil {
+		t.Fatalf("Parse() err = %v", err)
+	}
+
+	if got := record.Control("001"); got != "ocm00012345" {
+		t.Errorf("Control(001) = %q, want %q", got, "ocm00012345")
+	}
+	if got := record.Value("020", "a"); got != "9780547928227" {
+		t.Errorf("Value(020, a) = %q, want %q", got, "9780547928227")
+	}
+	if got := record.Value("245", "b"); got != "or There and Back Again" {
+		t.Errorf("Value(245, b) = %q, want %q", got, "or There and Back Again")
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	// setBytes returns a copy of the Hobbit record with the bytes at offset replaced
+	setBytes := func(offset int, value string) []byte {
+		data := hobbitRecord()
+		copy(data[offset:], value)
+		return data
+	}
+	// the first directory entry starts right after the leader, its length after the tag
+	firstLength := leaderLength + 3
+	firstStart := leaderLength + 7
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"shorter than the leader", []byte("00010nam a22")},
+		{"base address not a number", setBytes(baseAddressPosition, "00x49")},
+		{"signed base address", setBytes(baseAddressPosition, "-0049")},
+		{"base address inside the leader", setBytes(baseAddressPosition, "00010")},
+		{"base address past the record", setBytes(baseAddressPosition, "99999")},
+		{"directory not made of whole entries", setBytes(baseAddressPosition, fmt.Sprintf("%05d", leaderLength+directoryEntryLen*4))},
+		{"signed field length", setBytes(firstLength, "-001")},
+		{"field length not a number", setBytes(firstLength, "0 12")},
+		{"signed field start", setBytes(firstStart, "-0001")},
+		{"field past the end of the record", setBytes(firstStart, "99999")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := Parse(tt.data)
+			if !errors.Is(err, ErrInvalidRecord) {
+				t.Fatalf("Parse() = %v, %v, want %v", record, err, ErrInvalidRecord)
+			}
+		})
+	}
+}
+
+func TestParseReplacesInvalidUTF8(t *testing.T) {
+	record, err := Parse(buildRecord(field{"100", subfields("a", "Caf\xe9")}))
+	if err != nil {
+		t.Fatalf("Parse() err = %v", err)
+	}
+	if got := record.Value("100", "a"); got != "Caf�" {
+		t.Errorf("Value(100, a) = %q, want %q", got, "Caf�")
+	}
+}
+
+func TestReaderSkipsMalformedRecords(t *testing.T) {
+	broken := hobbitRecord()
+	copy(broken[leaderLength+3:], "-001")
+
+	var stream bytes.Buffer
+	stream.Write(broken)
+	stream.WriteString("\n")
+	stream.Write(hobbitRecord())
+
+	reader := NewReader(&stream)
+	if _, err := reader.Read(); !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("Read() of the broken record err = %v, want %v", err, ErrInvalidRecord)
+	}
+	record, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read() of the next record err = %v", err)
+	}
+	if got := record.Value("245", "a"); got != "The Hobbit" {
+		t.Errorf("Value(245, a) = %q, want %q", got, "The Hobbit")
+	}
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("Read() after the last record err = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestReaderInvalidRecordLength(t *testing.T) {
+	for _, length := range []string{"-0100", "00010", "abcde", "000"} {
+		reader := NewReader(bytes.NewBufferString(length + "nam a22"))
+		if _, err := reader.Read(); !errors.Is(err, ErrInvalidRecordLength) {
+			t.Errorf("Read() of record length %q err = %v, want %v", length, err, ErrInvalidRecordLength)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// XMLNamespace is the namespace of the MARC 21 XML schema
+const XMLNamespace = "http://www.loc.gov/MARC21/slim"
+
+// xmlRecord is a record of the MARC 21 XML schema
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+// xmlControlField is a control field of the MARC 21 XML schema
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+// xmlDataField is a data field of the MARC 21 XML schema
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+// xmlSubfield is a subfield of the MARC 21 XML schema
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// XMLReader reads the records of a MARCXML collection, or of a single record document, one at a time
+type XMLReader struct {
+	decoder *xml.Decoder
+}
+
+// NewXMLReader returns a new XMLReader reading from r
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{decoder: xml.NewDecoder(r)}
+}
+
+// Read returns the next record and io.EOF after the last one. A record which does not match the
+// schema returns an error wrapping ErrInvalidRecord and the next call continues after it, malformed
+// XML ends the reading.
+func (xr *XMLReader) Read() (*Record, error) {
+	for {
+		token, err := xr.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var element xmlRecord
+		if err := xr.decoder.DecodeElement(&element, &start); err != nil {
+			return nil, err
+		}
+		return element.record()
+	}
+}
+
+// record converts the decoded element into a Record
+func (x *xmlRecord) record() (*Record, error) {
+	record := &Record{
+		Leader:        x.Leader,
+		ControlFields: map[string]string{},
+		DataFields:    []DataField{},
+	}
+
+	for _, control := range x.ControlFields {
+		if len(control.Tag) != 3 {
+			return nil, fmt.Errorf("%w: control field tag %q", ErrInvalidRecord, control.Tag)
+		}
+		record.ControlFields[control.Tag] = control.Value
+	}
+
+	for _, data := range x.DataFields {
+		if len(data.Tag) != 3 {
+			return nil, fmt.Errorf("%w: data field tag %q", ErrInvalidRecord, data.Tag)
+		}
+		field := DataField{Tag: data.Tag, Ind1: data.Ind1, Ind2: data.Ind2, Subfields: []Subfield{}}
+		for _, subfield := range data.Subfields {
+			field.Subfields = append(field.Subfields, Subfield{Code: subfield.Code, Value: subfield.Value})
+		}
+		record.DataFields = append(record.DataFields, field)
+	}
+
+	return record, nil
+}
+
+// XMLWriter writes records as a MARCXML collection, one at a time
+type XMLWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+// NewXMLWriter returns a new XMLWriter writing to w, Close must be called to end the collection
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{w: w, encoder: xml.NewEncoder(w)}
+}
+
+// Write writes a record of the collection
+func (xw *XMLWriter) Write(record *Record) error {
+	if err := xw.start(); err != nil {
+		return err
+	}
+	if err := xw.encoder.Encode(fromRecord(record)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(xw.w, "\n")
+	return err
+}
+
+// Close ends the collection, it does not close the underlying writer
+func (xw *XMLWriter) Close() error {
+	if err := xw.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(xw.w, "</collection>\n")
+	return err
+}
+
+// start writes the declaration and the opening of the collection once
+func (xw *XMLWriter) start() error {
+	if xw.started {
+		return nil
+	}
+	xw.started = true
+	_, err := io.WriteString(xw.w, xml.Header+`<collection xmlns="`+XMLNamespace+`">`+"\n")
+	return err
+}
+
+// fromRecord converts a Record into its element, control fields are written in the order of their tags
+func fromRecord(record *Record) *xmlRecord {
+	element := &xmlRecord{
+		Leader:        record.Leader,
+		ControlFields: make([]xmlControlField, 0, len(record.ControlFields)),
+		DataFields:    make([]xmlDataField, 0, len(record.DataFields)),
+	}
+
+	tags := make([]string, 0, len(record.ControlFields))
+	for tag := range record.ControlFields {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		element.ControlFields = append(element.ControlFields, xmlControlField{Tag: tag, Value: record.ControlFields[tag]})
+	}
+
+	for _, data := range record.DataFields {
+		field := xmlDataField{Tag: data.Tag, Ind1: data.Ind1, Ind2: data.Ind2, Subfields: make([]xmlSubfield, 0, len(data.Subfields))}
+		for _, subfield := range data.Subfields {
+			field.Subfields = append(field.Subfields, xmlSubfield{Code: subfield.Code, Value: subfield.Value})
+		}
+		element.DataFields = append(element.DataFields, field)
+	}
+
+	return element
+}
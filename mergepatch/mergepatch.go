@@ -0,0 +1,80 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrNotObject is an error when a merge patch or the patched document is not a JSON object
+	ErrNotObject = errors.New("merge patch must be a JSON object")
+)
+
+// Keys returns the sorted top level members of a merge patch
+func Keys(patch []byte) ([]string, error) {
+	members, err := decodeObject(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Apply applies a JSON merge patch (RFC 7396) to a JSON object document. Members set to null in the
+// patch are removed, objects are merged recursively and every other value replaces the document's.
+func Apply(document, patch []byte) ([]byte, error) {
+	target, err := decodeObject(document)
+	if err != nil {
+		return nil, err
+	}
+	members, err := decodeObject(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merge(target, members))
+}
+
+// merge merges patch into target following RFC 7396
+func merge(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = merge(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// decodeObject decodes a JSON object keeping numbers as written
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, ErrNotObject
+	}
+	return object, nil
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+
+	"integrated-library-service/domain"
 )
 
 const (
@@ -72,6 +74,23 @@ func (m *UserMiddleware) DoAuthenticate(c *gin.Context) {
 		return
 	}
 
+	// tokens issued before an account was closed stop working with it
+	if _, err := m.roles.GetUserRole(userID); err != nil {
+		if errors.Is(err, domain.ErrGetUserRoleNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized: account not found or closed",
+			})
+			c.Abort()
+			return
+		}
+		log.Printf("[error] DoAuthenticate(): %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
 	c.Set(userIDContextKey, userID)
 	c.Next()
 }
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/domain"
+)
+
+const (
+	kioskDeviceIDContextKey = "kioskDeviceID"
+	kioskPrefix             = "Kiosk "
+)
+
+// DoAuthenticateKiosk lets kiosks through by their device token. Kiosk routes take no user JWTs
+// and kiosk tokens work nowhere else, so a kiosk can only do what its routes offer.
+func (m *UserMiddleware) DoAuthenticateKiosk(c *gin.Context) {
+	authorizationHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authorizationHeader, kioskPrefix) {
+		c.Header("WWW-Authenticate", "Kiosk")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized. Kiosk device token required.",
+		})
+		c.Abort()
+		return
+	}
+
+	token := strings.TrimPrefix(authorizationHeader, kioskPrefix)
+	deviceID, err := m.kiosks.AuthenticateKioskDevice(token)
+	if err != nil {
+		if errors.Is(err, domain.ErrKioskDeviceUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Unauthorized: " + err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		log.Printf("[error] DoAuthenticateKiosk(): %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set(kioskDeviceIDContextKey, deviceID)
+	c.Next()
+}
@@ -2,18 +2,36 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/model"
 )
 
 type Middleware interface {
 	DoAuthenticate(c *gin.Context)
+	RequireLibrarian(c *gin.Context)
+	DoAuthenticateKiosk(c *gin.Context)
+}
+
+// RoleLookup looks up the current role of a user
+type RoleLookup interface {
+	GetUserRole(userID string) (model.RoleType, error)
+}
+
+// KioskLookup looks up the kiosk a device token belongs to
+type KioskLookup interface {
+	AuthenticateKioskDevice(token string) (string, error)
 }
 
 type UserMiddleware struct {
 	secretKey string
+	roles     RoleLookup
+	kiosks    KioskLookup
 }
 
-func NewAuthMiddleware(secretKey string) *UserMiddleware {
+func NewAuthMiddleware(secretKey string, roles RoleLookup, kiosks KioskLookup) *UserMiddleware {
 	return &UserMiddleware{
 		secretKey: secretKey,
+		roles:     roles,
+		kiosks:    kiosks,
 	}
 }
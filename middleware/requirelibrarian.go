@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"integrated-library-service/domain"
+	"integrated-library-service/model"
+)
+
+// RequireLibrarian only lets librarians through, it has to run after DoAuthenticate.
+// The role is looked up on every request so a revoked librarian loses access immediately.
+func (m *UserMiddleware) RequireLibrarian(c *gin.Context) {
+	userID := c.GetString(userIDContextKey)
+	if len(userID) == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized. Bearer token required.",
+		})
+		c.Abort()
+		return
+	}
+
+	role, err := m.roles.GetUserRole(userID)
+	if err != nil && !errors.Is(err, domain.ErrGetUserRoleNotFound) {
+		log.Printf("[error] RequireLibrarian(): %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	if role != model.Librarian {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Forbidden. Librarian role required.",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
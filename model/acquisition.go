@@ -0,0 +1,53 @@
+package model
+
+// AcquisitionKind is what a librarian is suggested to do
+type AcquisitionKind string
+
+const (
+	// AcquisitionKindAddCopies is a book in the library whose holds outrun its copies
+	AcquisitionKindAddCopies AcquisitionKind = "addCopies"
+	// AcquisitionKindAcquire is a book in the catalogue which the library does not hold
+	AcquisitionKindAcquire AcquisitionKind = "acquire"
+	// AcquisitionKindSearchTerm is a term patrons searched for without finding any book
+	AcquisitionKindSearchTerm AcquisitionKind = "searchTerm"
+)
+
+// GetAcquisitionSuggestionsRequest
+type GetAcquisitionSuggestionsRequest struct {
+	Page  uint32 `json:"page" form:"page" binding:"required,min=1"`
+	Limit uint32 `json:"limit" form:"limit" binding:"required,min=5"`
+}
+
+// AcquisitionSuggestion is a title to buy or add copies of, or a search term without local hits
+type AcquisitionSuggestion struct {
+	Kind              AcquisitionKind `json:"kind"`
+	ISBN              string          `json:"ISBN,omitempty"`
+	Title             string          `json:"title"`
+	Author            string          `json:"author,omitempty"`
+	InLibrary         bool            `json:"inLibrary"`
+	BooksLeft         int64           `json:"booksLeft"`
+	Holds             int64           `json:"holds"`
+	WishlistCount     int64           `json:"wishlistCount"`
+	ApproximateDemand int64           `json:"approximateDemand"`
+	SearchMisses      int64           `json:"searchMisses"`
+	CopiesNeeded      int64           `json:"copiesNeeded"`
+	Score             float64         `json:"score"`
+}
+
+// Define how acquisition suggestions are estimated and ranked
+const (
+	// AcquisitionPatronsPerCopy is how many waiting patrons one copy is expected to serve
+	AcquisitionPatronsPerCopy = 3.0
+	// AcquisitionWishlistConversion is the share of wishlisting patrons expected to borrow the book
+	AcquisitionWishlistConversion = 0.5
+	// AcquisitionHoldWeight ranks every waiting hold
+	AcquisitionHoldWeight = 3.0
+	// AcquisitionWishlistWeight ranks every wishlist entry
+	AcquisitionWishlistWeight = 1.0
+	// AcquisitionDemandWeight ranks the approximate demand
+	AcquisitionDemandWeight = 0.5
+	// AcquisitionSearchMissWeight ranks every search without local hits
+	AcquisitionSearchMissWeight = 1.0
+	// AcquisitionSearchMissDays is how long a search term without hits is suggested after its last search
+	AcquisitionSearchMissDays = 90
+)
@@ -6,20 +6,30 @@ import (
 
 // Book
 type Book struct {
-	ID            string    `json:"ID" binding:"required,uuid"`
-	ISBN          string    `json:"ISBN" binding:"required"`
-	Title         string    `json:"title" binding:"required"`
-	Author        string    `json:"author" binding:"required"`
-	Genre         string    `json:"genre"`
-	PublishedDate time.Time `json:"publishedDate" binding:"required"`
-	Description   string    `json:"desc"`
-	PreviewLink   string    `json:"previewLink"`
-	CoverImage    string    `json:"coverImage" binding:"required"`
-	ShelfNumber   int64     `json:"shelfNumber" binding:"required"`
-	InLibrary     bool      `json:"inLibrary" binding:"required"`
-	BooksLeft     int64     `json:"booksLeft" binding:"required"`
-	Rating        float64   `json:"rating" binding:"required"`
+	ID             string    `json:"ID" binding:"required,uuid"`
+	ISBN           string    `json:"ISBN" binding:"required"`
+	ISBN10         string    `json:"ISBN10"`
+	Title          string    `json:"title" binding:"required"`
+	Subtitle       string    `json:"subtitle"`
+	Author         string    `json:"author" binding:"required"`
+	Genre          string    `json:"genre"`
+	PublishedDate  time.Time `json:"publishedDate" binding:"required"`
+	Description    string    `json:"desc"`
+	PreviewLink    string    `json:"previewLink"`
+	CoverImage     string    `json:"coverImage" binding:"required"`
+	Publisher      string    `json:"publisher"`
+	PageCount      int64     `json:"pageCount"`
+	Language       string    `json:"language"`
+	MaturityRating string    `json:"maturityRating"`
+	ShelfNumber    int64     `json:"shelfNumber" binding:"required"`
+	InLibrary      bool      `json:"inLibrary" binding:"required"`
+	BooksLeft      int64     `json:"booksLeft" binding:"required"`
+	Rating         float64   `json:"rating" binding:"required"`
+	// Price is the replacement cost of a copy, fees for lost copies are charged from it
+	Price *float64 `json:"price"`
 	// list
+	Authors     []string `json:"authors"`
+	Genres      []string `json:"genres"`
 	WishList    []string `json:"wishList"`
 	ReviewsList []string `json:"reviewsList"`
 	ViewsList   []string `json:"viewsList"`
@@ -28,25 +38,36 @@ type Book struct {
 	WishlistCount     int64      `json:"wishlistCount" binding:"required"`
 	ReviewCount       int64      `json:"reviewCount" binding:"required"`
 	ApproximateDemand int64      `json:"approximateDemand" binding:"required"`
+	Version           int64      `json:"version"`
+	WithdrawnAt       *time.Time `json:"withdrawnAt,omitempty"`
+	WithdrawalReason  string     `json:"withdrawalReason,omitempty"`
 	CreatedAt         time.Time  `json:"createdAt"`
 	UpdatedAt         *time.Time `json:"updatedAt"`
 }
 
 // CreateBookRequest
 type CreateBookRequest struct {
-	ISBN          string    `json:"ISBN" binding:"required"`
-	Title         string    `json:"title" binding:"required"`
-	Author        string    `json:"author" binding:"required"`
-	Genre         string    `json:"genre"`
-	PublishedDate time.Time `json:"publishedDate" binding:"required"`
-	Description   string    `json:"desc"`
-	PreviewLink   string    `json:"previewLink"`
-	CoverImage    string    `json:"coverImage" binding:"required"`
-	ShelfNumber   *int64    `json:"shelfNumber" binding:"omitempty"`
-	InLibrary     *bool     `json:"inLibrary" binding:"omitempty"`
-	Views         *int64    `json:"views" binding:"omitempty"`
-	BooksLeft     *int64    `json:"booksLeft" binding:"omitempty"`
+	ISBN           string    `json:"ISBN" binding:"required"`
+	Title          string    `json:"title" binding:"required"`
+	Subtitle       string    `json:"subtitle"`
+	Author         string    `json:"author" binding:"required"`
+	Genre          string    `json:"genre"`
+	PublishedDate  time.Time `json:"publishedDate" binding:"required"`
+	Description    string    `json:"desc"`
+	PreviewLink    string    `json:"previewLink"`
+	CoverImage     string    `json:"coverImage" binding:"required"`
+	Publisher      string    `json:"publisher"`
+	PageCount      int64     `json:"pageCount" binding:"omitempty,min=0"`
+	Language       string    `json:"language"`
+	MaturityRating string    `json:"maturityRating" binding:"omitempty,oneof=NOT_MATURE MATURE"`
+	ShelfNumber    *int64    `json:"shelfNumber" binding:"omitempty"`
+	InLibrary      *bool     `json:"inLibrary" binding:"omitempty"`
+	Views          *int64    `json:"views" binding:"omitempty"`
+	BooksLeft      *int64    `json:"booksLeft" binding:"omitempty"`
+	Price          *float64  `json:"price" binding:"omitempty,min=0"`
 	// list
+	Authors     []string `json:"authors"`
+	Genres      []string `json:"genres"`
 	WishList    []string `json:"wishList"`
 	ReviewsList []string `json:"reviewsList"`
 	ViewsList   []string `json:"viewsList"`
@@ -57,6 +78,21 @@ type CreateBookRequest struct {
 	ApproximateDemand *int64   `json:"approximateDemand" binding:"omitempty"`
 }
 
+// CreateBooksBatchResponse counts the books of a batch which were created or already existed, books
+// with an invalid ISBN are skipped and reported with their position in the batch
+type CreateBooksBatchResponse struct {
+	Created  int64              `json:"created"`
+	Existing int64              `json:"existing"`
+	Skipped  []SkippedBatchBook `json:"skipped"`
+}
+
+// SkippedBatchBook is a book of a batch which was not created
+type SkippedBatchBook struct {
+	Index   int    `json:"index"`
+	ISBN    string `json:"ISBN"`
+	Message string `json:"message"`
+}
+
 // GetBookByISBNRequest
 type GetBookByISBNRequest struct {
 	ISBN string `json:"isbn" uri:"isbn" binding:"required"`
@@ -84,27 +120,61 @@ type GetAllBooksByBookDetailsFromRequest struct {
 }
 
 type UpdateBookRequest struct {
-	ISBN          string    `json:"ISBN" binding:"required"`
-	Title         string    `json:"title" binding:"required"`
-	Author        string    `json:"author" binding:"required"`
-	Genre         string    `json:"genre"`
-	PublishedDate time.Time `json:"publishedDate" binding:"required"`
-	Description   string    `json:"desc"`
-	PreviewLink   string    `json:"previewLink"`
-	CoverImage    string    `json:"coverImage" binding:"required"`
-	ShelfNumber   *int64    `json:"shelfNumber" binding:"required"`
-	InLibrary     *bool     `json:"inLibrary" binding:"omitempty"`
-	BooksLeft     *int64    `json:"booksLeft" binding:"required"`
-	Rating        *float64  `json:"rating" binding:"required"`
+	ISBN           string    `json:"ISBN" binding:"required"`
+	Title          string    `json:"title" binding:"required"`
+	Subtitle       string    `json:"subtitle"`
+	Author         string    `json:"author" binding:"required"`
+	Genre          string    `json:"genre"`
+	PublishedDate  time.Time `json:"publishedDate" binding:"required"`
+	Description    string    `json:"desc"`
+	PreviewLink    string    `json:"previewLink"`
+	CoverImage     string    `json:"coverImage" binding:"required"`
+	Publisher      string    `json:"publisher"`
+	PageCount      int64     `json:"pageCount" binding:"omitempty,min=0"`
+	Language       string    `json:"language"`
+	MaturityRating string    `json:"maturityRating" binding:"omitempty,oneof=NOT_MATURE MATURE"`
+	ShelfNumber    *int64    `json:"shelfNumber" binding:"required"`
+	InLibrary      *bool     `json:"inLibrary" binding:"omitempty"`
+	BooksLeft      *int64    `json:"booksLeft" binding:"required"`
+	Rating         *float64  `json:"rating" binding:"required"`
+	Price          *float64  `json:"price" binding:"omitempty,min=0"`
 	// list
+	Authors     []string `json:"authors"`
+	Genres      []string `json:"genres"`
 	WishList    []string `json:"wishList"`
 	ReviewsList []string `json:"reviewsList"`
-	ViewsList   []string `json:"viewsList"`
 	// count
-	Views             *int64 `json:"views" binding:"required"`
-	WishlistCount     *int64 `json:"wishlistCount" binding:"required"`
-	ReviewCount       *int64 `json:"reviewCount" binding:"required"`
-	ApproximateDemand *int64 `json:"approximateDemand" binding:"omitempty"`
+	WishlistCount *int64 `json:"wishlistCount" binding:"required"`
+	ReviewCount   *int64 `json:"reviewCount" binding:"required"`
+}
+
+// BookPatch is a book after a merge patch was applied, only the writable fields are stored
+type BookPatch struct {
+	Title          string    `json:"title" binding:"required"`
+	Subtitle       string    `json:"subtitle"`
+	Author         string    `json:"author" binding:"required"`
+	Genre          string    `json:"genre"`
+	PublishedDate  time.Time `json:"publishedDate" binding:"required"`
+	Description    string    `json:"desc"`
+	PreviewLink    string    `json:"previewLink"`
+	CoverImage     string    `json:"coverImage" binding:"required"`
+	Publisher      string    `json:"publisher"`
+	PageCount      int64     `json:"pageCount" binding:"omitempty,min=0"`
+	Language       string    `json:"language"`
+	MaturityRating string    `json:"maturityRating" binding:"omitempty,oneof=NOT_MATURE MATURE"`
+	ShelfNumber    int64     `json:"shelfNumber" binding:"min=0"`
+	InLibrary      bool      `json:"inLibrary"`
+	BooksLeft      int64     `json:"booksLeft" binding:"min=0"`
+	Price          *float64  `json:"price" binding:"omitempty,min=0"`
+	Authors        []string  `json:"authors"`
+	Genres         []string  `json:"genres"`
+}
+
+// BookPatchWritableFields are the book fields a librarian can change with a merge patch
+var BookPatchWritableFields = []string{
+	"title", "subtitle", "author", "authors", "genre", "genres", "publishedDate", "desc", "previewLink",
+	"coverImage", "publisher", "pageCount", "language", "maturityRating", "shelfNumber", "inLibrary", "booksLeft",
+	"price",
 }
 
 // GetAllBooksRequest
@@ -127,3 +197,23 @@ type GetAllCheckoutData struct {
 type SimilarBooksRequest struct {
 	ISBN string `json:"isbn" uri:"isbn" binding:"required"`
 }
+
+// SimilarBook is a book similar to another one with the reasons it is similar
+type SimilarBook struct {
+	Book
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Define weights and reasons of the book similarity components
+const (
+	SimilarityAuthorWeight      = 0.35
+	SimilarityGenreWeight       = 0.25
+	SimilarityDescriptionWeight = 0.2
+	SimilarityCoBorrowWeight    = 0.2
+
+	SimilarityReasonAuthor      = "same author"
+	SimilarityReasonGenre       = "same genre"
+	SimilarityReasonDescription = "similar description"
+	SimilarityReasonCoBorrow    = "patrons who borrowed this also borrowed"
+)
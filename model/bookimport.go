@@ -0,0 +1,92 @@
+package model
+
+import "time"
+
+// BookImportFormat
+type BookImportFormat string
+
+const (
+	// BookImportFormatCSV is a spreadsheet with a header row, its columns are mapped to book fields
+	BookImportFormatCSV BookImportFormat = "csv"
+	// BookImportFormatMARC is MARC 21 in the ISO 2709 transmission format
+	BookImportFormatMARC BookImportFormat = "marc"
+	// BookImportFormatMARCXML is MARC 21 as MARCXML
+	BookImportFormatMARCXML BookImportFormat = "marcxml"
+)
+
+// BookImportStatus
+type BookImportStatus string
+
+const (
+	// BookImportStatusQueued
+	BookImportStatusQueued BookImportStatus = "queued"
+	// BookImportStatusRunning
+	BookImportStatusRunning BookImportStatus = "running"
+	// BookImportStatusSucceeded
+	BookImportStatusSucceeded BookImportStatus = "succeeded"
+	// BookImportStatusFailed
+	BookImportStatusFailed BookImportStatus = "failed"
+)
+
+// BookImportOutcome is what an import did, or would do in a dry run, with a single row
+type BookImportOutcome string
+
+const (
+	// BookImportOutcomeCreated is a row whose ISBN was not in the catalogue yet
+	BookImportOutcomeCreated BookImportOutcome = "created"
+	// BookImportOutcomeUpdated is a row which updated the book with its ISBN
+	BookImportOutcomeUpdated BookImportOutcome = "updated"
+	// BookImportOutcomeError is a row which was invalid or could not be stored
+	BookImportOutcomeError BookImportOutcome = "error"
+)
+
+// BookImportRowsChunk is the number of rows an import reports at a time
+const BookImportRowsChunk = 200
+
+// CreateBookImportRequest is the form of an uploaded import file, the columns of a CSV file are
+// mapped with mapping[<book field>]=<column header>
+type CreateBookImportRequest struct {
+	Format BookImportFormat `json:"format" form:"format" binding:"omitempty,oneof=csv marc marcxml"`
+	DryRun bool             `json:"dryRun" form:"dryRun"`
+}
+
+// BookImport is an import of a catalogue file with its progress and counts
+type BookImport struct {
+	ID          string           `json:"ID"`
+	Format      BookImportFormat `json:"format"`
+	Filename    string           `json:"filename"`
+	DryRun      bool             `json:"dryRun"`
+	Status      BookImportStatus `json:"status"`
+	TotalRows   int64            `json:"totalRows"`
+	Processed   int64            `json:"processed"`
+	Created     int64            `json:"created"`
+	Updated     int64            `json:"updated"`
+	Failed      int64            `json:"failed"`
+	Error       string           `json:"error,omitempty"`
+	LibrarianID *string          `json:"librarianID"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	StartedAt   *time.Time       `json:"startedAt"`
+	FinishedAt  *time.Time       `json:"finishedAt"`
+}
+
+// BookImportRow is the report of a single row of an import
+type BookImportRow struct {
+	RowNumber int64             `json:"rowNumber"`
+	ISBN      string            `json:"ISBN"`
+	Title     string            `json:"title"`
+	Outcome   BookImportOutcome `json:"outcome"`
+	Message   string            `json:"message,omitempty"`
+}
+
+// GetBookImportsRequest
+type GetBookImportsRequest struct {
+	Page  uint32 `json:"page" form:"page" binding:"required,min=1"`
+	Limit uint32 `json:"limit" form:"limit" binding:"required,min=5"`
+}
+
+// GetBookImportRowsRequest
+type GetBookImportRowsRequest struct {
+	Page    uint32            `json:"page" form:"page" binding:"required,min=1"`
+	Limit   uint32            `json:"limit" form:"limit" binding:"required,min=5"`
+	Outcome BookImportOutcome `json:"outcome" form:"outcome" binding:"omitempty,oneof=created updated error"`
+}
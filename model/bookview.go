@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Define how book views are recorded
+const (
+	// BookViewDedupeWindow is how long further views of a book by the same user are not counted
+	BookViewDedupeWindow = 30 * time.Minute
+	// BookViewsListSize is how many of the most recent viewers a book keeps in "viewsList"
+	BookViewsListSize = 50
+)
+
+// RecordBookViewRequest
+type RecordBookViewRequest struct {
+	BookID string `json:"bookid" uri:"bookid" binding:"required,uuid"`
+}
+
+// RecordBookViewResponse
+type RecordBookViewResponse struct {
+	// Recorded is false when the user viewed the book within the dedupe window
+	Recorded bool  `json:"recorded"`
+	Views    int64 `json:"views"`
+}
+
+// GetBookViewsOverTimeRequest
+type GetBookViewsOverTimeRequest struct {
+	From        time.Time `json:"from" form:"from" binding:"required" time_format:"2006-01-02"`
+	To          time.Time `json:"to" form:"to" binding:"required,gtefield=From" time_format:"2006-01-02"`
+	Granularity string    `json:"granularity" form:"granularity" binding:"omitempty,oneof=day week month"`
+	// BookID limits the views to a single book
+	BookID string `json:"bookID" form:"bookID" binding:"omitempty,uuid"`
+}
+
+// BookViewsPeriod is the views of a day, week or month
+type BookViewsPeriod struct {
+	Period        time.Time `json:"period"`
+	Views         int64     `json:"views"`
+	UniqueViewers int64     `json:"uniqueViewers"`
+}
+
+// GetTopViewedBooksRequest
+type GetTopViewedBooksRequest struct {
+	// Days is how many days back views are counted, the last week when not given
+	Days  uint32 `json:"days" form:"days" binding:"omitempty,min=1,max=365"`
+	Limit uint32 `json:"limit" form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// TopViewedBook is a book with its views over the requested days
+type TopViewedBook struct {
+	Book
+	RecentViews   int64 `json:"recentViews"`
+	UniqueViewers int64 `json:"uniqueViewers"`
+}
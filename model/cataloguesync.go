@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// SyncRunStatus
+type SyncRunStatus string
+
+const (
+	// SyncRunStatusRunning
+	SyncRunStatusRunning SyncRunStatus = "running"
+	// SyncRunStatusSucceeded
+	SyncRunStatusSucceeded SyncRunStatus = "succeeded"
+	// SyncRunStatusFailed
+	SyncRunStatusFailed SyncRunStatus = "failed"
+)
+
+// SyncOutcome is what a catalogue sync did with a single book
+type SyncOutcome string
+
+const (
+	// SyncOutcomeAdded is a book which was not in the catalogue yet
+	SyncOutcomeAdded SyncOutcome = "added"
+	// SyncOutcomeUpdated is an existing book whose stale metadata was refreshed
+	SyncOutcomeUpdated SyncOutcome = "updated"
+	// SyncOutcomeSkipped is an existing book whose metadata is still fresh
+	SyncOutcomeSkipped SyncOutcome = "skipped"
+)
+
+// CatalogueSyncRun
+type CatalogueSyncRun struct {
+	ID      string        `json:"ID"`
+	Trigger string        `json:"trigger"`
+	Status  SyncRunStatus `json:"status"`
+	Added   int64         `json:"added"`
+	Updated int64         `json:"updated"`
+	Skipped int64         `json:"skipped"`
+	// NotFound is the stale books no provider knows, they are marked as checked until they are stale again
+	NotFound   int64      `json:"notFound"`
+	Failed     int64      `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+// GetCatalogueSyncRunsRequest
+type GetCatalogueSyncRunsRequest struct {
+	Page  uint32 `json:"page" form:"page" binding:"required,min=1"`
+	Limit uint32 `json:"limit" form:"limit" binding:"required,min=5"`
+}
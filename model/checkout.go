@@ -14,8 +14,29 @@ type CheckoutTicket struct {
 	ReservedOn   time.Time `json:"reservedOn"`
 	CheckedOutOn time.Time `json:"checkedOutOn"`
 	ReturnedDate time.Time `json:"returnedDate"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	// LostAt and DamagedAt are set on loans which ended lost or damaged, FoundAt on lost copies found again
+	LostAt      *time.Time `json:"lostAt,omitempty"`
+	DamagedAt   *time.Time `json:"damagedAt,omitempty"`
+	FoundAt     *time.Time `json:"foundAt,omitempty"`
+	IncidentFee float64    `json:"incidentFee"`
+	Version     int64      `json:"version"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// CheckoutTicketPatch is a checkout ticket after a merge patch was applied, only the writable fields are stored
+type CheckoutTicketPatch struct {
+	IsCheckedOut bool       `json:"isCheckedOut"`
+	IsReturned   bool       `json:"isReturned"`
+	NumberOfDays int64      `json:"numberOfDays" binding:"min=0"`
+	FineAmount   float64    `json:"fineAmount" binding:"min=0"`
+	CheckedOutOn *time.Time `json:"checkedOutOn"`
+	ReturnedDate *time.Time `json:"returnedDate"`
+}
+
+// CheckoutTicketPatchWritableFields are the checkout ticket fields a librarian can change with a merge patch
+var CheckoutTicketPatchWritableFields = []string{
+	"isCheckedOut", "isReturned", "numberOfDays", "fineAmount", "checkedOutOn", "returnedDate",
 }
 
 // CheckoutTicketResponse
@@ -38,8 +59,10 @@ type CheckoutTicketResponse struct {
 
 // CreateCheckoutRequest
 type CreateCheckoutRequest struct {
-	BookID       string `json:"bookID" binding:"required"`
-	UserID       string `json:"userID" binding:"required"`
+	BookID string `json:"bookID" binding:"required"`
+	// the borrower is given by their userID or by the number of their library card
+	UserID       string `json:"userID" binding:"required_without=CardNumber"`
+	CardNumber   string `json:"cardNumber" binding:"omitempty,librarycard"`
 	NumberOfDays int64  `json:"numberOfDays"`
 }
 
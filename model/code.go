@@ -0,0 +1,94 @@
+package model
+
+// CodeSymbology
+type CodeSymbology string
+
+const (
+	// CodeSymbologyCode128 is a linear barcode read by the desk scanners
+	CodeSymbologyCode128 CodeSymbology = "code128"
+	// CodeSymbologyQR is a QR code read by phone cameras as well
+	CodeSymbologyQR CodeSymbology = "qr"
+)
+
+// CodeFormat
+type CodeFormat string
+
+const (
+	// CodeFormatPNG is a PNG image
+	CodeFormatPNG CodeFormat = "png"
+	// CodeFormatSVG is an SVG image which prints sharp at any size
+	CodeFormatSVG CodeFormat = "svg"
+)
+
+// LabelsPerSheet is the number of labels on a printed sheet, three columns of eight rows
+const LabelsPerSheet = 24
+
+// GetCodeRequest selects how a code is rendered, scale is the width of a module in pixels
+type GetCodeRequest struct {
+	Symbology CodeSymbology `json:"symbology" form:"symbology" binding:"omitempty,oneof=code128 qr"`
+	Format    CodeFormat    `json:"format" form:"format" binding:"omitempty,oneof=png svg"`
+	Scale     int           `json:"scale" form:"scale" binding:"omitempty,min=1,max=20"`
+}
+
+// GetBookCopyCodeRequest
+type GetBookCopyCodeRequest struct {
+	ISBN       string `json:"isbn" uri:"isbn" binding:"required"`
+	CopyNumber int64  `json:"copyNumber" uri:"copynumber" binding:"required,min=1"`
+}
+
+// RegisterBookCopiesRequest takes the number of copies to register, all copies of the book by default
+type RegisterBookCopiesRequest struct {
+	Copies int64 `json:"copies" binding:"omitempty,min=1,max=500"`
+}
+
+// GetBookCopiesRequest takes the number of registered copies to list, all of them by default
+type GetBookCopiesRequest struct {
+	Copies int64 `json:"copies" form:"copies" binding:"omitempty,min=1,max=500"`
+}
+
+// GetLabelSheetRequest takes the books whose registered copies are labelled, copies limits the labels per book
+type GetLabelSheetRequest struct {
+	ISBNs  []string `json:"isbn" form:"isbn" binding:"required,min=1,max=100"`
+	Copies int64    `json:"copies" form:"copies" binding:"omitempty,min=1,max=500"`
+}
+
+// BookCopy is a copy of a book with the barcode on its label
+type BookCopy struct {
+	Barcode     string     `json:"barcode"`
+	BookID      string     `json:"bookID"`
+	CopyNumber  int64      `json:"copyNumber"`
+	ISBN        string     `json:"ISBN"`
+	Title       string     `json:"title"`
+	Author      string     `json:"author"`
+	ShelfNumber int64      `json:"shelfNumber"`
+	Status      CopyStatus `json:"status"`
+}
+
+// ScanType is the kind of entity a scanned code stands for
+type ScanType string
+
+const (
+	// ScanTypeUser is a library card
+	ScanTypeUser ScanType = "user"
+	// ScanTypeBookCopy is the label of a book copy
+	ScanTypeBookCopy ScanType = "bookCopy"
+	// ScanTypeBook is an ISBN or the ID of a book
+	ScanTypeBook ScanType = "book"
+	// ScanTypeCheckoutTicket is the code of a checkout ticket
+	ScanTypeCheckoutTicket ScanType = "checkoutTicket"
+)
+
+// ScanCodeRequest
+type ScanCodeRequest struct {
+	Code string `json:"code" form:"code" binding:"required"`
+}
+
+// ScanResult is the entity a scanned code resolved to, only the field of its type is set
+type ScanResult struct {
+	Type           ScanType        `json:"type"`
+	Code           string          `json:"code"`
+	User           *User           `json:"user,omitempty"`
+	BookCopy       *BookCopy       `json:"bookCopy,omitempty"`
+	Book           *Book           `json:"book,omitempty"`
+	CheckoutTicket *CheckoutTicket `json:"checkoutTicket,omitempty"`
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"unicode"
 
+	"integrated-library-service/librarycard"
+
 	"github.com/gin-gonic/gin/binding"
 	validator "github.com/go-playground/validator/v10"
 )
@@ -17,6 +19,7 @@ func init() {
 	var ok bool
 	if Validator, ok = binding.Validator.Engine().(*validator.Validate); ok {
 		_ = Validator.RegisterValidation("validatepassword", validatePassword, false)
+		_ = Validator.RegisterValidation("librarycard", validateLibraryCard, false)
 		Validator.RegisterTagNameFunc(func(fld reflect.StructField) string {
 			name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
 			if name == "-" {
@@ -38,6 +41,16 @@ var validatePassword validator.Func = func(fl validator.FieldLevel) bool {
 	return false
 }
 
+// validateLibraryCard accepts card numbers with a valid check digit, printed spaces and hyphens included
+var validateLibraryCard validator.Func = func(fl validator.FieldLevel) bool {
+	value, ok := fl.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+	_, err := librarycard.Parse(value)
+	return err == nil
+}
+
 // helper functions
 func CheckLowerCase(value string) bool {
 	for _, char := range value {
@@ -2,14 +2,84 @@ package model
 
 import "time"
 
+const (
+	// DashboardGraphPeriods is the number of periods the line graph covers without dates
+	DashboardGraphPeriods = 7
+	// DashboardMaxPeriods is the most periods a line graph can have
+	DashboardMaxPeriods = 366
+	// DashboardMostViewedBooks is the number of most viewed books on the data board
+	DashboardMostViewedBooks = 5
+	// RollupLookbackDays is the number of days up to today the rollup job recomputes by default
+	RollupLookbackDays = 2
+)
+
+// DashboardRequest is the days from and to of the dashboard, both included, and the granularity of
+// its line graph. Without dates the line graph covers the last seven periods and the data board the
+// current month.
+type DashboardRequest struct {
+	From        *time.Time `json:"from" form:"from" time_format:"2006-01-02" binding:"omitempty"`
+	To          *time.Time `json:"to" form:"to" time_format:"2006-01-02" binding:"omitempty"`
+	Granularity string     `json:"granularity" form:"granularity" binding:"omitempty,oneof=day week month year"`
+}
+
 // DashboardLineGraphData
 type DashboardLineGraphData struct {
-	// month
+	// Period is the start of the day, week, month or year
+	Period time.Time `json:"period" binding:"required"`
+	// Month is the start of the period, kept for clients of the monthly graph
 	Month time.Time `json:"month" binding:"required"`
-	// NoOfActiveusers is the number of Active users of the application with membership in this month
+	// NoOfActiveusers is the number of users who reserved, borrowed or returned a book in this period
 	NoOfActiveusers int `json:"noOfActiveUsers" binding:"required"`
-	// NoOfCheckouts is the number of Checkouts in this month
+	// NoOfCheckouts is the number of books checked out in this period
 	NoOfCheckouts int `json:"noOfCheckouts" binding:"required"`
+	// NoOfReturns is the number of books returned in this period
+	NoOfReturns int `json:"noOfReturns"`
+	// NoOfNewUsers is the number of users registered in this period
+	NoOfNewUsers int `json:"noOfNewUsers"`
+	// NoOfViews is the number of book page views in this period
+	NoOfViews int `json:"noOfViews"`
+}
+
+// DashboardFreshness tells how current the daily rollups behind a dashboard are. RefreshedAt is the
+// oldest refresh of the days read, MissingDays the days up to today which were never rolled up.
+type DashboardFreshness struct {
+	RefreshedAt *time.Time `json:"refreshedAt"`
+	MissingDays int        `json:"missingDays"`
+}
+
+// DashboardLineGraph is the line graph of the requested days with the same number of periods
+// before them, and the totals of both compared
+type DashboardLineGraph struct {
+	Granularity string                   `json:"granularity"`
+	GraphData   []DashboardLineGraphData `json:"graphData"`
+	// PreviousGraphData is the periods right before the first period of the graph
+	PreviousGraphData []DashboardLineGraphData `json:"previousGraphData"`
+	Comparison        DashboardComparison      `json:"comparison"`
+	Freshness         DashboardFreshness       `json:"freshness"`
+}
+
+// DashboardPeriodCounts is the activity of the library in the days from and to, both included
+type DashboardPeriodCounts struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// ActiveUsers is the number of users who reserved, borrowed or returned a book
+	ActiveUsers int64 `json:"activeUsers"`
+	NewUsers    int64 `json:"newUsers"`
+	NewBooks    int64 `json:"newBooks"`
+	// CheckoutTickets is the number of reservations and checkouts opened
+	CheckoutTickets int64   `json:"checkoutTickets"`
+	Checkouts       int64   `json:"checkouts"`
+	Returns         int64   `json:"returns"`
+	FineAmount      float64 `json:"fineAmount"`
+	Views           int64   `json:"views"`
+}
+
+// DashboardComparison compares the activity of a period with the period before it. Change is the
+// change of every count in percent, counts which were zero before have none.
+type DashboardComparison struct {
+	Current  DashboardPeriodCounts `json:"current"`
+	Previous DashboardPeriodCounts `json:"previous"`
+	Change   map[string]*float64   `json:"change"`
 }
 
 // DashboardDataBoard
@@ -20,15 +90,29 @@ type DashboardDataBoard struct {
 	CheckoutsCount int `json:"checkoutsCount" binding:"required"`
 	// Revenue is collection of Total fineAmount and for each user with isPaymentDone * 30 in rupees
 	RevenueAmountTotal int `json:"revenueAmount" binding:"required"`
-	// monthly data
-	// MonthlyNewBooksAddedCount nothing but book with createdAt within this month
+	// period data, the current month without dates
+	// MonthlyNewBooksAddedCount nothing but book with createdAt within the period
 	MonthlyNewBooksAddedCount int `json:"monthlyNewBooksAddedCount" binding:"required"`
-	// MonthlyNewRegisteredUserCount nothing but user with createdAt within this month
+	// MonthlyNewRegisteredUserCount nothing but user with createdAt within the period
 	MonthlyNewRegisteredUserCount int `json:"monthlyNewRegisteredUserCount" binding:"required"`
-	//MonthlyNewCheckoutTicketsCount nothing but checkout_tickets with createdAt within this month
+	//MonthlyNewCheckoutTicketsCount nothing but checkout_tickets with createdAt within the period
 	MonthlyNewCheckoutTicketsCount int `json:"monthlyNewCheckoutTicketsCount" binding:"required"`
-	// MonthlyFineAmountTotal nothing but sum of fineAmount which is in the checkout_tickets with createdAt within this month
+	// MonthlyFineAmountTotal nothing but sum of fineAmount which is in the checkout_tickets with createdAt within the period
 	MonthlyFineAmountTotal int `json:"monthlyFineAmountTotal" binding:"required"`
+	// Comparison is the activity of the period and of as many days before it
+	Comparison DashboardComparison `json:"comparison"`
+	// MostViewedBooks is the books with the most views in the period
+	MostViewedBooks []DashboardBookViews `json:"mostViewedBooks"`
+	Freshness       DashboardFreshness   `json:"freshness"`
+}
+
+// DashboardBookViews is the views of a book in the period of the data board
+type DashboardBookViews struct {
+	BookID        string `json:"bookID"`
+	ISBN          string `json:"ISBN"`
+	Title         string `json:"title"`
+	Views         int64  `json:"views"`
+	UniqueViewers int64  `json:"uniqueViewers"`
 }
 
 // HighDemandBooks is books sorted based on wishListCount and Limit 3
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // GetBooksByApproximateDemandRequest
 type GetBooksByApproximateDemandRequest struct {
 	Page  uint32 `json:"page" form:"page" binding:"required,min=1"`
@@ -12,10 +14,60 @@ type GetRecommendedBooksForUserRequest struct {
 	Limit uint32 `json:"limit" form:"limit" binding:"required,min=5"`
 }
 
-// Define point weights for different factors
+// RecommendationSeed is how the patron came across the book a recommendation is because of
+type RecommendationSeed string
+
 const (
-	RatingWeight   = 5
-	ReviewWeight   = 1
-	ViewWeight     = 1
-	WishlistWeight = 1
+	// RecommendationSeedRead is a returned loan
+	RecommendationSeedRead RecommendationSeed = "read"
+	// RecommendationSeedBorrowed is a book the patron has checked out
+	RecommendationSeedBorrowed RecommendationSeed = "borrowed"
+	// RecommendationSeedReserved is a reservation which was never checked out
+	RecommendationSeedReserved RecommendationSeed = "reserved"
+	// RecommendationSeedReviewed is a review
+	RecommendationSeedReviewed RecommendationSeed = "reviewed"
+	// RecommendationSeedWishlisted is a wishlist entry
+	RecommendationSeedWishlisted RecommendationSeed = "wishlisted"
 )
+
+// RecommendedBook is a recommended book with the book of the patron it is recommended because of,
+// BecauseOf tells whether the patron read, borrowed, reserved, reviewed or wishlisted that book
+type RecommendedBook struct {
+	Book
+	Score               float64            `json:"score"`
+	Explanation         string             `json:"explanation"`
+	BecauseOf           RecommendationSeed `json:"becauseOf,omitempty"`
+	BecauseYouReadISBN  string             `json:"becauseYouReadISBN,omitempty"`
+	BecauseYouReadTitle string             `json:"becauseYouReadTitle,omitempty"`
+}
+
+// Define weights of the recommendation signals
+const (
+	// RecommendationCollaborativeWeight weighs books read by patrons who read the same books
+	RecommendationCollaborativeWeight = 1.0
+	// RecommendationContentWeight weighs books sharing authors or genres
+	RecommendationContentWeight = 0.5
+	// RecommendationAuthorMatch is the content score of every shared author
+	RecommendationAuthorMatch = 1.0
+	// RecommendationGenreMatch is the content score of every shared genre
+	RecommendationGenreMatch = 0.5
+	// RecommendationHalfLife is the age at which a loan, reservation, review or wishlist entry of a patron
+	// counts half its weight
+	RecommendationHalfLife = 180 * 24 * time.Hour
+)
+
+// DemandWeights weighs the signals the approximate demand of a book is computed from
+type DemandWeights struct {
+	// Checkout is the weight of a checkout made today
+	Checkout float64
+	// Hold is the weight of a reservation placed today which is not checked out yet
+	Hold float64
+	// Wishlist is the weight of a wishlist entry added today
+	Wishlist float64
+	// View is the weight of a view made today
+	View float64
+	// HalfLife is the age at which a checkout, hold, wishlist entry or view counts half its weight
+	HalfLife time.Duration
+	// Window is how far back checkouts and views are counted
+	Window time.Duration
+}
@@ -0,0 +1,124 @@
+package model
+
+import "time"
+
+// ExportFormat
+type ExportFormat string
+
+const (
+	// ExportFormatCSV is a CSV file with a header row
+	ExportFormatCSV ExportFormat = "csv"
+	// ExportFormatJSONL is a JSON object per line
+	ExportFormatJSONL ExportFormat = "jsonl"
+	// ExportFormatMARCXML is a MARCXML collection, only for the catalogue
+	ExportFormatMARCXML ExportFormat = "marcxml"
+)
+
+// ExportBooksRequest takes the sorting, search and filters of a book search without its paging
+type ExportBooksRequest struct {
+	Format         ExportFormat `json:"format" form:"format" binding:"required,oneof=csv jsonl marcxml"`
+	SortBy         string       `json:"sortBy" form:"sortBy"`
+	OrderBy        string       `json:"orderBy" form:"orderBy"`
+	SearchBy       string       `json:"searchBy" form:"searchBy" binding:"omitempty,ne=recommendation"`
+	SearchText     string       `json:"searchText" form:"searchText"`
+	Language       string       `json:"language" form:"language"`
+	Publisher      string       `json:"publisher" form:"publisher"`
+	MaturityRating string       `json:"maturityRating" form:"maturityRating" binding:"omitempty,oneof=NOT_MATURE MATURE"`
+	MinPageCount   uint32       `json:"minPageCount" form:"minPageCount"`
+	MaxPageCount   uint32       `json:"maxPageCount" form:"maxPageCount" binding:"omitempty,gtefield=MinPageCount"`
+}
+
+// SearchRequest returns the book search the export runs
+func (r *ExportBooksRequest) SearchRequest() *SearchRequest {
+	return &SearchRequest{
+		SortBy:         r.SortBy,
+		OrderBy:        r.OrderBy,
+		SearchBy:       r.SearchBy,
+		SearchText:     r.SearchText,
+		Type:           SearchRequestTypeBook,
+		Language:       r.Language,
+		Publisher:      r.Publisher,
+		MaturityRating: r.MaturityRating,
+		MinPageCount:   r.MinPageCount,
+		MaxPageCount:   r.MaxPageCount,
+	}
+}
+
+// ExportUsersRequest takes the sorting and search of a user search without its paging
+type ExportUsersRequest struct {
+	Format     ExportFormat `json:"format" form:"format" binding:"required,oneof=csv jsonl"`
+	SortBy     string       `json:"sortBy" form:"sortBy"`
+	OrderBy    string       `json:"orderBy" form:"orderBy"`
+	SearchBy   string       `json:"searchBy" form:"searchBy"`
+	SearchText string       `json:"searchText" form:"searchText"`
+}
+
+// SearchRequest returns the user search the export runs
+func (r *ExportUsersRequest) SearchRequest() *SearchRequest {
+	return &SearchRequest{
+		SortBy:     r.SortBy,
+		OrderBy:    r.OrderBy,
+		SearchBy:   r.SearchBy,
+		SearchText: r.SearchText,
+		Type:       SearchRequestTypeUser,
+	}
+}
+
+// ExportCheckoutsRequest takes the sorting of the checkout listing, the loans can be narrowed to a
+// user, a book and a period of their reservation
+type ExportCheckoutsRequest struct {
+	Format     ExportFormat `json:"format" form:"format" binding:"required,oneof=csv jsonl"`
+	SortBy     string       `json:"sortBy" form:"sortBy"`
+	OrderBy    string       `json:"orderBy" form:"orderBy"`
+	UserID     string       `json:"userID" form:"userID" binding:"omitempty,uuid"`
+	ISBN       string       `json:"isbn" form:"isbn"`
+	From       *time.Time   `json:"from" form:"from" time_format:"2006-01-02" binding:"omitempty"`
+	To         *time.Time   `json:"to" form:"to" time_format:"2006-01-02" binding:"omitempty"`
+	IsReturned *bool        `json:"isReturned" form:"isReturned" binding:"omitempty"`
+}
+
+// BookExport is a catalogue entry with its copies and their availability
+type BookExport struct {
+	Book
+	OnLoan      int64 `json:"onLoan"`
+	Reserved    int64 `json:"reserved"`
+	TotalCopies int64 `json:"totalCopies"`
+	Available   bool  `json:"available"`
+}
+
+// UserExport is a user without credentials with the counts of their loans
+type UserExport struct {
+	UserID               string     `json:"userID"`
+	Name                 string     `json:"name"`
+	Email                string     `json:"email"`
+	Role                 RoleType   `json:"role"`
+	DateOfBirth          *time.Time `json:"dateOfBirth"`
+	PhoneNumber          *string    `json:"phoneNumber"`
+	Address              *string    `json:"address"`
+	Country              *string    `json:"country"`
+	JoinedDate           time.Time  `json:"joinedDate"`
+	FineAmount           float64    `json:"fineAmount"`
+	IsPaymentDone        bool       `json:"isPaymentDone"`
+	ReservedBooksCount   int64      `json:"reservedBooksCount"`
+	CheckedOutBooksCount int64      `json:"checkedOutBooksCount"`
+	CompletedBooksCount  int64      `json:"completedBooksCount"`
+	CreatedAt            time.Time  `json:"createdAt"`
+}
+
+// CheckoutExport is a loan with its user and book
+type CheckoutExport struct {
+	ID           string     `json:"ID"`
+	UserID       string     `json:"userID"`
+	UserName     string     `json:"userName"`
+	UserEmail    string     `json:"userEmail"`
+	BookID       string     `json:"bookID"`
+	ISBN         string     `json:"ISBN"`
+	Title        string     `json:"title"`
+	IsCheckedOut bool       `json:"isCheckedOut"`
+	IsReturned   bool       `json:"isReturned"`
+	NumberOfDays int64      `json:"numberOfDays"`
+	FineAmount   float64    `json:"fineAmount"`
+	ReservedOn   *time.Time `json:"reservedOn"`
+	CheckedOutOn *time.Time `json:"checkedOutOn"`
+	ReturnedDate *time.Time `json:"returnedDate"`
+}
@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+// CopyStatus is whether a copy can be lent
+type CopyStatus string
+
+const (
+	// CopyStatusAvailable is a copy on the shelf or on loan
+	CopyStatusAvailable CopyStatus = "available"
+	// CopyStatusLost is a copy declared lost by its borrower
+	CopyStatusLost CopyStatus = "lost"
+	// CopyStatusDamaged is a copy returned damaged and kept off the shelf
+	CopyStatusDamaged CopyStatus = "damaged"
+	// CopyStatusWithdrawn is a copy taken out of circulation by a librarian
+	CopyStatusWithdrawn CopyStatus = "withdrawn"
+)
+
+const (
+	// AuditActionCheckoutLost is a librarian declaring a loan lost
+	AuditActionCheckoutLost AuditAction = "checkout.lost"
+	// AuditActionCheckoutDamaged is a librarian taking back a copy damaged
+	AuditActionCheckoutDamaged AuditAction = "checkout.damaged"
+	// AuditActionCheckoutFound is a librarian taking back a copy which was declared lost
+	AuditActionCheckoutFound AuditAction = "checkout.found"
+	// AuditActionCopyRestocked is a librarian putting a repaired copy back on the shelf
+	AuditActionCopyRestocked AuditAction = "copy.restocked"
+)
+
+// IncidentFees are the fees charged for lost and damaged copies of books without a price
+type IncidentFees struct {
+	// Replacement is charged for a lost copy of a book without a price
+	Replacement float64
+	// Repair is charged for a damaged copy of a book without a price
+	Repair float64
+	// RepairRate is the part of the price charged for a damaged copy
+	RepairRate float64
+}
+
+// DeclareLostRequest declares a loan lost, without a fee the price of the book or the default
+// replacement fee is charged
+type DeclareLostRequest struct {
+	Fee  *float64 `json:"fee" binding:"omitempty,min=0"`
+	Note string   `json:"note" binding:"max=500"`
+}
+
+// DeclareDamagedRequest takes back a copy damaged, without a fee a part of the price of the book or
+// the default repair fee is charged. A copy which can still be lent is put back on the shelf with
+// restock.
+type DeclareDamagedRequest struct {
+	Fee     *float64 `json:"fee" binding:"omitempty,min=0"`
+	Restock bool     `json:"restock"`
+	Note    string   `json:"note" binding:"max=500"`
+}
+
+// MarkFoundRequest takes back a copy which was declared lost
+type MarkFoundRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// RestockCopyRequest puts a damaged copy back on the shelf once it was repaired
+type RestockCopyRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// CheckoutIncident is a loan declared lost or damaged or found again. Fee is what was charged or
+// refunded, the part of a refund which was already paid is RefundDue and has to be paid back to
+// the patron.
+type CheckoutIncident struct {
+	CheckoutID string      `json:"checkoutID"`
+	BookID     string      `json:"bookID"`
+	UserID     string      `json:"userID"`
+	Barcode    string      `json:"barcode,omitempty"`
+	CopyStatus CopyStatus  `json:"copyStatus,omitempty"`
+	Action     AuditAction `json:"action"`
+	Fee        float64     `json:"fee"`
+	RefundDue  float64     `json:"refundDue"`
+	FineAmount float64     `json:"fineAmount"`
+	At         time.Time   `json:"at"`
+}
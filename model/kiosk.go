@@ -0,0 +1,146 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// KioskSessionTTL is how long a patron session at a kiosk stays open without activity
+	KioskSessionTTL = 5 * time.Minute
+	// KioskLoanDays is the loan period of books checked out at a kiosk
+	KioskLoanDays = 14
+	// KioskPinAttempts is the number of wrong PINs after which the PIN is locked until the patron sets a new one
+	KioskPinAttempts = 5
+)
+
+// AuditAction is what was done in an audit log entry
+type AuditAction string
+
+const (
+	// AuditActionKioskDeviceCreate is a librarian registering a kiosk
+	AuditActionKioskDeviceCreate AuditAction = "kiosk.device.create"
+	// AuditActionKioskDeviceRevoke is a librarian revoking the credentials of a kiosk
+	AuditActionKioskDeviceRevoke AuditAction = "kiosk.device.revoke"
+	// AuditActionKioskSessionStart is a patron signing in at a kiosk
+	AuditActionKioskSessionStart AuditAction = "kiosk.session.start"
+	// AuditActionKioskPinFailed is a wrong PIN entered at a kiosk
+	AuditActionKioskPinFailed AuditAction = "kiosk.session.pin_failed"
+	// AuditActionKioskSessionEnd is a patron signing out at a kiosk
+	AuditActionKioskSessionEnd AuditAction = "kiosk.session.end"
+	// AuditActionKioskCheckout is a copy checked out at a kiosk
+	AuditActionKioskCheckout AuditAction = "kiosk.checkout"
+	// AuditActionKioskReturn is a copy returned at a kiosk
+	AuditActionKioskReturn AuditAction = "kiosk.return"
+)
+
+// AuditLog is an entry of the audit trail, actions at a kiosk have the device and session set and
+// actions of staff the acting user
+type AuditLog struct {
+	ID         string          `json:"ID"`
+	Action     AuditAction     `json:"action"`
+	ActorID    *string         `json:"actorID"`
+	DeviceID   *string         `json:"deviceID"`
+	SessionID  *string         `json:"sessionID"`
+	UserID     *string         `json:"userID"`
+	EntityType string          `json:"entityType,omitempty"`
+	EntityID   string          `json:"entityID,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// GetAuditLogsRequest filters the audit trail by device, patron or action
+type GetAuditLogsRequest struct {
+	Page     uint32 `json:"page" form:"page" binding:"required,min=1"`
+	Limit    uint32 `json:"limit" form:"limit" binding:"required,min=5"`
+	DeviceID string `json:"deviceID" form:"deviceID" binding:"omitempty,uuid"`
+	UserID   string `json:"userID" form:"userID" binding:"omitempty,uuid"`
+	Action   string `json:"action" form:"action" binding:"omitempty,max=50"`
+}
+
+// KioskDevice is a self-service kiosk
+type KioskDevice struct {
+	ID         string     `json:"ID"`
+	Name       string     `json:"name"`
+	CreatedBy  *string    `json:"createdBy"`
+	LastSeenAt *time.Time `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateKioskDeviceRequest
+type CreateKioskDeviceRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// KioskDeviceCredentials is a new kiosk with its token, the token is only shown once
+type KioskDeviceCredentials struct {
+	Device KioskDevice `json:"device"`
+	Token  string      `json:"token"`
+}
+
+// SetPinRequest sets the PIN a patron signs in at kiosks with
+type SetPinRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	Pin             string `json:"pin" binding:"required,numeric,min=4,max=6"`
+}
+
+// StartKioskSessionRequest is a scanned library card with the PIN of its patron
+type StartKioskSessionRequest struct {
+	CardNumber string `json:"cardNumber" binding:"required,librarycard"`
+	Pin        string `json:"pin" binding:"required,numeric,min=4,max=6"`
+}
+
+// UserPin is the stored PIN of a patron
+type UserPin struct {
+	Hash string
+	// FailedAttempts counts the attempts since the PIN last matched, including the one being checked
+	FailedAttempts int64
+}
+
+// KioskSession is a patron signed in at a kiosk
+type KioskSession struct {
+	ID        string    `json:"ID"`
+	DeviceID  string    `json:"deviceID"`
+	UserID    string    `json:"userID"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// KioskSessionToken is a new kiosk session with the token the kiosk sends in the Session header
+type KioskSessionToken struct {
+	Session KioskSession `json:"session"`
+	Token   string       `json:"token"`
+}
+
+// KioskItemRequest is a copy scanned at a kiosk
+type KioskItemRequest struct {
+	Barcode string `json:"barcode" binding:"required"`
+}
+
+// KioskLoan is a loan checked out or returned at a kiosk
+type KioskLoan struct {
+	CheckoutID   string     `json:"checkoutID"`
+	Barcode      string     `json:"barcode"`
+	ISBN         string     `json:"ISBN"`
+	Title        string     `json:"title"`
+	Author       string     `json:"author"`
+	CheckedOutOn time.Time  `json:"checkedOutOn"`
+	DueDate      time.Time  `json:"dueDate"`
+	ReturnedOn   *time.Time `json:"returnedOn,omitempty"`
+}
+
+// KioskReceipt lists what a patron did in a kiosk session, lines is the receipt as text for
+// printers which only print text
+type KioskReceipt struct {
+	SessionID  string      `json:"sessionID"`
+	DeviceName string      `json:"deviceName"`
+	Name       string      `json:"name"`
+	CardNumber string      `json:"cardNumber"`
+	StartedAt  time.Time   `json:"startedAt"`
+	PrintedAt  time.Time   `json:"printedAt"`
+	CheckedOut []KioskLoan `json:"checkedOut"`
+	Returned   []KioskLoan `json:"returned"`
+	Lines      []string    `json:"lines"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// NotificationType
+type NotificationType string
+
+const (
+	// NotificationTypePurchaseRequest is a status change of the patron's purchase request
+	NotificationTypePurchaseRequest NotificationType = "purchaseRequest"
+	// NotificationTypeWishlistStock is a wishlisted book which can be borrowed again
+	NotificationTypeWishlistStock NotificationType = "wishlistStock"
+)
+
+// Notification is a message for a user
+type Notification struct {
+	ID          string           `json:"ID"`
+	UserID      string           `json:"userID"`
+	Type        NotificationType `json:"type"`
+	Message     string           `json:"message"`
+	ReferenceID *string          `json:"referenceID"`
+	ReadAt      *time.Time       `json:"readAt"`
+	CreatedAt   time.Time        `json:"createdAt"`
+}
+
+// GetNotificationsRequest
+type GetNotificationsRequest struct {
+	Page       uint32 `json:"page" form:"page" binding:"required,min=1"`
+	Limit      uint32 `json:"limit" form:"limit" binding:"required,min=5"`
+	UnreadOnly bool   `json:"unreadOnly" form:"unreadOnly"`
+}
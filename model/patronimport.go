@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// UserInviteTTL is how long the invite of an imported user to set a password is valid
+const UserInviteTTL = 14 * 24 * time.Hour
+
+// PatronImportOutcome is what an import did, or would do in a dry run, with a single row
+type PatronImportOutcome string
+
+const (
+	// PatronImportOutcomeCreated is a row whose email had no account yet
+	PatronImportOutcomeCreated PatronImportOutcome = "created"
+	// PatronImportOutcomeUpdated is a row which updated the account with its email
+	PatronImportOutcomeUpdated PatronImportOutcome = "updated"
+	// PatronImportOutcomeSkipped is a row whose email already has an account which is kept as it is
+	PatronImportOutcomeSkipped PatronImportOutcome = "skipped"
+	// PatronImportOutcomeError is a row which was invalid or could not be stored
+	PatronImportOutcomeError PatronImportOutcome = "error"
+)
+
+// ImportPatronsRequest is the form of an uploaded patron file, onExisting decides whether rows
+// whose email already has an account are skipped or update it
+type ImportPatronsRequest struct {
+	OnExisting string `json:"onExisting" form:"onExisting" binding:"omitempty,oneof=skip update"`
+	DryRun     bool   `json:"dryRun" form:"dryRun"`
+}
+
+// ImportPatron is a row of a patron file, empty optional fields leave an existing account unchanged
+type ImportPatron struct {
+	Email       string
+	Name        string
+	PhoneNumber *string
+	Address     *string
+	Country     *string
+	DateOfBirth *time.Time
+	CardNumber  string
+}
+
+// PatronImportRow is the report of a single row of a patron import
+type PatronImportRow struct {
+	RowNumber  int64               `json:"rowNumber"`
+	Email      string              `json:"email"`
+	Name       string              `json:"name"`
+	Outcome    PatronImportOutcome `json:"outcome"`
+	UserID     string              `json:"userID,omitempty"`
+	CardNumber string              `json:"cardNumber,omitempty"`
+	Message    string              `json:"message,omitempty"`
+}
+
+// UserInvite is the invite of an imported user, the token is only known until it is mailed
+type UserInvite struct {
+	UserID     string
+	Email      string
+	Name       string
+	CardNumber string
+	Token      string
+}
+
+// AcceptUserInviteRequest sets the first password of an imported user
+type AcceptUserInviteRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=20,validatepassword"`
+}
+
+// LibraryCard is the card number of a user
+type LibraryCard struct {
+	UserID     string `json:"userID"`
+	CardNumber string `json:"cardNumber"`
+}
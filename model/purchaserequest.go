@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// PurchaseRequestStatus
+type PurchaseRequestStatus string
+
+const (
+	// PurchaseRequestStatusSubmitted is a request a patron just filed
+	PurchaseRequestStatusSubmitted PurchaseRequestStatus = "submitted"
+	// PurchaseRequestStatusUnderReview is a request a librarian is looking into
+	PurchaseRequestStatusUnderReview PurchaseRequestStatus = "under_review"
+	// PurchaseRequestStatusApproved is a request the library agreed to buy
+	PurchaseRequestStatusApproved PurchaseRequestStatus = "approved"
+	// PurchaseRequestStatusOrdered is a request whose book is on order
+	PurchaseRequestStatusOrdered PurchaseRequestStatus = "ordered"
+	// PurchaseRequestStatusReceived is a request whose book arrived in the library
+	PurchaseRequestStatusReceived PurchaseRequestStatus = "received"
+	// PurchaseRequestStatusRejected is a request the library will not buy
+	PurchaseRequestStatusRejected PurchaseRequestStatus = "rejected"
+)
+
+// PurchaseRequestTransitions lists the statuses each status can move to, received and rejected are final
+var PurchaseRequestTransitions = map[PurchaseRequestStatus][]PurchaseRequestStatus{
+	PurchaseRequestStatusSubmitted:   {PurchaseRequestStatusUnderReview, PurchaseRequestStatusApproved, PurchaseRequestStatusRejected},
+	PurchaseRequestStatusUnderReview: {PurchaseRequestStatusApproved, PurchaseRequestStatusRejected},
+	PurchaseRequestStatusApproved:    {PurchaseRequestStatusOrdered, PurchaseRequestStatusRejected},
+	PurchaseRequestStatusOrdered:     {PurchaseRequestStatusReceived, PurchaseRequestStatusRejected},
+}
+
+// CanMoveTo reports whether a request in this status can move to next
+func (s PurchaseRequestStatus) CanMoveTo(next PurchaseRequestStatus) bool {
+	for _, allowed := range PurchaseRequestTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PurchaseRequest is a patron's request for the library to buy a book
+type PurchaseRequest struct {
+	ID        string                 `json:"ID"`
+	UserID    string                 `json:"userID"`
+	BookID    *string                `json:"bookID"`
+	ISBN      string                 `json:"ISBN"`
+	Title     string                 `json:"title"`
+	Note      string                 `json:"note"`
+	Status    PurchaseRequestStatus  `json:"status"`
+	HoldID    *string                `json:"holdID"`
+	CreatedAt time.Time              `json:"createdAt"`
+	UpdatedAt *time.Time             `json:"updatedAt"`
+	History   []PurchaseRequestEvent `json:"history,omitempty"`
+}
+
+// PurchaseRequestEvent is a status change of a purchase request with the librarian's comment
+type PurchaseRequestEvent struct {
+	Status    PurchaseRequestStatus `json:"status"`
+	Comment   string                `json:"comment"`
+	ChangedBy *string               `json:"changedBy"`
+	CreatedAt time.Time             `json:"createdAt"`
+}
+
+// CreatePurchaseRequestRequest files a request against a catalogue book or a bare ISBN
+type CreatePurchaseRequestRequest struct {
+	BookID string `json:"bookID" binding:"required_without=ISBN,omitempty,uuid"`
+	ISBN   string `json:"ISBN" binding:"required_without=BookID"`
+	Title  string `json:"title" binding:"omitempty,max=100"`
+	Note   string `json:"note" binding:"omitempty,max=1000"`
+}
+
+// GetPurchaseRequestsRequest
+type GetPurchaseRequestsRequest struct {
+	Page   uint32                `json:"page" form:"page" binding:"required,min=1"`
+	Limit  uint32                `json:"limit" form:"limit" binding:"required,min=5"`
+	Status PurchaseRequestStatus `json:"status" form:"status" binding:"omitempty,oneof=submitted under_review approved ordered received rejected"`
+	// UserID limits the list to one patron's requests, it is never bound from the query
+	UserID string `json:"-" form:"-"`
+}
+
+// UpdatePurchaseRequestStatusRequest moves a purchase request to its next status
+type UpdatePurchaseRequestStatusRequest struct {
+	Status  PurchaseRequestStatus `json:"status" binding:"required,oneof=submitted under_review approved ordered received rejected"`
+	Comment string                `json:"comment" binding:"omitempty,max=1000"`
+	// Copies is the number of copies received, one when not given
+	Copies int64 `json:"copies" binding:"omitempty,min=1"`
+}
+
+// PurchaseRequestHoldDays is the loan length of the hold placed for the requester of a received book
+const PurchaseRequestHoldDays = 14
@@ -0,0 +1,134 @@
+package model
+
+import "time"
+
+// ReportName is a librarian report
+type ReportName string
+
+const (
+	// ReportOverdue is the loans past their due date grouped by patron
+	ReportOverdue ReportName = "overdue"
+	// ReportDue is the loans due in a period, the coming week by default
+	ReportDue ReportName = "due"
+	// ReportHoldShelf is the reservations waiting to be picked up
+	ReportHoldShelf ReportName = "holds"
+	// ReportTopBooks is the most borrowed books of a period
+	ReportTopBooks ReportName = "top-books"
+	// ReportTopAuthors is the most borrowed authors of a period
+	ReportTopAuthors ReportName = "top-authors"
+	// ReportInactivePatrons is the patrons without loans or reservations in a period
+	ReportInactivePatrons ReportName = "inactive-patrons"
+)
+
+const (
+	// ReportDueDays is the number of days from today the due report covers by default
+	ReportDueDays = 7
+	// ReportTopDays is the number of days back the top books and authors are counted by default
+	ReportTopDays = 30
+	// ReportInactiveDays is the number of days without loans after which a patron is inactive by default
+	ReportInactiveDays = 365
+)
+
+// ReportURI names the report of a request
+type ReportURI struct {
+	Report ReportName `uri:"report" binding:"required,oneof=overdue due holds top-books top-authors inactive-patrons"`
+}
+
+// ReportRequest pages a report and narrows it to the days from and to, both included. Reports of
+// a period have a default period when no days are given.
+type ReportRequest struct {
+	Page  uint32     `json:"page" form:"page" binding:"required,min=1"`
+	Limit uint32     `json:"limit" form:"limit" binding:"required,min=5"`
+	From  *time.Time `json:"from" form:"from" time_format:"2006-01-02" binding:"omitempty"`
+	To    *time.Time `json:"to" form:"to" time_format:"2006-01-02" binding:"omitempty"`
+}
+
+// ExportReportRequest narrows an exported report to the days from and to, the export has every row
+type ExportReportRequest struct {
+	From *time.Time `json:"from" form:"from" time_format:"2006-01-02" binding:"omitempty"`
+	To   *time.Time `json:"to" form:"to" time_format:"2006-01-02" binding:"omitempty"`
+}
+
+// OverdueLoan is a loan past its due date, the fine is the one recorded on the ticket
+type OverdueLoan struct {
+	CheckoutID   string    `json:"checkoutID"`
+	ISBN         string    `json:"ISBN"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	Barcode      string    `json:"barcode,omitempty"`
+	CheckedOutOn time.Time `json:"checkedOutOn"`
+	DueDate      time.Time `json:"dueDate"`
+	DaysOverdue  int64     `json:"daysOverdue"`
+	FineAmount   float64   `json:"fineAmount"`
+}
+
+// OverduePatron is a patron with overdue loans, the most overdue first
+type OverduePatron struct {
+	UserID         string        `json:"userID"`
+	Name           string        `json:"name"`
+	Email          string        `json:"email"`
+	CardNumber     string        `json:"cardNumber,omitempty"`
+	FineAmount     float64       `json:"fineAmount"`
+	IsPaymentDone  bool          `json:"isPaymentDone"`
+	MaxDaysOverdue int64         `json:"maxDaysOverdue"`
+	Loans          []OverdueLoan `json:"loans"`
+}
+
+// DueLoan is a loan due in the period of the report
+type DueLoan struct {
+	CheckoutID   string    `json:"checkoutID"`
+	UserID       string    `json:"userID"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	ISBN         string    `json:"ISBN"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	Barcode      string    `json:"barcode,omitempty"`
+	CheckedOutOn time.Time `json:"checkedOutOn"`
+	DueDate      time.Time `json:"dueDate"`
+	DaysLeft     int64     `json:"daysLeft"`
+}
+
+// HoldShelfItem is a reservation waiting to be picked up, it is ready when a copy is on the shelf
+type HoldShelfItem struct {
+	CheckoutID  string    `json:"checkoutID"`
+	UserID      string    `json:"userID"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	ISBN        string    `json:"ISBN"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	ShelfNumber int64     `json:"shelfNumber"`
+	ReservedOn  time.Time `json:"reservedOn"`
+	DaysWaiting int64     `json:"daysWaiting"`
+	BooksLeft   int64     `json:"booksLeft"`
+	Ready       bool      `json:"ready"`
+}
+
+// TopBook is a book with its checkouts in the period of the report
+type TopBook struct {
+	ISBN      string `json:"ISBN"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Checkouts int64  `json:"checkouts"`
+	Borrowers int64  `json:"borrowers"`
+}
+
+// TopAuthor is an author with the checkouts of their books in the period of the report
+type TopAuthor struct {
+	Author    string `json:"author"`
+	Books     int64  `json:"books"`
+	Checkouts int64  `json:"checkouts"`
+	Borrowers int64  `json:"borrowers"`
+}
+
+// InactivePatron is a patron without loans or reservations in the period of the report
+type InactivePatron struct {
+	UserID         string     `json:"userID"`
+	Name           string     `json:"name"`
+	Email          string     `json:"email"`
+	CardNumber     string     `json:"cardNumber,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	LastCheckoutAt *time.Time `json:"lastCheckoutAt"`
+	Checkouts      int64      `json:"checkouts"`
+}
@@ -9,6 +9,12 @@ type SearchRequest struct {
 	SearchBy   string            `json:"searchBy" form:"searchBy" binding:"required"`
 	Type       SearchRequestType `json:"type" form:"type" binding:"required,oneof=user book checkout review"`
 	SearchText string            `json:"searchText" form:"searchText" binding:"omitempty"`
+	// book filters
+	Language       string `json:"language" form:"language" binding:"omitempty"`
+	Publisher      string `json:"publisher" form:"publisher" binding:"omitempty"`
+	MaturityRating string `json:"maturityRating" form:"maturityRating" binding:"omitempty,oneof=NOT_MATURE MATURE"`
+	MinPageCount   uint32 `json:"minPageCount" form:"minPageCount" binding:"omitempty"`
+	MaxPageCount   uint32 `json:"maxPageCount" form:"maxPageCount" binding:"omitempty,gtefield=MinPageCount"`
 }
 
 // SearchRequestType
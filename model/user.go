@@ -28,24 +28,100 @@ type User struct {
 	FineAmount      float64     `json:"fineAmount"`
 	Password        string      `json:"password"`
 	IsPaymentDone   bool        `json:"isPaymentDone"`
+	CardNumber      *string     `json:"cardNumber,omitempty"`
+	Version         int64       `json:"version,omitempty"`
 	CreatedAt       time.Time   `json:"createdAt"`
 	UpdatedAt       *time.Time  `json:"updatedAt"`
 	BookDetails     BookDetails `json:"bookDetails,omitempty" binding:"omitempty"`
 }
 
-// RegisterUserRequest
+// UpdateProfileRequest holds the profile fields a user can change on their own
+type UpdateProfileRequest struct {
+	ProfileImageUrl string     `json:"profileImageUrl"`
+	Name            string     `json:"name" binding:"required"`
+	DateOfBirth     *time.Time `json:"dateOfBirth" binding:"omitempty"`
+	PhoneNumber     *string    `json:"phoneNumber" binding:"omitempty"`
+	Address         *string    `json:"address"`
+	Country         *string    `json:"country"`
+}
+
+// AdminUpdateUserRequest holds the fields a librarian can change on any user
+type AdminUpdateUserRequest struct {
+	UpdateProfileRequest
+	Role          RoleType  `json:"role" binding:"required,oneof=librarian patrons"`
+	JoinedDate    time.Time `json:"joinedDate" binding:"required"`
+	FineAmount    float64   `json:"fineAmount" binding:"min=0"`
+	IsPaymentDone bool      `json:"isPaymentDone"`
+}
+
+// ChangeEmailRequest starts an email change, the new address is only used once it is verified
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// VerifyEmailChangeRequest confirms an email change with the token mailed to the new address
+type VerifyEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// EmailChange is a confirmed email change
+type EmailChange struct {
+	UserID   string `json:"userID"`
+	OldEmail string `json:"oldEmail"`
+	NewEmail string `json:"newEmail"`
+}
+
+// ChangePasswordRequest
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8,max=20,validatepassword"`
+}
+
+// EmailChangeTTL is how long an email change verification token is valid
+const EmailChangeTTL = 24 * time.Hour
+
+// UserPatch is a user after a merge patch was applied, only the writable fields are stored
+type UserPatch struct {
+	ProfileImageUrl string     `json:"profileImageUrl"`
+	Name            string     `json:"name" binding:"required"`
+	Role            RoleType   `json:"role" binding:"required,oneof=librarian patrons"`
+	DateOfBirth     *time.Time `json:"dateOfBirth" binding:"omitempty"`
+	PhoneNumber     *string    `json:"phoneNumber" binding:"omitempty"`
+	Address         *string    `json:"address"`
+	Country         *string    `json:"country"`
+	FineAmount      float64    `json:"fineAmount" binding:"min=0"`
+	IsPaymentDone   bool       `json:"isPaymentDone"`
+}
+
+// UserPatchWritableFields are the user fields a patron can change on their own profile with a merge patch
+var UserPatchWritableFields = []string{"profileImageUrl", "name", "dateOfBirth", "phoneNumber", "address", "country"}
+
+// UserPatchLibrarianWritableFields are the user fields a librarian can change with a merge patch
+var UserPatchLibrarianWritableFields = append(
+	append([]string{}, UserPatchWritableFields...),
+	"role", "fineAmount", "isPaymentDone",
+)
+
+// RegisterUserRequest, a user registering on their own is always a patron
 type RegisterUserRequest struct {
-	Email           string   `json:"email" binding:"required,email"`
-	ProfileImageUrl string   `json:"profileImageUrl" binding:"omitempty"`
-	Name            string   `json:"name" binding:"required"`
-	Role            RoleType `json:"role" binding:"required,oneof=librarian patrons"`
-	Password        string   `json:"password" binding:"required,min=8,max=20,validatepassword"`
+	Email           string `json:"email" binding:"required,email"`
+	ProfileImageUrl string `json:"profileImageUrl" binding:"omitempty"`
+	Name            string `json:"name" binding:"required"`
+	Password        string `json:"password" binding:"required,min=8,max=20,validatepassword"`
+}
+
+// CreateUserByAdminRequest is an account a librarian opens, the only way to add another librarian
+type CreateUserByAdminRequest struct {
+	RegisterUserRequest
+	Role RoleType `json:"role" binding:"required,oneof=librarian patrons"`
 }
 
 // LoginUserRequest
 type LoginUserRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8,max=20,validatepassword"`
+	// a user signs in with their email or the number of their library card
+	Email      string `json:"email" binding:"required_without=CardNumber,omitempty,email"`
+	CardNumber string `json:"cardNumber" binding:"omitempty,librarycard"`
+	Password   string `json:"password" binding:"required,min=8,max=20,validatepassword"`
 }
 
 // GetAllUsersRequest
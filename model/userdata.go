@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// DeletedUserName is the name a closed account is anonymised to
+const DeletedUserName = "Deleted user"
+
+// DeletedUserEmailDomain is the domain of the placeholder email of a closed account
+const DeletedUserEmailDomain = "deleted.invalid"
+
+// UserDataExportFormat
+type UserDataExportFormat string
+
+const (
+	// UserDataExportFormatJSON exports every section in one JSON document
+	UserDataExportFormatJSON UserDataExportFormat = "json"
+	// UserDataExportFormatCSV exports a zip archive with a CSV file per section
+	UserDataExportFormatCSV UserDataExportFormat = "csv"
+)
+
+// ExportUserDataRequest
+type ExportUserDataRequest struct {
+	Format UserDataExportFormat `json:"format" form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// UserDataExport is everything the library stores about a user
+type UserDataExport struct {
+	ExportedAt time.Time        `json:"exportedAt"`
+	Profile    UserDataProfile  `json:"profile"`
+	Loans      []UserDataLoan   `json:"loans"`
+	Fines      UserDataFines    `json:"fines"`
+	Reviews    []UserDataReview `json:"reviews"`
+}
+
+// UserDataProfile is the profile part of a user data export
+type UserDataProfile struct {
+	UserID          string     `json:"userID"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Role            RoleType   `json:"role"`
+	ProfileImageUrl string     `json:"profileImageUrl"`
+	DateOfBirth     *time.Time `json:"dateOfBirth"`
+	PhoneNumber     *string    `json:"phoneNumber"`
+	Address         *string    `json:"address"`
+	Country         *string    `json:"country"`
+	JoinedDate      time.Time  `json:"joinedDate"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       *time.Time `json:"updatedAt"`
+}
+
+// UserDataLoan is a reservation or checkout of a user
+type UserDataLoan struct {
+	ID           string     `json:"ID"`
+	BookID       string     `json:"bookID"`
+	ISBN         string     `json:"ISBN"`
+	Title        string     `json:"title"`
+	IsCheckedOut bool       `json:"isCheckedOut"`
+	IsReturned   bool       `json:"isReturned"`
+	NumberOfDays int64      `json:"numberOfDays"`
+	FineAmount   float64    `json:"fineAmount"`
+	ReservedOn   *time.Time `json:"reservedOn"`
+	CheckedOutOn *time.Time `json:"checkedOutOn"`
+	ReturnedDate *time.Time `json:"returnedDate"`
+}
+
+// UserDataFines is the fine balance of a user with the loans that were fined
+type UserDataFines struct {
+	Outstanding float64        `json:"outstanding"`
+	PaymentDone bool           `json:"paymentDone"`
+	FinedLoans  []UserDataLoan `json:"finedLoans"`
+}
+
+// UserDataReview is a review written by a user
+type UserDataReview struct {
+	ID             string     `json:"ID"`
+	BookID         string     `json:"bookID"`
+	ISBN           string     `json:"ISBN"`
+	Title          string     `json:"title"`
+	CommentHeading string     `json:"commentHeading"`
+	Comment        string     `json:"comment"`
+	Rating         float64    `json:"rating"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      *time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// WishlistRequest names the book added to or removed from the current user's wishlist
+type WishlistRequest struct {
+	ISBN string `json:"isbn" uri:"isbn" binding:"required"`
+}
+
+// GetWishlistRequest
+type GetWishlistRequest struct {
+	Page  uint32 `json:"page" form:"page" binding:"required,min=1"`
+	Limit uint32 `json:"limit" form:"limit" binding:"required,min=5"`
+}
+
+// WishlistBook is a wishlisted book with whether it can be borrowed right now
+type WishlistBook struct {
+	Book
+	Available bool `json:"available"`
+}
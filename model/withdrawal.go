@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// BookWithdrawalAction
+type BookWithdrawalAction string
+
+const (
+	// BookWithdrawalActionWithdraw takes a book or some of its copies out of circulation
+	BookWithdrawalActionWithdraw BookWithdrawalAction = "withdraw"
+	// BookWithdrawalActionRestore puts a withdrawn book back into circulation
+	BookWithdrawalActionRestore BookWithdrawalAction = "restore"
+	// BookWithdrawalActionPurge permanently removes a withdrawn book from the catalogue
+	BookWithdrawalActionPurge BookWithdrawalAction = "purge"
+)
+
+// WithdrawBookRequest withdraws the copies of a book with the barcodes, or the whole book when no
+// barcodes are given
+type WithdrawBookRequest struct {
+	Reason   string   `json:"reason" binding:"required"`
+	Barcodes []string `json:"barcodes" binding:"omitempty,dive,required"`
+}
+
+// RestoreBookRequest restores the withdrawn copies of a book with the barcodes, or the whole book
+// when no barcodes are given
+type RestoreBookRequest struct {
+	Reason   string   `json:"reason"`
+	Barcodes []string `json:"barcodes" binding:"omitempty,dive,required"`
+}
+
+// PurgeBookRequest
+type PurgeBookRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// BookWithdrawal is an entry of the withdrawal history of a book, kept after the book is purged
+type BookWithdrawal struct {
+	ID          string               `json:"ID"`
+	BookID      *string              `json:"bookID"`
+	ISBN        string               `json:"ISBN"`
+	Title       string               `json:"title"`
+	Action      BookWithdrawalAction `json:"action"`
+	Copies      *int64               `json:"copies"`
+	Barcodes    []string             `json:"barcodes"`
+	Reason      string               `json:"reason"`
+	LibrarianID *string              `json:"librarianID"`
+	CreatedAt   time.Time            `json:"createdAt"`
+}
+
+// GetWithdrawnBooksRequest
+type GetWithdrawnBooksRequest struct {
+	Page  uint32 `json:"page" form:"page" binding:"required,min=1"`
+	Limit uint32 `json:"limit" form:"limit" binding:"required,min=5"`
+}
@@ -0,0 +1,100 @@
+package openlibrary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"integrated-library-service/bookprovider"
+)
+
+const (
+	// DefaultBaseURL is the public open library endpoint
+	DefaultBaseURL = "https://openlibrary.org"
+	// ProviderName is the name open library is registered with in the provider priority list
+	ProviderName = "openlibrary"
+
+	defaultFailureThreshold = 5
+	defaultOpenDuration     = 30 * time.Second
+)
+
+var (
+	// ErrCircuitOpen is an error when open library calls are short circuited after repeated failures
+	ErrCircuitOpen = errors.New("open library circuit is open")
+)
+
+// OpenLibraryClient
+type OpenLibraryClient struct {
+	url     string
+	client  *http.Client
+	breaker *bookprovider.CircuitBreaker
+}
+
+// NewOpenLibraryService returns new instance of OpenLibraryClient, the circuit opens after failureThreshold
+// consecutive failures for openDuration, non positive values fall back to 5 failures and 30 seconds
+func NewOpenLibraryService(URL string, client *http.Client, failureThreshold int, openDuration time.Duration) *OpenLibraryClient {
+	if len(URL) == 0 {
+		URL = DefaultBaseURL
+	}
+	if failureThreshold <= 0 {
+		failureThreshold = defaultFailureThreshold
+	}
+	if openDuration <= 0 {
+		openDuration = defaultOpenDuration
+	}
+	return &OpenLibraryClient{
+		client:  client,
+		url:     URL,
+		breaker: bookprovider.NewCircuitBreaker(failureThreshold, openDuration),
+	}
+}
+
+// Name returns the provider name of the open library client
+func (o *OpenLibraryClient) Name() string {
+	return ProviderName
+}
+
+// parseURL returns prefixed URL with base open library URL
+func (o *OpenLibraryClient) parseURL(URL string) (*url.URL, error) {
+	fullURL, err := url.Parse(fmt.Sprint(o.url, URL))
+	if err != nil {
+		return nil, err
+	}
+
+	return fullURL, nil
+}
+
+// do modify request by attaching baseURL, repeated failures open the circuit breaker
+func (o *OpenLibraryClient) do(request *http.Request) (*http.Response, error) {
+	fullURL, err := o.parseURL(request.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	request.URL = fullURL
+	request.Header.Set("Accept", "application/json")
+
+	if !o.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	res, err := o.client.Do(request)
+	switch {
+	case err != nil && callerGaveUp(request.Context()):
+		o.breaker.Abandoned()
+	case err != nil, res.StatusCode == http.StatusTooManyRequests, res.StatusCode >= http.StatusInternalServerError:
+		o.breaker.Failure()
+	default:
+		o.breaker.Success()
+	}
+	return res, err
+}
+
+// callerGaveUp reports whether the caller cancelled the call or its deadline passed, which says nothing
+// about open library and must not open the circuit for everyone
+func callerGaveUp(ctx context.Context) bool {
+	return ctx.Err() != nil
+}
@@ -0,0 +1,176 @@
+package openlibrary
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"integrated-library-service/isbn"
+	"integrated-library-service/model"
+)
+
+// maxSubjects is the number of open library subjects kept as genres, works often carry dozens of them
+const maxSubjects = 5
+
+var (
+	// publishDateLayouts are the layouts open library uses for free text publish dates
+	publishDateLayouts = []string{"January 2, 2006", "Jan 2, 2006", "January 2006", "Jan 2006", "2006-01-02", "2006"}
+	// languageCodes maps the MARC language codes of open library to the ISO 639-1 codes google books uses
+	languageCodes = map[string]string{
+		"eng": "en",
+		"fre": "fr",
+		"ger": "de",
+		"spa": "es",
+		"ita": "it",
+		"por": "pt",
+		"dut": "nl",
+		"rus": "ru",
+		"jpn": "ja",
+		"chi": "zh",
+		"hin": "hi",
+		"ara": "ar",
+	}
+)
+
+// languageCode returns the ISO 639-1 code of a MARC language code, unknown codes are returned as they are
+func languageCode(code string) string {
+	if iso, ok := languageCodes[code]; ok {
+		return iso
+	}
+	return code
+}
+
+// limitSubjects keeps the first maxSubjects subjects
+func limitSubjects(subjects []string) []string {
+	if len(subjects) > maxSubjects {
+		return subjects[:maxSubjects]
+	}
+	return subjects
+}
+
+// coverURL returns the medium cover image link for the given cover id
+func coverURL(coverID int) string {
+	if coverID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://covers.openlibrary.org/b/id/%d-M.jpg", coverID)
+}
+
+// pickISBN returns the canonical ISBN-13 of the first valid identifier, preferring 13 digit identifiers
+func pickISBN(identifiers []string) string {
+	for _, length := range []int{13, 10} {
+		for _, identifier := range identifiers {
+			if len(isbn.Normalize(identifier)) != length {
+				continue
+			}
+			if ISBN13, _, err := isbn.Parse(identifier); err == nil {
+				return ISBN13
+			}
+		}
+	}
+	return ""
+}
+
+// fillDefaults sets the counters which are not provided by open library
+func fillDefaults(book *model.CreateBookRequest) {
+	mockvalue := int64(0)
+	mockFloat := float64(0)
+	mockBool := false
+	book.BooksLeft = &mockvalue
+	book.ShelfNumber = &mockvalue
+	book.InLibrary = &mockBool
+	book.Rating = &mockFloat
+	book.ReviewCount = &mockvalue
+	book.Views = &mockvalue
+	book.ReviewsList = []string{}
+	book.ViewsList = []string{}
+	book.WishList = []string{}
+	book.WishlistCount = &mockvalue
+	book.ApproximateDemand = &mockvalue
+}
+
+// docToCreateBookRequest maps a search doc into a CreateBookRequest, it returns false when the doc has no ISBN
+func (o *OpenLibraryClient) docToCreateBookRequest(doc SearchDoc) (*model.CreateBookRequest, bool) {
+	ISBN := pickISBN(doc.ISBN)
+	if len(ISBN) == 0 {
+		return nil, false
+	}
+
+	book := model.CreateBookRequest{
+		ISBN:        ISBN,
+		Title:       doc.Title,
+		Subtitle:    doc.Subtitle,
+		CoverImage:  coverURL(doc.CoverID),
+		PreviewLink: o.url + doc.Key,
+		PageCount:   int64(doc.NumberOfPages),
+		Genre:       "other",
+		Author:      "unknown",
+		Authors:     doc.AuthorName,
+		Genres:      limitSubjects(doc.Subject),
+	}
+	if len(doc.Publisher) > 0 {
+		book.Publisher = doc.Publisher[0]
+	}
+	if len(doc.Language) > 0 {
+		book.Language = languageCode(doc.Language[0])
+	}
+	if len(doc.Subject) > 0 {
+		book.Genre = doc.Subject[0]
+	}
+	if len(doc.AuthorName) > 0 {
+		book.Author = doc.AuthorName[0]
+	}
+	if doc.FirstPublishYear > 0 {
+		book.PublishedDate = time.Date(doc.FirstPublishYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	fillDefaults(&book)
+	return &book, true
+}
+
+// editionToCreateBookRequest maps an edition into a CreateBookRequest
+func editionToCreateBookRequest(ISBN string, edition Edition) *model.CreateBookRequest {
+	if ISBN13, _, err := isbn.Parse(ISBN); err == nil {
+		ISBN = ISBN13
+	}
+	identifiers := append(edition.Identifiers.ISBN13, edition.Identifiers.ISBN10...)
+	if picked := pickISBN(identifiers); len(picked) > 0 {
+		ISBN = picked
+	}
+
+	book := model.CreateBookRequest{
+		ISBN:        ISBN,
+		Title:       edition.Title,
+		Subtitle:    edition.Subtitle,
+		CoverImage:  edition.Cover.Medium,
+		PreviewLink: edition.URL,
+		PageCount:   int64(edition.NumberOfPages),
+		Genre:       "other",
+		Author:      "unknown",
+	}
+	for _, author := range edition.Authors {
+		book.Authors = append(book.Authors, author.Name)
+	}
+	for _, subject := range edition.Subjects {
+		book.Genres = append(book.Genres, subject.Name)
+	}
+	book.Genres = limitSubjects(book.Genres)
+	if len(edition.Publishers) > 0 {
+		book.Publisher = edition.Publishers[0].Name
+	}
+	if len(edition.Subjects) > 0 {
+		book.Genre = edition.Subjects[0].Name
+	}
+	if len(edition.Authors) > 0 {
+		book.Author = edition.Authors[0].Name
+	}
+	for _, layout := range publishDateLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(edition.PublishDate)); err == nil {
+			book.PublishedDate = t.UTC()
+			break
+		}
+	}
+
+	fillDefaults(&book)
+	return &book
+}
@@ -0,0 +1,47 @@
+package openlibrary
+
+// SearchResponse is the response of the open library search api
+type SearchResponse struct {
+	NumFound int         `json:"numFound"`
+	Docs     []SearchDoc `json:"docs"`
+}
+
+// SearchDoc is a single work returned by the open library search api
+type SearchDoc struct {
+	Key              string   `json:"key"`
+	Title            string   `json:"title"`
+	Subtitle         string   `json:"subtitle"`
+	AuthorName       []string `json:"author_name"`
+	ISBN             []string `json:"isbn"`
+	Subject          []string `json:"subject"`
+	Publisher        []string `json:"publisher"`
+	Language         []string `json:"language"`
+	FirstPublishYear int      `json:"first_publish_year"`
+	CoverID          int      `json:"cover_i"`
+	NumberOfPages    int      `json:"number_of_pages_median"`
+}
+
+// Edition is a single edition returned by the open library books api with jscmd=data
+type Edition struct {
+	Title         string       `json:"title"`
+	Subtitle      string       `json:"subtitle"`
+	URL           string       `json:"url"`
+	Authors       []namedEntry `json:"authors"`
+	Subjects      []namedEntry `json:"subjects"`
+	Publishers    []namedEntry `json:"publishers"`
+	PublishDate   string       `json:"publish_date"`
+	NumberOfPages int          `json:"number_of_pages"`
+	Cover         struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"cover"`
+	Identifiers struct {
+		ISBN10 []string `json:"isbn_10"`
+		ISBN13 []string `json:"isbn_13"`
+	} `json:"identifiers"`
+}
+
+type namedEntry struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,137 @@
+package openlibrary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"integrated-library-service/bookprovider"
+	"integrated-library-service/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrGetOpenLibraryBooks is an error when get books from open library failed
+	ErrGetOpenLibraryBooks = errors.New("get open library books failed")
+	// ErrOpenLibraryBookNotFound is an error when open library has no edition for the given ISBN, it
+	// wraps bookprovider.ErrBookNotFound so the provider chain moves on without counting a failure
+	ErrOpenLibraryBookNotFound = fmt.Errorf("open library: %w", bookprovider.ErrBookNotFound)
+)
+
+// Search searches open library with the given search request
+func (o *OpenLibraryClient) Search(ctx context.Context, request *model.SearchRequest) ([]*model.CreateBookRequest, int, error) {
+	params := url.Values{}
+	switch request.SearchBy {
+	case "title":
+		params.Set("title", request.SearchText)
+	case "author":
+		params.Set("author", request.SearchText)
+	case "isbn":
+		params.Set("isbn", request.SearchText)
+	case "subject", "genre":
+		params.Set("subject", request.SearchText)
+	default:
+		params.Set("q", request.SearchText)
+	}
+
+	return o.search(ctx, params, request.Page, request.Limit)
+}
+
+// ListNewReleases lists the most recently published works on open library
+func (o *OpenLibraryClient) ListNewReleases(ctx context.Context, request *model.GetAllBooksRequest) ([]*model.CreateBookRequest, int, error) {
+	params := url.Values{}
+	params.Set("q", fmt.Sprintf("first_publish_year:[%d TO *]", time.Now().UTC().Year()-1))
+	params.Set("sort", "new")
+
+	return o.search(ctx, params, request.Page, request.Limit)
+}
+
+// search calls the open library search api and maps the docs which carry an ISBN
+func (o *OpenLibraryClient) search(ctx context.Context, params url.Values, page, limit uint32) ([]*model.CreateBookRequest, int, error) {
+	params.Set("page", fmt.Sprint(page))
+	params.Set("limit", fmt.Sprint(limit))
+	params.Set("fields", "key,title,subtitle,author_name,isbn,subject,publisher,language,first_publish_year,cover_i,number_of_pages_median")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/search.json?"+params.Encode(), nil)
+	if err != nil {
+		log.Error().Msgf("http request error : %v ", err)
+		return nil, 0, ErrGetOpenLibraryBooks
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		log.Error().Msgf("response error : %v ", err)
+		return nil, 0, ErrGetOpenLibraryBooks
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Error().Msgf("searchOpenLibrary error : %s , status : %v ", bodyBytes, resp.StatusCode)
+		return nil, 0, ErrGetOpenLibraryBooks
+	}
+
+	var searchResponse SearchResponse
+	if err = json.NewDecoder(resp.Body).Decode(&searchResponse); err != nil {
+		log.Error().Msgf("decode body error : %v ", err)
+		return nil, 0, ErrGetOpenLibraryBooks
+	}
+
+	books := []*model.CreateBookRequest{}
+	for _, doc := range searchResponse.Docs {
+		book, ok := o.docToCreateBookRequest(doc)
+		if !ok {
+			continue
+		}
+		books = append(books, book)
+	}
+
+	return books, searchResponse.NumFound, nil
+}
+
+// GetByISBN gets a single edition by its ISBN
+func (o *OpenLibraryClient) GetByISBN(ctx context.Context, ISBN string) (*model.CreateBookRequest, error) {
+	bibKey := "ISBN:" + ISBN
+	params := url.Values{}
+	params.Set("bibkeys", bibKey)
+	params.Set("format", "json")
+	params.Set("jscmd", "data")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/books?"+params.Encode(), nil)
+	if err != nil {
+		log.Error().Msgf("http request error : %v ", err)
+		return nil, ErrGetOpenLibraryBooks
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		log.Error().Msgf("response error : %v ", err)
+		return nil, ErrGetOpenLibraryBooks
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		log.Error().Msgf("getOpenLibraryByISBN error : %s , status : %v ", bodyBytes, resp.StatusCode)
+		return nil, ErrGetOpenLibraryBooks
+	}
+
+	editions := map[string]Edition{}
+	if err = json.NewDecoder(resp.Body).Decode(&editions); err != nil {
+		log.Error().Msgf("decode body error : %v ", err)
+		return nil, ErrGetOpenLibraryBooks
+	}
+
+	edition, ok := editions[bibKey]
+	if !ok {
+		return nil, ErrOpenLibraryBookNotFound
+	}
+
+	return editionToCreateBookRequest(ISBN, edition), nil
+}
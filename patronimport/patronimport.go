@@ -0,0 +1,206 @@
+// Package patronimport turns the student and staff lists of a cohort into library users
+package patronimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	"integrated-library-service/librarycard"
+	"integrated-library-service/model"
+)
+
+var (
+	// ErrNoRows is an error when a file contains no rows to import
+	ErrNoRows = errors.New("import file contains no rows")
+	// ErrMissingColumn is an error when a required column is not in the header row
+	ErrMissingColumn = errors.New("column missing in header row")
+)
+
+// Row is a parsed row of a patron file, Err is set when the row can not be imported
+type Row struct {
+	Number int64
+	Patron *model.ImportPatron
+	Err    error
+}
+
+// columns are the headers a patron file may have, matched case insensitively. A name can also be
+// given as a first and a last name.
+var columns = []string{"email", "name", "firstname", "lastname", "phonenumber", "address", "country", "dateofbirth", "cardnumber"}
+
+// ReadCSV parses a CSV file whose first row is the header, the email column and a name are
+// required. Rows are numbered by their line in the file, an email repeated in the file is an
+// error of the later rows.
+func ReadCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		name = strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name)
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+	if _, ok := index["email"]; !ok {
+		return nil, fmt.Errorf("%w: email", ErrMissingColumn)
+	}
+	_, hasName := index["name"]
+	_, hasFirstName := index["firstname"]
+	_, hasLastName := index["lastname"]
+	if !hasName && !hasFirstName && !hasLastName {
+		return nil, fmt.Errorf("%w: name", ErrMissingColumn)
+	}
+
+	rows := []Row{}
+	seen := map[string]int64{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, Row{Number: int64(parseErr.StartLine), Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isBlank(record) {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		values := map[string]string{}
+		for _, column := range columns {
+			if i, ok := index[column]; ok && i < len(record) {
+				values[column] = strings.TrimSpace(record[i])
+			}
+		}
+
+		row := Row{Number: int64(line)}
+		row.Patron, row.Err = parse(values)
+		if row.Err == nil {
+			key := strings.ToLower(row.Patron.Email)
+			if first, ok := seen[key]; ok {
+				row.Err = fmt.Errorf("email %q is repeated from row %d", row.Patron.Email, first)
+			} else {
+				seen[key] = row.Number
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+// parse checks the values of a row, the patron is returned even when a value is invalid so the
+// report can name the row
+func parse(values map[string]string) (*model.ImportPatron, error) {
+	patron := &model.ImportPatron{
+		Email: values["email"],
+		Name:  values["name"],
+	}
+	if len(patron.Name) == 0 {
+		patron.Name = strings.TrimSpace(values["firstname"] + " " + values["lastname"])
+	}
+
+	missing := []string{}
+	if len(patron.Email) == 0 {
+		missing = append(missing, "email")
+	}
+	if len(patron.Name) == 0 {
+		missing = append(missing, "name")
+	}
+	if len(missing) > 0 {
+		return patron, fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+
+	if address, err := mail.ParseAddress(patron.Email); err != nil || address.Address != patron.Email {
+		return patron, fmt.Errorf("invalid email %q", patron.Email)
+	}
+	// the columns are as wide as the ones of registered users
+	if len(patron.Email) > 50 {
+		return patron, errors.New("email is longer than 50 characters")
+	}
+	if len(patron.Name) > 100 {
+		return patron, errors.New("name is longer than 100 characters")
+	}
+
+	if phone := values["phonenumber"]; len(phone) > 0 {
+		digits := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(" -()+.", r) {
+				return -1
+			}
+			return r
+		}, phone)
+		for _, r := range digits {
+			if r < '0' || r > '9' {
+				return patron, fmt.Errorf("invalid phoneNumber %q", phone)
+			}
+		}
+		patron.PhoneNumber = &digits
+	}
+	if address := values["address"]; len(address) > 0 {
+		patron.Address = &address
+	}
+	if country := values["country"]; len(country) > 0 {
+		patron.Country = &country
+	}
+
+	if date := values["dateofbirth"]; len(date) > 0 {
+		dateOfBirth, err := parseDate(date)
+		if err != nil {
+			return patron, err
+		}
+		patron.DateOfBirth = &dateOfBirth
+	}
+
+	if cardNumber := values["cardnumber"]; len(cardNumber) > 0 {
+		parsed, err := librarycard.Parse(cardNumber)
+		if err != nil {
+			return patron, fmt.Errorf("%w %q", err, cardNumber)
+		}
+		patron.CardNumber = parsed
+	}
+
+	return patron, nil
+}
+
+// parseDate parses the dates of birth found in school exports
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006", "02.01.2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid dateOfBirth %q", value)
+}
+
+// isBlank reports whether every cell of a record is empty
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if len(strings.TrimSpace(value)) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -10,12 +10,15 @@ import (
 	"integrated-library-service/handlers"
 )
 
-// Route Structure of new routes
+// Route Structure of new routes, LibrarianOnly routes additionally require the librarian role and
+// KioskOnly routes take a kiosk device token instead of a user JWT
 type Route struct {
 	Name           string
 	Method         string
 	Pattern        string
 	ProtectedRoute bool
+	LibrarianOnly  bool
+	KioskOnly      bool
 	HandlerFunc    gin.HandlerFunc
 }
 
@@ -40,6 +43,14 @@ func NewRoutes(libraryHandler *handlers.LibraryHandler) Routes {
 			ProtectedRoute: false,
 			HandlerFunc:    libraryHandler.RegisterUserHandler,
 		},
+		Route{
+			Name:           "Create User By Librarian",
+			Method:         http.MethodPost,
+			Pattern:        "/users/accounts",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.CreateUserByAdminHandler,
+		},
 		Route{
 			Name:           "Login User",
 			Method:         http.MethodPost,
@@ -75,6 +86,35 @@ func NewRoutes(libraryHandler *handlers.LibraryHandler) Routes {
 			ProtectedRoute: true,
 			HandlerFunc:    libraryHandler.UpdateUserHandler,
 		},
+		Route{
+			Name:           "Update User By Librarian",
+			Method:         http.MethodPut,
+			Pattern:        "/users/:userid",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.UpdateUserByAdminHandler,
+		},
+		Route{
+			Name:           "Request Email Change",
+			Method:         http.MethodPost,
+			Pattern:        "/users/email",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.RequestEmailChangeHandler,
+		},
+		Route{
+			Name:           "Verify Email Change",
+			Method:         http.MethodPost,
+			Pattern:        "/users/email/verify",
+			ProtectedRoute: false,
+			HandlerFunc:    libraryHandler.VerifyEmailChangeHandler,
+		},
+		Route{
+			Name:           "Change Password",
+			Method:         http.MethodPut,
+			Pattern:        "/users/password",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.ChangePasswordHandler,
+		},
 		Route{
 			Name:           "Update User Book Details",
 			Method:         http.MethodPut,
@@ -89,6 +129,44 @@ func NewRoutes(libraryHandler *handlers.LibraryHandler) Routes {
 			ProtectedRoute: true,
 			HandlerFunc:    libraryHandler.DeleteUserHandler,
 		},
+		Route{
+			Name:           "Delete User By Librarian",
+			Method:         http.MethodDelete,
+			Pattern:        "/users/:userid",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.DeleteUserByAdminHandler,
+		},
+		Route{
+			Name:           "Export User Data",
+			Method:         http.MethodGet,
+			Pattern:        "/users/export",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.ExportUserDataHandler,
+		},
+		Route{
+			Name:           "Import Patrons",
+			Method:         http.MethodPost,
+			Pattern:        "/users/import",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.ImportPatronsHandler,
+		},
+		Route{
+			Name:           "Accept User Invite",
+			Method:         http.MethodPost,
+			Pattern:        "/users/invites/accept",
+			ProtectedRoute: false,
+			HandlerFunc:    libraryHandler.AcceptUserInviteHandler,
+		},
+		Route{
+			Name:           "Issue Library Card",
+			Method:         http.MethodPost,
+			Pattern:        "/users/:userid/card",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.IssueLibraryCardHandler,
+		},
 		// book related
 		Route{
 			Name:           "Create Books in Batch", // will be added manually
@@ -146,6 +224,80 @@ func NewRoutes(libraryHandler *handlers.LibraryHandler) Routes {
 			ProtectedRoute: true,
 			HandlerFunc:    libraryHandler.GetAllBooksByBookDetailsFromHandler,
 		},
+		// book withdrawal related
+		Route{
+			Name:           "Withdraw Book",
+			Method:         http.MethodPost,
+			Pattern:        "/books/:isbn/withdraw",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.WithdrawBookHandler,
+		},
+		Route{
+			Name:           "Restore Book",
+			Method:         http.MethodPost,
+			Pattern:        "/books/:isbn/restore",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.RestoreBookHandler,
+		},
+		Route{
+			Name:           "Purge Book",
+			Method:         http.MethodDelete,
+			Pattern:        "/books/:isbn",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.PurgeBookHandler,
+		},
+		Route{
+			Name:           "Get Withdrawn Books",
+			Method:         http.MethodGet,
+			Pattern:        "/allbooks/withdrawn",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetWithdrawnBooksHandler,
+		},
+		Route{
+			Name:           "Get Book Withdrawal History",
+			Method:         http.MethodGet,
+			Pattern:        "/bookwithdrawals/:isbn",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetBookWithdrawalsHandler,
+		},
+		// book import related
+		Route{
+			Name:           "Import Books From CSV Or MARC",
+			Method:         http.MethodPost,
+			Pattern:        "/bookimports",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.CreateBookImportHandler,
+		},
+		Route{
+			Name:           "Get Book Imports",
+			Method:         http.MethodGet,
+			Pattern:        "/bookimports",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetBookImportsHandler,
+		},
+		Route{
+			Name:           "Get Book Import",
+			Method:         http.MethodGet,
+			Pattern:        "/bookimports/:importid",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetBookImportHandler,
+		},
+		Route{
+			Name:           "Get Book Import Report",
+			Method:         http.MethodGet,
+			Pattern:        "/bookimports/:importid/rows",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetBookImportRowsHandler,
+		},
 		// checkout related
 		Route{
 			Name:           "Create Checkout Ticket",
@@ -279,6 +431,366 @@ func NewRoutes(libraryHandler *handlers.LibraryHandler) Routes {
 			ProtectedRoute: true,
 			HandlerFunc:    libraryHandler.GetRecommendedBooksForUserHandler,
 		},
+		Route{
+			Name:           "Get Acquisition Suggestions",
+			Method:         http.MethodGet,
+			Pattern:        "/dataanalysis/acquisitions",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetAcquisitionSuggestionsHandler,
+		},
+		Route{
+			Name:           "Export Acquisition Suggestions",
+			Method:         http.MethodGet,
+			Pattern:        "/dataanalysis/acquisitions/export",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.ExportAcquisitionSuggestionsHandler,
+		},
+		// book view related
+		Route{
+			Name:           "Record Book View",
+			Method:         http.MethodPost,
+			Pattern:        "/bookviews/:bookid",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.RecordBookViewHandler,
+		},
+		Route{
+			Name:           "Get Book Views Over Time",
+			Method:         http.MethodGet,
+			Pattern:        "/dataanalysis/views",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetBookViewsOverTimeHandler,
+		},
+		Route{
+			Name:           "Get Top Viewed Books",
+			Method:         http.MethodGet,
+			Pattern:        "/dataanalysis/topviewed",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.GetTopViewedBooksHandler,
+		},
+		// wishlist related
+		Route{
+			Name:           "Add To Wishlist",
+			Method:         http.MethodPost,
+			Pattern:        "/wishlist/:isbn",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.AddToWishlistHandler,
+		},
+		Route{
+			Name:           "Remove From Wishlist",
+			Method:         http.MethodDelete,
+			Pattern:        "/wishlist/:isbn",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.RemoveFromWishlistHandler,
+		},
+		Route{
+			Name:           "Get Wishlist",
+			Method:         http.MethodGet,
+			Pattern:        "/wishlist",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.GetWishlistHandler,
+		},
+		// purchase request related
+		Route{
+			Name:           "Create Purchase Request",
+			Method:         http.MethodPost,
+			Pattern:        "/purchaserequests",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.CreatePurchaseRequestHandler,
+		},
+		Route{
+			Name:           "Get Purchase Requests",
+			Method:         http.MethodGet,
+			Pattern:        "/purchaserequests",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.GetPurchaseRequestsHandler,
+		},
+		Route{
+			Name:           "Get All Purchase Requests",
+			Method:         http.MethodGet,
+			Pattern:        "/allpurchaserequests",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetAllPurchaseRequestsHandler,
+		},
+		Route{
+			Name:           "Get Purchase Request By ID",
+			Method:         http.MethodGet,
+			Pattern:        "/purchaserequests/:requestid",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.GetPurchaseRequestByIDHandler,
+		},
+		Route{
+			Name:           "Update Purchase Request Status",
+			Method:         http.MethodPut,
+			Pattern:        "/purchaserequests/:requestid/status",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.UpdatePurchaseRequestStatusHandler,
+		},
+		// notification related
+		Route{
+			Name:           "Get Notifications",
+			Method:         http.MethodGet,
+			Pattern:        "/notifications",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.GetNotificationsHandler,
+		},
+		Route{
+			Name:           "Mark Notification Read",
+			Method:         http.MethodPut,
+			Pattern:        "/notifications/:notificationid/read",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.MarkNotificationReadHandler,
+		},
+		// catalogue sync related
+		Route{
+			Name:           "Trigger Catalogue Sync",
+			Method:         http.MethodPost,
+			Pattern:        "/catalogue/sync",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.TriggerCatalogueSyncHandler,
+		},
+		Route{
+			Name:           "Get Catalogue Sync Runs",
+			Method:         http.MethodGet,
+			Pattern:        "/catalogue/sync/runs",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetCatalogueSyncRunsHandler,
+		},
+		// partial update related
+		Route{
+			Name:           "Patch Book",
+			Method:         http.MethodPatch,
+			Pattern:        "/books/:isbn",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.PatchBookHandler,
+		},
+		Route{
+			Name:           "Patch User",
+			Method:         http.MethodPatch,
+			Pattern:        "/users/:userid",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.PatchUserHandler,
+		},
+		Route{
+			Name:           "Patch Checkout Ticket",
+			Method:         http.MethodPatch,
+			Pattern:        "/checkouts/:checkoutid",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.PatchCheckoutTicketHandler,
+		},
+		// export related
+		Route{
+			Name:           "Export Books",
+			Method:         http.MethodGet,
+			Pattern:        "/export/books",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.ExportBooksHandler,
+		},
+		Route{
+			Name:           "Export Users",
+			Method:         http.MethodGet,
+			Pattern:        "/export/users",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.ExportUsersHandler,
+		},
+		Route{
+			Name:           "Export Checkouts",
+			Method:         http.MethodGet,
+			Pattern:        "/export/checkouts",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.ExportCheckoutsHandler,
+		},
+		// code and label related
+		Route{
+			Name:           "Get Library Card Code",
+			Method:         http.MethodGet,
+			Pattern:        "/users/:userid/card/code",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.GetLibraryCardCodeHandler,
+		},
+		Route{
+			Name:           "Get Checkout Ticket Code",
+			Method:         http.MethodGet,
+			Pattern:        "/checkouts/:checkoutid/code",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.GetCheckoutTicketCodeHandler,
+		},
+		Route{
+			Name:           "Register Book Copies",
+			Method:         http.MethodPost,
+			Pattern:        "/books/:isbn/copies",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.RegisterBookCopiesHandler,
+		},
+		Route{
+			Name:           "Get Book Copies",
+			Method:         http.MethodGet,
+			Pattern:        "/books/:isbn/copies",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetBookCopiesHandler,
+		},
+		Route{
+			Name:           "Get Book Copy Code",
+			Method:         http.MethodGet,
+			Pattern:        "/books/:isbn/copies/:copynumber/code",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetBookCopyCodeHandler,
+		},
+		Route{
+			Name:           "Get Label Sheet",
+			Method:         http.MethodGet,
+			Pattern:        "/labels",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetLabelSheetHandler,
+		},
+		Route{
+			Name:           "Scan Code",
+			Method:         http.MethodGet,
+			Pattern:        "/scan",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.ScanCodeHandler,
+		},
+		// kiosk related
+		Route{
+			Name:           "Create Kiosk Device",
+			Method:         http.MethodPost,
+			Pattern:        "/kiosk/devices",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.CreateKioskDeviceHandler,
+		},
+		Route{
+			Name:           "Get Kiosk Devices",
+			Method:         http.MethodGet,
+			Pattern:        "/kiosk/devices",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetKioskDevicesHandler,
+		},
+		Route{
+			Name:           "Revoke Kiosk Device",
+			Method:         http.MethodDelete,
+			Pattern:        "/kiosk/devices/:deviceid",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.RevokeKioskDeviceHandler,
+		},
+		Route{
+			Name:           "Set Kiosk PIN",
+			Method:         http.MethodPut,
+			Pattern:        "/users/pin",
+			ProtectedRoute: true,
+			HandlerFunc:    libraryHandler.SetPinHandler,
+		},
+		Route{
+			Name:        "Start Kiosk Session",
+			Method:      http.MethodPost,
+			Pattern:     "/kiosk/sessions",
+			KioskOnly:   true,
+			HandlerFunc: libraryHandler.StartKioskSessionHandler,
+		},
+		Route{
+			Name:        "End Kiosk Session",
+			Method:      http.MethodDelete,
+			Pattern:     "/kiosk/sessions",
+			KioskOnly:   true,
+			HandlerFunc: libraryHandler.EndKioskSessionHandler,
+		},
+		Route{
+			Name:        "Get Kiosk Receipt",
+			Method:      http.MethodGet,
+			Pattern:     "/kiosk/sessions/receipt",
+			KioskOnly:   true,
+			HandlerFunc: libraryHandler.GetKioskReceiptHandler,
+		},
+		Route{
+			Name:        "Kiosk Checkout",
+			Method:      http.MethodPost,
+			Pattern:     "/kiosk/checkouts",
+			KioskOnly:   true,
+			HandlerFunc: libraryHandler.KioskCheckoutHandler,
+		},
+		Route{
+			Name:        "Kiosk Return",
+			Method:      http.MethodPost,
+			Pattern:     "/kiosk/returns",
+			KioskOnly:   true,
+			HandlerFunc: libraryHandler.KioskReturnHandler,
+		},
+		// lost and damaged item related
+		Route{
+			Name:           "Declare Checkout Lost",
+			Method:         http.MethodPost,
+			Pattern:        "/checkouts/:checkoutid/lost",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.DeclareCheckoutLostHandler,
+		},
+		Route{
+			Name:           "Declare Checkout Damaged",
+			Method:         http.MethodPost,
+			Pattern:        "/checkouts/:checkoutid/damaged",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.DeclareCheckoutDamagedHandler,
+		},
+		Route{
+			Name:           "Mark Checkout Found",
+			Method:         http.MethodPost,
+			Pattern:        "/checkouts/:checkoutid/found",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.MarkCheckoutFoundHandler,
+		},
+		Route{
+			Name:           "Restock Book Copy",
+			Method:         http.MethodPost,
+			Pattern:        "/copies/:barcode/restock",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.RestockBookCopyHandler,
+		},
+		// report related
+		Route{
+			Name:           "Get Report",
+			Method:         http.MethodGet,
+			Pattern:        "/reports/:report",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetReportHandler,
+		},
+		Route{
+			Name:           "Export Report",
+			Method:         http.MethodGet,
+			Pattern:        "/reports/:report/export",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.ExportReportHandler,
+		},
+		// audit related
+		Route{
+			Name:           "Get Audit Logs",
+			Method:         http.MethodGet,
+			Pattern:        "/audit-logs",
+			ProtectedRoute: true,
+			LibrarianOnly:  true,
+			HandlerFunc:    libraryHandler.GetAuditLogsHandler,
+		},
 		// token expiration handler
 		Route{
 			Name:           "To check token expiry",
@@ -293,7 +805,13 @@ func NewRoutes(libraryHandler *handlers.LibraryHandler) Routes {
 // AttachRoutes Attaches routes to the provided server
 func AttachRoutes(server *gin.RouterGroup, routes Routes, authMiddleware auth.Middleware) {
 	for _, route := range routes {
-		if route.ProtectedRoute {
+		if route.KioskOnly {
+			server.
+				Handle(route.Method, route.Pattern, authMiddleware.DoAuthenticateKiosk, route.HandlerFunc)
+		} else if route.LibrarianOnly {
+			server.
+				Handle(route.Method, route.Pattern, authMiddleware.DoAuthenticate, authMiddleware.RequireLibrarian, route.HandlerFunc)
+		} else if route.ProtectedRoute {
 			server.
 				Handle(route.Method, route.Pattern, authMiddleware.DoAuthenticate, route.HandlerFunc)
 		} else {